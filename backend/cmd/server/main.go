@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 
 	"ticktick-backend/config"
 	"ticktick-backend/internal/dal"
 	"ticktick-backend/internal/handlers"
-	"ticktick-backend/internal/middleware"
+	"ticktick-backend/internal/metrics"
+	"ticktick-backend/internal/repository"
+	"ticktick-backend/internal/router"
 	"ticktick-backend/internal/services"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	autoMigrate := flag.Bool("auto-migrate", false, "启动时自动执行数据库迁移")
+	flag.Usage = usage
+	flag.Parse()
+
 	// 加载配置
 	cfg := config.LoadConfig()
 
@@ -34,10 +42,26 @@ func main() {
 	}
 	log.Println("数据库连接成功")
 
+	// 加载数据库迁移
+	migrator, err := dal.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+
+	// migrate子命令执行完即退出
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), migrator, flag.Args()[1:]); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	}
+
 	// 自动迁移数据库
-	// if err := db.AutoMigrate(); err != nil {
-	// 	log.Fatalf("数据库迁移失败: %v", err)
-	// }
+	if *autoMigrate || cfg.Database.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+	}
 
 	// 初始化Redis服务
 	redisService, err := services.NewRedisService(&cfg.Redis)
@@ -54,125 +78,160 @@ func main() {
 	tokenMonitor.Start()
 	defer tokenMonitor.Stop()
 
-	// 初始化服务层
-	userService := services.NewUserService(db)
-
-	// 初始化处理器
-	authHandler := handlers.NewAuthHandler(userService, tokenStore, cfg)
-	monitorHandler := handlers.NewMonitorHandler(tokenMonitor, tokenStore)
-
-	// 创建Gin路由器
-	router := gin.Default()
-
-	// 配置CORS中间件 - 允许所有端口
-	config := cors.Config{
-		AllowOrigins:     []string{"http://localhost:5273", "http://127.0.0.1:5174"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowCredentials: true,
-	}
-	router.Use(cors.New(config))
-
-	// API路由组
-	api := router.Group("/api/v1")
-
-	// 健康检查端点
-	api.GET("/health", monitorHandler.GetHealth)
-	api.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	// 初始化健康检查
+	healthChecker := services.NewHealthChecker(cfg.Health.CheckTimeout)
+	healthChecker.AddCheck("postgres", db.PingContext)
+	healthChecker.AddCheck("redis", redisService.PingContext)
+	healthChecker.AddCheck("migrations", migrator.CheckPending)
+	healthChecker.AddCheck("token_monitor", func(ctx context.Context) error {
+		if !tokenMonitor.IsRunning() {
+			return errors.New("Token监控服务未运行")
+		}
+		return nil
 	})
 
-	// 认证路由（不需要JWT验证）
-	authGroup := api.Group("/auth")
-	{
-		authGroup.POST("/register", authHandler.Register)
-		authGroup.POST("/login", authHandler.Login)
-		authGroup.POST("/refresh", authHandler.RefreshToken)
-		authGroup.POST("/logout", authHandler.Logout)
-
+	// 初始化数据访问层
+	userDAL := dal.NewUserDAL(db)
+	projectDAL := dal.NewProjectDAL(db)
+	taskDAL := dal.NewTaskDAL(db)
+	labelDAL := dal.NewLabelDAL(db)
+	reminderDAL := dal.NewReminderDAL(db)
+	trashDAL := dal.NewTrashDAL(db)
+	smartListDAL := dal.NewSmartListDAL(db)
+	sectionDAL := dal.NewSectionDAL(db)
+	projectGroupDAL := dal.NewProjectGroupDAL(db)
+	userSettingsDAL := dal.NewUserSettingsDAL(db)
+	calendarFeedDAL := dal.NewCalendarFeedDAL(db)
+	appPasswordDAL := dal.NewAppPasswordDAL(db)
+	webhookDAL := dal.NewWebhookDAL(db)
+	syncDAL := dal.NewSyncDAL(db)
+
+	// 初始化服务层，数据变更事件通过事件总线发送给Webhook
+	eventBus := services.NewEventBus()
+	userService := services.NewUserService(userDAL)
+	settingsService := services.NewSettingsService(userSettingsDAL)
+	projectService := services.NewProjectService(projectDAL, projectGroupDAL, eventBus)
+	taskService := services.NewTaskService(db, taskDAL, projectDAL, labelDAL, sectionDAL, eventBus)
+	labelService := services.NewLabelService(labelDAL, eventBus)
+	reminderService := services.NewReminderService(reminderDAL, taskDAL, eventBus)
+	trashService := services.NewTrashService(trashDAL, projectDAL, taskDAL)
+	smartListService := services.NewSmartListService(smartListDAL, taskDAL, settingsService)
+	sectionService := services.NewSectionService(db, sectionDAL, projectDAL, taskDAL)
+	projectGroupService := services.NewProjectGroupService(projectGroupDAL)
+	calendarService := services.NewCalendarService(db, taskDAL, projectDAL, labelDAL, reminderDAL, settingsService)
+	calendarFeedService := services.NewCalendarFeedService(calendarFeedDAL, projectDAL, calendarService, cfg)
+	appPasswordService := services.NewAppPasswordService(appPasswordDAL, userDAL)
+	caldavService := services.NewCalDAVService(db, taskDAL, projectDAL, reminderDAL, calendarService, redisService)
+	importService := services.NewImportService(db, projectDAL, taskDAL, labelDAL, settingsService, redisService)
+	accountDataService := services.NewAccountDataService(db, userDAL, projectGroupDAL, projectDAL, sectionDAL, labelDAL, smartListDAL, taskDAL, reminderDAL, settingsService, redisService, cfg)
+	webhookService := services.NewWebhookService(webhookDAL, redisService, cfg)
+	syncService := services.NewSyncService(syncDAL, projectService, labelService, taskService, reminderService)
+	eventBus.Subscribe(webhookService.HandleEvent)
+
+	// 启动实时变更流，事件经Redis分发到各实例的连接
+	eventStream := services.NewEventStream(redisService, cfg)
+	eventBus.Subscribe(eventStream.HandleEvent)
+	eventStream.Start()
+	defer eventStream.Stop()
+
+	// 启动Webhook投递任务和提醒触发任务
+	webhookDispatcher := services.NewWebhookDispatcher(webhookService)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+	reminderDispatcher := services.NewReminderDispatcher(reminderDAL, eventBus, redisService, cfg.Webhook.ReminderInterval)
+	reminderDispatcher.Start()
+	defer reminderDispatcher.Stop()
+	healthChecker.AddCheck("webhook_dispatcher", webhookDispatcher.CheckAlive)
+	healthChecker.AddCheck("reminder_dispatcher", reminderDispatcher.CheckAlive)
+
+	// 启动回收站清理任务
+	trashPurger := services.NewTrashPurger(trashDAL, &cfg.Trash)
+	trashPurger.Start()
+	defer trashPurger.Stop()
+
+	// 启动排序键重新平衡任务
+	orderRebalancer := services.NewOrderRebalancer(db, projectDAL, taskDAL, sectionDAL, projectGroupDAL, &cfg.Ordering)
+	orderRebalancer.Start()
+	defer orderRebalancer.Stop()
+
+	// 注册Prometheus指标采集
+	if cfg.Metrics.Enabled {
+		registerMetrics(db, redisService, reminderDAL, importService, accountDataService, webhookService)
 	}
 
-	// 需要认证的路由
-	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg, tokenStore))
-	{
-		// 用户信息路由
-		protected.GET("/profile", authHandler.GetProfile)
-
-		// 会话管理路由
-		protected.GET("/sessions", authHandler.GetSessions)
-		protected.DELETE("/sessions/:tokenId", authHandler.RevokeSession)
-		protected.POST("/logout-all", authHandler.LogoutAll)
-
-		// 管理员功能（实际项目中需要权限验证）
-		protected.POST("/revoke-token", authHandler.RevokeToken)
-
-		// 监控相关路由
-		monitor := protected.Group("/monitor")
-		{
-			monitor.GET("/health", monitorHandler.GetHealth)
-			monitor.GET("/stats", monitorHandler.GetStats)
-			monitor.GET("/data", monitorHandler.GetMonitoringData)
-			monitor.POST("/cleanup", monitorHandler.ForceCleanup)
-			monitor.GET("/token/:tokenId", monitorHandler.GetTokenInfo)
-			monitor.GET("/user/:userId/sessions", monitorHandler.GetUserSessions)
-			monitor.DELETE("/user/:userId/sessions", monitorHandler.RevokeUserAllSessions)
-			monitor.GET("/metrics", monitorHandler.GetSystemMetrics)
-		}
-
-		// 项目路由
-		projects := protected.Group("/projects")
-		{
-			projects.GET("", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "获取项目列表功能待实现"})
-			})
-			projects.POST("", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "创建项目功能待实现"})
-			})
-			projects.GET("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "获取项目详情功能待实现"})
-			})
-			projects.PUT("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "更新项目功能待实现"})
-			})
-			projects.DELETE("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "删除项目功能待实现"})
-			})
-		}
+	// 初始化安全通知服务
+	notifier := services.NewNotifier(&cfg.SMTP)
+	signInAlertService := services.NewSignInAlertService(redisService, tokenStore, userService, notifier, cfg)
 
-		// 任务路由
-		tasks := protected.Group("/tasks")
-		{
-			tasks.GET("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "获取任务详情功能待实现"})
-			})
-			tasks.POST("", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "创建任务功能待实现"})
-			})
-			tasks.PUT("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "更新任务功能待实现"})
-			})
-			tasks.DELETE("/:id", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "删除任务功能待实现"})
-			})
-			tasks.POST("/:id/complete", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "完成任务功能待实现"})
-			})
-		}
+	// 创建Gin路由器
+	r := router.New(cfg, tokenStore, appPasswordService, &router.Handlers{
+		Auth:        handlers.NewAuthHandler(userService, tokenStore, signInAlertService, cfg),
+		Monitor:     handlers.NewMonitorHandler(tokenMonitor, tokenStore, healthChecker),
+		Project:     handlers.NewProjectHandler(projectService),
+		Task:        handlers.NewTaskHandler(taskService, reminderService),
+		Label:       handlers.NewLabelHandler(labelService),
+		Trash:       handlers.NewTrashHandler(trashService),
+		SmartList:   handlers.NewSmartListHandler(smartListService),
+		Section:     handlers.NewSectionHandler(sectionService),
+		Group:       handlers.NewProjectGroupHandler(projectGroupService),
+		Settings:    handlers.NewSettingsHandler(settingsService),
+		Calendar:    handlers.NewCalendarHandler(calendarService),
+		Feed:        handlers.NewCalendarFeedHandler(calendarFeedService),
+		AppPassword: handlers.NewAppPasswordHandler(appPasswordService),
+		CalDAV:      handlers.NewCalDAVHandler(caldavService),
+		Import:      handlers.NewImportHandler(importService),
+		AccountData: handlers.NewAccountDataHandler(accountDataService),
+		Webhook:     handlers.NewWebhookHandler(webhookService),
+		EventStream: handlers.NewEventStreamHandler(eventStream, tokenStore),
+		Sync:        handlers.NewSyncHandler(syncService),
+	})
 
-		// 日历视图路由
-		calendar := protected.Group("/calendar")
-		{
-			calendar.GET("/view", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "日历视图功能待实现"})
-			})
+	// Prometheus指标端点
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.ListenAddr != "" {
+			metricsServer := metrics.NewServer(cfg.Metrics.ListenAddr, cfg.Metrics.Token)
+			go func() {
+				log.Printf("指标服务启动在 %s", cfg.Metrics.ListenAddr)
+				if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Printf("指标服务异常退出: %v", err)
+				}
+			}()
+		} else if cfg.Metrics.Token == "" {
+			log.Println("警告: 未配置METRICS_TOKEN或METRICS_LISTEN_ADDR，/metrics端点未启用")
 		}
 	}
 
 	// 启动服务器
 	addr := cfg.Server.Host + ":" + cfg.Server.Port
 	log.Printf("服务器启动在 %s", addr)
-	if err := router.Run(addr); err != nil {
+	if err := r.Run(addr); err != nil {
 		log.Fatalf("服务器启动失败: %v", err)
 	}
 }
+
+// registerMetrics 注册数据库、Redis连接池和队列长度指标
+func registerMetrics(db *dal.Database, redisService *services.RedisService, reminders repository.ReminderRepository, imports *services.ImportService, accountData *services.AccountDataService, webhooks *services.WebhookService) {
+	sqlDB, err := db.SQLDB()
+	if err != nil {
+		log.Printf("获取数据库连接池失败，跳过数据库指标: %v", err)
+	} else {
+		metrics.RegisterDB(sqlDB, "postgres")
+	}
+
+	metrics.RegisterRedis(redisService.GetClient())
+
+	metrics.RegisterQueue("reminders", func() (float64, error) {
+		count, err := reminders.CountPending(context.Background())
+		return float64(count), err
+	})
+	metrics.RegisterQueue("imports", func() (float64, error) {
+		return float64(imports.ActiveJobs()), nil
+	})
+	metrics.RegisterQueue("exports", func() (float64, error) {
+		return float64(accountData.ActiveExports()), nil
+	})
+	metrics.RegisterQueue("webhooks", func() (float64, error) {
+		count, err := webhooks.QueueDepth()
+		return float64(count), err
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"ticktick-backend/internal/dal"
+)
+
+// usage 打印命令行用法
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "用法:\n")
+	fmt.Fprintf(out, "  %s [选项]                 启动服务器\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate up             执行所有未执行的迁移\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate down [n]       回滚最近n个迁移（默认1）\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate redo           回滚并重新执行最近一个迁移\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate status         查看迁移状态\n", os.Args[0])
+	fmt.Fprintf(out, "\n选项:\n")
+	flag.PrintDefaults()
+}
+
+// runMigrate 执行migrate子命令
+func runMigrate(ctx context.Context, migrator *dal.Migrator, args []string) error {
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("缺少migrate子命令")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("数据库已是最新版本")
+		}
+		for _, m := range done {
+			fmt.Printf("已执行 %04d_%s\n", m.Version, m.Name)
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("无效的回滚数量: %s", args[1])
+			}
+			steps = n
+		}
+		done, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("没有可以回滚的迁移")
+		}
+		for _, m := range done {
+			fmt.Printf("已回滚 %04d_%s\n", m.Version, m.Name)
+		}
+
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已重做 %04d_%s\n", m.Version, m.Name)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", "-"
+			if s.Applied {
+				state = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state = "modified"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		usage()
+		return fmt.Errorf("未知的migrate子命令: %s", args[0])
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,15 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Redis    RedisConfig
+	Security SecurityConfig
+	SMTP     SMTPConfig
+	Metrics  MetricsConfig
+	Health   HealthConfig
+	Trash    TrashConfig
+	Ordering OrderingConfig
+	Feed     CalendarFeedConfig
+	Webhook  WebhookConfig
+	Stream   EventStreamConfig
 }
 
 // ServerConfig 服务器配置
@@ -22,6 +32,8 @@ type ServerConfig struct {
 	Port string
 	Host string
 	Mode string // gin模式: debug, release, test
+
+	PublicURL string // 对外访问地址，用于生成邮件等场景中的链接
 }
 
 // DatabaseConfig 数据库配置
@@ -32,6 +44,14 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// 连接池配置
+	MaxOpenConns    int           // 最大打开连接数
+	MaxIdleConns    int           // 最大空闲连接数
+	ConnMaxLifetime time.Duration // 连接最长存活时间，0表示不限制
+	ConnMaxIdleTime time.Duration // 连接最长空闲时间，0表示不限制
+
+	AutoMigrate bool // 启动时自动执行数据库迁移
 }
 
 // JWTConfig JWT配置
@@ -59,12 +79,87 @@ type RedisConfig struct {
 	WriteTimeout time.Duration
 
 	// JWT相关配置
-	AccessTokenTTL      time.Duration // AccessToken在Redis中的TTL
-	RefreshTokenTTL     time.Duration // RefreshToken在Redis中的TTL
-	BlacklistMinTTL     time.Duration // 黑名单最小TTL
-	BlacklistMaxTTL     time.Duration // 黑名单最大TTL
-	BlacklistDefaultTTL time.Duration // 黑名单默认TTL
-	MaxSessionsPerUser  int           // 每用户最大会话数
+	AccessTokenTTL      time.Duration  // AccessToken在Redis中的TTL
+	RefreshTokenTTL     time.Duration  // RefreshToken在Redis中的TTL
+	BlacklistMinTTL     time.Duration  // 黑名单最小TTL
+	BlacklistMaxTTL     time.Duration  // 黑名单最大TTL
+	BlacklistDefaultTTL time.Duration  // 黑名单默认TTL
+	MaxSessionsPerUser  int            // 每用户最大会话数
+	MaxSessionsPerRole  map[string]int // 按角色覆盖的最大会话数
+}
+
+// MaxSessionsForRole 获取指定角色的最大会话数，未单独配置的角色使用MaxSessionsPerUser
+// 返回值小于等于0表示不限制
+func (c *RedisConfig) MaxSessionsForRole(role string) int {
+	if limit, ok := c.MaxSessionsPerRole[role]; ok {
+		return limit
+	}
+	return c.MaxSessionsPerUser
+}
+
+// SecurityConfig 账号安全配置
+type SecurityConfig struct {
+	KnownDeviceTTL   time.Duration // 已知设备记录保留时间
+	SignInAlertTTL   time.Duration // "不是我本人"链接有效期
+	PasswordResetTTL time.Duration // 密码重置令牌有效期
+}
+
+// SMTPConfig 邮件通知配置，Host为空时仅记录日志
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled    bool
+	Token      string // 访问/metrics所需的Bearer令牌
+	ListenAddr string // 独立监听地址，为空时挂载在主服务上
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	CheckTimeout time.Duration // 每项就绪检查的超时时间
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays int           // 软删除数据保留天数，超过后彻底删除，<=0表示不自动清理
+	PurgeInterval time.Duration // 清理任务执行间隔
+}
+
+// OrderingConfig 手动排序配置
+type OrderingConfig struct {
+	MaxKeyLength      int           // 排序键超过该长度时重新平衡同级数据
+	RebalanceInterval time.Duration // 重新平衡任务执行间隔
+}
+
+// CalendarFeedConfig 日历订阅源配置，订阅内容只包含该时间窗口内的任务和循环实例
+type CalendarFeedConfig struct {
+	PastDays   int // 包含今天之前多少天
+	FutureDays int // 包含今天之后多少天
+}
+
+// WebhookConfig 出站Webhook配置
+type WebhookConfig struct {
+	Timeout              time.Duration // 单次投递的请求超时
+	MaxAttempts          int           // 每次投递的最大尝试次数，包括第一次
+	RetryBaseDelay       time.Duration // 第一次重试的等待时间，之后每次翻倍
+	RetryMaxDelay        time.Duration // 重试等待时间的上限
+	DisableAfter         int           // 连续失败多少次后自动停用端点
+	PollInterval         time.Duration // 投递队列的轮询间隔
+	ReminderInterval     time.Duration // 检查到期提醒的间隔
+	AllowPrivateNetworks bool          // 是否允许投递到内网、回环和链路本地地址，只应在开发和测试环境开启
+}
+
+// EventStreamConfig 实时变更流配置
+type EventStreamConfig struct {
+	ReplaySize        int           // 每个用户保留的最近事件数，断线重连时据此补发
+	ReplayTTL         time.Duration // 用户没有新事件时补发缓冲的保留时间
+	HeartbeatInterval time.Duration // 心跳间隔，防止代理因连接空闲而断开
 }
 
 // findProjectRoot 查找项目根目录（包含go.mod的目录）
@@ -131,6 +226,8 @@ func LoadConfig() *Config {
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "localhost"),
 			Mode: getEnv("GIN_MODE", "debug"),
+
+			PublicURL: getEnv("SERVER_PUBLIC_URL", "http://localhost:8080"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -139,6 +236,14 @@ func LoadConfig() *Config {
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", "ticktick"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			// 连接池配置
+			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+			AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", false),
 		},
 		JWT: JWTConfig{
 			SecretKey:            getEnv("JWT_SECRET", "your-secret-key"),
@@ -168,6 +273,54 @@ func LoadConfig() *Config {
 			BlacklistMaxTTL:     getEnvAsDuration("REDIS_BLACKLIST_MAX_TTL", 30*24*time.Hour),
 			BlacklistDefaultTTL: getEnvAsDuration("REDIS_BLACKLIST_DEFAULT_TTL", 24*time.Hour),
 			MaxSessionsPerUser:  getEnvAsInt("REDIS_MAX_SESSIONS_PER_USER", 5),
+			MaxSessionsPerRole:  getEnvAsIntMap("REDIS_MAX_SESSIONS_PER_ROLE"),
+		},
+		Security: SecurityConfig{
+			KnownDeviceTTL:   getEnvAsDuration("SECURITY_KNOWN_DEVICE_TTL", 180*24*time.Hour),
+			SignInAlertTTL:   getEnvAsDuration("SECURITY_SIGNIN_ALERT_TTL", 7*24*time.Hour),
+			PasswordResetTTL: getEnvAsDuration("SECURITY_PASSWORD_RESET_TTL", time.Hour),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "no-reply@localhost"),
+		},
+		Metrics: MetricsConfig{
+			Enabled:    getEnvAsBool("METRICS_ENABLED", true),
+			Token:      getEnv("METRICS_TOKEN", ""),
+			ListenAddr: getEnv("METRICS_LISTEN_ADDR", ""),
+		},
+		Health: HealthConfig{
+			CheckTimeout: getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		},
+		Trash: TrashConfig{
+			RetentionDays: getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+			PurgeInterval: getEnvAsDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Ordering: OrderingConfig{
+			MaxKeyLength:      getEnvAsInt("ORDER_MAX_KEY_LENGTH", 32),
+			RebalanceInterval: getEnvAsDuration("ORDER_REBALANCE_INTERVAL", 10*time.Minute),
+		},
+		Feed: CalendarFeedConfig{
+			PastDays:   getEnvAsInt("CALENDAR_FEED_PAST_DAYS", 30),
+			FutureDays: getEnvAsInt("CALENDAR_FEED_FUTURE_DAYS", 180),
+		},
+		Webhook: WebhookConfig{
+			Timeout:              getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:          getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+			RetryBaseDelay:       getEnvAsDuration("WEBHOOK_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:        getEnvAsDuration("WEBHOOK_RETRY_MAX_DELAY", time.Hour),
+			DisableAfter:         getEnvAsInt("WEBHOOK_DISABLE_AFTER", 20),
+			PollInterval:         getEnvAsDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+			ReminderInterval:     getEnvAsDuration("WEBHOOK_REMINDER_INTERVAL", 30*time.Second),
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Stream: EventStreamConfig{
+			ReplaySize:        getEnvAsInt("EVENT_STREAM_REPLAY_SIZE", 500),
+			ReplayTTL:         getEnvAsDuration("EVENT_STREAM_REPLAY_TTL", 24*time.Hour),
+			HeartbeatInterval: getEnvAsDuration("EVENT_STREAM_HEARTBEAT", 25*time.Second),
 		},
 	}
 }
@@ -190,6 +343,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool 获取环境变量并转换为布尔值
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsDuration 获取环境变量并转换为时间间隔
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -200,6 +363,26 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvAsIntMap 获取形如"key1:1,key2:2"的环境变量并转换为整数映射
+func getEnvAsIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if intValue, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+			result[strings.TrimSpace(parts[0])] = intValue
+		}
+	}
+	return result
+}
+
 // GetDSN 获取数据库连接字符串
 func (c *Config) GetDSN() string {
 	return "host=" + c.Database.Host +
@@ -0,0 +1,230 @@
+// Package archive 定义账户数据导出文件的格式
+//
+// 导出文件是一个zip，包含manifest.json和account.json两个文件：manifest.json记录格式标识、
+// 版本、导出时间和各类数据的数量，account.json是账户的全部数据。数据之间通过导出时的ID引用，
+// 导入时会分配新的ID并按引用关系重建。任务的完成记录保存在status和completedAt中，
+// 循环任务的例外随循环任务一起保存
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Format 导出文件的格式标识
+	Format = "ticktick-backend/account-export"
+	// CurrentVersion 当前的格式版本，不兼容的结构变更时递增
+	CurrentVersion = 1
+
+	manifestName = "manifest.json"
+	accountName  = "account.json"
+	// maxEntrySize 解压后单个文件的大小上限
+	maxEntrySize = 200 << 20
+)
+
+// ErrInvalidArchive 文件不是有效的账户导出文件
+var ErrInvalidArchive = errors.New("导出文件无效")
+
+// Manifest 导出文件的说明
+type Manifest struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exportedAt"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// Account 账户的全部数据，不包含密码、会话、应用专用密码和回收站中的数据
+type Account struct {
+	Profile       Profile        `json:"profile"`
+	Settings      *Settings      `json:"settings,omitempty"`
+	ProjectGroups []ProjectGroup `json:"projectGroups"`
+	Projects      []Project      `json:"projects"`
+	Sections      []Section      `json:"sections"`
+	Labels        []Label        `json:"labels"`
+	SmartLists    []SmartList    `json:"smartLists"`
+	Tasks         []Task         `json:"tasks"`
+}
+
+// Profile 用户资料
+type Profile struct {
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Settings 用户设置文档及其结构版本
+type Settings struct {
+	Version  int             `json:"version"`
+	Document json.RawMessage `json:"document"`
+}
+
+// ProjectGroup 项目分组
+type ProjectGroup struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	SortOrder string    `json:"sortOrder"`
+	Collapsed bool      `json:"collapsed"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Project 项目
+type Project struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Color     string     `json:"color"`
+	GroupID   *uuid.UUID `json:"groupId,omitempty"`
+	SortOrder string     `json:"sortOrder"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Section 项目分栏
+type Section struct {
+	ID        uuid.UUID `json:"id"`
+	ProjectID uuid.UUID `json:"projectId"`
+	Name      string    `json:"name"`
+	SortOrder string    `json:"sortOrder"`
+	Collapsed bool      `json:"collapsed"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Label 标签
+type Label struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SmartList 智能清单
+type SmartList struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Task 任务，子任务通过ParentID引用父任务
+type Task struct {
+	ID          uuid.UUID             `json:"id"`
+	ProjectID   uuid.UUID             `json:"projectId"`
+	ParentID    *uuid.UUID            `json:"parentId,omitempty"`
+	SectionID   *uuid.UUID            `json:"sectionId,omitempty"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Status      string                `json:"status"`
+	Priority    int                   `json:"priority"`
+	StartTime   *time.Time            `json:"startTime,omitempty"`
+	DueTime     *time.Time            `json:"dueTime,omitempty"`
+	CompletedAt *time.Time            `json:"completedAt,omitempty"`
+	RRule       string                `json:"rrule,omitempty"`
+	SortOrder   string                `json:"sortOrder"`
+	ICalUID     string                `json:"icalUid,omitempty"`
+	LabelIDs    []uuid.UUID           `json:"labelIds"`
+	Reminders   []time.Time           `json:"reminders"`
+	Exceptions  []RecurrenceException `json:"exceptions,omitempty"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+}
+
+// RecurrenceException 循环任务的例外，NewTaskID为空表示该次实例被删除，否则被该任务替代
+type RecurrenceException struct {
+	OriginalTime time.Time  `json:"originalTime"`
+	NewTaskID    *uuid.UUID `json:"newTaskId,omitempty"`
+}
+
+// Counts 统计各类数据的数量
+func (a *Account) Counts() map[string]int {
+	return map[string]int{
+		"projectGroups": len(a.ProjectGroups),
+		"projects":      len(a.Projects),
+		"sections":      len(a.Sections),
+		"labels":        len(a.Labels),
+		"smartLists":    len(a.SmartLists),
+		"tasks":         len(a.Tasks),
+	}
+}
+
+// Encode 生成导出文件
+func Encode(account *Account, exportedAt time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	manifest := &Manifest{Format: Format, Version: CurrentVersion, ExportedAt: exportedAt.UTC(), Counts: account.Counts()}
+	if err := writeJSON(w, manifestName, exportedAt, manifest); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(w, accountName, exportedAt, account); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode 读取导出文件，格式标识或版本不符时返回ErrInvalidArchive
+func Decode(data []byte) (*Manifest, *Account, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: 不是zip文件", ErrInvalidArchive)
+	}
+
+	var manifest Manifest
+	if err := readJSON(r, manifestName, &manifest); err != nil {
+		return nil, nil, err
+	}
+	if manifest.Format != Format {
+		return nil, nil, fmt.Errorf("%w: 未知的格式 %q", ErrInvalidArchive, manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > CurrentVersion {
+		return nil, nil, fmt.Errorf("%w: 不支持的版本 %d", ErrInvalidArchive, manifest.Version)
+	}
+
+	var account Account
+	if err := readJSON(r, accountName, &account); err != nil {
+		return nil, nil, err
+	}
+	return &manifest, &account, nil
+}
+
+func writeJSON(w *zip.Writer, name string, modified time.Time, v any) error {
+	f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func readJSON(r *zip.Reader, name string, v any) error {
+	f, err := r.Open(name)
+	if err != nil {
+		return fmt.Errorf("%w: 缺少%s", ErrInvalidArchive, name)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxEntrySize+1))
+	if err != nil {
+		return fmt.Errorf("%w: 读取%s失败: %v", ErrInvalidArchive, name, err)
+	}
+	if len(data) > maxEntrySize {
+		return fmt.Errorf("%w: %s过大", ErrInvalidArchive, name)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: 解析%s失败: %v", ErrInvalidArchive, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.AppPasswordRepository = (*AppPasswordDAL)(nil)
+
+// AppPasswordDAL 应用专用密码数据访问层
+type AppPasswordDAL struct {
+	db *Database
+}
+
+// NewAppPasswordDAL 创建应用专用密码数据访问层实例
+func NewAppPasswordDAL(db *Database) *AppPasswordDAL {
+	return &AppPasswordDAL{db: db}
+}
+
+// CreateAppPassword 创建应用专用密码
+func (dal *AppPasswordDAL) CreateAppPassword(ctx context.Context, password *models.AppPassword) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(password).Error
+}
+
+// GetAppPasswordByHash 按密码摘要获取应用专用密码
+func (dal *AppPasswordDAL) GetAppPasswordByHash(ctx context.Context, tokenHash string) (*models.AppPassword, error) {
+	var password models.AppPassword
+	if err := dal.db.conn(ctx).Where("token_hash = ?", tokenHash).First(&password).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 密码不存在
+		}
+		return nil, err
+	}
+	return &password, nil
+}
+
+// ListAppPasswords 按分页请求获取用户的应用专用密码
+func (dal *AppPasswordDAL) ListAppPasswords(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.AppPassword, error) {
+	var passwords []*models.AppPassword
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "app_passwords.id").Find(&passwords).Error
+	return passwords, err
+}
+
+// TouchAppPassword 记录密码最近一次使用的时间
+func (dal *AppPasswordDAL) TouchAppPassword(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return dal.db.conn(ctx).Model(&models.AppPassword{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// DeleteAppPassword 删除用户的应用专用密码
+func (dal *AppPasswordDAL) DeleteAppPassword(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.AppPassword{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,83 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.CalendarFeedRepository = (*CalendarFeedDAL)(nil)
+
+// CalendarFeedDAL 日历订阅源数据访问层
+type CalendarFeedDAL struct {
+	db *Database
+}
+
+// NewCalendarFeedDAL 创建日历订阅源数据访问层实例
+func NewCalendarFeedDAL(db *Database) *CalendarFeedDAL {
+	return &CalendarFeedDAL{db: db}
+}
+
+// CreateCalendarFeed 创建订阅源
+func (dal *CalendarFeedDAL) CreateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(feed).Error
+}
+
+// GetCalendarFeedByID 根据ID获取用户的订阅源
+func (dal *CalendarFeedDAL) GetCalendarFeedByID(ctx context.Context, userID, id uuid.UUID) (*models.CalendarFeed, error) {
+	return dal.first(dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+// GetCalendarFeedByToken 按订阅令牌获取订阅源
+func (dal *CalendarFeedDAL) GetCalendarFeedByToken(ctx context.Context, token string) (*models.CalendarFeed, error) {
+	return dal.first(dal.db.conn(ctx).Where("token = ?", token))
+}
+
+func (dal *CalendarFeedDAL) first(query *gorm.DB) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := query.First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 订阅源不存在
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// ListCalendarFeeds 按分页请求获取用户的订阅源
+func (dal *CalendarFeedDAL) ListCalendarFeeds(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.CalendarFeed, error) {
+	var feeds []*models.CalendarFeed
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "calendar_feeds.id").Find(&feeds).Error
+	return feeds, err
+}
+
+// UpdateCalendarFeed 更新订阅源
+func (dal *CalendarFeedDAL) UpdateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(feed).Error
+}
+
+// DeleteCalendarFeed 删除订阅源，订阅地址随即失效
+func (dal *CalendarFeedDAL) DeleteCalendarFeed(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.CalendarFeed{}).Error
+}
+
+// CalendarFeedExists 检查用户是否已有该范围的订阅源
+func (dal *CalendarFeedDAL) CalendarFeedExists(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) (bool, error) {
+	query := dal.db.conn(ctx).Model(&models.CalendarFeed{}).Where("user_id = ?", userID)
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
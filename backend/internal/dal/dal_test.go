@@ -0,0 +1,295 @@
+package dal
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDatabase 连接TEST_DATABASE_DSN指定的PostgreSQL并执行全部迁移，未设置时跳过测试
+// 测试数据使用随机邮箱的用户隔离，可以在已有数据的库上重复运行
+func openTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("未设置TEST_DATABASE_DSN，跳过数据库集成测试")
+	}
+
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	db := &Database{GORM: gormDB}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
+
+func TestMigratorCheckPendingIsReadOnly(t *testing.T) {
+	openTestDatabase(t) // 确保迁移已全部执行
+	ctx := context.Background()
+
+	// 就绪检查使用只读会话，执行任何DDL或写入都会报错
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "default_transaction_read_only=on"
+	} else {
+		dsn += " default_transaction_read_only=on"
+	}
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	readOnly := &Database{GORM: gormDB}
+	t.Cleanup(func() { readOnly.Close() })
+
+	migrator, err := NewMigrator(readOnly)
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	if err := migrator.CheckPending(ctx); err != nil {
+		t.Fatalf("只读连接上CheckPending = %v", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) == 0 || !statuses[0].Applied {
+		t.Fatalf("只读连接上Status = %v, %v", statuses, err)
+	}
+
+	// 修改数据库的操作需要可写连接
+	if _, err := migrator.Up(ctx); err == nil {
+		t.Fatal("只读连接上Up应返回错误")
+	}
+}
+
+// createTestUser 创建邮箱唯一的测试用户
+func createTestUser(t *testing.T, db *Database) *models.User {
+	t.Helper()
+	user := &models.User{
+		Email:        uuid.NewString() + "@example.com",
+		PasswordHash: "x",
+	}
+	if err := NewUserDAL(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+func TestProjectCRUD(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	projects := NewProjectDAL(db)
+	user := createTestUser(t, db)
+	other := createTestUser(t, db)
+
+	project := &models.Project{UserID: user.ID, Name: "工作", Color: "#FF0000"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+
+	got, err := projects.GetProjectByID(ctx, user.ID, project.ID)
+	if err != nil || got == nil || got.Name != "工作" {
+		t.Fatalf("GetProjectByID = %+v, %v", got, err)
+	}
+	if got, err := projects.GetProjectByID(ctx, other.ID, project.ID); err != nil || got != nil {
+		t.Fatalf("其他用户读取项目 = %+v, %v, 期望不存在", got, err)
+	}
+
+	exists, err := projects.ProjectNameExists(ctx, user.ID, "工作", uuid.Nil)
+	if err != nil || !exists {
+		t.Fatalf("ProjectNameExists = %v, %v, 期望存在", exists, err)
+	}
+	if exists, _ := projects.ProjectNameExists(ctx, user.ID, "工作", project.ID); exists {
+		t.Fatal("排除自身后仍然认为项目重名")
+	}
+
+	got.Name = "个人"
+	if err := projects.UpdateProject(ctx, got); err != nil {
+		t.Fatalf("更新项目失败: %v", err)
+	}
+	list, err := projects.ListProjects(ctx, user.ID, pagination.Request{})
+	if err != nil || len(list) != 1 || list[0].Name != "个人" {
+		t.Fatalf("ListProjects = %+v, %v", list, err)
+	}
+	if list, _ := projects.ListProjects(ctx, other.ID, pagination.Request{}); len(list) != 0 {
+		t.Fatalf("其他用户的项目列表 = %+v, 期望为空", list)
+	}
+}
+
+func TestTaskLabelsAndReminders(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	projects, tasks, labels, reminders := NewProjectDAL(db), NewTaskDAL(db), NewLabelDAL(db), NewReminderDAL(db)
+
+	project := &models.Project{UserID: user.ID, Name: "工作", Color: "#FF0000"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+	task := &models.Task{UserID: user.ID, ProjectID: project.ID, Title: "写周报", Status: models.TaskStatusIncomplete, Priority: 2}
+	if err := tasks.CreateTask(ctx, task); err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+
+	urgent := &models.Label{UserID: user.ID, Name: "紧急"}
+	home := &models.Label{UserID: user.ID, Name: "家庭"}
+	for _, label := range []*models.Label{urgent, home} {
+		if err := labels.CreateLabel(ctx, label); err != nil {
+			t.Fatalf("创建标签失败: %v", err)
+		}
+	}
+	if err := tasks.SetTaskLabels(ctx, task.ID, []uuid.UUID{urgent.ID, home.ID}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	// 重新设置时替换原有标签而不是追加
+	if err := tasks.SetTaskLabels(ctx, task.ID, []uuid.UUID{urgent.ID}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+
+	remindAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	reminder := &models.Reminder{TaskID: task.ID, RemindAt: remindAt}
+	if err := reminders.CreateReminder(ctx, reminder); err != nil {
+		t.Fatalf("创建提醒失败: %v", err)
+	}
+
+	got, err := tasks.GetTaskByID(ctx, user.ID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetTaskByID = %+v, %v", got, err)
+	}
+	if len(got.Labels) != 1 || got.Labels[0].ID != urgent.ID {
+		t.Fatalf("任务标签 = %+v, 期望只有紧急", got.Labels)
+	}
+	if len(got.Reminders) != 1 || !got.Reminders[0].RemindAt.Equal(remindAt) {
+		t.Fatalf("任务提醒 = %+v", got.Reminders)
+	}
+
+	// 删除标签时同时解除与任务的关联
+	if err := labels.DeleteLabel(ctx, user.ID, urgent.ID); err != nil {
+		t.Fatalf("删除标签失败: %v", err)
+	}
+	if got, _ := tasks.GetTaskByID(ctx, user.ID, task.ID); len(got.Labels) != 0 {
+		t.Fatalf("删除标签后任务标签 = %+v, 期望为空", got.Labels)
+	}
+
+	if err := reminders.DeleteReminder(ctx, task.ID, reminder.ID); err != nil {
+		t.Fatalf("删除提醒失败: %v", err)
+	}
+	if got, err := reminders.GetReminderByID(ctx, task.ID, reminder.ID); err != nil || got != nil {
+		t.Fatalf("删除后GetReminderByID = %+v, %v, 期望不存在", got, err)
+	}
+	if list, _ := reminders.ListReminders(ctx, task.ID, pagination.Request{}); len(list) != 0 {
+		t.Fatalf("删除后提醒列表 = %+v, 期望为空", list)
+	}
+}
+
+func TestDeleteProjectCascades(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	projects, tasks, reminders := NewProjectDAL(db), NewTaskDAL(db), NewReminderDAL(db)
+
+	project := &models.Project{UserID: user.ID, Name: "工作", Color: "#FF0000"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+	parent := &models.Task{UserID: user.ID, ProjectID: project.ID, Title: "父任务", Status: models.TaskStatusIncomplete, Priority: 4}
+	if err := tasks.CreateTask(ctx, parent); err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+	child := &models.Task{UserID: user.ID, ProjectID: project.ID, ParentID: &parent.ID, Title: "子任务", Status: models.TaskStatusIncomplete, Priority: 4}
+	if err := tasks.CreateTask(ctx, child); err != nil {
+		t.Fatalf("创建子任务失败: %v", err)
+	}
+	reminder := &models.Reminder{TaskID: child.ID, RemindAt: time.Now().Add(time.Hour)}
+	if err := reminders.CreateReminder(ctx, reminder); err != nil {
+		t.Fatalf("创建提醒失败: %v", err)
+	}
+
+	if err := projects.DeleteProject(ctx, user.ID, project.ID); err != nil {
+		t.Fatalf("删除项目失败: %v", err)
+	}
+	if got, err := projects.GetProjectByID(ctx, user.ID, project.ID); err != nil || got != nil {
+		t.Fatalf("删除后GetProjectByID = %+v, %v, 期望不存在", got, err)
+	}
+	for _, id := range []uuid.UUID{parent.ID, child.ID} {
+		if got, err := tasks.GetTaskByID(ctx, user.ID, id); err != nil || got != nil {
+			t.Fatalf("删除项目后任务 %s = %+v, %v, 期望不存在", id, got, err)
+		}
+	}
+	if got, err := reminders.GetReminderByID(ctx, child.ID, reminder.ID); err != nil || got != nil {
+		t.Fatalf("删除项目后提醒 = %+v, %v, 期望不存在", got, err)
+	}
+
+	// 项目、任务和提醒使用同一个删除时间，回收站据此整体恢复
+	var deletedAt []time.Time
+	db.GORM.Unscoped().Model(&models.Task{}).Where("project_id = ?", project.ID).Pluck("deleted_at", &deletedAt)
+	var reminderDeletedAt []time.Time
+	db.GORM.Unscoped().Model(&models.Reminder{}).Where("id = ?", reminder.ID).Pluck("deleted_at", &reminderDeletedAt)
+	if len(deletedAt) != 2 || !deletedAt[0].Equal(deletedAt[1]) || len(reminderDeletedAt) != 1 || !deletedAt[0].Equal(reminderDeletedAt[0]) {
+		t.Fatalf("删除时间不一致: 任务 %v, 提醒 %v", deletedAt, reminderDeletedAt)
+	}
+}
+
+func TestForeignKeys(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	projects, tasks, reminders, trash := NewProjectDAL(db), NewTaskDAL(db), NewReminderDAL(db), NewTrashDAL(db)
+
+	// 引用不存在的项目或任务时写入失败
+	orphan := &models.Task{UserID: user.ID, ProjectID: uuid.New(), Title: "孤立任务", Status: models.TaskStatusIncomplete, Priority: 4}
+	if err := tasks.CreateTask(ctx, orphan); err == nil {
+		t.Fatal("引用不存在的项目时创建任务应失败")
+	}
+	if err := reminders.CreateReminder(ctx, &models.Reminder{TaskID: uuid.New(), RemindAt: time.Now()}); err == nil {
+		t.Fatal("引用不存在的任务时创建提醒应失败")
+	}
+
+	// 彻底删除项目时先删除引用它的任务和提醒
+	project := &models.Project{UserID: user.ID, Name: "工作", Color: "#FF0000"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+	task := &models.Task{UserID: user.ID, ProjectID: project.ID, Title: "写周报", Status: models.TaskStatusIncomplete, Priority: 4}
+	if err := tasks.CreateTask(ctx, task); err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+	if err := reminders.CreateReminder(ctx, &models.Reminder{TaskID: task.ID, RemindAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("创建提醒失败: %v", err)
+	}
+	if err := projects.DeleteProject(ctx, user.ID, project.ID); err != nil {
+		t.Fatalf("删除项目失败: %v", err)
+	}
+	if err := trash.PurgeProject(ctx, user.ID, project.ID); err != nil {
+		t.Fatalf("彻底删除项目失败: %v", err)
+	}
+	var count int64
+	db.GORM.Unscoped().Model(&models.Task{}).Where("project_id = ?", project.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("彻底删除项目后剩余 %d 个任务", count)
+	}
+}
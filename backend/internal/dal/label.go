@@ -0,0 +1,88 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.LabelRepository = (*LabelDAL)(nil)
+
+// LabelDAL 标签数据访问层
+type LabelDAL struct {
+	db *Database
+}
+
+// NewLabelDAL 创建标签数据访问层实例
+func NewLabelDAL(db *Database) *LabelDAL {
+	return &LabelDAL{db: db}
+}
+
+// CreateLabel 创建标签
+func (dal *LabelDAL) CreateLabel(ctx context.Context, label *models.Label) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(label).Error
+}
+
+// GetLabelByID 根据ID获取用户的标签
+func (dal *LabelDAL) GetLabelByID(ctx context.Context, userID, id uuid.UUID) (*models.Label, error) {
+	var label models.Label
+	err := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).First(&label).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 标签不存在
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+// GetLabelsByIDs 获取属于用户的指定标签
+func (dal *LabelDAL) GetLabelsByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*models.Label, error) {
+	var labels []*models.Label
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	err := dal.db.conn(ctx).Where("user_id = ? AND id IN ?", userID, ids).Find(&labels).Error
+	return labels, err
+}
+
+// ListLabels 按分页请求获取用户的标签
+func (dal *LabelDAL) ListLabels(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Label, error) {
+	var labels []*models.Label
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "labels.id").Find(&labels).Error
+	return labels, err
+}
+
+// UpdateLabel 更新标签
+func (dal *LabelDAL) UpdateLabel(ctx context.Context, label *models.Label) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(label).Error
+}
+
+// DeleteLabel 软删除标签并解除与任务的关联
+func (dal *LabelDAL) DeleteLabel(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.db.conn(ctx)
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Label{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Exec("DELETE FROM task_labels WHERE label_id = ?", id).Error
+	})
+}
+
+// LabelNameExists 检查用户下是否存在同名标签
+func (dal *LabelDAL) LabelNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := dal.db.conn(ctx).Model(&models.Label{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS task_recurrence_exceptions;
+DROP TABLE IF EXISTS reminders;
+DROP TABLE IF EXISTS task_labels;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS labels;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,98 @@
+-- 初始表结构，与此前AutoMigrate创建的结构保持一致，已有数据库可以直接执行
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email         VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    first_name    VARCHAR(100),
+    last_name     VARCHAR(100),
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    deleted_at    TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    color      VARCHAR(7) NOT NULL DEFAULT '#CCCCCC',
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS labels (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL,
+    project_id   UUID NOT NULL,
+    parent_id    UUID,
+    title        VARCHAR(255) NOT NULL,
+    description  TEXT,
+    status       TEXT NOT NULL DEFAULT 'incomplete' CHECK (status IN ('incomplete', 'completed')),
+    priority     BIGINT NOT NULL DEFAULT 4 CHECK (priority BETWEEN 1 AND 4),
+    start_time   TIMESTAMPTZ,
+    due_time     TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    r_rule_string TEXT,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    deleted_at   TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id  UUID NOT NULL,
+    label_id UUID NOT NULL,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id    UUID NOT NULL,
+    remind_at  TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS task_recurrence_exceptions (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recurring_task_id UUID NOT NULL,
+    original_time     TIMESTAMPTZ NOT NULL,
+    new_task_id       UUID,
+    deleted_at        TIMESTAMPTZ
+);
+
+-- 软删除索引
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_labels_deleted_at ON labels(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_reminders_deleted_at ON reminders(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_task_recurrence_exceptions_deleted_at ON task_recurrence_exceptions(deleted_at);
+
+-- 唯一约束
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_name ON projects(user_id, name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_user_name ON labels(user_id, name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_exceptions_unique ON task_recurrence_exceptions(recurring_task_id, original_time) WHERE deleted_at IS NULL;
+
+-- 性能索引
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id);
+CREATE INDEX IF NOT EXISTS idx_labels_user_id ON labels(user_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks(user_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_time ON tasks(due_time);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
+CREATE INDEX IF NOT EXISTS idx_reminders_task_id ON reminders(task_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_remind_at ON reminders(remind_at);
+CREATE INDEX IF NOT EXISTS idx_task_recurrence_exceptions_recurring_task_id ON task_recurrence_exceptions(recurring_task_id);
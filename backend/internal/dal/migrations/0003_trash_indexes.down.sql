@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_trash;
+DROP INDEX IF EXISTS idx_projects_trash;
@@ -0,0 +1,3 @@
+-- 回收站查询只关心已软删除的记录，使用部分索引避免影响正常数据的写入
+CREATE INDEX IF NOT EXISTS idx_projects_trash ON projects(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_trash ON tasks(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_tasks_search_text_trgm;
+DROP INDEX IF EXISTS idx_tasks_search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_text;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
+DROP TRIGGER IF EXISTS trg_labels_sync_label_names ON labels;
+DROP TRIGGER IF EXISTS trg_task_labels_sync_label_names ON task_labels;
+DROP FUNCTION IF EXISTS labels_sync_label_names();
+DROP FUNCTION IF EXISTS task_labels_sync_label_names();
+DROP FUNCTION IF EXISTS task_label_names(UUID);
+ALTER TABLE tasks DROP COLUMN IF EXISTS label_names;
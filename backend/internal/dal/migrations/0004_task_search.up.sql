@@ -0,0 +1,60 @@
+-- 任务全文搜索：标题、描述和标签名称
+-- 生成列不能引用其他表，标签名称由触发器同步到 tasks.label_names，再参与生成列计算
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS label_names TEXT NOT NULL DEFAULT '';
+
+-- 任务当前关联的未删除标签名称，以空格分隔
+CREATE OR REPLACE FUNCTION task_label_names(p_task_id UUID) RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(l.name, ' ' ORDER BY l.name), '')
+    FROM task_labels tl
+    JOIN labels l ON l.id = tl.label_id
+    WHERE tl.task_id = p_task_id AND l.deleted_at IS NULL
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION task_labels_sync_label_names() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE tasks SET label_names = task_label_names(OLD.task_id) WHERE id = OLD.task_id;
+    ELSE
+        UPDATE tasks SET label_names = task_label_names(NEW.task_id) WHERE id = NEW.task_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION labels_sync_label_names() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE tasks SET label_names = task_label_names(tasks.id)
+    WHERE id IN (SELECT task_id FROM task_labels WHERE label_id = OLD.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_task_labels_sync_label_names ON task_labels;
+CREATE TRIGGER trg_task_labels_sync_label_names
+    AFTER INSERT OR DELETE ON task_labels
+    FOR EACH ROW EXECUTE FUNCTION task_labels_sync_label_names();
+
+-- 标签改名、删除或恢复时刷新关联任务
+DROP TRIGGER IF EXISTS trg_labels_sync_label_names ON labels;
+CREATE TRIGGER trg_labels_sync_label_names
+    AFTER UPDATE OF name, deleted_at OR DELETE ON labels
+    FOR EACH ROW EXECUTE FUNCTION labels_sync_label_names();
+
+UPDATE tasks SET label_names = task_label_names(id);
+
+-- 使用simple配置：不做词干提取，前缀匹配的结果与用户输入保持一致
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(label_names, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'C')
+) STORED;
+
+-- 中文等不按空格分词的文本无法通过tsvector匹配，使用三元组索引支持子串搜索
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    lower(COALESCE(title, '') || ' ' || COALESCE(label_names, '') || ' ' || COALESCE(description, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_tasks_search_text_trgm ON tasks USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,11 @@
+-- 智能清单：按用户保存的任务筛选表达式，内置清单不入库
+CREATE TABLE IF NOT EXISTS smart_lists (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    query      TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_smart_lists_user_name ON smart_lists(user_id, name);
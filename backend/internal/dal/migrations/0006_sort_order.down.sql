@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_sort_order;
+DROP INDEX IF EXISTS idx_projects_sort_order;
+ALTER TABLE tasks DROP COLUMN IF EXISTS sort_order;
+ALTER TABLE projects DROP COLUMN IF EXISTS sort_order;
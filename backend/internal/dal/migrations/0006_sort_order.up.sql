@@ -0,0 +1,24 @@
+-- 手动排序使用的分数索引，排序键按字节序比较，必须使用 "C" 排序规则
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS sort_order TEXT COLLATE "C" NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS sort_order TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+-- 已有数据按创建时间生成初始排序键：8位十六进制序号加后缀V，保证不以0结尾
+UPDATE projects p
+SET sort_order = lpad(to_hex(o.rn), 8, '0') || 'V'
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY created_at, id) AS rn
+    FROM projects
+) o
+WHERE p.id = o.id;
+
+-- 任务在同一项目下的同一父任务内排序
+UPDATE tasks t
+SET sort_order = lpad(to_hex(o.rn), 8, '0') || 'V'
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id, project_id, parent_id ORDER BY created_at, id) AS rn
+    FROM tasks
+) o
+WHERE t.id = o.id;
+
+CREATE INDEX IF NOT EXISTS idx_projects_sort_order ON projects(user_id, sort_order) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_sort_order ON tasks(project_id, parent_id, sort_order) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_section_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS section_id;
+DROP TABLE IF EXISTS sections;
@@ -0,0 +1,17 @@
+-- 项目分栏（看板列），分栏之间按分数索引手动排序
+CREATE TABLE IF NOT EXISTS sections (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    project_id UUID NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    sort_order TEXT COLLATE "C" NOT NULL DEFAULT '',
+    collapsed  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sections_project_sort_order ON sections(project_id, sort_order);
+
+-- 任务所属的分栏，为空表示项目的默认分栏
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS section_id UUID;
+CREATE INDEX IF NOT EXISTS idx_tasks_section_id ON tasks(section_id) WHERE section_id IS NOT NULL;
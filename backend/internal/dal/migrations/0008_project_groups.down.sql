@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_projects_group_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS group_id;
+DROP TABLE IF EXISTS project_groups;
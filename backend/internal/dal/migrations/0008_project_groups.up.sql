@@ -0,0 +1,16 @@
+-- 项目分组（侧边栏文件夹），分组之间按分数索引手动排序
+CREATE TABLE IF NOT EXISTS project_groups (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    sort_order TEXT COLLATE "C" NOT NULL DEFAULT '',
+    collapsed  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_groups_user_sort_order ON project_groups(user_id, sort_order);
+
+-- 项目所属的分组，为空表示未分组
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS group_id UUID;
+CREATE INDEX IF NOT EXISTS idx_projects_group_id ON projects(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- 用户设置，document为JSON文档，version为写入时的文档结构版本
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id    UUID PRIMARY KEY,
+    version    INTEGER NOT NULL DEFAULT 1,
+    document   JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
@@ -0,0 +1,3 @@
+-- 从iCalendar导入的任务保存原始UID，重复导入同一日历时据此跳过已导入的任务
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS ical_uid VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_ical_uid ON tasks(user_id, ical_uid) WHERE ical_uid <> '';
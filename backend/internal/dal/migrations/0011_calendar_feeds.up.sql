@@ -0,0 +1,13 @@
+-- iCalendar订阅源，每个用户的全部任务和每个项目各最多一个
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL,
+    project_id UUID,
+    token      VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feeds_token ON calendar_feeds(token);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feeds_scope
+    ON calendar_feeds(user_id, COALESCE(project_id, '00000000-0000-0000-0000-000000000000'));
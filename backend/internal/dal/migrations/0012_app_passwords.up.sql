@@ -0,0 +1,12 @@
+-- 应用专用密码，CalDAV客户端使用HTTP Basic认证
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    token_hash   VARCHAR(64) NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user_id ON app_passwords(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_app_passwords_token_hash ON app_passwords(token_hash);
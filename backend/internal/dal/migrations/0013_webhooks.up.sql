@@ -0,0 +1,36 @@
+-- 出站Webhook端点，events是订阅的事件类型数组
+CREATE TABLE IF NOT EXISTS webhooks (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL,
+    url           VARCHAR(2048) NOT NULL,
+    secret        VARCHAR(64) NOT NULL,
+    events        JSONB NOT NULL DEFAULT '[]',
+    enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    disabled_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+
+-- Webhook投递记录，待投递的记录同时在Redis队列中排队
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id      UUID NOT NULL,
+    event_id        UUID NOT NULL,
+    event_type      VARCHAR(50) NOT NULL,
+    payload         TEXT NOT NULL,
+    status          VARCHAR(20) NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    response_code   INTEGER,
+    response_body   TEXT,
+    error           TEXT,
+    duration_ms     BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    redelivery_of   UUID,
+    created_at      TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS trg_task_recurrence_exceptions_sync ON task_recurrence_exceptions;
+DROP TRIGGER IF EXISTS trg_reminders_sync ON reminders;
+DROP TRIGGER IF EXISTS trg_tasks_sync ON tasks;
+DROP TRIGGER IF EXISTS trg_labels_sync ON labels;
+DROP TRIGGER IF EXISTS trg_projects_sync ON projects;
+DROP FUNCTION IF EXISTS sync_track_exceptions();
+DROP FUNCTION IF EXISTS sync_track_reminders();
+DROP FUNCTION IF EXISTS sync_track_owned();
+DROP FUNCTION IF EXISTS sync_record_change(UUID, TEXT, UUID, BOOLEAN);
+DROP TABLE IF EXISTS sync_changes;
+DROP TABLE IF EXISTS sync_sequences;
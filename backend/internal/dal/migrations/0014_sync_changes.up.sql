@@ -0,0 +1,130 @@
+-- 增量同步的变更日志：每个实体只保留最近一次变更，删除的实体保留为墓碑
+-- 序号按用户递增，由触发器在写入项目、标签、任务、提醒和循环例外时维护，覆盖所有写入路径
+CREATE TABLE IF NOT EXISTS sync_sequences (
+    user_id UUID PRIMARY KEY,
+    seq     BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sync_changes (
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id   UUID NOT NULL,
+    user_id     UUID NOT NULL,
+    seq         BIGINT NOT NULL,
+    deleted     BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (entity_type, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_changes_user_seq ON sync_changes(user_id, seq);
+
+-- 分配序号并记录变更
+-- sync_sequences的行锁持有到事务提交，同一用户的事务按序号顺序提交，客户端按序号拉取不会漏掉变更
+CREATE OR REPLACE FUNCTION sync_record_change(p_user_id UUID, p_type TEXT, p_id UUID, p_deleted BOOLEAN) RETURNS VOID AS $$
+DECLARE
+    v_seq BIGINT;
+BEGIN
+    IF p_user_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO sync_sequences (user_id, seq) VALUES (p_user_id, 1)
+    ON CONFLICT (user_id) DO UPDATE SET seq = sync_sequences.seq + 1
+    RETURNING seq INTO v_seq;
+
+    INSERT INTO sync_changes (entity_type, entity_id, user_id, seq, deleted, changed_at)
+    VALUES (p_type, p_id, p_user_id, v_seq, p_deleted, clock_timestamp())
+    ON CONFLICT (entity_type, entity_id) DO UPDATE
+    SET seq = EXCLUDED.seq, deleted = EXCLUDED.deleted, changed_at = EXCLUDED.changed_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 带user_id的表：项目、标签和任务，TG_ARGV[0]是实体类型
+-- 软删除记为墓碑；彻底删除已软删除的记录时墓碑已存在，不再记录
+CREATE OR REPLACE FUNCTION sync_track_owned() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NULL THEN
+            PERFORM sync_record_change(OLD.user_id, TG_ARGV[0], OLD.id, TRUE);
+        END IF;
+        RETURN NULL;
+    END IF;
+    PERFORM sync_record_change(NEW.user_id, TG_ARGV[0], NEW.id, NEW.deleted_at IS NOT NULL);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 提醒通过所属任务确定用户
+CREATE OR REPLACE FUNCTION sync_track_reminders() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NULL THEN
+            PERFORM sync_record_change((SELECT user_id FROM tasks WHERE id = OLD.task_id), 'reminder', OLD.id, TRUE);
+        END IF;
+        RETURN NULL;
+    END IF;
+    PERFORM sync_record_change((SELECT user_id FROM tasks WHERE id = NEW.task_id), 'reminder', NEW.id, NEW.deleted_at IS NOT NULL);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 循环例外通过循环任务确定用户
+CREATE OR REPLACE FUNCTION sync_track_exceptions() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NULL THEN
+            PERFORM sync_record_change((SELECT user_id FROM tasks WHERE id = OLD.recurring_task_id), 'exception', OLD.id, TRUE);
+        END IF;
+        RETURN NULL;
+    END IF;
+    PERFORM sync_record_change((SELECT user_id FROM tasks WHERE id = NEW.recurring_task_id), 'exception', NEW.id, NEW.deleted_at IS NOT NULL);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_projects_sync ON projects;
+CREATE TRIGGER trg_projects_sync
+    AFTER INSERT OR UPDATE OR DELETE ON projects
+    FOR EACH ROW EXECUTE FUNCTION sync_track_owned('project');
+
+DROP TRIGGER IF EXISTS trg_labels_sync ON labels;
+CREATE TRIGGER trg_labels_sync
+    AFTER INSERT OR UPDATE OR DELETE ON labels
+    FOR EACH ROW EXECUTE FUNCTION sync_track_owned('label');
+
+-- 任务标签的变化经 tasks.label_names 的同步触发任务的更新，无需单独跟踪 task_labels
+DROP TRIGGER IF EXISTS trg_tasks_sync ON tasks;
+CREATE TRIGGER trg_tasks_sync
+    AFTER INSERT OR UPDATE OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION sync_track_owned('task');
+
+DROP TRIGGER IF EXISTS trg_reminders_sync ON reminders;
+CREATE TRIGGER trg_reminders_sync
+    AFTER INSERT OR UPDATE OR DELETE ON reminders
+    FOR EACH ROW EXECUTE FUNCTION sync_track_reminders();
+
+DROP TRIGGER IF EXISTS trg_task_recurrence_exceptions_sync ON task_recurrence_exceptions;
+CREATE TRIGGER trg_task_recurrence_exceptions_sync
+    AFTER INSERT OR UPDATE OR DELETE ON task_recurrence_exceptions
+    FOR EACH ROW EXECUTE FUNCTION sync_track_exceptions();
+
+-- 已有数据生成初始变更记录，项目和标签排在任务之前，客户端首次同步时先收到被引用的数据
+INSERT INTO sync_changes (entity_type, entity_id, user_id, seq, deleted, changed_at)
+SELECT entity_type, entity_id, user_id,
+       row_number() OVER (PARTITION BY user_id ORDER BY rank, created_at, entity_id),
+       deleted, NOW()
+FROM (
+    SELECT 'project' AS entity_type, id AS entity_id, user_id, deleted_at IS NOT NULL AS deleted, 1 AS rank, created_at FROM projects
+    UNION ALL
+    SELECT 'label', id, user_id, deleted_at IS NOT NULL, 2, created_at FROM labels
+    UNION ALL
+    SELECT 'task', id, user_id, deleted_at IS NOT NULL, 3, created_at FROM tasks
+    UNION ALL
+    SELECT 'reminder', r.id, t.user_id, r.deleted_at IS NOT NULL, 4, r.created_at FROM reminders r JOIN tasks t ON t.id = r.task_id
+    UNION ALL
+    SELECT 'exception', e.id, t.user_id, e.deleted_at IS NOT NULL, 5, t.created_at FROM task_recurrence_exceptions e JOIN tasks t ON t.id = e.recurring_task_id
+) c
+ON CONFLICT (entity_type, entity_id) DO NOTHING;
+
+INSERT INTO sync_sequences (user_id, seq)
+SELECT user_id, MAX(seq) FROM sync_changes GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
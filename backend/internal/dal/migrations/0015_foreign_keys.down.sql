@@ -0,0 +1,11 @@
+-- 从AutoMigrate升级的数据库中这些外键在0015之前已存在，回滚后同样会被删除
+ALTER TABLE task_recurrence_exceptions DROP CONSTRAINT IF EXISTS fk_task_recurrence_exceptions_new_task;
+ALTER TABLE task_recurrence_exceptions DROP CONSTRAINT IF EXISTS fk_tasks_exceptions;
+ALTER TABLE reminders DROP CONSTRAINT IF EXISTS fk_tasks_reminders;
+ALTER TABLE task_labels DROP CONSTRAINT IF EXISTS fk_task_labels_label;
+ALTER TABLE task_labels DROP CONSTRAINT IF EXISTS fk_task_labels_task;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS fk_tasks_sub_tasks;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS fk_projects_tasks;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS fk_users_tasks;
+ALTER TABLE labels DROP CONSTRAINT IF EXISTS fk_users_labels;
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_users_projects;
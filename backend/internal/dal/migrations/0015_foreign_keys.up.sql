@@ -0,0 +1,34 @@
+-- 补充0001遗漏的外键：此前AutoMigrate根据模型的constraint标签创建了这些外键，0001没有包含
+-- 从AutoMigrate升级的数据库已有同名外键，只为缺少外键的列添加，约束名与GORM生成的一致
+DO $$
+DECLARE
+    fk RECORD;
+BEGIN
+    FOR fk IN
+        SELECT * FROM (VALUES
+            ('fk_users_projects',                       'projects',                   'user_id',           'users',    'SET NULL'),
+            ('fk_users_labels',                         'labels',                     'user_id',           'users',    'SET NULL'),
+            ('fk_users_tasks',                          'tasks',                      'user_id',           'users',    'SET NULL'),
+            ('fk_projects_tasks',                       'tasks',                      'project_id',        'projects', 'SET NULL'),
+            ('fk_tasks_sub_tasks',                      'tasks',                      'parent_id',         'tasks',    'SET NULL'),
+            ('fk_task_labels_task',                     'task_labels',                'task_id',           'tasks',    'CASCADE'),
+            ('fk_task_labels_label',                    'task_labels',                'label_id',          'labels',   'CASCADE'),
+            ('fk_tasks_reminders',                      'reminders',                  'task_id',           'tasks',    'SET NULL'),
+            ('fk_tasks_exceptions',                     'task_recurrence_exceptions', 'recurring_task_id', 'tasks',    'SET NULL'),
+            ('fk_task_recurrence_exceptions_new_task',  'task_recurrence_exceptions', 'new_task_id',       'tasks',    'SET NULL')
+        ) AS t(name, tbl, col, ref, on_delete)
+    LOOP
+        IF NOT EXISTS (
+            SELECT 1
+            FROM pg_constraint c
+            JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+            WHERE c.contype = 'f'
+              AND c.conrelid = fk.tbl::regclass
+              AND cardinality(c.conkey) = 1
+              AND a.attname = fk.col
+        ) THEN
+            EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (%I) REFERENCES %I(id) ON UPDATE CASCADE ON DELETE %s',
+                fk.tbl, fk.name, fk.col, fk.ref, fk.on_delete);
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,111 @@
+package dal
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sortOrderNeighbor 在query的范围内查找与key相邻的排序键，key为空时取第一个或最后一个
+func sortOrderNeighbor(query *gorm.DB, key string, next bool) (string, error) {
+	order := "sort_order DESC"
+	if next {
+		order = "sort_order"
+		query = query.Where("sort_order > ?", key)
+	} else if key != "" {
+		query = query.Where("sort_order < ?", key)
+	}
+
+	var keys []string
+	if err := query.Order(order).Limit(1).Pluck("sort_order", &keys).Error; err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	return keys[0], nil
+}
+
+// setSortOrders 在同一事务中逐条修改排序键，使用UpdateColumn避免改动updated_at
+func setSortOrders(ctx context.Context, db *Database, model interface{}, orders map[uuid.UUID]string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		tx := db.conn(ctx)
+		for id, key := range orders {
+			if err := tx.Model(model).Where("id = ?", id).UpdateColumn("sort_order", key).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ProjectSortOrderNeighbor 获取与key相邻的项目排序键
+func (dal *ProjectDAL) ProjectSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	query := dal.db.conn(ctx).Model(&models.Project{}).Where("user_id = ? AND id <> ?", userID, excludeID)
+	return sortOrderNeighbor(query, key, next)
+}
+
+// ListUsersWithLongProjectSortOrder 获取存在过长项目排序键的用户
+func (dal *ProjectDAL) ListUsersWithLongProjectSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.Project{}).
+		Where("length(sort_order) > ?", maxLen).
+		Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// ListProjectIDsBySortOrder 按排序键获取用户全部项目的ID
+func (dal *ProjectDAL) ListProjectIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.Project{}).
+		Where("user_id = ?", userID).
+		Order("sort_order, id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SetProjectSortOrders 批量修改项目的排序键
+func (dal *ProjectDAL) SetProjectSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	return setSortOrders(ctx, dal.db, &models.Project{}, orders)
+}
+
+// taskScopeQuery 限定查询范围为同级任务
+func taskScopeQuery(query *gorm.DB, scope repository.TaskScope) *gorm.DB {
+	query = query.Where("user_id = ? AND project_id = ?", scope.UserID, scope.ProjectID)
+	if scope.ParentID == nil {
+		return query.Where("parent_id IS NULL")
+	}
+	return query.Where("parent_id = ?", *scope.ParentID)
+}
+
+// TaskSortOrderNeighbor 获取同级任务中与key相邻的排序键
+func (dal *TaskDAL) TaskSortOrderNeighbor(ctx context.Context, scope repository.TaskScope, key string, next bool, excludeID uuid.UUID) (string, error) {
+	query := taskScopeQuery(dal.db.conn(ctx).Model(&models.Task{}), scope).Where("id <> ?", excludeID)
+	return sortOrderNeighbor(query, key, next)
+}
+
+// ListTaskScopesWithLongSortOrder 获取存在过长任务排序键的排序范围
+func (dal *TaskDAL) ListTaskScopesWithLongSortOrder(ctx context.Context, maxLen int) ([]repository.TaskScope, error) {
+	var scopes []repository.TaskScope
+	err := dal.db.conn(ctx).Model(&models.Task{}).
+		Distinct("user_id", "project_id", "parent_id").
+		Where("length(sort_order) > ?", maxLen).
+		Scan(&scopes).Error
+	return scopes, err
+}
+
+// ListTaskIDsBySortOrder 按排序键获取同级任务的ID
+func (dal *TaskDAL) ListTaskIDsBySortOrder(ctx context.Context, scope repository.TaskScope) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := taskScopeQuery(dal.db.conn(ctx).Model(&models.Task{}), scope).
+		Order("sort_order, id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SetTaskSortOrders 批量修改任务的排序键
+func (dal *TaskDAL) SetTaskSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	return setSortOrders(ctx, dal.db, &models.Task{}, orders)
+}
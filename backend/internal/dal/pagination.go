@@ -0,0 +1,44 @@
+package dal
+
+import (
+	"ticktick-backend/internal/pagination"
+
+	"gorm.io/gorm"
+)
+
+// paginate 按分页请求追加游标条件、排序和条数限制，ID作为第二排序键保证顺序稳定
+// 多取一条记录，由调用方据此判断是否还有下一页；未指定排序字段时不做处理
+func paginate(query *gorm.DB, page pagination.Request, idColumn string) *gorm.DB {
+	field := page.Sort.Field
+	if field.Column == "" {
+		return query
+	}
+
+	direction, cmp := "ASC", ">"
+	if page.Sort.Desc {
+		direction, cmp = "DESC", "<"
+	}
+	order := field.Column + " " + direction
+	if field.Nullable {
+		order += " NULLS LAST"
+	}
+
+	if after := page.After; after != nil {
+		if after.Value == nil {
+			// 游标已经位于NULL区间，只能继续按ID向后读取
+			query = query.Where(field.Column+" IS NULL AND "+idColumn+" > ?", after.ID)
+		} else {
+			cond := "(" + field.Column + " " + cmp + " ? OR (" + field.Column + " = ? AND " + idColumn + " > ?)"
+			if field.Nullable {
+				cond += " OR " + field.Column + " IS NULL"
+			}
+			query = query.Where(cond+")", after.Value, after.Value, after.ID)
+		}
+	}
+
+	query = query.Order(order).Order(idColumn)
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit + 1)
+	}
+	return query
+}
@@ -0,0 +1,79 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.ProjectRepository = (*ProjectDAL)(nil)
+
+// ProjectDAL 项目数据访问层
+type ProjectDAL struct {
+	db *Database
+}
+
+// NewProjectDAL 创建项目数据访问层实例
+func NewProjectDAL(db *Database) *ProjectDAL {
+	return &ProjectDAL{db: db}
+}
+
+// CreateProject 创建项目
+func (dal *ProjectDAL) CreateProject(ctx context.Context, project *models.Project) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(project).Error
+}
+
+// GetProjectByID 根据ID获取用户的项目
+func (dal *ProjectDAL) GetProjectByID(ctx context.Context, userID, id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	err := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).First(&project).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 项目不存在
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// ListProjects 按分页请求获取用户的项目
+func (dal *ProjectDAL) ListProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error) {
+	var projects []*models.Project
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "projects.id").Find(&projects).Error
+	return projects, err
+}
+
+// UpdateProject 更新项目
+func (dal *ProjectDAL) UpdateProject(ctx context.Context, project *models.Project) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(project).Error
+}
+
+// DeleteProject 软删除项目及其下所有任务
+func (dal *ProjectDAL) DeleteProject(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.db.conn(ctx)
+		now := time.Now()
+		if err := softDeleteTasks(tx, now, "project_id = ? AND user_id = ?", id, userID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Project{}).Where("id = ? AND user_id = ?", id, userID).UpdateColumn("deleted_at", now).Error
+	})
+}
+
+// ProjectNameExists 检查用户下是否存在同名项目
+func (dal *ProjectDAL) ProjectNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := dal.db.conn(ctx).Model(&models.Project{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,109 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.ProjectGroupRepository = (*ProjectGroupDAL)(nil)
+
+// ProjectGroupDAL 项目分组数据访问层
+type ProjectGroupDAL struct {
+	db *Database
+}
+
+// NewProjectGroupDAL 创建项目分组数据访问层实例
+func NewProjectGroupDAL(db *Database) *ProjectGroupDAL {
+	return &ProjectGroupDAL{db: db}
+}
+
+// CreateProjectGroup 创建分组
+func (dal *ProjectGroupDAL) CreateProjectGroup(ctx context.Context, group *models.ProjectGroup) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(group).Error
+}
+
+// GetProjectGroupByID 根据ID获取用户的分组
+func (dal *ProjectGroupDAL) GetProjectGroupByID(ctx context.Context, userID, id uuid.UUID) (*models.ProjectGroup, error) {
+	var group models.ProjectGroup
+	err := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).First(&group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 分组不存在
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListProjectGroups 按分页请求获取用户的分组
+func (dal *ProjectGroupDAL) ListProjectGroups(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.ProjectGroup, error) {
+	var groups []*models.ProjectGroup
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "project_groups.id").Find(&groups).Error
+	return groups, err
+}
+
+// UpdateProjectGroup 更新分组
+func (dal *ProjectGroupDAL) UpdateProjectGroup(ctx context.Context, group *models.ProjectGroup) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(group).Error
+}
+
+// DeleteProjectGroup 删除分组，分组只是项目的归类方式，直接物理删除
+// 回收站中的项目也一并变为未分组，避免恢复后指向不存在的分组
+func (dal *ProjectGroupDAL) DeleteProjectGroup(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.db.conn(ctx)
+		if err := tx.Unscoped().Model(&models.Project{}).
+			Where("group_id = ? AND user_id = ?", id, userID).
+			UpdateColumn("group_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.ProjectGroup{}).Error
+	})
+}
+
+// ProjectGroupNameExists 检查用户下是否存在同名分组
+func (dal *ProjectGroupDAL) ProjectGroupNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := dal.db.conn(ctx).Model(&models.ProjectGroup{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ProjectGroupSortOrderNeighbor 获取用户分组中与key相邻的排序键
+func (dal *ProjectGroupDAL) ProjectGroupSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	query := dal.db.conn(ctx).Model(&models.ProjectGroup{}).Where("user_id = ? AND id <> ?", userID, excludeID)
+	return sortOrderNeighbor(query, key, next)
+}
+
+// ListUsersWithLongProjectGroupSortOrder 获取存在过长分组排序键的用户
+func (dal *ProjectGroupDAL) ListUsersWithLongProjectGroupSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.ProjectGroup{}).
+		Where("length(sort_order) > ?", maxLen).
+		Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// ListProjectGroupIDsBySortOrder 按排序键获取用户全部分组的ID
+func (dal *ProjectGroupDAL) ListProjectGroupIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.ProjectGroup{}).
+		Where("user_id = ?", userID).
+		Order("sort_order, id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SetProjectGroupSortOrders 批量修改分组的排序键
+func (dal *ProjectGroupDAL) SetProjectGroupSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	return setSortOrders(ctx, dal.db, &models.ProjectGroup{}, orders)
+}
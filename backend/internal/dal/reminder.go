@@ -0,0 +1,77 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.ReminderRepository = (*ReminderDAL)(nil)
+
+// ReminderDAL 提醒数据访问层
+type ReminderDAL struct {
+	db *Database
+}
+
+// NewReminderDAL 创建提醒数据访问层实例
+func NewReminderDAL(db *Database) *ReminderDAL {
+	return &ReminderDAL{db: db}
+}
+
+// CreateReminder 创建提醒
+func (dal *ReminderDAL) CreateReminder(ctx context.Context, reminder *models.Reminder) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(reminder).Error
+}
+
+// GetReminderByID 根据ID获取任务的提醒
+func (dal *ReminderDAL) GetReminderByID(ctx context.Context, taskID, id uuid.UUID) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := dal.db.conn(ctx).Where("id = ? AND task_id = ?", id, taskID).First(&reminder).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 提醒不存在
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// ListReminders 按分页请求获取任务的提醒
+func (dal *ReminderDAL) ListReminders(ctx context.Context, taskID uuid.UUID, page pagination.Request) ([]*models.Reminder, error) {
+	var reminders []*models.Reminder
+	query := dal.db.conn(ctx).Where("task_id = ?", taskID)
+	err := paginate(query, page, "reminders.id").Find(&reminders).Error
+	return reminders, err
+}
+
+// DeleteReminder 软删除提醒
+func (dal *ReminderDAL) DeleteReminder(ctx context.Context, taskID, id uuid.UUID) error {
+	return dal.db.conn(ctx).Where("id = ? AND task_id = ?", id, taskID).Delete(&models.Reminder{}).Error
+}
+
+// CountPending 统计尚未到达提醒时间的提醒数量
+func (dal *ReminderDAL) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := dal.db.conn(ctx).Model(&models.Reminder{}).Where("remind_at >= ? AND deleted_at IS NULL", time.Now()).Count(&count).Error
+	return count, err
+}
+
+// ListDueReminders 获取提醒时间在(from, to]之间、所属任务未删除且未完成的提醒
+func (dal *ReminderDAL) ListDueReminders(ctx context.Context, from, to time.Time) ([]*models.Reminder, error) {
+	var reminders []*models.Reminder
+	err := dal.db.conn(ctx).
+		Joins("Task").
+		Where("reminders.remind_at > ? AND reminders.remind_at <= ?", from, to).
+		Where(`"Task".deleted_at IS NULL AND "Task".status = ?`, models.TaskStatusIncomplete).
+		Order("reminders.remind_at, reminders.id").
+		Find(&reminders).Error
+	return reminders, err
+}
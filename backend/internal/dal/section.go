@@ -0,0 +1,100 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.SectionRepository = (*SectionDAL)(nil)
+
+// SectionDAL 项目分栏数据访问层
+type SectionDAL struct {
+	db *Database
+}
+
+// NewSectionDAL 创建项目分栏数据访问层实例
+func NewSectionDAL(db *Database) *SectionDAL {
+	return &SectionDAL{db: db}
+}
+
+// CreateSection 创建分栏
+func (dal *SectionDAL) CreateSection(ctx context.Context, section *models.Section) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(section).Error
+}
+
+// GetSectionByID 根据ID获取用户的分栏
+func (dal *SectionDAL) GetSectionByID(ctx context.Context, userID, id uuid.UUID) (*models.Section, error) {
+	var section models.Section
+	err := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).First(&section).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 分栏不存在
+		}
+		return nil, err
+	}
+	return &section, nil
+}
+
+// ListSections 按分页请求获取项目的分栏
+func (dal *SectionDAL) ListSections(ctx context.Context, userID, projectID uuid.UUID, page pagination.Request) ([]*models.Section, error) {
+	var sections []*models.Section
+	query := dal.db.conn(ctx).Where("user_id = ? AND project_id = ?", userID, projectID)
+	err := paginate(query, page, "sections.id").Find(&sections).Error
+	return sections, err
+}
+
+// UpdateSection 更新分栏
+func (dal *SectionDAL) UpdateSection(ctx context.Context, section *models.Section) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(section).Error
+}
+
+// DeleteSection 删除分栏，分栏只是任务的分组方式，直接物理删除
+// 回收站中的任务也一并移到默认分栏，避免恢复后指向不存在的分栏
+func (dal *SectionDAL) DeleteSection(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.db.conn(ctx)
+		if err := tx.Unscoped().Model(&models.Task{}).
+			Where("section_id = ? AND user_id = ?", id, userID).
+			UpdateColumn("section_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Section{}).Error
+	})
+}
+
+// SectionSortOrderNeighbor 获取项目中与key相邻的分栏排序键
+func (dal *SectionDAL) SectionSortOrderNeighbor(ctx context.Context, projectID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	query := dal.db.conn(ctx).Model(&models.Section{}).Where("project_id = ? AND id <> ?", projectID, excludeID)
+	return sortOrderNeighbor(query, key, next)
+}
+
+// ListProjectsWithLongSectionSortOrder 获取存在过长分栏排序键的项目
+func (dal *SectionDAL) ListProjectsWithLongSectionSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	var projectIDs []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.Section{}).
+		Where("length(sort_order) > ?", maxLen).
+		Distinct().Pluck("project_id", &projectIDs).Error
+	return projectIDs, err
+}
+
+// ListSectionIDsBySortOrder 按排序键获取项目全部分栏的ID
+func (dal *SectionDAL) ListSectionIDsBySortOrder(ctx context.Context, projectID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := dal.db.conn(ctx).Model(&models.Section{}).
+		Where("project_id = ?", projectID).
+		Order("sort_order, id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SetSectionSortOrders 批量修改分栏的排序键
+func (dal *SectionDAL) SetSectionSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	return setSortOrders(ctx, dal.db, &models.Section{}, orders)
+}
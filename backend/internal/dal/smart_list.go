@@ -0,0 +1,71 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.SmartListRepository = (*SmartListDAL)(nil)
+
+// SmartListDAL 智能清单数据访问层
+type SmartListDAL struct {
+	db *Database
+}
+
+// NewSmartListDAL 创建智能清单数据访问层实例
+func NewSmartListDAL(db *Database) *SmartListDAL {
+	return &SmartListDAL{db: db}
+}
+
+// CreateSmartList 创建智能清单
+func (dal *SmartListDAL) CreateSmartList(ctx context.Context, list *models.SmartList) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(list).Error
+}
+
+// GetSmartListByID 根据ID获取用户的智能清单
+func (dal *SmartListDAL) GetSmartListByID(ctx context.Context, userID, id uuid.UUID) (*models.SmartList, error) {
+	var list models.SmartList
+	err := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).First(&list).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 清单不存在
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ListSmartLists 按分页请求获取用户的智能清单
+func (dal *SmartListDAL) ListSmartLists(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.SmartList, error) {
+	var lists []*models.SmartList
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "smart_lists.id").Find(&lists).Error
+	return lists, err
+}
+
+// UpdateSmartList 更新智能清单
+func (dal *SmartListDAL) UpdateSmartList(ctx context.Context, list *models.SmartList) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(list).Error
+}
+
+// DeleteSmartList 删除智能清单，清单只保存筛选条件，直接物理删除
+func (dal *SmartListDAL) DeleteSmartList(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.SmartList{}).Error
+}
+
+// SmartListNameExists 检查用户下是否存在同名智能清单
+func (dal *SmartListDAL) SmartListNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := dal.db.conn(ctx).Model(&models.SmartList{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,100 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var _ repository.SyncRepository = (*SyncDAL)(nil)
+
+// SyncDAL 增量同步数据访问层，变更日志由迁移中的触发器维护
+type SyncDAL struct {
+	db *Database
+}
+
+// NewSyncDAL 创建增量同步数据访问层实例
+func NewSyncDAL(db *Database) *SyncDAL {
+	return &SyncDAL{db: db}
+}
+
+// GetSyncSeq 获取用户当前的变更序号
+func (dal *SyncDAL) GetSyncSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var seqs []int64
+	err := dal.db.conn(ctx).Table("sync_sequences").Where("user_id = ?", userID).Pluck("seq", &seqs).Error
+	if err != nil || len(seqs) == 0 {
+		return 0, err
+	}
+	return seqs[0], nil
+}
+
+// ListSyncChanges 获取用户序号大于since的变更
+func (dal *SyncDAL) ListSyncChanges(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*models.SyncChange, error) {
+	var changes []*models.SyncChange
+	err := dal.db.conn(ctx).
+		Where("user_id = ? AND seq > ?", userID, since).
+		Order("seq").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, err
+}
+
+// GetSyncChange 获取实体最近一次变更
+func (dal *SyncDAL) GetSyncChange(ctx context.Context, entityType string, id uuid.UUID) (*models.SyncChange, error) {
+	var change models.SyncChange
+	err := dal.db.conn(ctx).Where("entity_type = ? AND entity_id = ?", entityType, id).First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 实体没有变更记录
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetSyncEntities 按实体类型和ID批量获取用户未删除的实体
+func (dal *SyncDAL) GetSyncEntities(ctx context.Context, userID uuid.UUID, ids map[string][]uuid.UUID) (*repository.SyncEntities, error) {
+	db := dal.db.conn(ctx)
+	entities := &repository.SyncEntities{}
+
+	if len(ids[models.SyncEntityProject]) > 0 {
+		if err := db.Where("user_id = ? AND id IN ?", userID, ids[models.SyncEntityProject]).
+			Order("sort_order, id").Find(&entities.Projects).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids[models.SyncEntityLabel]) > 0 {
+		if err := db.Where("user_id = ? AND id IN ?", userID, ids[models.SyncEntityLabel]).
+			Order("name, id").Find(&entities.Labels).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids[models.SyncEntityTask]) > 0 {
+		if err := db.Preload("Labels").
+			Where("user_id = ? AND id IN ?", userID, ids[models.SyncEntityTask]).
+			Order("created_at, id").Find(&entities.Tasks).Error; err != nil {
+			return nil, err
+		}
+	}
+	// 提醒和循环例外通过未删除的所属任务校验用户
+	if len(ids[models.SyncEntityReminder]) > 0 {
+		if err := db.Select("reminders.*").Joins("JOIN tasks ON tasks.id = reminders.task_id AND tasks.deleted_at IS NULL").
+			Where("tasks.user_id = ? AND reminders.id IN ?", userID, ids[models.SyncEntityReminder]).
+			Order("reminders.remind_at, reminders.id").Find(&entities.Reminders).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids[models.SyncEntityException]) > 0 {
+		if err := db.Select("task_recurrence_exceptions.*").Joins("JOIN tasks ON tasks.id = task_recurrence_exceptions.recurring_task_id AND tasks.deleted_at IS NULL").
+			Where("tasks.user_id = ? AND task_recurrence_exceptions.id IN ?", userID, ids[models.SyncEntityException]).
+			Order("task_recurrence_exceptions.original_time, task_recurrence_exceptions.id").Find(&entities.Exceptions).Error; err != nil {
+			return nil, err
+		}
+	}
+	return entities, nil
+}
@@ -0,0 +1,246 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/filter"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.TaskRepository = (*TaskDAL)(nil)
+
+// TaskDAL 任务数据访问层
+type TaskDAL struct {
+	db *Database
+}
+
+// NewTaskDAL 创建任务数据访问层实例
+func NewTaskDAL(db *Database) *TaskDAL {
+	return &TaskDAL{db: db}
+}
+
+// CreateTask 创建任务
+func (dal *TaskDAL) CreateTask(ctx context.Context, task *models.Task) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(task).Error
+}
+
+// GetTaskByID 根据ID获取用户的任务，同时加载标签和提醒
+func (dal *TaskDAL) GetTaskByID(ctx context.Context, userID, id uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	err := dal.db.conn(ctx).
+		Preload("Labels").
+		Preload("Reminders", func(db *gorm.DB) *gorm.DB { return db.Order("remind_at") }).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&task).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 任务不存在
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTaskByICalUID 按导入时的iCalendar UID获取任务
+func (dal *TaskDAL) GetTaskByICalUID(ctx context.Context, userID uuid.UUID, uid string) (*models.Task, error) {
+	var task models.Task
+	err := dal.db.conn(ctx).
+		Where("user_id = ? AND ical_uid = ?", userID, uid).
+		Order("created_at").
+		First(&task).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 没有导入过
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListTasks 按过滤条件和分页请求获取任务，同时加载标签
+func (dal *TaskDAL) ListTasks(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter, page pagination.Request) ([]*models.Task, error) {
+	query := applyTaskFilter(dal.db.conn(ctx).Preload("Labels").Where("tasks.user_id = ?", userID), filter)
+
+	var tasks []*models.Task
+	err := paginate(query, page, "tasks.id").Find(&tasks).Error
+	return tasks, err
+}
+
+// ListTasksWithDetails 按过滤条件获取全部任务，同时加载标签、提醒和循环例外
+func (dal *TaskDAL) ListTasksWithDetails(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*models.Task, error) {
+	query := applyTaskFilter(dal.db.conn(ctx).
+		Preload("Labels").
+		Preload("Reminders", func(db *gorm.DB) *gorm.DB { return db.Order("remind_at") }).
+		Preload("Exceptions", func(db *gorm.DB) *gorm.DB { return db.Order("original_time") }).
+		Where("tasks.user_id = ?", userID), filter)
+
+	var tasks []*models.Task
+	err := query.Order("tasks.created_at, tasks.id").Find(&tasks).Error
+	return tasks, err
+}
+
+// SearchTasks 全文搜索任务，查询词走tsvector前缀匹配，原始文本走pg_trgm子串匹配
+func (dal *TaskDAL) SearchTasks(ctx context.Context, userID uuid.UUID, search repository.TaskSearch) ([]*repository.TaskSearchHit, error) {
+	pattern := "%" + escapeLike(search.Text) + "%"
+	query := dal.db.conn(ctx).Model(&models.Task{}).Where("tasks.user_id = ?", userID)
+
+	if tsquery := prefixTSQuery(search.Terms); tsquery != "" {
+		query = query.
+			Select(`tasks.id,
+				ts_rank(tasks.search_vector, to_tsquery('simple', ?)) + word_similarity(?, tasks.search_text) AS rank,
+				ts_headline('simple', tasks.title, to_tsquery('simple', ?), ?) AS title_snippet,
+				ts_headline('simple', COALESCE(tasks.description, ''), to_tsquery('simple', ?), ?) AS description_snippet`,
+				tsquery, search.Text, tsquery, titleHeadlineOptions, tsquery, descriptionHeadlineOptions).
+			Where("(tasks.search_vector @@ to_tsquery('simple', ?) OR tasks.search_text LIKE ?)", tsquery, pattern)
+	} else {
+		query = query.
+			Select("tasks.id, word_similarity(?, tasks.search_text) AS rank, '' AS title_snippet, '' AS description_snippet", search.Text).
+			Where("tasks.search_text LIKE ?", pattern)
+	}
+
+	var rows []struct {
+		ID                 uuid.UUID
+		Rank               float64
+		TitleSnippet       string
+		DescriptionSnippet string
+	}
+	err := applyTaskFilter(query, search.TaskFilter).
+		Order("rank DESC").Order("tasks.updated_at DESC").
+		Limit(search.Limit).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var tasks []*models.Task
+	if err := dal.db.conn(ctx).Preload("Labels").Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	hits := make([]*repository.TaskSearchHit, 0, len(rows))
+	for _, row := range rows {
+		if task, ok := byID[row.ID]; ok {
+			hits = append(hits, &repository.TaskSearchHit{
+				Task:               task,
+				Rank:               row.Rank,
+				TitleSnippet:       row.TitleSnippet,
+				DescriptionSnippet: row.DescriptionSnippet,
+			})
+		}
+	}
+	return hits, nil
+}
+
+// ts_headline 高亮参数：标题完整返回，描述截取包含命中词的片段
+// 返回的片段未经转义，命中部分用控制字符标记，由服务层转义后生成<mark>
+const (
+	titleHeadlineOptions       = `StartSel="` + repository.HighlightStart + `", StopSel="` + repository.HighlightStop + `", HighlightAll=true`
+	descriptionHeadlineOptions = `StartSel="` + repository.HighlightStart + `", StopSel="` + repository.HighlightStop + `", MaxWords=30, MinWords=10, MaxFragments=2`
+)
+
+// applyTaskFilter 将任务过滤条件追加到查询上
+func applyTaskFilter(query *gorm.DB, f repository.TaskFilter) *gorm.DB {
+	if f.ProjectID != nil {
+		query = query.Where("tasks.project_id = ?", *f.ProjectID)
+	}
+	if f.ParentID != nil {
+		query = query.Where("tasks.parent_id = ?", *f.ParentID)
+	}
+	if f.TopLevel {
+		query = query.Where("tasks.parent_id IS NULL")
+	}
+	if f.Status != nil {
+		query = query.Where("tasks.status = ?", *f.Status)
+	}
+	if f.Priority != nil {
+		query = query.Where("tasks.priority = ?", *f.Priority)
+	}
+	if f.DueFrom != nil {
+		query = query.Where("tasks.due_time >= ?", *f.DueFrom)
+	}
+	if f.DueTo != nil {
+		query = query.Where("tasks.due_time < ?", *f.DueTo)
+	}
+	if f.Expr != nil {
+		sql, args := filter.ToSQL(f.Expr, f.Now)
+		query = query.Where(sql, args...)
+	}
+	return query
+}
+
+// prefixTSQuery 将查询词转换为前缀匹配的tsquery，例如 ["buy", "mil"] => 'buy':* & 'mil':*
+// 查询词只包含字母和数字，不会破坏tsquery语法
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term != "" {
+			parts = append(parts, "'"+term+"':*")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// UpdateTask 更新任务
+func (dal *TaskDAL) UpdateTask(ctx context.Context, task *models.Task) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(task).Error
+}
+
+// DeleteTask 软删除任务及其子任务
+func (dal *TaskDAL) DeleteTask(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		return softDeleteTasks(dal.db.conn(ctx), time.Now(), "user_id = ? AND (id = ? OR parent_id = ?)", userID, id, id)
+	})
+}
+
+// softDeleteTasks 软删除满足条件的任务及其提醒
+// 所有记录使用同一个删除时间，回收站恢复时据此识别同一次删除的数据
+func softDeleteTasks(tx *gorm.DB, deletedAt time.Time, query string, args ...interface{}) error {
+	taskIDs := tx.Session(&gorm.Session{NewDB: true}).Model(&models.Task{}).Select("id").Where(query, args...)
+	if err := tx.Model(&models.Reminder{}).Where("task_id IN (?)", taskIDs).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Task{}).Where(query, args...).UpdateColumn("deleted_at", deletedAt).Error
+}
+
+// CreateRecurrenceException 为循环任务添加例外
+func (dal *TaskDAL) CreateRecurrenceException(ctx context.Context, exception *models.TaskRecurrenceException) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(exception).Error
+}
+
+// SetTaskLabels 用labelIDs替换任务的全部标签
+func (dal *TaskDAL) SetTaskLabels(ctx context.Context, taskID uuid.UUID, labelIDs []uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.db.conn(ctx)
+		if err := tx.Exec("DELETE FROM task_labels WHERE task_id = ?", taskID).Error; err != nil {
+			return err
+		}
+		for _, labelID := range labelIDs {
+			if err := tx.Exec("INSERT INTO task_labels (task_id, label_id) VALUES (?, ?) ON CONFLICT DO NOTHING", taskID, labelID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
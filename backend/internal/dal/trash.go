@@ -0,0 +1,255 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var _ repository.TrashRepository = (*TrashDAL)(nil)
+
+// TrashDAL 回收站数据访问层
+type TrashDAL struct {
+	db *Database
+}
+
+// NewTrashDAL 创建回收站数据访问层实例
+func NewTrashDAL(db *Database) *TrashDAL {
+	return &TrashDAL{db: db}
+}
+
+// ListDeletedProjects 按分页请求获取用户已删除的项目
+func (dal *TrashDAL) ListDeletedProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error) {
+	var projects []*models.Project
+	query := dal.db.conn(ctx).Unscoped().
+		Where("projects.user_id = ? AND projects.deleted_at IS NOT NULL", userID)
+	err := paginate(query, page, "projects.id").Find(&projects).Error
+	return projects, err
+}
+
+// ListDeletedTasks 按分页请求获取回收站中的任务，同时加载未删除的标签
+func (dal *TrashDAL) ListDeletedTasks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Task, error) {
+	var tasks []*models.Task
+	query := dal.db.conn(ctx).Unscoped().
+		Preload("Labels", func(db *gorm.DB) *gorm.DB { return db.Where("labels.deleted_at IS NULL") }).
+		Where("tasks.user_id = ? AND tasks.deleted_at IS NOT NULL", userID).
+		Where("NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = tasks.project_id AND p.deleted_at IS NOT NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM tasks parent WHERE parent.id = tasks.parent_id AND parent.deleted_at IS NOT NULL)")
+	err := paginate(query, page, "tasks.id").Find(&tasks).Error
+	return tasks, err
+}
+
+// GetDeletedProject 获取用户已删除的项目
+func (dal *TrashDAL) GetDeletedProject(ctx context.Context, userID, id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	err := dal.db.conn(ctx).Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		First(&project).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 不在回收站中
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetDeletedTask 获取用户已删除的任务
+func (dal *TrashDAL) GetDeletedTask(ctx context.Context, userID, id uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	err := dal.db.conn(ctx).Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		First(&task).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 不在回收站中
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// RestoreProject 恢复项目以及随项目一起删除的任务和提醒
+func (dal *TrashDAL) RestoreProject(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		project, err := dal.GetDeletedProject(ctx, userID, id)
+		if err != nil || project == nil {
+			return err
+		}
+
+		tx := dal.unscoped(ctx)
+		deletedAt := project.DeletedAt.Time
+		if err := restoreTasks(tx, deletedAt, "user_id = ? AND project_id = ?", userID, id); err != nil {
+			return err
+		}
+		return tx.Model(&models.Project{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+	})
+}
+
+// RestoreTask 恢复任务以及随任务一起删除的子任务和提醒
+func (dal *TrashDAL) RestoreTask(ctx context.Context, userID, id uuid.UUID, detachParent bool) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		task, err := dal.GetDeletedTask(ctx, userID, id)
+		if err != nil || task == nil {
+			return err
+		}
+
+		tx := dal.unscoped(ctx)
+		deletedAt := task.DeletedAt.Time
+		if err := restoreTasks(tx, deletedAt, "user_id = ? AND (id = ? OR parent_id = ?)", userID, id, id); err != nil {
+			return err
+		}
+		if detachParent {
+			return tx.Model(&models.Task{}).Where("id = ?", id).UpdateColumn("parent_id", nil).Error
+		}
+		return nil
+	})
+}
+
+// PurgeProject 彻底删除回收站中的项目及其所有任务
+// 任务和分栏引用项目，需在删除项目之前删除
+func (dal *TrashDAL) PurgeProject(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.unscoped(ctx)
+		var count int64
+		if err := tx.Model(&models.Project{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).Count(&count).Error; err != nil || count == 0 {
+			return err
+		}
+		if _, err := purgeTasks(tx, "user_id = ? AND project_id = ?", userID, id); err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.Section{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Project{}).Error
+	})
+}
+
+// PurgeTask 彻底删除回收站中的任务及其子任务
+func (dal *TrashDAL) PurgeTask(ctx context.Context, userID, id uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.unscoped(ctx)
+		_, err := purgeTasks(tx, "user_id = ? AND (id = ? OR parent_id = ?) AND deleted_at IS NOT NULL", userID, id, id)
+		return err
+	})
+}
+
+// EmptyTrash 彻底删除用户所有已软删除的数据
+func (dal *TrashDAL) EmptyTrash(ctx context.Context, userID uuid.UUID) error {
+	return dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.unscoped(ctx)
+		if _, err := purgeTasks(tx, "user_id = ? AND deleted_at IS NOT NULL", userID); err != nil {
+			return err
+		}
+		if err := tx.Where("deleted_at IS NOT NULL AND task_id IN (SELECT id FROM tasks WHERE user_id = ?)", userID).
+			Delete(&models.Reminder{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND deleted_at IS NOT NULL", userID).Delete(&models.Label{}).Error; err != nil {
+			return err
+		}
+		if _, err := purgeSections(tx, "user_id = ? AND deleted_at IS NOT NULL", userID); err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND deleted_at IS NOT NULL", userID).Delete(&models.Project{}).Error
+	})
+}
+
+// PurgeDeletedBefore 彻底删除所有在cutoff之前软删除的数据
+func (dal *TrashDAL) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	purged := make(map[string]int64)
+	err := dal.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := dal.unscoped(ctx)
+
+		tasks, err := purgeTasks(tx, "deleted_at < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		purged["tasks"] = tasks
+
+		// 分栏没有软删除，随所属项目一起清理
+		sections, err := purgeSections(tx, "deleted_at < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		purged["sections"] = sections
+
+		steps := []struct {
+			table string
+			model interface{}
+		}{
+			{"reminders", &models.Reminder{}},
+			{"task_recurrence_exceptions", &models.TaskRecurrenceException{}},
+			{"labels", &models.Label{}},
+			{"projects", &models.Project{}},
+		}
+		for _, step := range steps {
+			result := tx.Where("deleted_at < ?", cutoff).Delete(step.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			purged[step.table] += result.RowsAffected
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// unscoped 获取包含已软删除记录的连接，可在多条语句间复用
+func (dal *TrashDAL) unscoped(ctx context.Context) *gorm.DB {
+	return dal.db.conn(ctx).Unscoped().Session(&gorm.Session{})
+}
+
+// restoreTasks 恢复满足条件且与deletedAt同时删除的任务及其提醒
+func restoreTasks(tx *gorm.DB, deletedAt time.Time, query string, args ...interface{}) error {
+	taskIDs := tx.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Model(&models.Task{}).Select("id").
+		Where(query, args...).Where("deleted_at = ?", deletedAt)
+	if err := tx.Model(&models.Reminder{}).
+		Where("task_id IN (?) AND deleted_at = ?", taskIDs, deletedAt).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Task{}).
+		Where(query, args...).Where("deleted_at = ?", deletedAt).
+		UpdateColumn("deleted_at", nil).Error
+}
+
+// purgeTasks 彻底删除满足条件的任务，以及它们的标签关联、提醒和循环例外，返回删除的任务数
+func purgeTasks(tx *gorm.DB, query string, args ...interface{}) (int64, error) {
+	var taskIDs []uuid.UUID
+	if err := tx.Model(&models.Task{}).Where(query, args...).Pluck("id", &taskIDs).Error; err != nil {
+		return 0, err
+	}
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := tx.Exec("DELETE FROM task_labels WHERE task_id IN ?", taskIDs).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.Reminder{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("recurring_task_id IN ?", taskIDs).Delete(&models.TaskRecurrenceException{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Where("id IN ?", taskIDs).Delete(&models.Task{})
+	return result.RowsAffected, result.Error
+}
+
+// purgeSections 彻底删除满足条件的项目下的所有分栏，需在删除项目之前调用，返回删除的分栏数
+func purgeSections(tx *gorm.DB, projectQuery string, args ...interface{}) (int64, error) {
+	projectIDs := tx.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Model(&models.Project{}).Select("id").
+		Where(projectQuery, args...)
+	result := tx.Where("project_id IN (?)", projectIDs).Delete(&models.Section{})
+	return result.RowsAffected, result.Error
+}
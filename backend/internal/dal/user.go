@@ -1,13 +1,17 @@
 package dal
 
 import (
+	"context"
 	"errors"
 	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var _ repository.UserRepository = (*UserDAL)(nil)
+
 // UserDAL 用户数据访问层
 type UserDAL struct {
 	db *Database
@@ -19,14 +23,14 @@ func NewUserDAL(db *Database) *UserDAL {
 }
 
 // CreateUser 创建新用户
-func (dal *UserDAL) CreateUser(user *models.User) error {
-	return dal.db.GORM.Create(user).Error
+func (dal *UserDAL) CreateUser(ctx context.Context, user *models.User) error {
+	return dal.db.conn(ctx).Create(user).Error
 }
 
 // GetUserByEmail 根据邮箱获取用户
-func (dal *UserDAL) GetUserByEmail(email string) (*models.User, error) {
+func (dal *UserDAL) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := dal.db.GORM.Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
+	err := dal.db.conn(ctx).Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // 用户不存在
@@ -37,9 +41,9 @@ func (dal *UserDAL) GetUserByEmail(email string) (*models.User, error) {
 }
 
 // GetUserByID 根据ID获取用户
-func (dal *UserDAL) GetUserByID(id uuid.UUID) (*models.User, error) {
+func (dal *UserDAL) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := dal.db.GORM.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
+	err := dal.db.conn(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // 用户不存在
@@ -50,18 +54,31 @@ func (dal *UserDAL) GetUserByID(id uuid.UUID) (*models.User, error) {
 }
 
 // UpdateUser 更新用户信息
-func (dal *UserDAL) UpdateUser(user *models.User) error {
-	return dal.db.GORM.Save(user).Error
+func (dal *UserDAL) UpdateUser(ctx context.Context, user *models.User) error {
+	return dal.db.conn(ctx).Save(user).Error
+}
+
+// UpdatePassword 更新用户密码并清除重置标记
+func (dal *UserDAL) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return dal.db.conn(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":           passwordHash,
+		"password_reset_required": false,
+	}).Error
+}
+
+// SetPasswordResetRequired 设置用户是否需要重置密码
+func (dal *UserDAL) SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error {
+	return dal.db.conn(ctx).Model(&models.User{}).Where("id = ?", id).Update("password_reset_required", required).Error
 }
 
 // DeleteUser 软删除用户
-func (dal *UserDAL) DeleteUser(id uuid.UUID) error {
-	return dal.db.GORM.Delete(&models.User{}, id).Error
+func (dal *UserDAL) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return dal.db.conn(ctx).Delete(&models.User{}, id).Error
 }
 
 // EmailExists 检查邮箱是否已存在
-func (dal *UserDAL) EmailExists(email string) (bool, error) {
+func (dal *UserDAL) EmailExists(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := dal.db.GORM.Model(&models.User{}).Where("email = ? AND deleted_at IS NULL", email).Count(&count).Error
+	err := dal.db.conn(ctx).Model(&models.User{}).Where("email = ? AND deleted_at IS NULL", email).Count(&count).Error
 	return count > 0, err
 }
@@ -0,0 +1,46 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.UserSettingsRepository = (*UserSettingsDAL)(nil)
+
+// UserSettingsDAL 用户设置数据访问层
+type UserSettingsDAL struct {
+	db *Database
+}
+
+// NewUserSettingsDAL 创建用户设置数据访问层实例
+func NewUserSettingsDAL(db *Database) *UserSettingsDAL {
+	return &UserSettingsDAL{db: db}
+}
+
+// GetUserSettings 获取用户设置
+func (dal *UserSettingsDAL) GetUserSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	err := dal.db.conn(ctx).Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 尚未保存过设置
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveUserSettings 保存用户设置，已存在时覆盖版本和文档
+func (dal *UserSettingsDAL) SaveUserSettings(ctx context.Context, settings *models.UserSettings) error {
+	return dal.db.conn(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "document", "updated_at"}),
+	}).Create(settings).Error
+}
@@ -0,0 +1,136 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repository.WebhookRepository = (*WebhookDAL)(nil)
+
+// WebhookDAL Webhook数据访问层
+type WebhookDAL struct {
+	db *Database
+}
+
+// NewWebhookDAL 创建Webhook数据访问层实例
+func NewWebhookDAL(db *Database) *WebhookDAL {
+	return &WebhookDAL{db: db}
+}
+
+// CreateWebhook 创建端点
+func (dal *WebhookDAL) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(webhook).Error
+}
+
+// GetWebhookByID 根据ID获取用户的端点
+func (dal *WebhookDAL) GetWebhookByID(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	return dal.first(dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+// GetWebhook 按ID获取端点，不校验所属用户
+func (dal *WebhookDAL) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	return dal.first(dal.db.conn(ctx).Where("id = ?", id))
+}
+
+func (dal *WebhookDAL) first(query *gorm.DB) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := query.First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 端点不存在
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks 按分页请求获取用户的端点
+func (dal *WebhookDAL) ListWebhooks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	query := dal.db.conn(ctx).Where("user_id = ?", userID)
+	err := paginate(query, page, "webhooks.id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// UpdateWebhook 更新端点
+func (dal *WebhookDAL) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(webhook).Error
+}
+
+// DeleteWebhook 删除用户的端点及其投递记录
+func (dal *WebhookDAL) DeleteWebhook(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	var deleted bool
+	err := dal.db.WithTx(ctx, func(ctx context.Context) error {
+		result := dal.db.conn(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+		return dal.db.conn(ctx).Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+	return deleted, err
+}
+
+// RecordWebhookSuccess 清零端点的连续失败次数
+func (dal *WebhookDAL) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	return dal.db.conn(ctx).Model(&models.Webhook{}).
+		Where("id = ? AND failure_count <> 0", id).
+		UpdateColumn("failure_count", 0).Error
+}
+
+// RecordWebhookFailure 连续失败次数加一，达到上限时停用端点
+// 停用只对仍启用的端点生效，并发投递失败时只有一次会返回停用
+func (dal *WebhookDAL) RecordWebhookFailure(ctx context.Context, id uuid.UUID, disableAfter int, at time.Time) (bool, error) {
+	conn := dal.db.conn(ctx)
+	err := conn.Model(&models.Webhook{}).Where("id = ?", id).
+		UpdateColumn("failure_count", gorm.Expr("failure_count + 1")).Error
+	if err != nil || disableAfter <= 0 {
+		return false, err
+	}
+
+	result := conn.Model(&models.Webhook{}).
+		Where("id = ? AND enabled AND failure_count >= ?", id, disableAfter).
+		UpdateColumns(map[string]interface{}{"enabled": false, "disabled_at": at})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateWebhookDelivery 创建投递记录
+func (dal *WebhookDAL) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Create(delivery).Error
+}
+
+// GetWebhookDelivery 按ID获取投递记录
+func (dal *WebhookDAL) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := dal.db.conn(ctx).Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 投递记录不存在
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListWebhookDeliveries 按分页请求获取端点的投递记录
+func (dal *WebhookDAL) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, page pagination.Request) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	query := dal.db.conn(ctx).Where("webhook_id = ?", webhookID)
+	err := paginate(query, page, "webhook_deliveries.id").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateWebhookDelivery 更新投递记录
+func (dal *WebhookDAL) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return dal.db.conn(ctx).Omit(clause.Associations).Save(delivery).Error
+}
@@ -0,0 +1,250 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+)
+
+// Field 可筛选的任务字段
+type Field string
+
+const (
+	FieldDue      Field = "due"
+	FieldPriority Field = "priority"
+	FieldStatus   Field = "status"
+	FieldLabel    Field = "label"
+	FieldProject  Field = "project"
+)
+
+// Op 比较运算符
+type Op string
+
+const (
+	OpEq  Op = "="
+	OpLt  Op = "<"
+	OpLte Op = "<="
+	OpGt  Op = ">"
+	OpGte Op = ">="
+)
+
+// Expr 筛选表达式语法树节点
+type Expr interface {
+	// String 返回规范化后的表达式
+	String() string
+	writeSQL(b *sqlBuilder)
+	match(s *Subject, now time.Time) bool
+}
+
+// And 两个条件同时满足
+type And struct{ Left, Right Expr }
+
+// Or 任一条件满足
+type Or struct{ Left, Right Expr }
+
+// Not 条件取反
+type Not struct{ Expr Expr }
+
+// Predicate 单个字段条件
+type Predicate struct {
+	Field Field
+	Op    Op
+	Value string
+
+	priority int
+	due      dueValue
+}
+
+type dueKind int
+
+const (
+	dueDay     dueKind = iota // 某一天，相对今天偏移days天，或绝对日期date
+	dueNone                   // 没有截止时间
+	dueOverdue                // 截止时间早于当前时间
+)
+
+type dueValue struct {
+	kind dueKind
+	days int
+	date time.Time
+}
+
+// Subject 内存求值时使用的任务数据
+type Subject struct {
+	Task        *models.Task
+	ProjectName string
+	LabelNames  []string
+}
+
+// Match 在内存中判断任务是否满足表达式，now决定相对日期和日期边界所在的时区
+func Match(expr Expr, now time.Time, s Subject) bool {
+	return expr.match(&s, now)
+}
+
+// ToSQL 将表达式编译为参数化的SQL条件，引用tasks表的列，可直接用于Where
+func ToSQL(expr Expr, now time.Time) (string, []interface{}) {
+	b := &sqlBuilder{now: now}
+	expr.writeSQL(b)
+	return b.sql.String(), b.args
+}
+
+// sqlBuilder 拼接SQL片段并收集参数
+type sqlBuilder struct {
+	sql  strings.Builder
+	args []interface{}
+	now  time.Time
+}
+
+func (b *sqlBuilder) write(sql string, args ...interface{}) {
+	b.sql.WriteString(sql)
+	b.args = append(b.args, args...)
+}
+
+func (e *And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+func (e *Or) String() string  { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+func (e *Not) String() string { return "NOT " + e.Expr.String() }
+
+func (p *Predicate) String() string {
+	op := string(p.Op)
+	if p.Op == OpEq {
+		op = ""
+	}
+	value := p.Value
+	if strings.ContainsAny(value, " ()\"") {
+		value = strconv.Quote(value)
+	}
+	return string(p.Field) + ":" + op + value
+}
+
+func (e *And) writeSQL(b *sqlBuilder) {
+	b.write("(")
+	e.Left.writeSQL(b)
+	b.write(" AND ")
+	e.Right.writeSQL(b)
+	b.write(")")
+}
+
+func (e *Or) writeSQL(b *sqlBuilder) {
+	b.write("(")
+	e.Left.writeSQL(b)
+	b.write(" OR ")
+	e.Right.writeSQL(b)
+	b.write(")")
+}
+
+func (e *Not) writeSQL(b *sqlBuilder) {
+	b.write("NOT ")
+	e.Expr.writeSQL(b)
+}
+
+// writeSQL 每个条件都不会产生NULL，保证NOT的结果与内存求值一致
+func (p *Predicate) writeSQL(b *sqlBuilder) {
+	switch p.Field {
+	case FieldDue:
+		if p.due.kind == dueNone {
+			b.write("tasks.due_time IS NULL")
+			return
+		}
+		from, to := p.dueRange(b.now)
+		b.write("(tasks.due_time IS NOT NULL")
+		if from != nil {
+			b.write(" AND tasks.due_time >= ?", *from)
+		}
+		if to != nil {
+			b.write(" AND tasks.due_time < ?", *to)
+		}
+		b.write(")")
+	case FieldPriority:
+		b.write("tasks.priority "+string(p.Op)+" ?", p.priority)
+	case FieldStatus:
+		b.write("tasks.status = ?", p.Value)
+	case FieldLabel:
+		b.write("EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id"+
+			" WHERE tl.task_id = tasks.id AND l.deleted_at IS NULL AND lower(l.name) = lower(?))", p.Value)
+	case FieldProject:
+		b.write("EXISTS (SELECT 1 FROM projects p WHERE p.id = tasks.project_id AND p.deleted_at IS NULL AND lower(p.name) = lower(?))", p.Value)
+	}
+}
+
+func (e *And) match(s *Subject, now time.Time) bool {
+	return e.Left.match(s, now) && e.Right.match(s, now)
+}
+
+func (e *Or) match(s *Subject, now time.Time) bool {
+	return e.Left.match(s, now) || e.Right.match(s, now)
+}
+
+func (e *Not) match(s *Subject, now time.Time) bool {
+	return !e.Expr.match(s, now)
+}
+
+func (p *Predicate) match(s *Subject, now time.Time) bool {
+	task := s.Task
+	switch p.Field {
+	case FieldDue:
+		if p.due.kind == dueNone {
+			return task.DueTime == nil
+		}
+		if task.DueTime == nil {
+			return false
+		}
+		from, to := p.dueRange(now)
+		return (from == nil || !task.DueTime.Before(*from)) && (to == nil || task.DueTime.Before(*to))
+	case FieldPriority:
+		switch p.Op {
+		case OpLt:
+			return task.Priority < p.priority
+		case OpLte:
+			return task.Priority <= p.priority
+		case OpGt:
+			return task.Priority > p.priority
+		case OpGte:
+			return task.Priority >= p.priority
+		}
+		return task.Priority == p.priority
+	case FieldStatus:
+		return string(task.Status) == p.Value
+	case FieldLabel:
+		for _, name := range s.LabelNames {
+			if strings.EqualFold(name, p.Value) {
+				return true
+			}
+		}
+		return false
+	case FieldProject:
+		return strings.EqualFold(s.ProjectName, p.Value)
+	}
+	return false
+}
+
+// dueRange 计算截止时间条件对应的区间[from, to)，nil表示不限
+// 日期边界按now所在时区的零点计算
+func (p *Predicate) dueRange(now time.Time) (from, to *time.Time) {
+	if p.due.kind == dueOverdue {
+		return nil, &now
+	}
+
+	var day time.Time
+	if p.due.date.IsZero() {
+		y, m, d := now.Date()
+		day = time.Date(y, m, d+p.due.days, 0, 0, 0, 0, now.Location())
+	} else {
+		y, m, d := p.due.date.Date()
+		day = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	}
+	next := day.AddDate(0, 0, 1)
+
+	switch p.Op {
+	case OpLt:
+		return nil, &day
+	case OpLte:
+		return nil, &next
+	case OpGt:
+		return &next, nil
+	case OpGte:
+		return &day, nil
+	}
+	return &day, &next
+}
@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ticktick-backend/internal/models"
+)
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"priority:1", "priority:1"},
+		{"priority:1 OR priority:2 AND label:work", "(priority:1 OR (priority:2 AND label:work))"},
+		{"priority:1 AND priority:2 OR label:work", "((priority:1 AND priority:2) OR label:work)"},
+		{"(priority:1 OR priority:2) label:work", "((priority:1 OR priority:2) AND label:work)"},
+		{"NOT label:work AND project:Inbox", "(NOT label:work AND project:Inbox)"},
+		{"NOT (label:work OR label:home)", "NOT (label:work OR label:home)"},
+		{"not not status:completed", "NOT NOT status:completed"},
+		{"label:a or label:b or label:c", "((label:a OR label:b) OR label:c)"},
+		{"due:<=7d priority:>=2", "(due:<=7d AND priority:>=2)"},
+		{"PRIORITY:3", "priority:3"},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, 期望 %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuoting(t *testing.T) {
+	tests := []struct {
+		input string
+		value string
+		want  string
+	}{
+		{`project:"Side Project"`, "Side Project", `project:"Side Project"`},
+		{`label:"a(b)"`, "a(b)", `label:"a(b)"`},
+		{`label:"say \"hi\""`, `say "hi"`, `label:"say \"hi\""`},
+		{`label:"工作"`, "工作", "label:工作"},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		pred, ok := expr.(*Predicate)
+		if !ok || pred.Value != tt.value {
+			t.Errorf("Parse(%q) = %#v, 期望值 %q", tt.input, expr, tt.value)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %s, 期望 %s", tt.input, got, tt.want)
+		}
+		// 规范化后的表达式重新解析得到相同的值
+		again, err := Parse(expr.String())
+		if err != nil || again.(*Predicate).Value != tt.value {
+			t.Errorf("重新解析 %s = %#v, %v", expr.String(), again, err)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"priority:1 AND", 14},
+		{"(priority:1", 11},
+		{"priority:1)", 10},
+		{"OR priority:1", 0},
+		{`project:"Side`, 0},
+		{"work", 0},
+		{"label:", 0},
+		{"color:red", 0},
+		{"priority:5", 0},
+		{"priority:high", 0},
+		{"status:done", 0},
+		{"label:>work", 0},
+		{"due:<none", 0},
+		{"due:someday", 0},
+		{"due:9999d", 0},
+		{"priority:1 NOT", 14},
+		{strings.Repeat("a", MaxLength+1), MaxLength},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) = %v, 期望SyntaxError", tt.input, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos {
+			t.Errorf("Parse(%q) 错误位置 = %d, 期望 %d (%s)", tt.input, syntaxErr.Pos, tt.pos, syntaxErr.Msg)
+		}
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{
+			"priority:1 OR priority:2 AND status:completed",
+			"(tasks.priority = ? OR (tasks.priority = ? AND tasks.status = ?))",
+			[]interface{}{1, 2, "completed"},
+		},
+		{
+			"NOT (priority:>=3 OR due:none)",
+			"NOT (tasks.priority >= ? OR tasks.due_time IS NULL)",
+			[]interface{}{3},
+		},
+		{
+			"due:today",
+			"(tasks.due_time IS NOT NULL AND tasks.due_time >= ? AND tasks.due_time < ?)",
+			[]interface{}{today, tomorrow},
+		},
+		{
+			"due:<=today",
+			"(tasks.due_time IS NOT NULL AND tasks.due_time < ?)",
+			[]interface{}{tomorrow},
+		},
+		{
+			"due:overdue",
+			"(tasks.due_time IS NOT NULL AND tasks.due_time < ?)",
+			[]interface{}{now},
+		},
+		{
+			// 值始终作为参数传递，引号和SQL关键字不会进入语句
+			`label:"x' OR 1=1 --"`,
+			"EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id" +
+				" WHERE tl.task_id = tasks.id AND l.deleted_at IS NULL AND lower(l.name) = lower(?))",
+			[]interface{}{"x' OR 1=1 --"},
+		},
+		{
+			`project:"Side Project"`,
+			"EXISTS (SELECT 1 FROM projects p WHERE p.id = tasks.project_id AND p.deleted_at IS NULL AND lower(p.name) = lower(?))",
+			[]interface{}{"Side Project"},
+		},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		sql, args := ToSQL(expr, now)
+		if sql != tt.sql {
+			t.Errorf("ToSQL(%q) = %s, 期望 %s", tt.input, sql, tt.sql)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("ToSQL(%q) 参数 = %v, 期望 %v", tt.input, args, tt.args)
+		}
+		if n := strings.Count(sql, "?"); n != len(args) {
+			t.Errorf("ToSQL(%q) 有 %d 个占位符和 %d 个参数", tt.input, n, len(args))
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	due := time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC)
+	subject := Subject{
+		Task:        &models.Task{Priority: 2, Status: models.TaskStatusIncomplete, DueTime: &due},
+		ProjectName: "Side Project",
+		LabelNames:  []string{"Work"},
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"due:tomorrow", true},
+		{"due:today", false},
+		{"due:<=7d AND priority:<=2", true},
+		{"due:none", false},
+		{"label:work", true},
+		{"NOT label:work", false},
+		{`project:"side project"`, true},
+		{"priority:1 OR priority:2 AND label:home", false},
+		{"(priority:1 OR priority:2) AND NOT label:home", true},
+		{"status:completed", false},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if got := Match(expr, now, subject); got != tt.want {
+			t.Errorf("Match(%q) = %v, 期望 %v", tt.input, got, tt.want)
+		}
+	}
+}
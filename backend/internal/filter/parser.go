@@ -0,0 +1,313 @@
+// Package filter 实现任务筛选表达式，例如 due:<=7d AND priority:1 AND label:work AND NOT project:Personal
+// 表达式先解析为语法树，再编译为参数化SQL或在内存中求值，智能清单和临时筛选共用同一套逻辑
+//
+// 语法：
+//
+//	expr      = and { "OR" and }
+//	and       = unary { ["AND"] unary }        相邻条件之间省略AND时按AND处理
+//	unary     = "NOT" unary | "(" expr ")" | predicate
+//	predicate = field ":" [op] value           op为 < <= > >= =，省略时为 =
+//
+// 关键字不区分大小写，包含空格的值用双引号包裹，例如 project:"Side Project"
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxLength 表达式最大长度
+const MaxLength = 500
+
+// SyntaxError 表达式语法错误
+type SyntaxError struct {
+	Pos int // 出错位置（字符偏移，从0开始）
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("位置%d: %s", e.Pos, e.Msg)
+}
+
+// Parse 解析筛选表达式
+func Parse(input string) (Expr, error) {
+	if len([]rune(input)) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("表达式不能超过%d个字符", MaxLength)}
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, &SyntaxError{Pos: 0, Msg: "表达式为空"}
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("无法识别的内容 %q", tok.text)}
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 将表达式切分为词法单元，引号内的空格和括号属于值的一部分
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		default:
+			start := i
+			var b strings.Builder
+			quoted := false
+			for i < len(runes) {
+				r := runes[i]
+				if quoted {
+					if r == '\\' && i+1 < len(runes) {
+						b.WriteRune(runes[i+1])
+						i += 2
+						continue
+					}
+					if r == '"' {
+						quoted = false
+					}
+					b.WriteRune(r)
+					i++
+					continue
+				}
+				if unicode.IsSpace(r) || r == '(' || r == ')' {
+					break
+				}
+				if r == '"' {
+					quoted = true
+				}
+				b.WriteRune(r)
+				i++
+			}
+			if quoted {
+				return nil, &SyntaxError{Pos: start, Msg: "引号未闭合"}
+			}
+
+			text := b.String()
+			kind := tokenTerm
+			switch strings.ToUpper(text) {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// parser 递归下降解析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenNot, tokenLParen, tokenTerm:
+			// 省略AND
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNot:
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "缺少右括号"}
+		}
+		return expr, nil
+	case tokenTerm:
+		return parsePredicate(tok)
+	case tokenEOF:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: "表达式不完整"}
+	default:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("此处不能出现 %q", tok.text)}
+	}
+}
+
+// parsePredicate 解析 field:[op]value 形式的条件
+func parsePredicate(tok token) (Expr, error) {
+	name, rest, ok := strings.Cut(tok.text, ":")
+	if !ok {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("条件 %q 缺少字段名，格式为 field:value", tok.text)}
+	}
+
+	field := Field(strings.ToLower(name))
+	op := OpEq
+	for _, candidate := range []Op{OpLte, OpGte, OpLt, OpGt, OpEq} {
+		if strings.HasPrefix(rest, string(candidate)) {
+			op = candidate
+			rest = rest[len(candidate):]
+			break
+		}
+	}
+	value := rest
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+	if value == "" {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("字段 %s 缺少值", name)}
+	}
+
+	pred := &Predicate{Field: field, Op: op, Value: value}
+	fail := func(format string, args ...interface{}) (Expr, error) {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	switch field {
+	case FieldDue:
+		due, err := parseDue(value)
+		if err != nil {
+			return fail("%v", err)
+		}
+		if due.kind != dueDay && op != OpEq {
+			return fail("due:%s 不支持比较运算", value)
+		}
+		pred.due = due
+	case FieldPriority:
+		priority, err := strconv.Atoi(value)
+		if err != nil || priority < 1 || priority > 4 {
+			return fail("优先级必须是1到4之间的整数")
+		}
+		pred.priority = priority
+	case FieldStatus:
+		if value != "incomplete" && value != "completed" {
+			return fail("状态只能是 incomplete 或 completed")
+		}
+	case FieldLabel, FieldProject:
+		// 名称不区分大小写
+	default:
+		return fail("未知字段 %q，可用字段：due、priority、status、label、project", name)
+	}
+	if op != OpEq && (field == FieldStatus || field == FieldLabel || field == FieldProject) {
+		return fail("字段 %s 只支持等值匹配", field)
+	}
+	return pred, nil
+}
+
+// parseDue 解析截止日期的值：today、tomorrow、yesterday、none、overdue、Nd、Nw 或 YYYY-MM-DD
+func parseDue(value string) (dueValue, error) {
+	switch strings.ToLower(value) {
+	case "today":
+		return dueValue{kind: dueDay}, nil
+	case "tomorrow":
+		return dueValue{kind: dueDay, days: 1}, nil
+	case "yesterday":
+		return dueValue{kind: dueDay, days: -1}, nil
+	case "none":
+		return dueValue{kind: dueNone}, nil
+	case "overdue":
+		return dueValue{kind: dueOverdue}, nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return dueValue{kind: dueDay, date: date}, nil
+	}
+	if n := len(value); n >= 2 {
+		unit := value[n-1]
+		if count, err := strconv.Atoi(value[:n-1]); err == nil && (unit == 'd' || unit == 'w') {
+			if unit == 'w' {
+				count *= 7
+			}
+			if count < -3650 || count > 3650 {
+				return dueValue{}, fmt.Errorf("相对日期超出范围")
+			}
+			return dueValue{kind: dueDay, days: count}, nil
+		}
+	}
+	return dueValue{}, fmt.Errorf("无法识别的日期 %q，可用 today、tomorrow、none、overdue、7d、2w 或 2006-01-02", value)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAccountImportSize 账户导出文件的大小上限
+const maxAccountImportSize = 100 << 20
+
+// AccountDataHandler 账户数据导出和恢复处理器
+type AccountDataHandler struct {
+	accountDataService *services.AccountDataService
+}
+
+// NewAccountDataHandler 创建账户数据处理器实例
+func NewAccountDataHandler(accountDataService *services.AccountDataService) *AccountDataHandler {
+	return &AccountDataHandler{
+		accountDataService: accountDataService,
+	}
+}
+
+// StartExport 开始导出账户的全部数据，完成后通过导出任务中的链接下载
+func (h *AccountDataHandler) StartExport(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.accountDataService.StartExport(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err, "开始导出失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetExportJob 获取导出任务的状态
+func (h *AccountDataHandler) GetExportJob(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	jobID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.accountDataService.GetExportJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		respondError(c, err, "获取导出任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// DownloadExport 下载导出文件，使用路径中的令牌认证，链接过期后返回404
+func (h *AccountDataHandler) DownloadExport(c *gin.Context) {
+	data, err := h.accountDataService.Download(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		respondError(c, err, "下载导出文件失败")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", `attachment; filename="account-export.zip"`)
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// ImportAccount 将导出文件恢复到当前账户，文件通过multipart的file字段或直接作为请求体上传
+func (h *AccountDataHandler) ImportAccount(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	data, _, err := readUpload(c, maxAccountImportSize, ".zip")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	result, err := h.accountDataService.Import(c.Request.Context(), userID, data)
+	if err != nil {
+		respondError(c, err, "导入账户数据失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppPasswordHandler 应用专用密码处理器
+type AppPasswordHandler struct {
+	appPasswordService *services.AppPasswordService
+}
+
+// NewAppPasswordHandler 创建应用专用密码处理器实例
+func NewAppPasswordHandler(appPasswordService *services.AppPasswordService) *AppPasswordHandler {
+	return &AppPasswordHandler{
+		appPasswordService: appPasswordService,
+	}
+}
+
+// ListAppPasswords 获取应用专用密码列表，不包含密码本身
+func (h *AppPasswordHandler) ListAppPasswords(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.appPasswordService.ListAppPasswords(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取应用专用密码失败")
+		return
+	}
+
+	respondPage(c, "appPasswords", page, query.Fields, nil)
+}
+
+// CreateAppPassword 创建应用专用密码，密码只在本次响应中返回
+func (h *AppPasswordHandler) CreateAppPassword(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateAppPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	password, err := h.appPasswordService.CreateAppPassword(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建应用专用密码失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"appPassword": password})
+}
+
+// DeleteAppPassword 删除应用专用密码
+func (h *AppPasswordHandler) DeleteAppPassword(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.appPasswordService.DeleteAppPassword(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除应用专用密码失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "应用专用密码已删除"})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 	"ticktick-backend/config"
@@ -15,17 +17,19 @@ import (
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	userService *services.UserService
-	tokenStore  *services.TokenStore
-	config      *config.Config
+	userService  *services.UserService
+	tokenStore   *services.TokenStore
+	signInAlerts *services.SignInAlertService
+	config       *config.Config
 }
 
 // NewAuthHandler 创建认证处理器实例
-func NewAuthHandler(userService *services.UserService, tokenStore *services.TokenStore, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(userService *services.UserService, tokenStore *services.TokenStore, signInAlerts *services.SignInAlertService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		tokenStore:  tokenStore,
-		config:      cfg,
+		userService:  userService,
+		tokenStore:   tokenStore,
+		signInAlerts: signInAlerts,
+		config:       cfg,
 	}
 }
 
@@ -41,7 +45,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// 调用服务层进行注册
-	user, err := h.userService.Register(&req)
+	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "邮箱已被注册") {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
@@ -55,23 +59,29 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	deviceInfo := middleware.GetDeviceInfo(c)
 
 	// 生成JWT令牌
-	accessToken, refreshToken, tokenID, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, deviceInfo)
+	tokens, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, user.Role, deviceInfo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
 		return
 	}
 
+	// 注册时的设备直接记为已知设备
+	if err := h.signInAlerts.RememberDevice(user.ID, deviceInfo); err != nil {
+		log.Printf("记录已知设备失败: %v", err)
+	}
+
 	// 设置Cookie
-	middleware.SetTokenCookies(c, accessToken, refreshToken, h.config)
+	middleware.SetTokenCookies(c, tokens.AccessToken, tokens.RefreshToken, h.config)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message":      "注册成功",
-		"user":         user,
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
-		"tokenID":      tokenID,
-		"deviceInfo":   deviceInfo,
-		"expiresIn":    h.config.JWT.AccessTokenDuration * 3600, // 转换为秒
+		"message":         "注册成功",
+		"user":            user,
+		"accessToken":     tokens.AccessToken,
+		"refreshToken":    tokens.RefreshToken,
+		"tokenID":         tokens.TokenID,
+		"deviceInfo":      deviceInfo,
+		"evictedSessions": tokens.EvictedSessions,
+		"expiresIn":       h.config.JWT.AccessTokenDuration * 3600, // 转换为秒
 	})
 }
 
@@ -87,12 +97,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// 调用服务层进行登录验证
-	user, err := h.userService.Login(&req)
+	user, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "邮箱或密码错误") {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "账号存在异常登录，请通过邮件中的链接重置密码",
+				"code":  "password_reset_required",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
 		return
 	}
@@ -101,23 +118,31 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	deviceInfo := middleware.GetDeviceInfo(c)
 
 	// 生成JWT令牌
-	accessToken, refreshToken, tokenID, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, deviceInfo)
+	tokens, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, user.Role, deviceInfo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
 		return
 	}
 
+	// 检测新设备登录并发送安全提醒
+	alert, err := h.signInAlerts.CheckSignIn(user, tokens.TokenID, deviceInfo)
+	if err != nil {
+		log.Printf("新设备登录检测失败: %v", err)
+	}
+
 	// 设置Cookie
-	middleware.SetTokenCookies(c, accessToken, refreshToken, h.config)
+	middleware.SetTokenCookies(c, tokens.AccessToken, tokens.RefreshToken, h.config)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "登录成功",
-		"user":         user,
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
-		"tokenID":      tokenID,
-		"deviceInfo":   deviceInfo,
-		"expiresIn":    h.config.JWT.AccessTokenDuration * 3600, // 转换为秒
+		"message":         "登录成功",
+		"newSignIn":       alert != nil,
+		"user":            user,
+		"accessToken":     tokens.AccessToken,
+		"refreshToken":    tokens.RefreshToken,
+		"tokenID":         tokens.TokenID,
+		"deviceInfo":      deviceInfo,
+		"evictedSessions": tokens.EvictedSessions,
+		"expiresIn":       h.config.JWT.AccessTokenDuration * 3600, // 转换为秒
 	})
 }
 
@@ -165,7 +190,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 			return
 		}
 		if isBlacklisted {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "刷新令牌已被撤销"})
+			c.JSON(http.StatusUnauthorized, middleware.RevokedTokenResponse(h.tokenStore, claims.TokenID, "刷新令牌已被撤销"))
 			return
 		}
 	}
@@ -182,14 +207,17 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// 验证用户是否仍然存在
-	user, err := h.userService.GetUserByID(claims.UserID)
+	user, err := h.userService.GetUserByID(c.Request.Context(), claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
 		return
 	}
 
-	// 获取设备信息
+	// 获取设备信息，未提供设备名称时沿用旧会话的名称
 	deviceInfo := middleware.GetDeviceInfo(c)
+	if deviceInfo.Name == "" {
+		deviceInfo.Name = refreshTokenInfo.DeviceInfo.Name
+	}
 
 	// 撤销旧的RefreshToken
 	if err := h.tokenStore.RevokeUserSession(claims.UserID, claims.TokenID, "token_refresh"); err != nil {
@@ -198,23 +226,24 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// 生成新的访问令牌和刷新令牌
-	newAccessToken, newRefreshToken, newTokenID, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, deviceInfo)
+	tokens, err := middleware.GenerateTokens(h.config, h.tokenStore, user.ID, user.Email, user.Role, deviceInfo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
 		return
 	}
 
 	// 设置新的Cookie
-	middleware.SetTokenCookies(c, newAccessToken, newRefreshToken, h.config)
+	middleware.SetTokenCookies(c, tokens.AccessToken, tokens.RefreshToken, h.config)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "令牌刷新成功",
-		"user":         user,
-		"accessToken":  newAccessToken,
-		"refreshToken": newRefreshToken,
-		"tokenID":      newTokenID,
-		"deviceInfo":   deviceInfo,
-		"expiresIn":    h.config.JWT.AccessTokenDuration * 3600,
+		"message":         "令牌刷新成功",
+		"user":            user,
+		"accessToken":     tokens.AccessToken,
+		"refreshToken":    tokens.RefreshToken,
+		"tokenID":         tokens.TokenID,
+		"deviceInfo":      deviceInfo,
+		"evictedSessions": tokens.EvictedSessions,
+		"expiresIn":       h.config.JWT.AccessTokenDuration * 3600,
 	})
 }
 
@@ -253,7 +282,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	}
 
 	// 获取用户信息
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
@@ -285,16 +314,7 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 
 	// 为每个会话添加是否为当前会话的标识
 	for _, session := range sessions {
-		session := map[string]interface{}{
-			"tokenId":               session.TokenID,
-			"deviceInfo":            session.DeviceInfo,
-			"createdAt":             session.CreatedAt,
-			"lastUsedAt":            session.LastUsedAt,
-			"accessTokenExpiresAt":  session.AccessTokenExpiresAt,
-			"refreshTokenExpiresAt": session.RefreshTokenExpiresAt,
-			"isCurrent":             session.TokenID == currentTokenID,
-		}
-		_ = session // 避免未使用变量警告
+		session.IsCurrent = session.TokenID == currentTokenID
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -303,6 +323,55 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 	})
 }
 
+// RenameSessionRequest 重命名会话请求结构
+type RenameSessionRequest struct {
+	Name string `json:"name" binding:"max=100"`
+}
+
+// RenameSession 重命名会话的设备名称
+func (h *AuthHandler) RenameSession(c *gin.Context) {
+	// 获取用户ID
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未找到用户信息"})
+		return
+	}
+
+	tokenID := c.Param("tokenId")
+	if tokenID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少tokenId参数"})
+		return
+	}
+
+	var req RenameSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	info, err := h.tokenStore.RenameSession(userID, tokenID, req.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), "会话不存在") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重命名会话失败"})
+		return
+	}
+
+	currentTokenID, _ := middleware.GetTokenIDFromContext(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "会话重命名成功",
+		"tokenId":    info.TokenID,
+		"deviceInfo": info.DeviceInfo,
+		"isCurrent":  info.TokenID == currentTokenID,
+	})
+}
+
 // RevokeSession 撤销特定会话
 func (h *AuthHandler) RevokeSession(c *gin.Context) {
 	// 获取用户ID
@@ -360,6 +429,82 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "已登出所有设备"})
 }
 
+// GetSignInAlert 打开新设备登录提醒中的"不是我本人"链接，只返回登录信息供用户确认
+// 邮件客户端会扫描和预取链接，因此GET请求不改变任何状态
+func (h *AuthHandler) GetSignInAlert(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少token参数"})
+		return
+	}
+
+	alert, err := h.signInAlerts.GetSignInAlert(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSecurityToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "处理失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "如果这不是您本人的操作，请确认退出该设备",
+		"deviceInfo": alert.DeviceInfo,
+		"signedInAt": alert.CreatedAt,
+	})
+}
+
+// RejectSignIn 确认新设备登录不是本人操作，退出该设备并通过邮件发送重置密码链接
+func (h *AuthHandler) RejectSignIn(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少token参数"})
+		return
+	}
+
+	alert, err := h.signInAlerts.RejectSignIn(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSecurityToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "处理失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "已退出该设备，重置密码的链接已发送到您的邮箱",
+		"deviceInfo": alert.DeviceInfo,
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if _, err := h.signInAlerts.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrInvalidSecurityToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置密码失败"})
+		return
+	}
+
+	// 所有会话均已撤销，清除当前Cookie
+	middleware.ClearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{"message": "密码重置成功，请重新登录"})
+}
+
 // RevokeToken 撤销指定Token（管理员功能）
 func (h *AuthHandler) RevokeToken(c *gin.Context) {
 	var req struct {
@@ -0,0 +1,582 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// CalDAVPrefix CalDAV服务的根路径
+	CalDAVPrefix = "/caldav"
+	// caldavAllow CalDAV地址支持的方法
+	caldavAllow = "OPTIONS, PROPFIND, REPORT, GET, HEAD, PUT, DELETE"
+	// caldavContentType 日历资源的内容类型
+	caldavContentType = "text/calendar; charset=utf-8; component=vtodo"
+)
+
+// davKind CalDAV地址的类型
+type davKind int
+
+const (
+	davRoot      davKind = iota // /caldav/
+	davPrincipal                // /caldav/principal/
+	davHome                     // /caldav/calendars/
+	davCalendar                 // /caldav/calendars/<项目ID>/
+	davResource                 // /caldav/calendars/<项目ID>/<UID>.ics
+)
+
+// davTarget 请求路径解析出的目标
+type davTarget struct {
+	kind      davKind
+	projectID uuid.UUID
+	name      string
+}
+
+// CalDAVHandler CalDAV处理器，实现RFC 4791的日历访问和RFC 6578的增量同步
+// 每个项目是一个只包含VTODO的日历，客户端使用邮箱和应用专用密码通过HTTP Basic认证
+type CalDAVHandler struct {
+	caldavService *services.CalDAVService
+}
+
+// NewCalDAVHandler 创建CalDAV处理器实例
+func NewCalDAVHandler(caldavService *services.CalDAVService) *CalDAVHandler {
+	return &CalDAVHandler{
+		caldavService: caldavService,
+	}
+}
+
+// WellKnown 服务发现地址（RFC 6764），重定向到CalDAV根路径
+func (h *CalDAVHandler) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, CalDAVPrefix+"/")
+}
+
+// Options 声明支持的DAV功能，客户端据此判断服务器是否支持CalDAV
+func (h *CalDAVHandler) Options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", caldavAllow)
+	c.Status(http.StatusOK)
+}
+
+// Handle 按方法分发CalDAV请求
+func (h *CalDAVHandler) Handle(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	target, ok := parseDAVPath(c.Param("path"))
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Header("DAV", "1, 3, calendar-access")
+	switch c.Request.Method {
+	case "PROPFIND":
+		h.propfind(c, userID, target)
+	case "REPORT":
+		h.report(c, userID, target)
+	case http.MethodGet, http.MethodHead:
+		h.get(c, userID, target)
+	case http.MethodPut:
+		h.put(c, userID, target)
+	case http.MethodDelete:
+		h.delete(c, userID, target)
+	default:
+		c.Header("Allow", caldavAllow)
+		c.Status(http.StatusMethodNotAllowed)
+	}
+}
+
+// propfind 返回目标（Depth为1时还包括其成员）的属性，Depth: infinity按1处理
+func (h *CalDAVHandler) propfind(c *gin.Context, userID uuid.UUID, target davTarget) {
+	body, err := readDAVBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var names []xml.Name
+	if body != nil && body.child(davNS, "prop") != nil {
+		names = body.propNames()
+	}
+	depth := c.GetHeader("Depth") != "0"
+	ctx := c.Request.Context()
+	email := c.GetString("email")
+
+	var responses []davResponse
+	switch target.kind {
+	case davRoot, davPrincipal:
+		responses = append(responses, newDAVResponse(davTargetHref(target), principalProps(target.kind, email), names))
+	case davHome:
+		responses = append(responses, newDAVResponse(davTargetHref(target), principalProps(davHome, email), names))
+		if depth {
+			projects, err := h.caldavService.ListCalendars(ctx, userID)
+			if err != nil {
+				respondDAVError(c, err)
+				return
+			}
+			for _, project := range projects {
+				props, err := h.calendarProps(ctx, userID, project, nil, names)
+				if err != nil {
+					respondDAVError(c, err)
+					return
+				}
+				responses = append(responses, newDAVResponse(calendarHref(project.ID), props, names))
+			}
+		}
+	case davCalendar:
+		cal, err := h.caldavService.Calendar(ctx, userID, target.projectID)
+		if err != nil {
+			respondDAVError(c, err)
+			return
+		}
+		props, err := h.calendarProps(ctx, userID, cal.Project, cal, names)
+		if err != nil {
+			respondDAVError(c, err)
+			return
+		}
+		responses = append(responses, newDAVResponse(davTargetHref(target), props, names))
+		if depth {
+			for _, res := range cal.Resources {
+				responses = append(responses, newDAVResponse(resourceHref(cal.Project.ID, res.Name), resourceProps(res), names))
+			}
+		}
+	case davResource:
+		cal, err := h.caldavService.Calendar(ctx, userID, target.projectID)
+		if err != nil {
+			respondDAVError(c, err)
+			return
+		}
+		res := cal.Resource(target.name)
+		if res == nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		responses = append(responses, newDAVResponse(davTargetHref(target), resourceProps(res), names))
+	}
+	writeMultistatus(c, responses, "")
+}
+
+// report 处理日历集合上的calendar-query、calendar-multiget和sync-collection报告
+func (h *CalDAVHandler) report(c *gin.Context, userID uuid.UUID, target davTarget) {
+	if target.kind != davCalendar {
+		writeDAVError(c, http.StatusForbidden, davNS, "supported-report")
+		return
+	}
+	body, err := readDAVBody(c)
+	if err != nil || body == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体不是有效的REPORT"})
+		return
+	}
+	ctx := c.Request.Context()
+	cal, err := h.caldavService.Calendar(ctx, userID, target.projectID)
+	if err != nil {
+		respondDAVError(c, err)
+		return
+	}
+	names := body.propNames()
+
+	var responses []davResponse
+	switch body.XMLName {
+	case xml.Name{Space: caldavNS, Local: "calendar-query"}:
+		start, end, ok := queryTimeRange(body)
+		if ok {
+			for _, res := range cal.Resources {
+				if res.MatchesTimeRange(start, end) {
+					responses = append(responses, newDAVResponse(resourceHref(cal.Project.ID, res.Name), resourceProps(res), names))
+				}
+			}
+		}
+	case xml.Name{Space: caldavNS, Local: "calendar-multiget"}:
+		for _, node := range body.Children {
+			if node.XMLName != (xml.Name{Space: davNS, Local: "href"}) {
+				continue
+			}
+			href := node.text()
+			ref, ok := parseDAVHref(href)
+			res := cal.Resource(ref.name)
+			if !ok || ref.kind != davResource || ref.projectID != cal.Project.ID || res == nil {
+				responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			responses = append(responses, newDAVResponse(href, resourceProps(res), names))
+		}
+	case xml.Name{Space: davNS, Local: "sync-collection"}:
+		token := ""
+		if node := body.child(davNS, "sync-token"); node != nil {
+			token = node.text()
+		}
+		changed, removed, err := h.caldavService.Changes(ctx, userID, cal, token)
+		if err != nil {
+			respondDAVError(c, err)
+			return
+		}
+		for _, res := range changed {
+			responses = append(responses, newDAVResponse(resourceHref(cal.Project.ID, res.Name), resourceProps(res), names))
+		}
+		for _, name := range removed {
+			responses = append(responses, davResponse{href: resourceHref(cal.Project.ID, name), status: http.StatusNotFound})
+		}
+		syncToken, err := h.caldavService.SyncToken(ctx, userID, cal)
+		if err != nil {
+			respondDAVError(c, err)
+			return
+		}
+		writeMultistatus(c, responses, syncToken)
+		return
+	default:
+		writeDAVError(c, http.StatusForbidden, davNS, "supported-report")
+		return
+	}
+	writeMultistatus(c, responses, "")
+}
+
+// get 返回日历资源的内容，集合不支持GET
+func (h *CalDAVHandler) get(c *gin.Context, userID uuid.UUID, target davTarget) {
+	if target.kind != davResource {
+		c.Header("Allow", "OPTIONS, PROPFIND, REPORT")
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	res, ok := h.resource(c, userID, target)
+	if !ok {
+		return
+	}
+	if res == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Header("ETag", res.ETag)
+	c.Header("Last-Modified", res.UpdatedAt.UTC().Format(http.TimeFormat))
+	if etagMatches(c.GetHeader("If-None-Match"), res.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, caldavContentType, res.Data)
+}
+
+// put 创建或更新日历资源，支持If-Match和If-None-Match: *
+// 资源以UID命名，客户端使用其他名称创建时返回Location且不返回ETag，客户端需要重新获取
+func (h *CalDAVHandler) put(c *gin.Context, userID uuid.UUID, target davTarget) {
+	if target.kind != davResource {
+		c.Header("Allow", "OPTIONS, PROPFIND, REPORT")
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := c.Request.Context()
+	cal, err := h.caldavService.Calendar(ctx, userID, target.projectID)
+	if err != nil {
+		respondDAVError(c, err)
+		return
+	}
+	existing := cal.Resource(target.name)
+	if !davPreconditions(c, existing) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxICSImportSize))
+	if err != nil {
+		c.Status(http.StatusRequestEntityTooLarge)
+		return
+	}
+	result, err := h.caldavService.PutResource(ctx, userID, cal, existing, data)
+	if err != nil {
+		respondDAVError(c, err)
+		return
+	}
+
+	if result.Name == target.name {
+		c.Header("ETag", result.ETag)
+	} else {
+		c.Header("Location", resourceHref(cal.Project.ID, result.Name))
+	}
+	if result.Created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// delete 删除日历资源，项目不能通过CalDAV删除
+func (h *CalDAVHandler) delete(c *gin.Context, userID uuid.UUID, target davTarget) {
+	if target.kind != davResource {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	res, ok := h.resource(c, userID, target)
+	if !ok {
+		return
+	}
+	if res == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if !davPreconditions(c, res) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.caldavService.DeleteResource(c.Request.Context(), userID, res); err != nil {
+		respondDAVError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// resource 加载目标资源，项目不存在时直接返回错误响应，资源不存在时返回nil
+func (h *CalDAVHandler) resource(c *gin.Context, userID uuid.UUID, target davTarget) (*services.CalDAVResource, bool) {
+	cal, err := h.caldavService.Calendar(c.Request.Context(), userID, target.projectID)
+	if err != nil {
+		respondDAVError(c, err)
+		return nil, false
+	}
+	return cal.Resource(target.name), true
+}
+
+// calendarProps 日历集合的属性，getctag和sync-token需要资源内容，只在被请求时加载
+func (h *CalDAVHandler) calendarProps(ctx context.Context, userID uuid.UUID, project *models.Project, cal *services.CalDAVCalendar, names []xml.Name) (davProps, error) {
+	props := make(davProps)
+	props.set(davNS, "resourcetype", `<collection xmlns="DAV:"/><calendar xmlns="`+caldavNS+`"/>`)
+	props.setText(davNS, "displayname", project.Name)
+	props.setText(appleICalNS, "calendar-color", project.Color)
+	props.set(caldavNS, "supported-calendar-component-set", `<comp xmlns="`+caldavNS+`" name="VTODO"/>`)
+	props.set(davNS, "current-user-principal", davHref(principalHref()))
+	props.set(davNS, "owner", davHref(principalHref()))
+	props.set(davNS, "current-user-privilege-set", davPrivileges("read", "write", "write-content", "bind", "unbind"))
+	props.set(davNS, "supported-report-set", davSupportedReports(
+		xml.Name{Space: caldavNS, Local: "calendar-query"},
+		xml.Name{Space: caldavNS, Local: "calendar-multiget"},
+		xml.Name{Space: davNS, Local: "sync-collection"},
+	))
+
+	if names == nil || requested(names, xml.Name{Space: calendarServerNS, Local: "getctag"}, xml.Name{Space: davNS, Local: "sync-token"}) {
+		if cal == nil {
+			var err error
+			if cal, err = h.caldavService.Calendar(ctx, userID, project.ID); err != nil {
+				return nil, err
+			}
+		}
+		token, err := h.caldavService.SyncToken(ctx, userID, cal)
+		if err != nil {
+			return nil, err
+		}
+		props.setText(calendarServerNS, "getctag", token)
+		props.setText(davNS, "sync-token", token)
+	}
+	return props, nil
+}
+
+// principalProps 根路径、用户主体和日历主目录的属性
+func principalProps(kind davKind, email string) davProps {
+	props := make(davProps)
+	switch kind {
+	case davPrincipal:
+		props.set(davNS, "resourcetype", `<collection xmlns="DAV:"/><principal xmlns="DAV:"/>`)
+	default:
+		props.set(davNS, "resourcetype", `<collection xmlns="DAV:"/>`)
+	}
+	props.setText(davNS, "displayname", email)
+	props.set(davNS, "current-user-principal", davHref(principalHref()))
+	props.set(davNS, "principal-URL", davHref(principalHref()))
+	props.set(caldavNS, "calendar-home-set", davHref(homeHref()))
+	props.set(caldavNS, "calendar-user-address-set", davHref("mailto:"+email))
+	props.set(davNS, "current-user-privilege-set", davPrivileges("read"))
+	return props
+}
+
+// resourceProps 日历资源的属性
+func resourceProps(res *services.CalDAVResource) davProps {
+	props := make(davProps)
+	props.set(davNS, "resourcetype", "")
+	props.setText(davNS, "getetag", res.ETag)
+	props.setText(davNS, "getcontenttype", caldavContentType)
+	props.setText(davNS, "getcontentlength", strconv.Itoa(len(res.Data)))
+	props.setText(davNS, "getlastmodified", res.UpdatedAt.UTC().Format(http.TimeFormat))
+	props.setText(caldavNS, "calendar-data", string(res.Data))
+	return props
+}
+
+// davPrivileges current-user-privilege-set的值
+func davPrivileges(privileges ...string) string {
+	var b strings.Builder
+	for _, p := range privileges {
+		b.WriteString(`<privilege xmlns="DAV:"><` + p + `/></privilege>`)
+	}
+	return b.String()
+}
+
+// davSupportedReports supported-report-set的值
+func davSupportedReports(reports ...xml.Name) string {
+	var b strings.Builder
+	for _, r := range reports {
+		b.WriteString(`<supported-report xmlns="DAV:"><report><` + r.Local + ` xmlns="` + r.Space + `"/></report></supported-report>`)
+	}
+	return b.String()
+}
+
+// requested 判断是否请求了任一属性
+func requested(names []xml.Name, candidates ...xml.Name) bool {
+	for _, name := range names {
+		for _, candidate := range candidates {
+			if name == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// davPreconditions 检查If-Match和If-None-Match，existing为nil表示资源不存在
+func davPreconditions(c *gin.Context, existing *services.CalDAVResource) bool {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		if existing == nil || !etagMatches(ifMatch, existing.ETag) {
+			return false
+		}
+	}
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && existing != nil {
+		if etagMatches(ifNoneMatch, existing.ETag) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryTimeRange 解析calendar-query的过滤条件，只支持VCALENDAR下的VTODO及其time-range
+// 过滤其他组件时ok为false，表示没有匹配的资源
+func queryTimeRange(body *davNode) (start, end *time.Time, ok bool) {
+	filter := body.child(caldavNS, "filter")
+	if filter == nil {
+		return nil, nil, true
+	}
+	calendar := filter.child(caldavNS, "comp-filter")
+	if calendar == nil {
+		return nil, nil, true
+	}
+	if !strings.EqualFold(calendar.attr("name"), "VCALENDAR") {
+		return nil, nil, false
+	}
+	component := calendar.child(caldavNS, "comp-filter")
+	if component == nil {
+		return nil, nil, true
+	}
+	if !strings.EqualFold(component.attr("name"), "VTODO") {
+		return nil, nil, false
+	}
+	if component.child(caldavNS, "is-not-defined") != nil {
+		return nil, nil, false
+	}
+	if timeRange := component.child(caldavNS, "time-range"); timeRange != nil {
+		start, end = parseDAVTime(timeRange.attr("start")), parseDAVTime(timeRange.attr("end"))
+	}
+	return start, end, true
+}
+
+// parseDAVTime 解析time-range中的UTC时间，格式无效时视为不限制
+func parseDAVTime(value string) *time.Time {
+	t, err := time.Parse("20060102T150405Z", value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// respondDAVError 将服务层错误映射为CalDAV响应，前置条件失败时附带对应的条件元素
+func respondDAVError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound),
+		errors.Is(err, services.ErrTaskNotFound),
+		errors.Is(err, services.ErrCalDAVResourceNotFound):
+		c.Status(http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidSyncToken):
+		writeDAVError(c, http.StatusForbidden, davNS, "valid-sync-token")
+	case errors.Is(err, services.ErrCalDAVUIDConflict):
+		writeDAVError(c, http.StatusForbidden, caldavNS, "no-uid-conflict")
+	case errors.Is(err, services.ErrInvalidICS):
+		writeDAVError(c, http.StatusForbidden, caldavNS, "valid-calendar-data")
+	default:
+		log.Printf("CalDAV请求失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+// parseDAVPath 解析/caldav之后的路径
+func parseDAVPath(path string) (davTarget, bool) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return davTarget{kind: davRoot}, true
+	}
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "principal":
+		return davTarget{kind: davPrincipal}, true
+	case parts[0] != "calendars":
+		return davTarget{}, false
+	case len(parts) == 1:
+		return davTarget{kind: davHome}, true
+	}
+
+	projectID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return davTarget{}, false
+	}
+	switch len(parts) {
+	case 2:
+		return davTarget{kind: davCalendar, projectID: projectID}, true
+	case 3:
+		return davTarget{kind: davResource, projectID: projectID, name: parts[2]}, true
+	}
+	return davTarget{}, false
+}
+
+// parseDAVHref 解析REPORT请求中的地址，可以是绝对URL或路径
+func parseDAVHref(href string) (davTarget, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return davTarget{}, false
+	}
+	path, ok := strings.CutPrefix(u.Path, CalDAVPrefix+"/")
+	if !ok {
+		return davTarget{}, false
+	}
+	return parseDAVPath(path)
+}
+
+func principalHref() string { return CalDAVPrefix + "/principal/" }
+
+func homeHref() string { return CalDAVPrefix + "/calendars/" }
+
+func calendarHref(projectID uuid.UUID) string { return homeHref() + projectID.String() + "/" }
+
+func resourceHref(projectID uuid.UUID, name string) string {
+	return calendarHref(projectID) + url.PathEscape(name)
+}
+
+// davTargetHref 目标的规范地址
+func davTargetHref(target davTarget) string {
+	switch target.kind {
+	case davPrincipal:
+		return principalHref()
+	case davHome:
+		return homeHref()
+	case davCalendar:
+		return calendarHref(target.projectID)
+	case davResource:
+		return resourceHref(target.projectID, target.name)
+	}
+	return CalDAVPrefix + "/"
+}
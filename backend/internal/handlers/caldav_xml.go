@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebDAV和CalDAV使用的XML命名空间
+const (
+	davNS            = "DAV:"
+	caldavNS         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNS = "http://calendarserver.org/ns/"
+	appleICalNS      = "http://apple.com/ns/ical/"
+)
+
+// maxDAVRequestSize PROPFIND和REPORT请求体的大小上限
+const maxDAVRequestSize = 1 << 20
+
+// davNode 请求体中的XML元素，按命名空间和本地名称匹配，不关心前缀
+type davNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []davNode  `xml:",any"`
+}
+
+// child 获取第一个指定名称的子元素，不存在时返回nil
+func (n *davNode) child(ns, local string) *davNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Space == ns && n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// attr 获取属性值，不存在时返回空字符串
+func (n *davNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// text 元素去掉首尾空白的文本内容
+func (n *davNode) text() string {
+	return strings.TrimSpace(n.Content)
+}
+
+// propNames prop元素中请求的属性名称
+func (n *davNode) propNames() []xml.Name {
+	prop := n.child(davNS, "prop")
+	if prop == nil {
+		return nil
+	}
+	names := make([]xml.Name, 0, len(prop.Children))
+	for _, p := range prop.Children {
+		names = append(names, p.XMLName)
+	}
+	return names
+}
+
+// readDAVBody 解析请求体，请求体为空时返回nil
+func readDAVBody(c *gin.Context) (*davNode, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxDAVRequestSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var root davNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("请求体不是有效的XML: %w", err)
+	}
+	return &root, nil
+}
+
+// davProps 一个资源的属性，值为元素内的XML片段
+type davProps map[xml.Name]string
+
+// set 设置属性值，value是已转义的XML片段
+func (p davProps) set(ns, local, value string) {
+	p[xml.Name{Space: ns, Local: local}] = value
+}
+
+// setText 设置文本属性值
+func (p davProps) setText(ns, local, text string) {
+	p.set(ns, local, xmlEscape(text))
+}
+
+// davResponse multistatus中的一个response，status不为0时表示整个资源的状态（如已删除）
+type davResponse struct {
+	href   string
+	found  davProps
+	absent []xml.Name
+	status int
+}
+
+// newDAVResponse 按请求的属性名称生成response，names为nil时返回全部属性（allprop）
+// allprop不包含calendar-data这类开销大的属性
+func newDAVResponse(href string, props davProps, names []xml.Name) davResponse {
+	resp := davResponse{href: href, found: make(davProps)}
+	if names == nil {
+		for name, value := range props {
+			if name != (xml.Name{Space: caldavNS, Local: "calendar-data"}) {
+				resp.found[name] = value
+			}
+		}
+		return resp
+	}
+	for _, name := range names {
+		if value, ok := props[name]; ok {
+			resp.found[name] = value
+		} else {
+			resp.absent = append(resp.absent, name)
+		}
+	}
+	return resp
+}
+
+// writeMultistatus 输出207 Multi-Status，syncToken不为空时附带sync-token元素
+// 每个属性元素单独声明命名空间，避免维护前缀
+func writeMultistatus(c *gin.Context, responses []davResponse, syncToken string) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<multistatus xmlns="DAV:">`)
+	for _, resp := range responses {
+		b.WriteString("<response><href>" + xmlEscape(resp.href) + "</href>")
+		if resp.status != 0 {
+			b.WriteString("<status>" + davStatus(resp.status) + "</status>")
+		} else {
+			if len(resp.found) > 0 || len(resp.absent) == 0 {
+				b.WriteString("<propstat><prop>")
+				for _, name := range sortedNames(resp.found) {
+					writeProp(&b, name, resp.found[name])
+				}
+				b.WriteString("</prop><status>" + davStatus(http.StatusOK) + "</status></propstat>")
+			}
+			if len(resp.absent) > 0 {
+				b.WriteString("<propstat><prop>")
+				for _, name := range resp.absent {
+					writeProp(&b, name, "")
+				}
+				b.WriteString("</prop><status>" + davStatus(http.StatusNotFound) + "</status></propstat>")
+			}
+		}
+		b.WriteString("</response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<sync-token>" + xmlEscape(syncToken) + "</sync-token>")
+	}
+	b.WriteString("</multistatus>")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// writeDAVError 输出带前置条件元素的错误响应，如DAV:valid-sync-token
+func writeDAVError(c *gin.Context, status int, ns, condition string) {
+	body := xml.Header + `<error xmlns="DAV:"><` + condition + ` xmlns="` + ns + `"/></error>`
+	c.Data(status, "application/xml; charset=utf-8", []byte(body))
+}
+
+// writeProp 输出一个属性元素
+func writeProp(b *strings.Builder, name xml.Name, value string) {
+	b.WriteString("<" + name.Local + ` xmlns="` + xmlEscape(name.Space) + `"`)
+	if value == "" {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">" + value + "</" + name.Local + ">")
+}
+
+// sortedNames 按命名空间和名称排序，使输出稳定
+func sortedNames(props davProps) []xml.Name {
+	names := make([]xml.Name, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+		return names[i].Local < names[j].Local
+	})
+	return names
+}
+
+// davStatus multistatus中的状态行
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// davHref 在其他命名空间的属性中引用地址，需要显式声明DAV:命名空间
+func davHref(href string) string {
+	return `<href xmlns="DAV:">` + xmlEscape(href) + `</href>`
+}
+
+// xmlEscape 转义XML文本
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
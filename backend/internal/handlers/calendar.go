@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// icsContentType iCalendar文件的内容类型
+	icsContentType = "text/calendar; charset=utf-8"
+	// maxICSImportSize 导入文件的大小上限
+	maxICSImportSize = 10 << 20
+)
+
+// CalendarHandler iCalendar导入导出处理器
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+}
+
+// NewCalendarHandler 创建iCalendar导入导出处理器实例
+func NewCalendarHandler(calendarService *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// ExportICS 导出全部任务为.ics文件
+func (h *CalendarHandler) ExportICS(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	h.exportICS(c, userID, nil, "tasks.ics")
+}
+
+// ExportProjectICS 导出单个项目的任务为.ics文件
+func (h *CalendarHandler) ExportProjectICS(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	h.exportICS(c, userID, &projectID, fmt.Sprintf("project-%s.ics", projectID))
+}
+
+// exportICS 生成日历并作为附件返回
+func (h *CalendarHandler) exportICS(c *gin.Context, userID uuid.UUID, projectID *uuid.UUID, filename string) {
+	data, err := h.calendarService.ExportICS(c.Request.Context(), userID, projectID)
+	if err != nil {
+		respondError(c, err, "导出日历失败")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+// ImportProjectICS 将.ics文件导入到项目，文件通过multipart的file字段或直接作为请求体上传
+// 单个条目失败不影响其他条目，结果中列出每个条目的导入状态
+func (h *CalendarHandler) ImportProjectICS(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, _, err := readUpload(c, maxICSImportSize, ".ics")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	result, err := h.calendarService.ImportICS(c.Request.Context(), userID, projectID, data)
+	if err != nil {
+		respondError(c, err, "导入日历失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarFeedHandler 日历订阅处理器
+type CalendarFeedHandler struct {
+	feedService *services.CalendarFeedService
+}
+
+// NewCalendarFeedHandler 创建日历订阅处理器实例
+func NewCalendarFeedHandler(feedService *services.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		feedService: feedService,
+	}
+}
+
+// ListFeeds 获取日历订阅列表
+func (h *CalendarFeedHandler) ListFeeds(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.feedService.ListFeeds(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取日历订阅失败")
+		return
+	}
+
+	respondPage(c, "feeds", page, query.Fields, nil)
+}
+
+// CreateFeed 创建日历订阅
+func (h *CalendarFeedHandler) CreateFeed(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateCalendarFeedRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	feed, err := h.feedService.CreateFeed(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建日历订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"feed": feed})
+}
+
+// RotateFeed 重新生成订阅地址，旧地址失效
+func (h *CalendarFeedHandler) RotateFeed(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	feed, err := h.feedService.RotateFeed(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "重新生成订阅地址失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feed": feed})
+}
+
+// DeleteFeed 删除日历订阅
+func (h *CalendarFeedHandler) DeleteFeed(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.feedService.DeleteFeed(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除日历订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "日历订阅已删除"})
+}
+
+// ServeFeed 供日历应用拉取的订阅内容，通过路径中的令牌认证
+// 支持If-None-Match，内容未变化时返回304
+func (h *CalendarFeedHandler) ServeFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := h.feedService.Feed(c.Request.Context(), token)
+	if err != nil {
+		respondError(c, err, "获取日历订阅失败")
+		return
+	}
+
+	c.Header("ETag", feed.ETag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), feed.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, icsContentType, feed.Data)
+}
+
+// etagMatches 判断If-None-Match是否包含当前ETag，按弱比较处理W/前缀
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
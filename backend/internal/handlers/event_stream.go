@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ticktick-backend/internal/middleware"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventStreamHandler 实时变更流处理器
+type EventStreamHandler struct {
+	stream     *services.EventStream
+	tokenStore *services.TokenStore
+}
+
+// NewEventStreamHandler 创建实时变更流处理器实例
+func NewEventStreamHandler(stream *services.EventStream, tokenStore *services.TokenStore) *EventStreamHandler {
+	return &EventStreamHandler{
+		stream:     stream,
+		tokenStore: tokenStore,
+	}
+}
+
+// Stream 以Server-Sent Events推送当前用户的数据变更
+// 连接建立后先发送ready事件，其ID是当前的事件序号；之后每个变更作为默认的message事件发送，ID为序号，
+// 数据是事件的JSON。重连时通过Last-Event-ID请求头（或lastEventId查询参数）补发断开期间的事件，
+// 缺失的事件超出补发缓冲时发送reset事件，客户端应重新拉取全部数据。
+// 访问令牌过期或会话被撤销时服务端结束连接，客户端刷新令牌后重连
+func (h *EventStreamHandler) Stream(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	after := int64(-1)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的Last-Event-ID"})
+			return
+		}
+		after = id
+	}
+
+	// 先订阅再读取补发缓冲，两者重叠的事件按序号去重
+	sub := h.stream.Subscribe(userID)
+	defer sub.Close()
+
+	ctx := c.Request.Context()
+	replay, err := h.stream.Replay(ctx, userID, after)
+	if err != nil {
+		respondError(c, err, "读取事件失败")
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	sent := replay.Seq
+	if replay.Gap {
+		writeSSE(c, replay.Seq, "reset", fmt.Sprintf(`{"seq":%d}`, replay.Seq))
+	} else {
+		writeSSE(c, replay.Seq, "ready", fmt.Sprintf(`{"seq":%d}`, replay.Seq))
+		for _, message := range replay.Messages {
+			writeSSE(c, message.Seq, "", string(message.Event))
+		}
+	}
+	c.Writer.Flush()
+
+	tokenID, _ := middleware.GetTokenIDFromContext(c)
+	heartbeat := time.NewTicker(h.stream.HeartbeatInterval())
+	defer heartbeat.Stop()
+
+	// 令牌过期后结束长连接，没有过期时间时（如应用专用密码）不限制
+	var expired <-chan time.Time
+	if expiresAt, ok := middleware.GetTokenExpiresAtFromContext(c); ok {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case message, ok := <-sub.C:
+			if !ok {
+				// 连接过慢或服务停止，客户端重连后补发
+				return
+			}
+			if message.Seq <= sent {
+				continue
+			}
+			writeSSE(c, message.Seq, "", string(message.Event))
+			c.Writer.Flush()
+			sent = message.Seq
+		case <-heartbeat.C:
+			// 会话被撤销后结束长连接
+			if tokenID != "" {
+				if revoked, err := h.tokenStore.IsInBlacklist(tokenID); err == nil && revoked {
+					return
+				}
+			}
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSE 写入一条SSE事件，event为空时是默认的message事件
+func writeSSE(c *gin.Context, id int64, event, data string) {
+	fmt.Fprintf(c.Writer, "id: %d\n", id)
+	if event != "" {
+		fmt.Fprintf(c.Writer, "event: %s\n", event)
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"ticktick-backend/internal/archive"
+	"ticktick-backend/internal/importer"
+	"ticktick-backend/internal/middleware"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+	"ticktick-backend/internal/settings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requireUserID 从上下文获取当前用户ID，不存在时直接返回401
+func requireUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未找到用户信息"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseIDParam 解析路径中的UUID参数，格式错误时直接返回400
+func parseIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := parseUUID(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID格式"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// bindJSON 绑定请求体，失败时直接返回400
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "请求参数无效",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// bindQuery 绑定查询参数，失败时直接返回400
+func bindQuery(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "请求参数无效",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// respondPage 返回一页列表数据，附带count和nextCursor，并按fields参数裁剪字段
+// extra中的内容会一并返回
+func respondPage[T any](c *gin.Context, key string, page *pagination.Page[T], fields string, extra gin.H) {
+	items, err := pagination.SelectFields(page.Items, fields)
+	if err != nil {
+		respondError(c, err, "获取列表失败")
+		return
+	}
+
+	resp := gin.H{
+		key:          items,
+		"count":      len(page.Items),
+		"nextCursor": nil,
+	}
+	if page.NextCursor != "" {
+		resp["nextCursor"] = page.NextCursor
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// respondError 将服务层错误映射为HTTP响应，未知错误统一返回500并记录日志
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound),
+		errors.Is(err, services.ErrTaskNotFound),
+		errors.Is(err, services.ErrLabelNotFound),
+		errors.Is(err, services.ErrReminderNotFound),
+		errors.Is(err, services.ErrTrashItemNotFound),
+		errors.Is(err, services.ErrSmartListNotFound),
+		errors.Is(err, services.ErrSectionNotFound),
+		errors.Is(err, services.ErrProjectGroupNotFound),
+		errors.Is(err, services.ErrCalendarFeedNotFound),
+		errors.Is(err, services.ErrAppPasswordNotFound),
+		errors.Is(err, services.ErrImportJobNotFound),
+		errors.Is(err, services.ErrAccountExportNotFound),
+		errors.Is(err, services.ErrWebhookNotFound),
+		errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrProjectNameExists),
+		errors.Is(err, services.ErrLabelNameExists),
+		errors.Is(err, services.ErrSmartListNameExists),
+		errors.Is(err, services.ErrProjectGroupNameExists),
+		errors.Is(err, services.ErrTrashProjectDeleted),
+		errors.Is(err, services.ErrCalendarFeedExists),
+		errors.Is(err, services.ErrAccountNotEmpty),
+		errors.Is(err, services.ErrWebhookDisabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidParentTask),
+		errors.Is(err, services.ErrInvalidTaskQuery),
+		errors.Is(err, services.ErrInvalidFilter),
+		errors.Is(err, services.ErrInvalidMove),
+		errors.Is(err, services.ErrInvalidSection),
+		errors.Is(err, services.ErrInvalidProjectGroup),
+		errors.Is(err, services.ErrInvalidICS),
+		errors.Is(err, services.ErrInvalidWebhook),
+		errors.Is(err, services.ErrInvalidSyncToken),
+		errors.Is(err, importer.ErrInvalidBackup),
+		errors.Is(err, archive.ErrInvalidArchive),
+		errors.Is(err, importer.ErrUnsupportedSource),
+		errors.Is(err, settings.ErrInvalidSettings),
+		errors.Is(err, pagination.ErrInvalidQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// readUpload 读取上传的文件，文件通过multipart的file字段或直接作为请求体上传
+// 直接上传时文件名取自filename查询参数，kind是提示信息中的文件类型
+func readUpload(c *gin.Context, limit int64, kind string) ([]byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(data) == 0 {
+			return nil, "", fmt.Errorf("请上传%s文件", kind)
+		}
+		return data, c.Query("filename"), nil
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("请通过file字段上传%s文件", kind)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	return data, header.Filename, err
+}
+
+// respondUploadError 返回读取上传文件失败的响应
+func respondUploadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件过大"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
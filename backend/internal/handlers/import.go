@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackupImportSize 备份文件的大小上限
+const maxBackupImportSize = 20 << 20
+
+// ImportHandler 备份导入处理器
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+// NewImportHandler 创建备份导入处理器实例
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// StartImport 上传滴答清单或Todoist的备份，dryRun时直接返回预览，否则在后台导入并返回导入任务
+func (h *ImportHandler) StartImport(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query services.ImportQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	data, filename, err := readUpload(c, maxBackupImportSize, "备份")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	if query.DryRun {
+		preview, err := h.importService.Preview(c.Request.Context(), userID, query.Source, filename, data)
+		if err != nil {
+			respondError(c, err, "解析备份失败")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"preview": preview})
+		return
+	}
+
+	job, err := h.importService.StartImport(c.Request.Context(), userID, query.Source, filename, data)
+	if err != nil {
+		respondError(c, err, "开始导入失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetImportJob 获取导入任务的进度
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	jobID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		respondError(c, err, "获取导入任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
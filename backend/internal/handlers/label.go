@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LabelHandler 标签处理器
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+// NewLabelHandler 创建标签处理器实例
+func NewLabelHandler(labelService *services.LabelService) *LabelHandler {
+	return &LabelHandler{
+		labelService: labelService,
+	}
+}
+
+// ListLabels 获取标签列表
+func (h *LabelHandler) ListLabels(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.labelService.ListLabels(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取标签列表失败")
+		return
+	}
+
+	respondPage(c, "labels", page, query.Fields, nil)
+}
+
+// CreateLabel 创建标签
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.LabelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	label, err := h.labelService.CreateLabel(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建标签失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"label": label})
+}
+
+// UpdateLabel 重命名标签
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.LabelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	label, err := h.labelService.UpdateLabel(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新标签失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"label": label})
+}
+
+// DeleteLabel 删除标签
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.labelService.DeleteLabel(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除标签失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "标签已删除"})
+}
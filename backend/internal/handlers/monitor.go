@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"ticktick-backend/internal/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,24 +11,29 @@ import (
 
 // MonitorHandler 监控处理器
 type MonitorHandler struct {
-	tokenMonitor *services.TokenMonitor
-	tokenStore   *services.TokenStore
+	tokenMonitor  *services.TokenMonitor
+	tokenStore    *services.TokenStore
+	healthChecker *services.HealthChecker
 }
 
 // NewMonitorHandler 创建监控处理器实例
-func NewMonitorHandler(tokenMonitor *services.TokenMonitor, tokenStore *services.TokenStore) *MonitorHandler {
+func NewMonitorHandler(tokenMonitor *services.TokenMonitor, tokenStore *services.TokenStore, healthChecker *services.HealthChecker) *MonitorHandler {
 	return &MonitorHandler{
-		tokenMonitor: tokenMonitor,
-		tokenStore:   tokenStore,
+		tokenMonitor:  tokenMonitor,
+		tokenStore:    tokenStore,
+		healthChecker: healthChecker,
 	}
 }
 
-// GetHealth 获取健康状态
+// GetHealth 获取健康状态，包含所有就绪检查项
 func (h *MonitorHandler) GetHealth(c *gin.Context) {
 	status := h.tokenMonitor.GetHealthStatus()
+	report := h.healthChecker.Ready(c.Request.Context())
+	status["overall_healthy"] = report.Ready
+	status["checks"] = report.Checks
 
 	httpStatus := http.StatusOK
-	if !status["overall_healthy"].(bool) {
+	if !report.Ready {
 		httpStatus = http.StatusServiceUnavailable
 	}
 
@@ -36,6 +42,27 @@ func (h *MonitorHandler) GetHealth(c *gin.Context) {
 	})
 }
 
+// Livez 存活探针，进程能够处理请求即返回200，不检查外部依赖
+func (h *MonitorHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":        services.HealthStatusUp,
+		"uptimeSeconds": int64(h.healthChecker.Uptime().Seconds()),
+	})
+}
+
+// Readyz 就绪探针，检查数据库、Redis、数据库迁移、Token监控、Webhook投递和提醒触发任务
+// 导入和导出按请求在独立协程中执行，没有常驻任务，通过队列长度指标观察
+func (h *MonitorHandler) Readyz(c *gin.Context) {
+	report := h.healthChecker.Ready(c.Request.Context())
+
+	httpStatus := http.StatusOK
+	if !report.Ready {
+		httpStatus = http.StatusServiceUnavailable
+	}
+
+	c.JSON(httpStatus, report)
+}
+
 // GetStats 获取统计信息
 func (h *MonitorHandler) GetStats(c *gin.Context) {
 	stats, err := h.tokenStore.GetStats()
@@ -179,10 +206,13 @@ func (h *MonitorHandler) GetSystemMetrics(c *gin.Context) {
 	health := h.tokenMonitor.GetHealthStatus()
 
 	// 组合系统指标
+	uptime := h.healthChecker.Uptime()
 	metrics := map[string]interface{}{
-		"stats":  stats,
-		"health": health,
-		"uptime": "运行中", // 实际项目中可以计算真实的运行时间
+		"stats":         stats,
+		"health":        health,
+		"startedAt":     h.healthChecker.StartedAt(),
+		"uptime":        uptime.Truncate(time.Second).String(),
+		"uptimeSeconds": int64(uptime.Seconds()),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
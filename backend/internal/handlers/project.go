@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHandler 项目处理器
+type ProjectHandler struct {
+	projectService *services.ProjectService
+}
+
+// NewProjectHandler 创建项目处理器实例
+func NewProjectHandler(projectService *services.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+// ListProjects 获取项目列表，grouped=true时返回侧边栏使用的分组结构
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var listQuery services.ProjectListQuery
+	var query pagination.Query
+	if !bindQuery(c, &listQuery) || !bindQuery(c, &query) {
+		return
+	}
+
+	if listQuery.Grouped {
+		grouped, err := h.projectService.ListGroupedProjects(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "获取项目列表失败")
+			return
+		}
+		c.JSON(http.StatusOK, grouped)
+		return
+	}
+
+	page, err := h.projectService.ListProjects(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取项目列表失败")
+		return
+	}
+
+	respondPage(c, "projects", page, query.Fields, nil)
+}
+
+// CreateProject 创建项目
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateProjectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, err := h.projectService.CreateProject(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建项目失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"project": project})
+}
+
+// GetProject 获取项目详情
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	project, err := h.projectService.GetProject(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "获取项目详情失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// UpdateProject 更新项目
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateProjectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, err := h.projectService.UpdateProject(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新项目失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// MoveProject 拖动排序项目
+func (h *ProjectHandler) MoveProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.MoveProjectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, err := h.projectService.MoveProject(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "移动项目失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// DeleteProject 删除项目及其下所有任务
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.projectService.DeleteProject(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除项目失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "项目已删除"})
+}
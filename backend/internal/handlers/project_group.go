@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectGroupHandler 项目分组处理器
+type ProjectGroupHandler struct {
+	groupService *services.ProjectGroupService
+}
+
+// NewProjectGroupHandler 创建项目分组处理器实例
+func NewProjectGroupHandler(groupService *services.ProjectGroupService) *ProjectGroupHandler {
+	return &ProjectGroupHandler{
+		groupService: groupService,
+	}
+}
+
+// ListProjectGroups 获取项目分组列表
+func (h *ProjectGroupHandler) ListProjectGroups(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.groupService.ListProjectGroups(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取项目分组失败")
+		return
+	}
+
+	respondPage(c, "groups", page, query.Fields, nil)
+}
+
+// CreateProjectGroup 创建项目分组
+func (h *ProjectGroupHandler) CreateProjectGroup(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateProjectGroupRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	group, err := h.groupService.CreateProjectGroup(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建项目分组失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"group": group})
+}
+
+// UpdateProjectGroup 修改分组名称或折叠状态
+func (h *ProjectGroupHandler) UpdateProjectGroup(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateProjectGroupRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	group, err := h.groupService.UpdateProjectGroup(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新项目分组失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
+// MoveProjectGroup 拖动排序项目分组
+func (h *ProjectGroupHandler) MoveProjectGroup(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.MoveRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	group, err := h.groupService.MoveProjectGroup(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "移动项目分组失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
+// DeleteProjectGroup 删除项目分组，其中的项目变为未分组
+func (h *ProjectGroupHandler) DeleteProjectGroup(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.DeleteProjectGroup(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除项目分组失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "项目分组已删除"})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SectionHandler 项目分栏处理器
+type SectionHandler struct {
+	sectionService *services.SectionService
+}
+
+// NewSectionHandler 创建项目分栏处理器实例
+func NewSectionHandler(sectionService *services.SectionService) *SectionHandler {
+	return &SectionHandler{
+		sectionService: sectionService,
+	}
+}
+
+// ListSections 获取项目的分栏
+func (h *SectionHandler) ListSections(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.sectionService.ListSections(c.Request.Context(), userID, projectID, &query)
+	if err != nil {
+		respondError(c, err, "获取分栏失败")
+		return
+	}
+
+	respondPage(c, "sections", page, query.Fields, nil)
+}
+
+// CreateSection 在项目中创建分栏
+func (h *SectionHandler) CreateSection(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.CreateSectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	section, err := h.sectionService.CreateSection(c.Request.Context(), userID, projectID, &req)
+	if err != nil {
+		respondError(c, err, "创建分栏失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"section": section})
+}
+
+// UpdateSection 修改分栏名称或折叠状态
+func (h *SectionHandler) UpdateSection(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "sectionId")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateSectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	section, err := h.sectionService.UpdateSection(c.Request.Context(), userID, projectID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新分栏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"section": section})
+}
+
+// MoveSection 拖动排序分栏
+func (h *SectionHandler) MoveSection(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "sectionId")
+	if !ok {
+		return
+	}
+
+	var req services.MoveRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	section, err := h.sectionService.MoveSection(c.Request.Context(), userID, projectID, id, &req)
+	if err != nil {
+		respondError(c, err, "移动分栏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"section": section})
+}
+
+// DeleteSection 删除分栏，其中的任务移到默认分栏
+func (h *SectionHandler) DeleteSection(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "sectionId")
+	if !ok {
+		return
+	}
+
+	if err := h.sectionService.DeleteSection(c.Request.Context(), userID, projectID, id); err != nil {
+		respondError(c, err, "删除分栏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "分栏已删除"})
+}
+
+// GetBoard 获取项目看板，包含各分栏及其中按顺序排列的任务
+func (h *SectionHandler) GetBoard(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	projectID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	board, err := h.sectionService.GetBoard(c.Request.Context(), userID, projectID)
+	if err != nil {
+		respondError(c, err, "获取看板失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler 用户设置处理器
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+}
+
+// NewSettingsHandler 创建用户设置处理器实例
+func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetSettings 获取当前用户的设置
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.settingsService.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err, "获取设置失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateSettings 部分更新当前用户的设置，请求体只需包含要修改的字段
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil || len(patch) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体不能为空"})
+		return
+	}
+
+	settings, err := h.settingsService.UpdateSettings(c.Request.Context(), userID, patch)
+	if err != nil {
+		respondError(c, err, "更新设置失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SmartListHandler 智能清单处理器
+type SmartListHandler struct {
+	smartListService *services.SmartListService
+}
+
+// NewSmartListHandler 创建智能清单处理器实例
+func NewSmartListHandler(smartListService *services.SmartListService) *SmartListHandler {
+	return &SmartListHandler{
+		smartListService: smartListService,
+	}
+}
+
+// ListSmartLists 获取内置和自定义的智能清单
+func (h *SmartListHandler) ListSmartLists(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.smartListService.ListSmartLists(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取智能清单失败")
+		return
+	}
+
+	respondPage(c, "smartLists", page, query.Fields, nil)
+}
+
+// CreateSmartList 创建智能清单
+func (h *SmartListHandler) CreateSmartList(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.SmartListRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	list, err := h.smartListService.CreateSmartList(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建智能清单失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"smartList": list})
+}
+
+// UpdateSmartList 更新智能清单，内置清单不能修改
+func (h *SmartListHandler) UpdateSmartList(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.SmartListRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	list, err := h.smartListService.UpdateSmartList(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新智能清单失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"smartList": list})
+}
+
+// DeleteSmartList 删除智能清单，内置清单不能删除
+func (h *SmartListHandler) DeleteSmartList(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.smartListService.DeleteSmartList(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除智能清单失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "智能清单已删除"})
+}
+
+// ListSmartListTasks 获取智能清单中的任务
+func (h *SmartListHandler) ListSmartListTasks(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	list, page, err := h.smartListService.ListSmartListTasks(c.Request.Context(), userID, c.Param("id"), &query)
+	if err != nil {
+		respondError(c, err, "获取智能清单任务失败")
+		return
+	}
+
+	respondPage(c, "tasks", page, query.Fields, gin.H{"smartList": list})
+}
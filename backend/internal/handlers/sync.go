@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncHandler 增量同步处理器
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+// NewSyncHandler 创建增量同步处理器实例
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Pull 获取同步令牌之后的变更，已删除的记录以墓碑返回
+func (h *SyncHandler) Pull(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query services.SyncPullQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	resp, err := h.syncService.Pull(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取变更失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Push 批量提交客户端的修改，返回每条修改的处理结果
+func (h *SyncHandler) Push(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.SyncPushRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.syncService.Push(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "提交修改失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
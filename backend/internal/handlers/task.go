@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHandler 任务处理器
+type TaskHandler struct {
+	taskService     *services.TaskService
+	reminderService *services.ReminderService
+}
+
+// NewTaskHandler 创建任务处理器实例
+func NewTaskHandler(taskService *services.TaskService, reminderService *services.ReminderService) *TaskHandler {
+	return &TaskHandler{
+		taskService:     taskService,
+		reminderService: reminderService,
+	}
+}
+
+// ListTasks 获取任务列表，支持按项目、父任务、状态、优先级和截止时间过滤
+func (h *TaskHandler) ListTasks(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query services.TaskListQuery
+	var pageQuery pagination.Query
+	if !bindQuery(c, &query) || !bindQuery(c, &pageQuery) {
+		return
+	}
+
+	page, err := h.taskService.ListTasks(c.Request.Context(), userID, &query, &pageQuery)
+	if err != nil {
+		respondError(c, err, "获取任务列表失败")
+		return
+	}
+
+	respondPage(c, "tasks", page, pageQuery.Fields, nil)
+}
+
+// SearchTasks 按标题、描述和标签名称全文搜索任务
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query services.TaskSearchQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	results, err := h.taskService.SearchTasks(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "搜索任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks": results,
+		"count": len(results),
+	})
+}
+
+// CreateTask 创建任务
+func (h *TaskHandler) CreateTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateTaskRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	task, err := h.taskService.CreateTask(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建任务失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"task": task})
+}
+
+// GetTask 获取任务详情
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.GetTask(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "获取任务详情失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// UpdateTask 更新任务
+func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateTaskRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	task, err := h.taskService.UpdateTask(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// MoveTask 拖动排序任务
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.MoveTaskRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	task, err := h.taskService.MoveTask(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "移动任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// DeleteTask 删除任务及其子任务
+func (h *TaskHandler) DeleteTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.DeleteTask(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "任务已删除"})
+}
+
+// CompleteTask 完成任务
+func (h *TaskHandler) CompleteTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.CompleteTask(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "完成任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// ReopenTask 重新打开已完成的任务
+func (h *TaskHandler) ReopenTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.ReopenTask(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "重新打开任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// ListReminders 获取任务的提醒列表
+func (h *TaskHandler) ListReminders(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	taskID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.reminderService.ListReminders(c.Request.Context(), userID, taskID, &query)
+	if err != nil {
+		respondError(c, err, "获取提醒列表失败")
+		return
+	}
+
+	respondPage(c, "reminders", page, query.Fields, nil)
+}
+
+// CreateReminder 为任务创建提醒
+func (h *TaskHandler) CreateReminder(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	taskID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.CreateReminderRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	reminder, err := h.reminderService.CreateReminder(c.Request.Context(), userID, taskID, &req)
+	if err != nil {
+		respondError(c, err, "创建提醒失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reminder": reminder})
+}
+
+// DeleteReminder 删除任务的提醒
+func (h *TaskHandler) DeleteReminder(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	taskID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	reminderID, ok := parseIDParam(c, "reminderId")
+	if !ok {
+		return
+	}
+
+	if err := h.reminderService.DeleteReminder(c.Request.Context(), userID, taskID, reminderID); err != nil {
+		respondError(c, err, "删除提醒失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "提醒已删除"})
+}
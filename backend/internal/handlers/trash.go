@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandler 创建回收站处理器实例
+func NewTrashHandler(trashService *services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// ListTrash 分页获取回收站中的任务，type=projects时获取回收站中的项目
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var listQuery services.TrashListQuery
+	var query pagination.Query
+	if !bindQuery(c, &listQuery) || !bindQuery(c, &query) {
+		return
+	}
+
+	if listQuery.Type == "projects" {
+		projects, err := h.trashService.ListDeletedProjects(c.Request.Context(), userID, &query)
+		if err != nil {
+			respondError(c, err, "获取回收站失败")
+			return
+		}
+		respondPage(c, "projects", projects, query.Fields, nil)
+		return
+	}
+
+	tasks, err := h.trashService.ListDeletedTasks(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取回收站失败")
+		return
+	}
+	respondPage(c, "tasks", tasks, query.Fields, nil)
+}
+
+// EmptyTrash 清空回收站
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.EmptyTrash(c.Request.Context(), userID); err != nil {
+		respondError(c, err, "清空回收站失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "回收站已清空"})
+}
+
+// RestoreProject 从回收站恢复项目
+func (h *TrashHandler) RestoreProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	project, err := h.trashService.RestoreProject(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "恢复项目失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// DeleteProject 彻底删除回收站中的项目
+func (h *TrashHandler) DeleteProject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.DeleteProject(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "彻底删除项目失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "项目已彻底删除"})
+}
+
+// RestoreTask 从回收站恢复任务
+func (h *TrashHandler) RestoreTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	task, err := h.trashService.RestoreTask(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "恢复任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// DeleteTask 彻底删除回收站中的任务
+func (h *TrashHandler) DeleteTask(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.DeleteTask(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "彻底删除任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "任务已彻底删除"})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler Webhook处理器
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler 创建Webhook处理器实例
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ListWebhooks 获取Webhook列表，不包含签名密钥
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.webhookService.ListWebhooks(c.Request.Context(), userID, &query)
+	if err != nil {
+		respondError(c, err, "获取Webhook列表失败")
+		return
+	}
+
+	respondPage(c, "webhooks", page, query.Fields, nil)
+}
+
+// CreateWebhook 注册Webhook，签名密钥只在本次响应中返回
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondError(c, err, "创建Webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook})
+}
+
+// GetWebhook 获取Webhook详情
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err, "获取Webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// UpdateWebhook 更新Webhook的地址、订阅的事件或启用状态
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		respondError(c, err, "更新Webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// DeleteWebhook 删除Webhook
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), userID, id); err != nil {
+		respondError(c, err, "删除Webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook已删除"})
+}
+
+// ListDeliveries 获取Webhook的投递记录
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var query pagination.Query
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	page, err := h.webhookService.ListDeliveries(c.Request.Context(), userID, id, &query)
+	if err != nil {
+		respondError(c, err, "获取投递记录失败")
+		return
+	}
+
+	respondPage(c, "deliveries", page, query.Fields, nil)
+}
+
+// Redeliver 重新投递，新的投递在后台发送
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseIDParam(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), userID, id, deliveryID)
+	if err != nil {
+		respondError(c, err, "重新投递失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
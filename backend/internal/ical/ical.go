@@ -0,0 +1,205 @@
+// Package ical 实现RFC 5545 iCalendar格式的组件模型、解析和序列化
+//
+// 日历由嵌套的组件（VCALENDAR、VTODO、VEVENT、VALARM等）组成，每个组件包含若干属性。
+// 序列化时按规范转义文本、给含特殊字符的参数值加引号，并将超过75字节的行折叠，
+// 折叠不会拆开UTF-8多字节字符；解析时展开折叠行，TZID可引用内嵌的VTIMEZONE
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// maxLineOctets 折叠前每行最多的字节数，不含CRLF
+	maxLineOctets = 75
+	// DateTimeFormat UTC日期时间格式
+	DateTimeFormat = "20060102T150405Z"
+)
+
+// Param 属性参数，如 RELATED=END
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Property 组件属性，Value为已编码的值，文本值需先经过EscapeText
+type Property struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+// Component 日历组件
+type Component struct {
+	Name       string
+	Props      []*Property
+	Components []*Component
+}
+
+// NewComponent 创建组件
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add 添加已编码的属性值
+func (c *Component) Add(name, value string, params ...Param) {
+	c.Props = append(c.Props, &Property{Name: name, Params: params, Value: value})
+}
+
+// AddText 添加文本属性，自动转义
+func (c *Component) AddText(name, text string, params ...Param) {
+	c.Add(name, EscapeText(text), params...)
+}
+
+// AddTime 添加UTC日期时间属性
+func (c *Component) AddTime(name string, t time.Time, params ...Param) {
+	c.Add(name, FormatDateTime(t), params...)
+}
+
+// AddComponent 添加子组件
+func (c *Component) AddComponent(child *Component) {
+	c.Components = append(c.Components, child)
+}
+
+// String 返回组件及其子组件的序列化结果，每行以CRLF结尾
+func (c *Component) String() string {
+	var b strings.Builder
+	c.encode(&b)
+	return b.String()
+}
+
+func (c *Component) encode(b *strings.Builder) {
+	writeLine(b, "BEGIN:"+c.Name)
+	for _, prop := range c.Props {
+		writeLine(b, prop.line())
+	}
+	for _, child := range c.Components {
+		child.encode(b)
+	}
+	writeLine(b, "END:"+c.Name)
+}
+
+// line 生成未折叠的属性行
+func (p *Property) line() string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, param := range p.Params {
+		b.WriteByte(';')
+		b.WriteString(param.Name)
+		b.WriteByte('=')
+		b.WriteString(quoteParam(param.Value))
+	}
+	b.WriteByte(':')
+	b.WriteString(p.Value)
+	return b.String()
+}
+
+// writeLine 写入一行，超过75字节时折叠为以空格开头的续行
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// 回退到UTF-8字符的起始字节，避免拆开多字节字符
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1 // 续行开头的空格占一个字节
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// EscapeText 转义TEXT类型的值：反斜杠、分号、逗号和换行
+func EscapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			// CRLF按一个换行处理
+			if i+1 < len(s) && s[i+1] == '\n' {
+				continue
+			}
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// JoinText 转义每个文本值后以逗号连接，用于CATEGORIES等多值属性
+func JoinText(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = EscapeText(v)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// quoteParam 参数值包含冒号、分号或逗号时需要用双引号包裹，值中的双引号无法表示，直接去掉
+func quoteParam(value string) string {
+	value = strings.ReplaceAll(value, `"`, "")
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// FormatDateTime 格式化为UTC日期时间
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(DateTimeFormat)
+}
+
+// FormatDuration 格式化为DURATION值，如 -PT15M、P1DT2H
+func FormatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+	if d == 0 {
+		b.WriteString("T0S")
+		return b.String()
+	}
+
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		b.WriteByte('T')
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCalendar 日历内容不符合iCalendar格式
+var ErrInvalidCalendar = errors.New("日历格式无效")
+
+// Parse 解析iCalendar数据，返回第一个VCALENDAR组件
+// 兼容只用LF换行的文件，续行以空格或制表符开头
+func Parse(data []byte) (*Component, error) {
+	lines := unfold(string(data))
+
+	var stack []*Component
+	var root *Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第%d行: %v", ErrInvalidCalendar, i+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(prop.Value))
+			if len(stack) > 0 {
+				stack[len(stack)-1].AddComponent(c)
+			} else if root != nil {
+				return nil, fmt.Errorf("%w: 只能包含一个日历", ErrInvalidCalendar)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: 第%d行: END:%s 没有对应的BEGIN", ErrInvalidCalendar, i+1, prop.Value)
+			}
+			if len(stack) == 1 {
+				root = stack[0]
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: 第%d行: 属性不在组件内", ErrInvalidCalendar, i+1)
+			}
+			stack[len(stack)-1].Props = append(stack[len(stack)-1].Props, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: %s 缺少END", ErrInvalidCalendar, stack[len(stack)-1].Name)
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: 缺少VCALENDAR", ErrInvalidCalendar)
+	}
+	return root, nil
+}
+
+// unfold 拆分为行并合并折叠的续行，行号以合并后的逻辑行计
+func unfold(data string) []string {
+	data = strings.TrimPrefix(data, "\uFEFF")
+	raw := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	lines := make([]string, 0, len(raw))
+	for _, line := range raw {
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+	return lines
+}
+
+// parseLine 解析一行内容：名称、参数和值，参数值可以用双引号包裹
+func parseLine(line string) (*Property, error) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return nil, fmt.Errorf("缺少属性名或冒号: %q", line)
+	}
+	prop := &Property{Name: strings.ToUpper(line[:i])}
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("参数格式错误: %q", line)
+		}
+		param := Param{Name: strings.ToUpper(rest[:eq])}
+		rest = rest[eq+1:]
+
+		// 读取参数值，引号内的冒号、分号和逗号不作为分隔符
+		j, quoted := 0, false
+		for ; j < len(rest); j++ {
+			c := rest[j]
+			if c == '"' {
+				quoted = !quoted
+			} else if !quoted && (c == ';' || c == ':') {
+				break
+			}
+		}
+		if j == len(rest) {
+			return nil, fmt.Errorf("缺少冒号: %q", line)
+		}
+		param.Value = strings.ReplaceAll(rest[:j], `"`, "")
+		prop.Params = append(prop.Params, param)
+		i += 1 + eq + 1 + j
+	}
+
+	prop.Value = line[i+1:]
+	return prop, nil
+}
+
+// Param 获取参数值，不存在时返回空字符串
+func (p *Property) Param(name string) string {
+	for _, param := range p.Params {
+		if strings.EqualFold(param.Name, name) {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Text 返回反转义后的文本值
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// TextList 按未转义的逗号拆分多值文本，如CATEGORIES
+func (p *Property) TextList() []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(p.Value); i++ {
+		switch p.Value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, UnescapeText(p.Value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, UnescapeText(p.Value[start:]))
+}
+
+// Get 获取第一个指定名称的属性，不存在时返回nil
+func (c *Component) Get(name string) *Property {
+	for _, prop := range c.Props {
+		if prop.Name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// GetAll 获取所有指定名称的属性
+func (c *Component) GetAll(name string) []*Property {
+	var props []*Property
+	for _, prop := range c.Props {
+		if prop.Name == name {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// Children 获取指定名称的直接子组件
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// UnescapeText 反转义TEXT类型的值
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// durationUnits 时长中各单位的长度，按是否在T之后区分M（月份不允许出现）和分钟
+var durationUnits = map[bool]map[rune]time.Duration{
+	false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+	true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+}
+
+// ParseDuration 解析DURATION值，如 -PT15M、P1W、P1DT2H
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = -1, value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("无效的时长: %q", s)
+	}
+	value = value[1:]
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+		case c == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("无效的时长: %q", s)
+			}
+			num = ""
+			unit, ok := durationUnits[inTime][c]
+			if !ok {
+				return 0, fmt.Errorf("无效的时长: %q", s)
+			}
+			d += time.Duration(n) * unit
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("无效的时长: %q", s)
+	}
+	return sign * d, nil
+}
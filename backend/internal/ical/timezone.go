@@ -0,0 +1,328 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// localDateTimeFormat 不带时区的本地日期时间格式
+	localDateTimeFormat = "20060102T150405"
+	// DateFormat DATE类型的日期格式
+	DateFormat = "20060102"
+)
+
+// weekdays BYDAY中的星期缩写
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// TimeZones 解析日期时间值时使用的时区
+// TZID优先按IANA名称解析，无法识别时使用日历中内嵌的VTIMEZONE；没有时区的浮动时间和日期使用fallback
+type TimeZones struct {
+	embedded map[string]*vtimezone
+	fallback *time.Location
+}
+
+// NewTimeZones 从日历的VTIMEZONE组件创建时区集合，无法解析的VTIMEZONE会被忽略
+func NewTimeZones(cal *Component, fallback *time.Location) *TimeZones {
+	if fallback == nil {
+		fallback = time.UTC
+	}
+	zones := &TimeZones{embedded: make(map[string]*vtimezone), fallback: fallback}
+	for _, c := range cal.Children("VTIMEZONE") {
+		tzid := c.Get("TZID")
+		if tzid == nil {
+			continue
+		}
+		if tz, err := parseVTimezone(c); err == nil {
+			zones.embedded[tzid.Value] = tz
+		}
+	}
+	return zones
+}
+
+// Time 解析DATE或DATE-TIME属性，allDay表示值为DATE类型
+func (z *TimeZones) Time(prop *Property) (t time.Time, allDay bool, err error) {
+	values, allDay, err := z.times(prop, prop.Value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(values) != 1 {
+		return time.Time{}, false, fmt.Errorf("%s 只能包含一个时间", prop.Name)
+	}
+	return values[0], allDay, nil
+}
+
+// TimeList 解析逗号分隔的多个时间，如EXDATE、RDATE
+func (z *TimeZones) TimeList(prop *Property) ([]time.Time, error) {
+	values, _, err := z.times(prop, prop.Value)
+	return values, err
+}
+
+func (z *TimeZones) times(prop *Property, value string) ([]time.Time, bool, error) {
+	allDay := strings.EqualFold(prop.Param("VALUE"), "DATE")
+	tzid := prop.Param("TZID")
+
+	var times []time.Time
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) == len(DateFormat) {
+			allDay = true
+		}
+		t, err := z.parse(v, tzid, allDay)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", prop.Name, err)
+		}
+		times = append(times, t)
+	}
+	return times, allDay, nil
+}
+
+// parse 解析单个时间值：DATE、UTC时间、带TZID的本地时间或浮动时间
+func (z *TimeZones) parse(value, tzid string, allDay bool) (time.Time, error) {
+	if allDay {
+		t, err := time.ParseInLocation(DateFormat, value, z.fallback)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的日期: %q", value)
+		}
+		return t, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(DateTimeFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的时间: %q", value)
+		}
+		return t, nil
+	}
+
+	local, err := time.Parse(localDateTimeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %q", value)
+	}
+	if tzid == "" {
+		return wallClock(local, z.fallback), nil
+	}
+	if loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+		return wallClock(local, loc), nil
+	}
+	tz, ok := z.embedded[tzid]
+	if !ok {
+		return time.Time{}, fmt.Errorf("未知的时区: %q", tzid)
+	}
+	return tz.resolve(local), nil
+}
+
+// wallClock 将按UTC解析的本地时间解释为loc中的时间
+func wallClock(local time.Time, loc *time.Location) time.Time {
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, loc)
+}
+
+// vtimezone 内嵌的时区定义，由若干标准时间和夏令时观测规则组成
+type vtimezone struct {
+	name        string
+	observances []*observance
+}
+
+// observance STANDARD或DAYLIGHT规则，时间均为按UTC表示的本地时间
+type observance struct {
+	offsetFrom int
+	offsetTo   int
+	start      time.Time
+	rule       *yearlyRule
+	rdates     []time.Time
+}
+
+// yearlyRule 时区规则中常见的按年循环：某月第n个（或倒数第n个）星期几
+type yearlyRule struct {
+	month   time.Month
+	week    int
+	weekday time.Weekday
+	byDay   bool
+	until   time.Time
+}
+
+func parseVTimezone(c *Component) (*vtimezone, error) {
+	tz := &vtimezone{name: c.Get("TZID").Value}
+	for _, child := range c.Components {
+		if child.Name != "STANDARD" && child.Name != "DAYLIGHT" {
+			continue
+		}
+		o, err := parseObservance(child)
+		if err != nil {
+			return nil, err
+		}
+		tz.observances = append(tz.observances, o)
+	}
+	if len(tz.observances) == 0 {
+		return nil, fmt.Errorf("时区 %s 缺少STANDARD或DAYLIGHT", tz.name)
+	}
+	return tz, nil
+}
+
+func parseObservance(c *Component) (*observance, error) {
+	start, from, to := c.Get("DTSTART"), c.Get("TZOFFSETFROM"), c.Get("TZOFFSETTO")
+	if start == nil || from == nil || to == nil {
+		return nil, fmt.Errorf("%s 缺少必要属性", c.Name)
+	}
+
+	o := &observance{}
+	var err error
+	if o.start, err = time.Parse(localDateTimeFormat, start.Value); err != nil {
+		return nil, fmt.Errorf("无效的时间: %q", start.Value)
+	}
+	if o.offsetFrom, err = parseUTCOffset(from.Value); err != nil {
+		return nil, err
+	}
+	if o.offsetTo, err = parseUTCOffset(to.Value); err != nil {
+		return nil, err
+	}
+	if rrule := c.Get("RRULE"); rrule != nil {
+		if o.rule, err = parseYearlyRule(rrule.Value, o.start); err != nil {
+			return nil, err
+		}
+	}
+	for _, rdate := range c.GetAll("RDATE") {
+		for _, v := range strings.Split(rdate.Value, ",") {
+			t, err := time.Parse(localDateTimeFormat, strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("无效的时间: %q", v)
+			}
+			o.rdates = append(o.rdates, t)
+		}
+	}
+	return o, nil
+}
+
+// parseUTCOffset 解析 +0800、-0430、+053000 形式的偏移，返回秒数
+func parseUTCOffset(value string) (int, error) {
+	if (len(value) != 5 && len(value) != 7) || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("无效的时区偏移: %q", value)
+	}
+	digits := value[1:] + "00"
+	h, err1 := strconv.Atoi(digits[0:2])
+	m, err2 := strconv.Atoi(digits[2:4])
+	s, err3 := strconv.Atoi(digits[4:6])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("无效的时区偏移: %q", value)
+	}
+	offset := h*3600 + m*60 + s
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// parseYearlyRule 解析时区规则的RRULE，只支持FREQ=YEARLY与BYMONTH、BYDAY、UNTIL的组合
+func parseYearlyRule(value string, start time.Time) (*yearlyRule, error) {
+	rule := &yearlyRule{month: start.Month()}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if !strings.EqualFold(val, "YEARLY") {
+				return nil, fmt.Errorf("不支持的时区规则: %q", value)
+			}
+		case "BYMONTH":
+			month, err := strconv.Atoi(val)
+			if err != nil || month < 1 || month > 12 {
+				return nil, fmt.Errorf("无效的时区规则: %q", value)
+			}
+			rule.month = time.Month(month)
+		case "BYDAY":
+			n := len(val) - 2
+			if n < 0 {
+				return nil, fmt.Errorf("无效的时区规则: %q", value)
+			}
+			weekday, ok := weekdays[strings.ToUpper(val[n:])]
+			if !ok {
+				return nil, fmt.Errorf("无效的时区规则: %q", value)
+			}
+			week := 1
+			if n > 0 {
+				var err error
+				if week, err = strconv.Atoi(val[:n]); err != nil || week == 0 {
+					return nil, fmt.Errorf("无效的时区规则: %q", value)
+				}
+			}
+			rule.week, rule.weekday, rule.byDay = week, weekday, true
+		case "UNTIL":
+			until, err := time.Parse(DateTimeFormat, val)
+			if err != nil {
+				if until, err = time.Parse(localDateTimeFormat, val); err != nil {
+					return nil, fmt.Errorf("无效的时区规则: %q", value)
+				}
+			}
+			rule.until = until
+		}
+	}
+	return rule, nil
+}
+
+// onset 规则在某一年的生效时间（本地时间）
+func (r *yearlyRule) onset(year int, start time.Time) time.Time {
+	day := start.Day()
+	if r.byDay {
+		if r.week > 0 {
+			first := time.Date(year, r.month, 1, 0, 0, 0, 0, time.UTC)
+			day = 1 + (int(r.weekday)-int(first.Weekday())+7)%7 + (r.week-1)*7
+		} else {
+			last := time.Date(year, r.month+1, 0, 0, 0, 0, 0, time.UTC)
+			day = last.Day() - (int(last.Weekday())-int(r.weekday)+7)%7 + (r.week+1)*7
+		}
+	}
+	return time.Date(year, r.month, day, start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+}
+
+// onsets 获取规则在year前后一年内的所有生效时间
+func (o *observance) onsets(year int) []time.Time {
+	times := []time.Time{o.start}
+	times = append(times, o.rdates...)
+	if o.rule != nil {
+		for y := year - 1; y <= year; y++ {
+			if y <= o.start.Year() {
+				continue
+			}
+			t := o.rule.onset(y, o.start)
+			if !o.rule.until.IsZero() && t.Add(-time.Duration(o.offsetFrom)*time.Second).After(o.rule.until) {
+				continue
+			}
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// resolve 计算本地时间对应的时刻：取生效时间不晚于该时间的最近一条规则的偏移
+// 早于所有规则时使用最早一条规则切换前的偏移
+func (tz *vtimezone) resolve(local time.Time) time.Time {
+	type candidate struct {
+		at     time.Time
+		offset int
+	}
+	var candidates []candidate
+	for _, o := range tz.observances {
+		for _, t := range o.onsets(local.Year()) {
+			candidates = append(candidates, candidate{at: t, offset: o.offsetTo})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].at.Before(candidates[j].at) })
+
+	offset := tz.observances[0].offsetFrom
+	for _, o := range tz.observances {
+		if o.start.Equal(candidates[0].at) {
+			offset = o.offsetFrom
+		}
+	}
+	for _, c := range candidates {
+		if c.at.After(local) {
+			break
+		}
+		offset = c.offset
+	}
+	return wallClock(local, time.FixedZone(tz.name, offset))
+}
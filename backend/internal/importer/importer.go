@@ -0,0 +1,175 @@
+// Package importer 解析其他待办应用的备份文件，转换为统一的项目和任务结构
+//
+// 支持滴答清单（TickTick）的CSV备份，以及Todoist的JSON备份、CSV导出和多个CSV组成的zip导出。
+// 解析只做格式转换，不访问数据库；清单对应项目，标签对应标签，检查项对应子任务，
+// 优先级统一换算为1（最高）到4（无优先级），重复规则转换为RRULE，无法转换的内容记录为警告
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ticktick-backend/internal/recurrence"
+)
+
+// 支持的备份来源
+const (
+	SourceTickTick = "ticktick"
+	SourceTodoist  = "todoist"
+)
+
+var (
+	ErrUnsupportedSource = errors.New("不支持的导入来源")
+	ErrInvalidBackup     = errors.New("备份文件无效")
+)
+
+const (
+	// defaultPriority 无优先级
+	defaultPriority = 4
+	// maxTitleLength 任务标题的最大字符数
+	maxTitleLength = 255
+	// maxNameLength 项目和标签名称的最大字符数
+	maxNameLength = 100
+)
+
+// Backup 解析后的备份内容，项目按在文件中首次出现的顺序排列
+type Backup struct {
+	Projects []*Project
+	Warnings []string
+}
+
+// Project 备份中的清单
+type Project struct {
+	Name  string
+	Color string // #RRGGBB，来源没有颜色时为空
+	Tasks []*Task
+}
+
+// Task 备份中的任务，Subtasks来自检查项或父子任务
+type Task struct {
+	Title       string
+	Description string
+	Priority    int
+	Labels      []string
+	StartTime   *time.Time
+	DueTime     *time.Time
+	RRule       string // 带RRULE:前缀，没有重复时为空
+	Completed   bool
+	CompletedAt *time.Time
+	Subtasks    []*Task
+}
+
+// Count 统计任务数量（包括子任务）
+func (p *Project) Count() int {
+	return countTasks(p.Tasks)
+}
+
+// Count 统计任务自身及其全部子任务的数量
+func (t *Task) Count() int {
+	return 1 + countTasks(t.Subtasks)
+}
+
+func countTasks(tasks []*Task) int {
+	n := len(tasks)
+	for _, task := range tasks {
+		n += countTasks(task.Subtasks)
+	}
+	return n
+}
+
+// Parse 按来源解析备份文件，filename用于Todoist的CSV导出推断项目名称
+// loc是全天任务和没有时区的时间所使用的时区
+func Parse(source, filename string, data []byte, loc *time.Location) (*Backup, error) {
+	var (
+		backup *Backup
+		err    error
+	)
+	switch source {
+	case SourceTickTick:
+		backup, err = parseTickTick(data, loc)
+	case SourceTodoist:
+		backup, err = parseTodoist(filename, data, loc)
+	default:
+		return nil, ErrUnsupportedSource
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if len(backup.Projects) == 0 {
+		return nil, fmt.Errorf("%w: 没有可导入的任务", ErrInvalidBackup)
+	}
+	return backup, nil
+}
+
+// warn 记录不影响导入的问题
+func (b *Backup) warn(format string, args ...any) {
+	b.Warnings = append(b.Warnings, fmt.Sprintf(format, args...))
+}
+
+// project 按名称获取项目，不存在时追加
+func (b *Backup) project(name string) *Project {
+	name = truncate(strings.TrimSpace(name), maxNameLength)
+	for _, p := range b.Projects {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := &Project{Name: name}
+	b.Projects = append(b.Projects, p)
+	return p
+}
+
+// validRRule 校验重复规则，返回带RRULE:前缀的规则
+func validRRule(rule string) (string, bool) {
+	rule = strings.TrimSpace(rule)
+	if rule == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(strings.ToUpper(rule), "RRULE:") {
+		rule = "RRULE:" + rule
+	}
+	if _, err := recurrence.Parse(rule); err != nil {
+		return "", false
+	}
+	return "RRULE:" + rule[len("RRULE:"):], true
+}
+
+// splitLabels 拆分逗号分隔的标签，去掉#前缀、空白和重复项
+func splitLabels(value string) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' }) {
+		name = truncate(strings.TrimPrefix(strings.TrimSpace(name), "#"), maxNameLength)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			labels = append(labels, name)
+		}
+	}
+	return labels
+}
+
+// dateOnly 取t在from时区中的日期，转换为loc时区的零点，用于全天任务
+func dateOnly(t time.Time, from, loc *time.Location) time.Time {
+	y, m, d := t.In(from).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// newTask 创建任务，标题为空时返回nil
+func newTask(title string) *Task {
+	title = truncate(strings.TrimSpace(title), maxTitleLength)
+	if title == "" {
+		return nil
+	}
+	return &Task{Title: title, Priority: defaultPriority}
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// naturalRepeat 从自然语言描述的重复规则（如Todoist的every day at 9am）转换出的结果
+type naturalRepeat struct {
+	rule    string // 带RRULE:前缀
+	hasTime bool
+	hour    int
+	minute  int
+}
+
+var (
+	repeatTimePattern     = regexp.MustCompile(`\s+at\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	repeatIntervalPattern = regexp.MustCompile(`^(\d+)\s+(day|week|month|year)s?$`)
+	repeatMonthDayPattern = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+// repeatWeekdays 英文星期名称（含缩写）对应的BYDAY值
+var repeatWeekdays = map[string]string{
+	"mon": "MO", "monday": "MO",
+	"tue": "TU", "tues": "TU", "tuesday": "TU",
+	"wed": "WE", "wednesday": "WE",
+	"thu": "TH", "thur": "TH", "thurs": "TH", "thursday": "TH",
+	"fri": "FR", "friday": "FR",
+	"sat": "SA", "saturday": "SA",
+	"sun": "SU", "sunday": "SU",
+}
+
+// repeatUnits 重复单位对应的FREQ
+var repeatUnits = map[string]string{
+	"day":   "DAILY",
+	"week":  "WEEKLY",
+	"month": "MONTHLY",
+	"year":  "YEARLY",
+}
+
+// parseNaturalRepeat 转换常见的英文重复描述，无法识别时ok为false
+// 支持daily/weekly/monthly/yearly、every [other|N] day/week/month/year、every weekday、
+// every mon, wed、every 15th、every last day，以及结尾的at 9am或at 18:30
+func parseNaturalRepeat(text string) (naturalRepeat, bool) {
+	var result naturalRepeat
+	s := strings.ToLower(strings.TrimSpace(text))
+	s = strings.ReplaceAll(s, "every!", "every")
+
+	if m := repeatTimePattern.FindStringSubmatch(s); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute := 0
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		switch m[3] {
+		case "pm":
+			if hour < 12 {
+				hour += 12
+			}
+		case "am":
+			if hour == 12 {
+				hour = 0
+			}
+		}
+		if hour > 23 || minute > 59 {
+			return result, false
+		}
+		result.hasTime, result.hour, result.minute = true, hour, minute
+		s = strings.TrimSpace(s[:len(s)-len(m[0])])
+	}
+
+	switch s {
+	case "daily", "weekly", "monthly", "yearly", "annually":
+		freq := map[string]string{"daily": "DAILY", "weekly": "WEEKLY", "monthly": "MONTHLY", "yearly": "YEARLY", "annually": "YEARLY"}[s]
+		result.rule = "RRULE:FREQ=" + freq
+		return result, true
+	}
+	rest, ok := strings.CutPrefix(s, "every ")
+	if !ok {
+		return result, false
+	}
+	rest = strings.TrimSpace(rest)
+
+	interval := 1
+	if r, ok := strings.CutPrefix(rest, "other "); ok {
+		interval, rest = 2, r
+	}
+	if freq, ok := repeatUnits[rest]; ok {
+		result.rule = repeatRule(freq, interval, "")
+		return result, true
+	}
+	if m := repeatIntervalPattern.FindStringSubmatch(rest); m != nil && interval == 1 {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 {
+			return result, false
+		}
+		result.rule = repeatRule(repeatUnits[m[2]], n, "")
+		return result, true
+	}
+
+	switch rest {
+	case "weekday", "workday":
+		result.rule = repeatRule("WEEKLY", interval, "BYDAY=MO,TU,WE,TH,FR")
+		return result, true
+	case "weekend":
+		result.rule = repeatRule("WEEKLY", interval, "BYDAY=SA,SU")
+		return result, true
+	case "last day", "last day of the month":
+		result.rule = repeatRule("MONTHLY", interval, "BYMONTHDAY=-1")
+		return result, true
+	}
+	if m := repeatMonthDayPattern.FindStringSubmatch(rest); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return result, false
+		}
+		result.rule = repeatRule("MONTHLY", interval, "BYMONTHDAY="+strconv.Itoa(day))
+		return result, true
+	}
+
+	var days []string
+	for _, word := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if word == "and" {
+			continue
+		}
+		day, ok := repeatWeekdays[word]
+		if !ok {
+			return result, false
+		}
+		days = append(days, day)
+	}
+	if len(days) == 0 {
+		return result, false
+	}
+	result.rule = repeatRule("WEEKLY", interval, "BYDAY="+strings.Join(days, ","))
+	return result, true
+}
+
+// repeatRule 拼接RRULE
+func repeatRule(freq string, interval int, extra string) string {
+	rule := "RRULE:FREQ=" + freq
+	if interval > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", interval)
+	}
+	if extra != "" {
+		rule += ";" + extra
+	}
+	return rule
+}
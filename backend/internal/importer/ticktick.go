@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// tickTickTimeLayouts 滴答清单备份中的时间格式
+var tickTickTimeLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// 滴答清单检查项的前缀，导出时已完成和未完成的检查项使用不同的符号
+const (
+	tickTickItemOpen = "▫"
+	tickTickItemDone = "▪"
+)
+
+// tickTickRow 按列名读取一行
+type tickTickRow struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r tickTickRow) get(name string) string {
+	if i, ok := r.columns[name]; ok && i < len(r.record) {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+// parseTickTick 解析滴答清单的CSV备份
+// 文件开头是若干说明行，之后是以"Folder Name","List Name","Title"...开头的表头
+func parseTickTick(data []byte, loc *time.Location) (*Backup, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	backup := &Backup{}
+	var columns map[string]int
+	type pending struct {
+		task     *Task
+		parentID string
+		project  *Project
+	}
+	var rows []pending
+	byID := make(map[string]*Task)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			if isTickTickHeader(record) {
+				columns = make(map[string]int, len(record))
+				for i, name := range record {
+					columns[strings.TrimSpace(name)] = i
+				}
+			}
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := tickTickRow{columns: columns, record: record}
+		task := newTask(row.get("Title"))
+		if task == nil {
+			continue
+		}
+		listName := row.get("List Name")
+		if listName == "" {
+			listName = "Inbox"
+		}
+		project := backup.project(listName)
+
+		tz := loc
+		if name := row.get("Timezone"); name != "" {
+			if l, err := time.LoadLocation(name); err == nil {
+				tz = l
+			}
+		}
+		allDay := strings.EqualFold(row.get("Is All Day"), "true")
+		task.StartTime = tickTickTime(row.get("Start Date"), allDay, tz, loc)
+		task.DueTime = tickTickTime(row.get("Due Date"), allDay, tz, loc)
+		if task.StartTime != nil && task.DueTime != nil && task.DueTime.Equal(*task.StartTime) {
+			task.StartTime = nil
+		}
+		task.Priority = tickTickPriority(row.get("Priority"))
+		task.Labels = splitLabels(row.get("Tags"))
+
+		if repeat := row.get("Repeat"); repeat != "" {
+			if rule, ok := validRRule(repeat); ok {
+				task.RRule = rule
+			} else {
+				backup.warn("第%d行「%s」的重复规则无法识别，已忽略: %s", line, task.Title, repeat)
+			}
+		}
+
+		if status := row.get("Status"); status == "1" || status == "2" {
+			task.Completed = true
+			task.CompletedAt = tickTickTime(row.get("Completed Time"), false, tz, loc)
+		}
+
+		content := row.get("Content")
+		if strings.EqualFold(row.get("Kind"), "CHECKLIST") || strings.EqualFold(row.get("Is Check list"), "Y") {
+			task.Description, task.Subtasks = tickTickChecklist(content)
+		} else {
+			task.Description = content
+		}
+
+		if id := row.get("taskId"); id != "" {
+			byID[id] = task
+		}
+		rows = append(rows, pending{task: task, parentID: row.get("parentId"), project: project})
+	}
+	if columns == nil {
+		return nil, errors.New("找不到滴答清单备份的表头")
+	}
+
+	// 父任务可能出现在子任务之后，读完全部行后再建立父子关系；父任务不存在或形成环时作为顶层任务
+	parents := make(map[*Task]*Task, len(rows))
+	for _, row := range rows {
+		if parent, ok := byID[row.parentID]; ok && row.parentID != "" && !isAncestor(parents, row.task, parent) {
+			parents[row.task] = parent
+			parent.Subtasks = append(parent.Subtasks, row.task)
+			continue
+		}
+		row.project.Tasks = append(row.project.Tasks, row.task)
+	}
+	return backup, nil
+}
+
+// isAncestor 判断task是否是candidate自身或其祖先
+func isAncestor(parents map[*Task]*Task, task, candidate *Task) bool {
+	for t := candidate; t != nil; t = parents[t] {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// isTickTickHeader 判断是否为表头行
+func isTickTickHeader(record []string) bool {
+	var hasList, hasTitle bool
+	for _, name := range record {
+		switch strings.TrimSpace(name) {
+		case "List Name":
+			hasList = true
+		case "Title":
+			hasTitle = true
+		}
+	}
+	return hasList && hasTitle
+}
+
+// tickTickTime 解析时间，全天任务按任务时区取日期后转换为loc的零点
+func tickTickTime(value string, allDay bool, tz, loc *time.Location) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range tickTickTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, tz)
+		if err != nil {
+			continue
+		}
+		if allDay {
+			t = dateOnly(t, tz, loc)
+		}
+		t = t.UTC()
+		return &t
+	}
+	return nil
+}
+
+// tickTickPriority 滴答清单的优先级：5高、3中、1低、0无
+func tickTickPriority(value string) int {
+	switch value {
+	case "5":
+		return 1
+	case "3":
+		return 2
+	case "1":
+		return 3
+	}
+	return defaultPriority
+}
+
+// tickTickChecklist 拆分检查清单的内容：带检查项符号的行转换为子任务，其余行作为描述
+func tickTickChecklist(content string) (string, []*Task) {
+	var description []string
+	var items []*Task
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, tickTickItemOpen):
+			if item := newTask(strings.TrimPrefix(trimmed, tickTickItemOpen)); item != nil {
+				items = append(items, item)
+			}
+		case strings.HasPrefix(trimmed, tickTickItemDone):
+			if item := newTask(strings.TrimPrefix(trimmed, tickTickItemDone)); item != nil {
+				item.Completed = true
+				items = append(items, item)
+			}
+		default:
+			description = append(description, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(description, "\n")), items
+}
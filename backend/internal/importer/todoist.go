@@ -0,0 +1,423 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoistDefaultProject 无法从文件名推断项目名称时使用的名称
+const todoistDefaultProject = "Todoist"
+
+// maxZipEntrySize zip导出中单个CSV文件的大小上限
+const maxZipEntrySize = 10 << 20
+
+// todoistColors Todoist调色板中的颜色名称
+var todoistColors = map[string]string{
+	"berry_red":   "#B8255F",
+	"red":         "#DB4035",
+	"orange":      "#FF9933",
+	"yellow":      "#FAD000",
+	"olive_green": "#AFB83B",
+	"lime_green":  "#7ECC49",
+	"green":       "#299438",
+	"mint_green":  "#6ACCBC",
+	"teal":        "#158FAD",
+	"sky_blue":    "#14AAF5",
+	"light_blue":  "#96C3EB",
+	"blue":        "#4073FF",
+	"grape":       "#884DFF",
+	"violet":      "#AF38EB",
+	"lavender":    "#EB96EB",
+	"magenta":     "#E05194",
+	"salmon":      "#FF8D85",
+	"charcoal":    "#808080",
+	"grey":        "#B8B8B8",
+	"taupe":       "#CCAC93",
+}
+
+var (
+	todoistLabelPattern    = regexp.MustCompile(`(?:^|\s)@([^\s@]+)`)
+	todoistFileIDPattern   = regexp.MustCompile(`\s*\[\d+\]$`)
+	todoistDateTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "Jan 2 2006 15:04", "2 Jan 2006 15:04"}
+	todoistDateLayouts     = []string{"2006-01-02", "Jan 2 2006", "2 Jan 2006", "January 2 2006", "2 January 2006"}
+)
+
+// parseTodoist 按内容判断Todoist导出的格式：zip中的多个CSV、JSON备份或单个CSV
+func parseTodoist(filename string, data []byte, loc *time.Location) (*Backup, error) {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	backup := &Backup{}
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return backup, parseTodoistZip(backup, data, loc)
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		return backup, parseTodoistJSON(backup, trimmed, loc)
+	default:
+		return backup, parseTodoistCSV(backup, todoistProjectName(filename), data, loc)
+	}
+}
+
+// parseTodoistZip 解析包含多个项目CSV的zip导出
+func parseTodoistZip(backup *Backup, data []byte, loc *time.Location) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || !strings.EqualFold(path.Ext(file.Name), ".csv") {
+			continue
+		}
+		if file.UncompressedSize64 > maxZipEntrySize {
+			return fmt.Errorf("%s过大", file.Name)
+		}
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(io.LimitReader(r, maxZipEntrySize))
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if err := parseTodoistCSV(backup, todoistProjectName(file.Name), bytes.TrimPrefix(content, []byte("\uFEFF")), loc); err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseTodoistCSV 解析单个项目的CSV导出
+// 每行的TYPE为task、section或note，INDENT表示子任务层级，note追加到上一个任务的描述
+func parseTodoistCSV(backup *Backup, projectName string, data []byte, loc *time.Location) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["CONTENT"]; !ok {
+		return errors.New("找不到Todoist导出的CONTENT列")
+	}
+
+	project := backup.project(projectName)
+	var stack []*Task // stack[i]是当前层级i+1的任务
+	var last *Task
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		row := tickTickRow{columns: columns, record: record}
+
+		switch strings.ToLower(row.get("TYPE")) {
+		case "task":
+		case "note":
+			if last != nil && row.get("CONTENT") != "" {
+				last.Description = strings.TrimSpace(last.Description + "\n\n" + row.get("CONTENT"))
+			}
+			continue
+		case "section":
+			if name := row.get("CONTENT"); name != "" {
+				backup.warn("项目「%s」的分栏「%s」已忽略，其中的任务导入到项目中", project.Name, name)
+			}
+			continue
+		default:
+			continue
+		}
+
+		title, labels := todoistLabels(row.get("CONTENT"))
+		task := newTask(title)
+		if task == nil {
+			continue
+		}
+		task.Labels = labels
+		task.Description = row.get("DESCRIPTION")
+		if p, err := strconv.Atoi(row.get("PRIORITY")); err == nil && p >= 1 && p <= 4 {
+			task.Priority = p
+		}
+
+		tz := loc
+		if name := row.get("TIMEZONE"); name != "" {
+			if l, err := time.LoadLocation(name); err == nil {
+				tz = l
+			}
+		}
+		if date := row.get("DATE"); date != "" {
+			if !todoistCSVDate(task, date, tz, loc) {
+				backup.warn("第%d行「%s」的日期无法识别，已忽略: %s", line, task.Title, date)
+			}
+		}
+
+		indent, err := strconv.Atoi(row.get("INDENT"))
+		if err != nil || indent < 1 {
+			indent = 1
+		}
+		if indent > len(stack)+1 {
+			indent = len(stack) + 1
+		}
+		stack = append(stack[:indent-1], task)
+		if indent > 1 {
+			parent := stack[indent-2]
+			parent.Subtasks = append(parent.Subtasks, task)
+		} else {
+			project.Tasks = append(project.Tasks, task)
+		}
+		last = task
+	}
+	return nil
+}
+
+// todoistCSVDate 解析CSV中的DATE列，可以是重复描述（every day at 9am）或日期
+// 重复任务没有具体日期时从今天开始
+func todoistCSVDate(task *Task, value string, tz, loc *time.Location) bool {
+	if repeat, ok := parseNaturalRepeat(value); ok {
+		now := time.Now().In(tz)
+		var due time.Time
+		if repeat.hasTime {
+			due = time.Date(now.Year(), now.Month(), now.Day(), repeat.hour, repeat.minute, 0, 0, tz)
+		} else {
+			due = dateOnly(now, tz, loc)
+		}
+		due = due.UTC()
+		task.DueTime, task.RRule = &due, repeat.rule
+		return true
+	}
+
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	now := time.Now().In(tz)
+	switch strings.ToLower(value) {
+	case "today", "tomorrow":
+		due := dateOnly(now, tz, loc)
+		if strings.EqualFold(value, "tomorrow") {
+			due = due.AddDate(0, 0, 1)
+		}
+		due = due.UTC()
+		task.DueTime = &due
+		return true
+	}
+	for _, layout := range todoistDateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, tz); err == nil {
+			t = t.UTC()
+			task.DueTime = &t
+			return true
+		}
+	}
+	for _, layout := range todoistDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			t = t.UTC()
+			task.DueTime = &t
+			return true
+		}
+	}
+	return false
+}
+
+// todoistLabels 取出内容中的@标签，返回去掉标签后的标题
+func todoistLabels(content string) (string, []string) {
+	var names []string
+	for _, m := range todoistLabelPattern.FindAllStringSubmatch(content, -1) {
+		names = append(names, m[1])
+	}
+	title := todoistLabelPattern.ReplaceAllString(content, "")
+	return strings.TrimSpace(title), splitLabels(strings.Join(names, ","))
+}
+
+// todoistProjectName 从导出文件名推断项目名称，去掉扩展名和结尾的[项目ID]
+func todoistProjectName(filename string) string {
+	name := strings.TrimSuffix(path.Base(strings.ReplaceAll(filename, "\\", "/")), path.Ext(filename))
+	name = strings.TrimSpace(todoistFileIDPattern.ReplaceAllString(name, ""))
+	if name == "" || name == "." || name == "/" {
+		return todoistDefaultProject
+	}
+	return name
+}
+
+// todoistID Todoist的ID在旧版备份中是数字，在新版中是字符串
+type todoistID string
+
+// UnmarshalJSON 同时接受数字、字符串和null
+func (id *todoistID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*id = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = todoistID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = todoistID(n.String())
+	return nil
+}
+
+// todoistDue 任务的截止日期，date为全天日期或不带时区的时间，datetime为UTC时间
+type todoistDue struct {
+	Date        string `json:"date"`
+	Datetime    string `json:"datetime"`
+	String      string `json:"string"`
+	IsRecurring bool   `json:"is_recurring"`
+	Timezone    string `json:"timezone"`
+}
+
+// todoistItem 任务，兼容Sync API（items、checked）和REST API（tasks、is_completed）的字段
+type todoistItem struct {
+	ID          todoistID   `json:"id"`
+	ProjectID   todoistID   `json:"project_id"`
+	ParentID    todoistID   `json:"parent_id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"`
+	Labels      []string    `json:"labels"`
+	Checked     bool        `json:"checked"`
+	IsCompleted bool        `json:"is_completed"`
+	CompletedAt string      `json:"completed_at"`
+	IsDeleted   bool        `json:"is_deleted"`
+	Due         *todoistDue `json:"due"`
+}
+
+// todoistBackup JSON备份
+type todoistBackup struct {
+	Projects []struct {
+		ID        todoistID `json:"id"`
+		Name      string    `json:"name"`
+		Color     string    `json:"color"`
+		IsDeleted bool      `json:"is_deleted"`
+	} `json:"projects"`
+	Items []todoistItem `json:"items"`
+	Tasks []todoistItem `json:"tasks"`
+}
+
+// parseTodoistJSON 解析JSON备份，API中的优先级4最高、1最低
+func parseTodoistJSON(backup *Backup, data []byte, loc *time.Location) error {
+	var raw todoistBackup
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	projects := make(map[todoistID]*Project, len(raw.Projects))
+	for _, p := range raw.Projects {
+		if p.IsDeleted || strings.TrimSpace(p.Name) == "" {
+			continue
+		}
+		project := backup.project(p.Name)
+		project.Color = todoistColors[p.Color]
+		projects[p.ID] = project
+	}
+
+	type pending struct {
+		task     *Task
+		parentID todoistID
+		project  *Project
+	}
+	var rows []pending
+	byID := make(map[todoistID]*Task)
+	for _, item := range append(raw.Items, raw.Tasks...) {
+		if item.IsDeleted {
+			continue
+		}
+		title, inline := todoistLabels(item.Content)
+		task := newTask(title)
+		if task == nil {
+			continue
+		}
+		task.Description = item.Description
+		task.Labels = splitLabels(strings.Join(append(item.Labels, inline...), ","))
+		if item.Priority >= 1 && item.Priority <= 4 {
+			task.Priority = 5 - item.Priority
+		}
+		if item.Checked || item.IsCompleted {
+			task.Completed = true
+			if t, err := time.Parse(time.RFC3339, item.CompletedAt); err == nil {
+				t = t.UTC()
+				task.CompletedAt = &t
+			}
+		}
+		if item.Due != nil && !todoistJSONDue(task, item.Due, loc) {
+			backup.warn("「%s」的截止日期无法识别，已忽略: %s", task.Title, item.Due.Date)
+		}
+		if item.Due != nil && item.Due.IsRecurring && task.RRule == "" {
+			backup.warn("「%s」的重复规则无法识别，已忽略: %s", task.Title, item.Due.String)
+		}
+
+		project, ok := projects[item.ProjectID]
+		if !ok {
+			project = backup.project("Inbox")
+		}
+		if item.ID != "" {
+			byID[item.ID] = task
+		}
+		rows = append(rows, pending{task: task, parentID: item.ParentID, project: project})
+	}
+
+	parents := make(map[*Task]*Task, len(rows))
+	for _, row := range rows {
+		if parent, ok := byID[row.parentID]; ok && row.parentID != "" && !isAncestor(parents, row.task, parent) {
+			parents[row.task] = parent
+			parent.Subtasks = append(parent.Subtasks, row.task)
+			continue
+		}
+		row.project.Tasks = append(row.project.Tasks, row.task)
+	}
+	return nil
+}
+
+// todoistJSONDue 解析JSON中的截止日期和重复规则
+func todoistJSONDue(task *Task, due *todoistDue, loc *time.Location) bool {
+	tz := loc
+	if due.Timezone != "" {
+		if l, err := time.LoadLocation(due.Timezone); err == nil {
+			tz = l
+		}
+	}
+	if due.IsRecurring {
+		if repeat, ok := parseNaturalRepeat(due.String); ok {
+			task.RRule = repeat.rule
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch {
+	case due.Datetime != "":
+		t, err = time.Parse(time.RFC3339, due.Datetime)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02T15:04:05", due.Datetime, tz)
+		}
+	case strings.Contains(due.Date, "T"):
+		t, err = time.Parse(time.RFC3339, due.Date)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02T15:04:05", due.Date, tz)
+		}
+	default:
+		t, err = time.ParseInLocation("2006-01-02", due.Date, loc)
+	}
+	if err != nil {
+		return false
+	}
+	t = t.UTC()
+	task.DueTime = &t
+	return true
+}
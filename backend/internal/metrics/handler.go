@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler 返回Prometheus文本格式的指标输出，token不为空时要求Bearer认证
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 必须带Bearer前缀，不接受直接把令牌放在Authorization头中
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// GinHandler 将指标输出挂载到Gin路由
+func GinHandler(token string) gin.HandlerFunc {
+	return gin.WrapH(Handler(token))
+}
+
+// NewServer 创建独立监听地址的指标服务器
+func NewServer(addr string, token string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(token))
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerRequiresBearerToken(t *testing.T) {
+	handler := Handler("secret")
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"Bearer令牌", "Bearer secret", http.StatusOK},
+		{"缺少Authorization", "", http.StatusUnauthorized},
+		{"令牌错误", "Bearer wrong", http.StatusUnauthorized},
+		{"缺少Bearer前缀", "secret", http.StatusUnauthorized},
+		{"其他认证方式", "Basic secret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.want)
+			}
+		})
+	}
+}
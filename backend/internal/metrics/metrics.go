@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "ticktick"
+
+// Registry 应用指标注册表，不使用全局默认注册表以免混入第三方库的指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP请求总数",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP请求处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "正在处理的HTTP请求数",
+	})
+
+	tokenStats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tokens",
+		Name:      "count",
+		Help:      "Token存储中各类记录的数量",
+	}, []string{"kind"})
+
+	tokenStatsUpdated = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tokens",
+		Name:      "stats_last_updated_timestamp_seconds",
+		Help:      "Token统计信息最近一次更新的时间",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpRequestsInFlight,
+		tokenStats,
+		tokenStatsUpdated,
+		queues,
+	)
+}
+
+// GinMiddleware 记录每个Gin路由的请求数和耗时
+// 使用路由模板（如/tasks/:id）作为标签，避免路径参数导致标签基数膨胀
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDB 注册数据库连接池指标
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedis 注册Redis连接池指标
+func RegisterRedis(client *redis.Client) {
+	Registry.MustRegister(newRedisPoolCollector(client))
+}
+
+// SetTokenStats 更新Token统计指标
+func SetTokenStats(stats map[string]interface{}) {
+	for kind, value := range stats {
+		if count, ok := value.(int); ok {
+			tokenStats.WithLabelValues(kind).Set(float64(count))
+		}
+	}
+	tokenStatsUpdated.SetToCurrentTime()
+}
+
+// QueueDepthFunc 返回队列当前长度
+type QueueDepthFunc func() (float64, error)
+
+// RegisterQueue 注册一个需要暴露长度的队列，抓取指标时调用fn获取当前值
+func RegisterQueue(name string, fn QueueDepthFunc) {
+	queues.mu.Lock()
+	defer queues.mu.Unlock()
+	queues.funcs[name] = fn
+}
+
+// queueCollector 队列长度采集器
+type queueCollector struct {
+	mu    sync.RWMutex
+	funcs map[string]QueueDepthFunc
+	desc  *prometheus.Desc
+}
+
+var queues = &queueCollector{
+	funcs: make(map[string]QueueDepthFunc),
+	desc: prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "depth"),
+		"各队列中等待处理的任务数",
+		[]string{"queue"}, nil,
+	),
+}
+
+// Describe 实现prometheus.Collector
+func (q *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+// Collect 实现prometheus.Collector
+func (q *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for name, fn := range q.funcs {
+		depth, err := fn()
+		if err != nil {
+			log.Printf("获取队列 %s 长度失败: %v", name, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, depth, name)
+	}
+}
+
+// redisPoolCollector Redis连接池采集器
+type redisPoolCollector struct {
+	client     *redis.Client
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newRedisPoolCollector(client *redis.Client) *redisPoolCollector {
+	name := func(n string) string { return prometheus.BuildFQName(namespace, "redis_pool", n) }
+	return &redisPoolCollector{
+		client:     client,
+		hits:       prometheus.NewDesc(name("hits_total"), "从连接池获取到空闲连接的次数", nil, nil),
+		misses:     prometheus.NewDesc(name("misses_total"), "连接池中没有空闲连接的次数", nil, nil),
+		timeouts:   prometheus.NewDesc(name("timeouts_total"), "等待连接超时的次数", nil, nil),
+		totalConns: prometheus.NewDesc(name("total_connections"), "连接池中的连接总数", nil, nil),
+		idleConns:  prometheus.NewDesc(name("idle_connections"), "连接池中的空闲连接数", nil, nil),
+		staleConns: prometheus.NewDesc(name("stale_connections_total"), "被移除的过期连接数", nil, nil),
+	}
+}
+
+// Describe 实现prometheus.Collector
+func (r *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.hits
+	ch <- r.misses
+	ch <- r.timeouts
+	ch <- r.totalConns
+	ch <- r.idleConns
+	ch <- r.staleConns
+}
+
+// Collect 实现prometheus.Collector
+func (r *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := r.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(r.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(r.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(r.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(r.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(r.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(r.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -86,26 +86,54 @@ func AuthMiddleware(cfg *config.Config, tokenStore *services.TokenStore) gin.Han
 				return
 			}
 			if isBlacklisted {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已被撤销"})
+				c.JSON(http.StatusUnauthorized, RevokedTokenResponse(tokenStore, claims.TokenID, "认证令牌已被撤销"))
 				c.Abort()
 				return
 			}
 		}
 
+		// 更新会话的最后使用时间，会话上限据此淘汰最久未使用的会话；失败不影响本次请求
+		if claims.TokenID != "" && claims.TokenType == string(services.AccessTokenType) {
+			if err := tokenStore.TouchSession(claims.UserID, claims.TokenID); err != nil {
+				log.Printf("更新会话最后使用时间失败: %v", err)
+			}
+		}
+
 		// 将用户信息存储到上下文中
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("tokenID", claims.TokenID)
 		c.Set("tokenType", claims.TokenType)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
 }
 
+// TokenPair 生成的令牌信息
+type TokenPair struct {
+	AccessToken     string
+	RefreshToken    string
+	TokenID         string
+	EvictedSessions []*services.SessionInfo // 因超出会话上限被登出的会话
+}
+
+// RevokedTokenResponse 构造令牌已被撤销的响应，附带撤销原因便于客户端提示
+func RevokedTokenResponse(tokenStore *services.TokenStore, tokenID string, message string) gin.H {
+	resp := gin.H{"error": message}
+	if info, err := tokenStore.GetBlacklistInfo(tokenID); err == nil && info != nil {
+		resp["reason"] = info.Reason
+		resp["revokedAt"] = info.RevokedAt
+	}
+	return resp
+}
+
 // GenerateTokens 生成访问令牌和刷新令牌
-func GenerateTokens(cfg *config.Config, tokenStore *services.TokenStore, userID uuid.UUID, email string, deviceInfo string) (accessToken, refreshToken string, tokenID string, err error) {
+func GenerateTokens(cfg *config.Config, tokenStore *services.TokenStore, userID uuid.UUID, email string, role string, deviceInfo services.DeviceInfo) (*TokenPair, error) {
 	// 生成唯一的TokenID
-	tokenID = uuid.New().String()
+	tokenID := uuid.New().String()
 	now := time.Now()
 
 	// 计算过期时间
@@ -128,9 +156,9 @@ func GenerateTokens(cfg *config.Config, tokenStore *services.TokenStore, userID
 	}
 
 	accessTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenObj.SignedString([]byte(cfg.JWT.SecretKey))
+	accessToken, err := accessTokenObj.SignedString([]byte(cfg.JWT.SecretKey))
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
 	// 生成刷新令牌
@@ -149,15 +177,16 @@ func GenerateTokens(cfg *config.Config, tokenStore *services.TokenStore, userID
 	}
 
 	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTokenObj.SignedString([]byte(cfg.JWT.SecretKey))
+	refreshToken, err := refreshTokenObj.SignedString([]byte(cfg.JWT.SecretKey))
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
 	// 存储RefreshToken信息到Redis
 	refreshTokenInfo := &services.RefreshTokenInfo{
 		UserID:     userID,
 		Email:      email,
+		Role:       role,
 		TokenID:    tokenID,
 		DeviceInfo: deviceInfo,
 		CreatedAt:  now,
@@ -166,10 +195,21 @@ func GenerateTokens(cfg *config.Config, tokenStore *services.TokenStore, userID
 	}
 
 	if err := tokenStore.StoreRefreshToken(refreshTokenInfo); err != nil {
-		return "", "", "", err
+		return nil, err
+	}
+
+	// 超出会话上限时淘汰最久未使用的会话
+	evicted, err := tokenStore.EnforceSessionLimit(userID, role, tokenID)
+	if err != nil {
+		return nil, err
 	}
 
-	return accessToken, refreshToken, tokenID, nil
+	return &TokenPair{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		TokenID:         tokenID,
+		EvictedSessions: evicted,
+	}, nil
 }
 
 // SetTokenCookies 设置JWT令牌到Cookie
@@ -231,24 +271,29 @@ func GetTokenIDFromContext(c *gin.Context) (string, bool) {
 	return "", false
 }
 
-// GetDeviceInfo 获取设备信息
-func GetDeviceInfo(c *gin.Context) string {
-	userAgent := c.GetHeader("User-Agent")
-	clientIP := c.ClientIP()
-
-	// 简化的设备信息，实际项目中可以更详细地解析User-Agent
-	deviceInfo := "Unknown"
-	if strings.Contains(userAgent, "Mobile") {
-		deviceInfo = "Mobile"
-	} else if strings.Contains(userAgent, "Chrome") {
-		deviceInfo = "Chrome Browser"
-	} else if strings.Contains(userAgent, "Firefox") {
-		deviceInfo = "Firefox Browser"
-	} else if strings.Contains(userAgent, "Safari") {
-		deviceInfo = "Safari Browser"
+// GetTokenExpiresAtFromContext 从上下文中获取当前令牌的过期时间，应用专用密码认证时不存在
+func GetTokenExpiresAtFromContext(c *gin.Context) (time.Time, bool) {
+	expiresAt, exists := c.Get("tokenExpiresAt")
+	if !exists {
+		return time.Time{}, false
+	}
+
+	if t, ok := expiresAt.(time.Time); ok {
+		return t, true
 	}
 
-	return fmt.Sprintf("%s (%s)", deviceInfo, clientIP)
+	return time.Time{}, false
+}
+
+// DeviceNameHeader 客户端自定义设备名称的请求头
+const DeviceNameHeader = "X-Device-Name"
+
+// GetDeviceInfo 获取设备信息
+func GetDeviceInfo(c *gin.Context) services.DeviceInfo {
+	deviceInfo := services.ParseUserAgent(c.GetHeader("User-Agent"))
+	deviceInfo.IP = c.ClientIP()
+	deviceInfo.Name = services.NormalizeDeviceName(c.GetHeader(DeviceNameHeader))
+	return deviceInfo
 }
 
 // RevokeToken 撤销Token
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BasicAuthMiddleware 应用专用密码认证中间件，用于CalDAV等只支持HTTP Basic的客户端
+// 用户名为账号邮箱，密码为应用专用密码；登录密码和JWT均不被接受
+func BasicAuthMiddleware(passwords *services.AppPasswordService, realm string) gin.HandlerFunc {
+	challenge := `Basic realm="` + realm + `", charset="UTF-8"`
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", challenge)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证信息"})
+			c.Abort()
+			return
+		}
+
+		userID, ok, err := passwords.Authenticate(c.Request.Context(), username, password)
+		if err != nil {
+			log.Printf("应用专用密码认证失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "验证认证信息失败"})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.Header("WWW-Authenticate", challenge)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或应用专用密码错误"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", userID)
+		c.Set("email", username)
+		c.Next()
+	}
+}
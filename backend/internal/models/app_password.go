@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AppPassword 应用专用密码，供CalDAV等无法使用JWT的客户端通过HTTP Basic认证
+// 只保存密码的SHA-256摘要，明文仅在创建时返回一次
+type AppPassword struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	TokenHash  string     `json:"-" gorm:"column:token_hash;not null;size:64;uniqueIndex"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (AppPassword) TableName() string {
+	return "app_passwords"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (p *AppPassword) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
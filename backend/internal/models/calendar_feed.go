@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeed iCalendar订阅源，持有令牌即可读取，供日历应用定期拉取
+// ProjectID为空表示订阅用户的全部项目
+type CalendarFeed struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	ProjectID *uuid.UUID `json:"projectId,omitempty" gorm:"type:uuid"`
+	Token     string     `json:"-" gorm:"not null;size:64;uniqueIndex"` // 订阅地址中的密钥，重新生成后旧地址失效
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
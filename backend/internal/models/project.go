@@ -13,6 +13,8 @@ type Project struct {
 	UserID    uuid.UUID      `json:"userId" gorm:"type:uuid;not null;index"`
 	Name      string         `json:"name" gorm:"not null;size:255"`
 	Color     string         `json:"color" gorm:"not null;size:7;default:#CCCCCC"`
+	GroupID   *uuid.UUID     `json:"groupId,omitempty" gorm:"type:uuid"`             // 所属分组，为空表示未分组
+	SortOrder string         `json:"sortOrder" gorm:"type:text;not null;default:''"` // 用户项目列表中的手动排序键
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProjectGroup 项目分组（侧边栏中的文件夹），未指定分组的项目直接显示在侧边栏中
+type ProjectGroup struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"userId" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	SortOrder string    `json:"sortOrder" gorm:"type:text;not null;default:''"` // 用户分组列表中的手动排序键
+	Collapsed bool      `json:"collapsed" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ProjectGroup) TableName() string {
+	return "project_groups"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (g *ProjectGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
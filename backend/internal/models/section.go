@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Section 项目分栏（看板列），未指定分栏的任务属于项目的默认分栏
+type Section struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"userId" gorm:"type:uuid;not null;index"`
+	ProjectID uuid.UUID `json:"projectId" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	SortOrder string    `json:"sortOrder" gorm:"type:text;not null;default:''"` // 项目内的手动排序键
+	Collapsed bool      `json:"collapsed" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (Section) TableName() string {
+	return "sections"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (s *Section) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SmartList 智能清单，保存用户自定义的任务筛选表达式
+type SmartList struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"userId" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Query     string    `json:"query" gorm:"type:text;not null"` // 筛选表达式，如 due:<=7d AND label:work
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// 关联关系 - 不使用外键约束
+	User User `json:"user,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+// TableName 指定表名
+func (SmartList) TableName() string {
+	return "smart_lists"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (l *SmartList) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 增量同步的实体类型
+const (
+	SyncEntityProject   = "project"
+	SyncEntityLabel     = "label"
+	SyncEntityTask      = "task"
+	SyncEntityReminder  = "reminder"
+	SyncEntityException = "exception"
+)
+
+// SyncChange 增量同步的变更日志，每个实体只保留最近一次变更，由数据库触发器维护
+// Seq是用户内递增的变更序号，Deleted为true表示实体已删除（墓碑）
+type SyncChange struct {
+	EntityType string    `json:"type" gorm:"primaryKey;size:20"`
+	EntityID   uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	Seq        int64     `json:"seq" gorm:"not null"`
+	Deleted    bool      `json:"deleted" gorm:"not null;default:false"`
+	ChangedAt  time.Time `json:"changedAt" gorm:"not null"`
+}
+
+// TableName 指定表名
+func (SyncChange) TableName() string {
+	return "sync_changes"
+}
@@ -21,6 +21,7 @@ type Task struct {
 	UserID      uuid.UUID      `json:"userId" gorm:"type:uuid;not null;index"`
 	ProjectID   uuid.UUID      `json:"projectId" gorm:"type:uuid;not null;index"`
 	ParentID    *uuid.UUID     `json:"parentId,omitempty" gorm:"type:uuid;index"` // 子任务的父任务ID
+	SectionID   *uuid.UUID     `json:"sectionId,omitempty" gorm:"type:uuid"`      // 所属分栏，为空表示默认分栏
 	Title       string         `json:"title" gorm:"not null;size:255"`
 	Description string         `json:"description" gorm:"type:text"`
 	Status      TaskStatus     `json:"status" gorm:"not null;default:incomplete;check:status IN ('incomplete', 'completed')"`
@@ -28,7 +29,9 @@ type Task struct {
 	StartTime   *time.Time     `json:"startTime,omitempty"`
 	DueTime     *time.Time     `json:"dueTime,omitempty"`
 	CompletedAt *time.Time     `json:"completedAt,omitempty"`
-	RRuleString string         `json:"rruleString,omitempty" gorm:"type:text"` // RFC 5545 循环规则
+	RRuleString string         `json:"rruleString,omitempty" gorm:"type:text"`            // RFC 5545 循环规则
+	SortOrder   string         `json:"sortOrder" gorm:"type:text;not null;default:''"`    // 同一项目同一父任务下的手动排序键
+	ICalUID     string         `json:"icalUid,omitempty" gorm:"column:ical_uid;size:255"` // 从iCalendar导入时的UID，用于重复导入去重
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -7,16 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 // User 用户模型
 type User struct {
-	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Email        string         `json:"email" gorm:"uniqueIndex;not null;size:255"`
-	PasswordHash string         `json:"-" gorm:"not null;size:255"`
-	FirstName    string         `json:"firstName" gorm:"size:100"`
-	LastName     string         `json:"lastName" gorm:"size:100"`
-	CreatedAt    time.Time      `json:"createdAt"`
-	UpdatedAt    time.Time      `json:"updatedAt"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email        string    `json:"email" gorm:"uniqueIndex;not null;size:255"`
+	PasswordHash string    `json:"-" gorm:"not null;size:255"`
+	FirstName    string    `json:"firstName" gorm:"size:100"`
+	LastName     string    `json:"lastName" gorm:"size:100"`
+	Role         string    `json:"role" gorm:"not null;size:20;default:user"`
+	// 用户标记"不是我本人"登录后需要重置密码才能再次登录
+	PasswordResetRequired bool           `json:"passwordResetRequired" gorm:"not null;default:false"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	UpdatedAt             time.Time      `json:"updatedAt"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联关系 - 不使用外键约束
 	Projects []Project `json:"projects,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
@@ -34,5 +43,8 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
 	}
+	if u.Role == "" {
+		u.Role = UserRoleUser
+	}
 	return nil
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSettings 用户设置，每个用户一条记录，设置内容以JSON文档保存
+// 文档结构和默认值见 internal/settings，Version 记录写入时的结构版本
+type UserSettings struct {
+	UserID    uuid.UUID `json:"userId" gorm:"type:uuid;primary_key"`
+	Version   int       `json:"version" gorm:"not null;default:1"`
+	Document  string    `json:"document" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (UserSettings) TableName() string {
+	return "user_settings"
+}
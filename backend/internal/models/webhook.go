@@ -0,0 +1,81 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook 用户注册的Webhook端点，订阅的事件发生时向URL发送带签名的POST请求
+// 连续投递失败达到上限后自动停用，重新启用时清零失败计数
+type Webhook struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	URL          string     `json:"url" gorm:"not null;size:2048"`
+	Secret       string     `json:"-" gorm:"not null;size:64"`
+	Events       []string   `json:"events" gorm:"type:jsonb;not null;serializer:json"`
+	Enabled      bool       `json:"enabled" gorm:"not null;default:true"`
+	FailureCount int        `json:"failureCount" gorm:"not null;default:0"`
+	DisabledAt   *time.Time `json:"disabledAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subscribes 判断端点是否订阅了该类型的事件
+func (w *Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+// 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投递或等待重试
+	WebhookDeliverySucceeded = "succeeded" // 端点返回2xx
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽或端点已停用
+)
+
+// WebhookDelivery 一次事件投递的记录，Payload是发送的请求体，重新投递时原样发送
+type WebhookDelivery struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID     uuid.UUID  `json:"webhookId" gorm:"type:uuid;not null;index"`
+	EventID       uuid.UUID  `json:"eventId" gorm:"type:uuid;not null"`
+	EventType     string     `json:"eventType" gorm:"not null;size:50"`
+	Payload       string     `json:"-" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null;size:20"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	ResponseCode  *int       `json:"responseCode"`
+	ResponseBody  string     `json:"responseBody" gorm:"type:text"`
+	Error         string     `json:"error" gorm:"type:text"`
+	DurationMs    int64      `json:"durationMs"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	RedeliveryOf  *uuid.UUID `json:"redeliveryOf" gorm:"type:uuid"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate GORM钩子，创建前生成UUID
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package ordering 实现手动排序使用的分数索引（fractional index）
+//
+// 排序键是由62进制数字组成的字符串，按字节序比较，可以看作小数点后的各位数字，
+// 例如 "V" 相当于 0.V。在任意两个键之间总能生成一个新键，拖动排序时只需修改被移动的记录，
+// 不必重新编号同级数据。键不以 "0" 结尾，保证每个小数只有一种表示
+package ordering
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits 按字节序递增排列的62进制数字，数据库中需使用 COLLATE "C" 保证相同的比较顺序
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+var (
+	// ErrInvalidKey 排序键包含非法字符或以0结尾
+	ErrInvalidKey = errors.New("排序键无效")
+	// ErrInvalidRange 下界不小于上界，通常是同级数据出现了重复的键
+	ErrInvalidRange = errors.New("排序键区间无效")
+)
+
+// Valid 判断是否为合法的排序键
+func Valid(key string) bool {
+	if key == "" || key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between 生成严格介于a和b之间的排序键，a为空表示没有下界，b为空表示没有上界
+// 追加到末尾或插入到开头时只改动第一位数字，连续追加时键长度增长较慢
+func Between(a, b string) (string, error) {
+	if (a != "" && !Valid(a)) || (b != "" && !Valid(b)) {
+		return "", ErrInvalidKey
+	}
+	switch {
+	case a == "" && b == "":
+		return string(digits[base/2]), nil
+	case b == "":
+		return increment(a), nil
+	case a == "":
+		return decrement(b), nil
+	case a >= b:
+		return "", ErrInvalidRange
+	}
+	return midpoint(a, b), nil
+}
+
+// Spread 生成n个长度尽量短且均匀分布的排序键，用于重新平衡同级数据
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	length, space := 1, base
+	for space <= n {
+		length++
+		space *= base
+	}
+	step := space / (n + 1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = encode((i+1)*step, length)
+	}
+	return keys
+}
+
+// encode 将value编码为length位的62进制数，去掉末尾的0
+func encode(value, length int) string {
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = digits[value%base]
+		value /= base
+	}
+	return strings.TrimRight(string(buf), digits[:1])
+}
+
+// digitAt 获取第i位数字的值，超出长度时视为0
+func digitAt(key string, i int) int {
+	if i >= len(key) {
+		return 0
+	}
+	return strings.IndexByte(digits, key[i])
+}
+
+// increment 生成大于a的键，优先只增加第一位
+func increment(a string) string {
+	if a == "" {
+		return string(digits[base/2])
+	}
+	if d := digitAt(a, 0); d < base-1 {
+		return string(digits[d+1])
+	}
+	return a[:1] + increment(a[1:])
+}
+
+// decrement 生成小于b的非空键，优先只减少第一位
+func decrement(b string) string {
+	if b == "" {
+		return string(digits[base/2])
+	}
+	d := digitAt(b, 0)
+	switch {
+	case d > 1:
+		return string(digits[d-1])
+	case d == 1 && len(b) > 1:
+		return b[:1]
+	}
+	// 第一位已经是0或1，只能在下一位继续寻找
+	return digits[:1] + decrement(b[1:])
+}
+
+// midpoint 生成a和b中间的键，要求a < b，b为空表示没有上界
+func midpoint(a, b string) string {
+	if b != "" {
+		// 跳过公共前缀，a较短时按末尾补0比较
+		n := 0
+		for n < len(b) && digitAt(a, n) == digitAt(b, n) {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	da, db := digitAt(a, 0), base
+	if b != "" {
+		db = digitAt(b, 0)
+	}
+	if db-da > 1 {
+		return string(digits[(da+db)/2])
+	}
+	// 第一位相邻，b还有后续位时取b的第一位即可，否则在a的下一位继续二分
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[da]) + midpoint(rest, "")
+}
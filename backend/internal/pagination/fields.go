@@ -0,0 +1,87 @@
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SelectFields 按fields裁剪列表中每一项的JSON字段，id始终保留
+// fields为空时原样返回；字段名必须是元素类型的JSON字段
+func SelectFields(items interface{}, fields string) (interface{}, error) {
+	if strings.TrimSpace(fields) == "" {
+		return items, nil
+	}
+
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("SelectFields需要切片，实际为 %T", items)
+	}
+	allowed := jsonFieldNames(value.Type().Elem())
+
+	keep := map[string]bool{"id": true}
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !allowed[name] {
+			return nil, fmt.Errorf("%w: 未知字段 %q", ErrInvalidQuery, name)
+		}
+		keep[name] = true
+	}
+
+	selected := make([]map[string]json.RawMessage, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		data, err := json.Marshal(value.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, err
+		}
+		for key := range object {
+			if !keep[key] {
+				delete(object, key)
+			}
+		}
+		selected = append(selected, object)
+	}
+	return selected, nil
+}
+
+// jsonFieldNames 获取结构体（含嵌入结构体）的JSON字段名
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
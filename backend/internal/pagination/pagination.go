@@ -0,0 +1,249 @@
+// Package pagination 提供列表接口共用的游标分页、排序和字段裁剪
+//
+// 游标对客户端不透明，内部记录排序方式以及上一页最后一条记录的(排序键, ID)，
+// 下一页从该位置之后继续读取，翻页过程中插入或删除数据不会导致重复或遗漏
+package pagination
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLimit 默认每页条数
+	DefaultLimit = 50
+	// MaxLimit 每页条数上限
+	MaxLimit = 200
+)
+
+// ErrInvalidQuery 分页参数无效
+var ErrInvalidQuery = errors.New("分页参数无效")
+
+// ValueType 排序键的值类型
+type ValueType int
+
+const (
+	TimeValue ValueType = iota
+	IntValue
+	StringValue
+)
+
+// Field 可排序字段
+type Field struct {
+	Name     string    // 对外的排序名，如 due
+	Column   string    // 数据库列，如 tasks.due_time
+	Type     ValueType // 值类型
+	Nullable bool      // 可为空，NULL始终排在最后
+}
+
+// Sort 排序方式
+type Sort struct {
+	Field Field
+	Desc  bool
+}
+
+// String 返回排序参数形式，降序时带 - 前缀
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field.Name
+	}
+	return s.Field.Name
+}
+
+// Cursor 上一页最后一条记录的位置
+type Cursor struct {
+	Value interface{} // 排序键的值，nil表示NULL
+	ID    uuid.UUID
+}
+
+// Request 解析后的分页请求，Limit为0表示不分页
+type Request struct {
+	Sort  Sort
+	After *Cursor
+	Limit int
+}
+
+// Page 一页数据，NextCursor为空表示没有更多数据
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
+
+// Query 列表接口通用的分页查询参数
+type Query struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Sort   string `form:"sort"`   // 排序字段，前缀 - 表示降序
+	Fields string `form:"fields"` // 逗号分隔的返回字段
+}
+
+// Request 校验排序字段和游标，生成分页请求
+func (q *Query) Request(fields []Field, defaultSort string) (Request, error) {
+	raw := q.Sort
+	if raw == "" {
+		raw = defaultSort
+	}
+	sort, err := parseSort(raw, fields)
+	if err != nil {
+		return Request{}, err
+	}
+
+	req := Request{Sort: sort, Limit: q.Limit}
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	if q.Cursor != "" {
+		if req.After, err = decodeCursor(q.Cursor, sort); err != nil {
+			return Request{}, err
+		}
+	}
+	return req, nil
+}
+
+// parseSort 解析排序参数，例如 due 或 -priority
+func parseSort(raw string, fields []Field) (Sort, error) {
+	name := strings.TrimPrefix(raw, "-")
+	for _, field := range fields {
+		if field.Name == name {
+			return Sort{Field: field, Desc: strings.HasPrefix(raw, "-")}, nil
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return Sort{}, fmt.Errorf("%w: 不支持按 %q 排序，可选 %s", ErrInvalidQuery, name, strings.Join(names, "、"))
+}
+
+// cursorPayload 游标编码前的内容
+type cursorPayload struct {
+	Sort  string    `json:"s"`
+	Value *string   `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Trim 截取一页数据并生成下一页游标，items需按请求多取一条以判断是否还有下一页
+func Trim[T any](items []T, req Request, position func(T) (interface{}, uuid.UUID)) ([]T, string) {
+	if req.Limit == 0 || len(items) <= req.Limit {
+		return items, ""
+	}
+
+	items = items[:req.Limit]
+	value, id := position(items[len(items)-1])
+	payload := cursorPayload{Sort: req.Sort.String(), Value: formatValue(value), ID: id}
+	data, _ := json.Marshal(payload)
+	return items, base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游标，游标必须与当前排序方式一致
+func decodeCursor(raw string, sort Sort) (*Cursor, error) {
+	invalid := fmt.Errorf("%w: 游标无效", ErrInvalidQuery)
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == uuid.Nil {
+		return nil, invalid
+	}
+	if payload.Sort != sort.String() {
+		return nil, fmt.Errorf("%w: 游标与排序方式不一致", ErrInvalidQuery)
+	}
+
+	cursor := &Cursor{ID: payload.ID}
+	if payload.Value == nil {
+		if !sort.Field.Nullable {
+			return nil, invalid
+		}
+		return cursor, nil
+	}
+	if cursor.Value, err = parseValue(*payload.Value, sort.Field.Type); err != nil {
+		return nil, invalid
+	}
+	return cursor, nil
+}
+
+// formatValue 将排序键的值编码为字符串，nil表示NULL
+func formatValue(value interface{}) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		s = v.UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		s = v.UTC().Format(time.RFC3339Nano)
+	case int:
+		s = strconv.Itoa(v)
+	case string:
+		s = v
+	default:
+		s = fmt.Sprint(v)
+	}
+	return &s
+}
+
+// parseValue 按类型解析排序键的值
+func parseValue(s string, typ ValueType) (interface{}, error) {
+	switch typ {
+	case TimeValue:
+		return time.Parse(time.RFC3339Nano, s)
+	case IntValue:
+		return strconv.Atoi(s)
+	}
+	return s, nil
+}
+
+// Compare 按排序方向比较两个排序键的值，nil（NULL）无论升降序都排在最后
+func Compare(a, b interface{}, desc bool) int {
+	if t, ok := a.(*time.Time); ok {
+		a = derefTime(t)
+	}
+	if t, ok := b.(*time.Time); ok {
+		b = derefTime(t)
+	}
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	c := 0
+	switch x := a.(type) {
+	case time.Time:
+		c = x.Compare(b.(time.Time))
+	case int:
+		c = cmp.Compare(x, b.(int))
+	case string:
+		c = strings.Compare(x, b.(string))
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// derefTime 将空的*time.Time转换为nil接口值
+func derefTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
@@ -0,0 +1,390 @@
+// Package recurrence 解析RFC 5545循环规则（RRULE）并展开为具体的实例时间
+//
+// 支持DAILY、WEEKLY、MONTHLY、YEARLY频率，以及INTERVAL、COUNT、UNTIL、BYDAY、
+// BYMONTHDAY、BYMONTH、BYSETPOS和WKST。实例的时分秒沿用DTSTART，并按DTSTART所在时区的
+// 本地时间计算，跨越夏令时切换时保持相同的钟点
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule 循环规则格式错误或使用了不支持的部分
+var ErrInvalidRule = errors.New("循环规则无效")
+
+// maxPeriods 展开时最多遍历的周期数，防止无法产生实例的规则无限循环
+const maxPeriods = 100000
+
+// Frequency 循环频率
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencies = map[string]Frequency{
+	"DAILY": Daily, "WEEKLY": Weekly, "MONTHLY": Monthly, "YEARLY": Yearly,
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// WeekdayNum BYDAY中的一项，N为0表示每个该星期几，否则为月内（或年内）第N个，负数从末尾数起
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule 解析后的循环规则
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// Parse 解析循环规则，兼容带 RRULE: 前缀和包含DTSTART等其他行的写法
+func Parse(s string) (*Rule, error) {
+	value := strings.TrimSpace(s)
+	for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > len("RRULE:") && strings.EqualFold(line[:len("RRULE:")], "RRULE:") {
+			value = line[len("RRULE:"):]
+			break
+		}
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	hasFreq := false
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq, hasFreq = frequencies[strings.ToUpper(val)]
+			if !hasFreq {
+				return nil, fmt.Errorf("%w: 不支持的频率 %s", ErrInvalidRule, val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("INTERVAL必须大于0")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("COUNT必须大于0")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			rule.BySetPos, err = parseInts(val, -366, 366)
+		case "WKST":
+			var ok bool
+			if rule.WeekStart, ok = weekdays[strings.ToUpper(val)]; !ok {
+				err = fmt.Errorf("无效的WKST %s", val)
+			}
+		default:
+			return nil, fmt.Errorf("%w: 不支持 %s", ErrInvalidRule, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, key, err)
+		}
+	}
+	if !hasFreq {
+		return nil, fmt.Errorf("%w: 缺少FREQ", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT和UNTIL不能同时使用", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+func parseUntil(val string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, val); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second) // 只有日期时包含当天
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 %s", val)
+}
+
+func parseByDay(val string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(val, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("无效的星期 %s", item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("无效的星期 %s", item)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("无效的星期 %s", item)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseInts(val string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(val, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("无效的值 %s", item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// Between 展开以dtstart为首次实例的规则，返回[from, to)内的实例，最多limit个（limit<=0表示不限制）
+// COUNT从dtstart开始计数，包括窗口之前的实例
+func (r *Rule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	var result []time.Time
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		candidates, periodStart := r.period(dtstart, period)
+		if !periodStart.Before(to) || (!r.Until.IsZero() && periodStart.After(r.Until)) {
+			break
+		}
+		for _, t := range candidates {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return result
+			}
+			count++
+			if !t.Before(from) && t.Before(to) {
+				result = append(result, t)
+				if limit > 0 && len(result) >= limit {
+					return result
+				}
+			}
+			if r.Count > 0 && count >= r.Count {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// period 生成第n个周期内的候选实例（已排序并应用BYSETPOS），同时返回周期的起点
+func (r *Rule) period(dtstart time.Time, n int) ([]time.Time, time.Time) {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	step := n * r.Interval
+
+	var days []time.Time
+	var start time.Time
+	switch r.Freq {
+	case Daily:
+		start = time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+		if r.matchMonth(start) && r.matchMonthDay(start) && r.matchWeekday(start) {
+			days = []time.Time{start}
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		start = time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i)
+			if r.matchMonth(day) && r.weeklyDay(day, dtstart) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		start = time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+		if r.matchMonth(start) {
+			days = r.monthDays(start, dtstart)
+		}
+	case Yearly:
+		start = time.Date(y+step, 1, 1, 0, 0, 0, 0, loc)
+		days = r.yearDays(start, dtstart)
+	}
+
+	days = r.applySetPos(days)
+	hour, min, sec := dtstart.Clock()
+	times := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		dy, dm, dd := day.Date()
+		times = append(times, time.Date(dy, dm, dd, hour, min, sec, 0, loc))
+	}
+	return times, start
+}
+
+// weeklyDay WEEKLY频率下判断某天是否为实例：有BYDAY时按星期筛选，否则与dtstart同一星期几
+func (r *Rule) weeklyDay(day, dtstart time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return day.Weekday() == dtstart.Weekday()
+	}
+	return r.matchWeekday(day)
+}
+
+// monthDays 生成某月内的实例日期
+func (r *Rule) monthDays(month, dtstart time.Time) []time.Time {
+	last := month.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	for d := 1; d <= last; d++ {
+		day := month.AddDate(0, 0, d-1)
+		switch {
+		case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if d == dtstart.Day() {
+				days = append(days, day)
+			}
+		case r.matchMonthDay(day) && r.matchNth(day, d-1, last):
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// yearDays 生成某年内的实例日期
+func (r *Rule) yearDays(year, dtstart time.Time) []time.Time {
+	var days []time.Time
+	switch {
+	case len(r.ByMonth) > 0 || len(r.ByMonthDay) > 0:
+		// 有BYMONTH时BYDAY的序号按月计算，只有BYMONTHDAY时每个月都适用
+		for m := time.January; m <= time.December; m++ {
+			month := time.Date(year.Year(), m, 1, 0, 0, 0, 0, year.Location())
+			if !r.matchMonth(month) {
+				continue
+			}
+			days = append(days, r.monthDays(month, dtstart)...)
+		}
+	case len(r.ByDay) > 0:
+		// 没有BYMONTH时BYDAY的序号按全年计算
+		total := year.AddDate(1, 0, -1).YearDay()
+		for i := 0; i < total; i++ {
+			day := year.AddDate(0, 0, i)
+			if r.matchNth(day, i, total) {
+				days = append(days, day)
+			}
+		}
+	default:
+		day := time.Date(year.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, year.Location())
+		if day.Day() == dtstart.Day() { // 跳过不存在的日期，如非闰年的2月29日
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if day.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday 按星期筛选，忽略序号
+func (r *Rule) matchWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNth 判断day是否满足BYDAY，index为day在范围（月或年）内从0开始的位置，total为范围的天数
+func (r *Rule) matchNth(day time.Time, index, total int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0:
+			return true
+		case wd.N > 0 && index/7+1 == wd.N:
+			return true
+		case wd.N < 0 && (total-1-index)/7+1 == -wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+// applySetPos 从周期内排序后的候选中按BYSETPOS取出指定位置
+func (r *Rule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	var picked []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(days) + pos
+		}
+		if i >= 0 && i < len(days) {
+			picked = append(picked, days[i])
+		}
+	}
+	sort.Slice(picked, func(i, j int) bool { return picked[i].Before(picked[j]) })
+	return picked
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// AppPasswordRepository 应用专用密码仓储的内存实现
+type AppPasswordRepository struct{ s *Store }
+
+var _ repository.AppPasswordRepository = (*AppPasswordRepository)(nil)
+
+// CreateAppPassword 创建应用专用密码
+func (r *AppPasswordRepository) CreateAppPassword(ctx context.Context, password *models.AppPassword) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := password.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&password.CreatedAt, nil)
+	stored := *password
+	r.s.appPasswords[password.ID] = &stored
+	return nil
+}
+
+// GetAppPasswordByHash 按密码摘要获取应用专用密码
+func (r *AppPasswordRepository) GetAppPasswordByHash(ctx context.Context, tokenHash string) (*models.AppPassword, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, password := range r.s.appPasswords {
+		if password.TokenHash == tokenHash {
+			found := *password
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListAppPasswords 按分页请求获取用户的应用专用密码
+func (r *AppPasswordRepository) ListAppPasswords(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.AppPassword, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	passwords := make([]*models.AppPassword, 0)
+	for _, password := range r.s.appPasswords {
+		if password.UserID == userID {
+			found := *password
+			passwords = append(passwords, &found)
+		}
+	}
+	return paginate(passwords, page, func(password *models.AppPassword) (interface{}, uuid.UUID) {
+		return repository.AppPasswordSortValue(password, page.Sort.Field.Name), password.ID
+	}), nil
+}
+
+// TouchAppPassword 记录密码最近一次使用的时间
+func (r *AppPasswordRepository) TouchAppPassword(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if password, ok := r.s.appPasswords[id]; ok {
+		password.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+// DeleteAppPassword 删除用户的应用专用密码
+func (r *AppPasswordRepository) DeleteAppPassword(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	password, ok := r.s.appPasswords[id]
+	if !ok || password.UserID != userID {
+		return false, nil
+	}
+	delete(r.s.appPasswords, id)
+	return true, nil
+}
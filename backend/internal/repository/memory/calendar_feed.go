@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeedRepository 日历订阅源仓储的内存实现
+type CalendarFeedRepository struct{ s *Store }
+
+var _ repository.CalendarFeedRepository = (*CalendarFeedRepository)(nil)
+
+// CreateCalendarFeed 创建订阅源
+func (r *CalendarFeedRepository) CreateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := feed.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&feed.CreatedAt, &feed.UpdatedAt)
+	stored := *feed
+	r.s.calendarFeeds[feed.ID] = &stored
+	return nil
+}
+
+// GetCalendarFeedByID 根据ID获取用户的订阅源
+func (r *CalendarFeedRepository) GetCalendarFeedByID(ctx context.Context, userID, id uuid.UUID) (*models.CalendarFeed, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	feed, ok := r.s.calendarFeeds[id]
+	if !ok || feed.UserID != userID {
+		return nil, nil
+	}
+	found := *feed
+	return &found, nil
+}
+
+// GetCalendarFeedByToken 按订阅令牌获取订阅源
+func (r *CalendarFeedRepository) GetCalendarFeedByToken(ctx context.Context, token string) (*models.CalendarFeed, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, feed := range r.s.calendarFeeds {
+		if feed.Token == token {
+			found := *feed
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListCalendarFeeds 按分页请求获取用户的订阅源
+func (r *CalendarFeedRepository) ListCalendarFeeds(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.CalendarFeed, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	feeds := make([]*models.CalendarFeed, 0)
+	for _, feed := range r.s.calendarFeeds {
+		if feed.UserID == userID {
+			found := *feed
+			feeds = append(feeds, &found)
+		}
+	}
+	return paginate(feeds, page, func(feed *models.CalendarFeed) (interface{}, uuid.UUID) {
+		return repository.CalendarFeedSortValue(feed, page.Sort.Field.Name), feed.ID
+	}), nil
+}
+
+// UpdateCalendarFeed 更新订阅源
+func (r *CalendarFeedRepository) UpdateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &feed.UpdatedAt)
+	stored := *feed
+	r.s.calendarFeeds[feed.ID] = &stored
+	return nil
+}
+
+// DeleteCalendarFeed 删除订阅源
+func (r *CalendarFeedRepository) DeleteCalendarFeed(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if feed, ok := r.s.calendarFeeds[id]; ok && feed.UserID == userID {
+		delete(r.s.calendarFeeds, id)
+	}
+	return nil
+}
+
+// CalendarFeedExists 检查用户是否已有该范围的订阅源
+func (r *CalendarFeedRepository) CalendarFeedExists(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, feed := range r.s.calendarFeeds {
+		if feed.UserID == userID && sameProjectID(feed.ProjectID, projectID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sameProjectID 判断两个可选项目ID是否相同，都为nil表示全部项目
+func sameProjectID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
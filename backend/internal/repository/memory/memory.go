@@ -0,0 +1,871 @@
+// Package memory 提供仓储接口的内存实现，用于不依赖PostgreSQL的测试
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ticktick-backend/internal/filter"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store 内存数据存储，所有仓储共享同一把锁以模拟跨表操作的原子性
+type Store struct {
+	txMu          sync.Mutex // 串行执行事务，内存实现不支持回滚
+	mu            sync.RWMutex
+	users         map[uuid.UUID]*models.User
+	projects      map[uuid.UUID]*models.Project
+	tasks         map[uuid.UUID]*models.Task
+	labels        map[uuid.UUID]*models.Label
+	reminders     map[uuid.UUID]*models.Reminder
+	taskLabels    map[uuid.UUID]map[uuid.UUID]bool // taskID -> labelID集合
+	smartLists    map[uuid.UUID]*models.SmartList
+	sections      map[uuid.UUID]*models.Section
+	projectGroups map[uuid.UUID]*models.ProjectGroup
+	userSettings  map[uuid.UUID]*models.UserSettings // userID -> 设置
+	exceptions    map[uuid.UUID]*models.TaskRecurrenceException
+	calendarFeeds map[uuid.UUID]*models.CalendarFeed
+	appPasswords  map[uuid.UUID]*models.AppPassword
+	webhooks      map[uuid.UUID]*models.Webhook
+	deliveries    map[uuid.UUID]*models.WebhookDelivery
+	syncSeqs      map[uuid.UUID]int64 // userID -> 当前变更序号
+	syncChanges   map[syncKey]*models.SyncChange
+}
+
+// NewStore 创建内存数据存储
+func NewStore() *Store {
+	return &Store{
+		users:         make(map[uuid.UUID]*models.User),
+		projects:      make(map[uuid.UUID]*models.Project),
+		tasks:         make(map[uuid.UUID]*models.Task),
+		labels:        make(map[uuid.UUID]*models.Label),
+		reminders:     make(map[uuid.UUID]*models.Reminder),
+		taskLabels:    make(map[uuid.UUID]map[uuid.UUID]bool),
+		smartLists:    make(map[uuid.UUID]*models.SmartList),
+		sections:      make(map[uuid.UUID]*models.Section),
+		projectGroups: make(map[uuid.UUID]*models.ProjectGroup),
+		userSettings:  make(map[uuid.UUID]*models.UserSettings),
+		exceptions:    make(map[uuid.UUID]*models.TaskRecurrenceException),
+		calendarFeeds: make(map[uuid.UUID]*models.CalendarFeed),
+		appPasswords:  make(map[uuid.UUID]*models.AppPassword),
+		webhooks:      make(map[uuid.UUID]*models.Webhook),
+		deliveries:    make(map[uuid.UUID]*models.WebhookDelivery),
+		syncSeqs:      make(map[uuid.UUID]int64),
+		syncChanges:   make(map[syncKey]*models.SyncChange),
+	}
+}
+
+// Users 获取用户仓储
+func (s *Store) Users() repository.UserRepository { return &UserRepository{s} }
+
+// Projects 获取项目仓储
+func (s *Store) Projects() repository.ProjectRepository { return &ProjectRepository{s} }
+
+// Tasks 获取任务仓储
+func (s *Store) Tasks() repository.TaskRepository { return &TaskRepository{s} }
+
+// Labels 获取标签仓储
+func (s *Store) Labels() repository.LabelRepository { return &LabelRepository{s} }
+
+// Reminders 获取提醒仓储
+func (s *Store) Reminders() repository.ReminderRepository { return &ReminderRepository{s} }
+
+// Trash 获取回收站仓储
+func (s *Store) Trash() repository.TrashRepository { return &TrashRepository{s} }
+
+// SmartLists 获取智能清单仓储
+func (s *Store) SmartLists() repository.SmartListRepository { return &SmartListRepository{s} }
+
+// Sections 获取项目分栏仓储
+func (s *Store) Sections() repository.SectionRepository { return &SectionRepository{s} }
+
+// ProjectGroups 获取项目分组仓储
+func (s *Store) ProjectGroups() repository.ProjectGroupRepository { return &ProjectGroupRepository{s} }
+
+// UserSettings 获取用户设置仓储
+func (s *Store) UserSettings() repository.UserSettingsRepository { return &UserSettingsRepository{s} }
+
+// CalendarFeeds 获取日历订阅源仓储
+func (s *Store) CalendarFeeds() repository.CalendarFeedRepository { return &CalendarFeedRepository{s} }
+
+// AppPasswords 获取应用专用密码仓储
+func (s *Store) AppPasswords() repository.AppPasswordRepository { return &AppPasswordRepository{s} }
+
+// Webhooks 获取Webhook仓储
+func (s *Store) Webhooks() repository.WebhookRepository { return &WebhookRepository{s} }
+
+// Sync 获取增量同步仓储
+func (s *Store) Sync() repository.SyncRepository { return &SyncRepository{s} }
+
+var _ repository.Transactor = (*Store)(nil)
+
+// txKey 上下文中标记已处于事务内的键
+type txKey struct{}
+
+// WithTx 串行执行fn，嵌套调用时直接复用外层事务
+// 内存实现只保证事务之间互斥，fn返回错误时已执行的修改不会回滚
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+// isDeleted 判断记录是否已被软删除
+func isDeleted(deletedAt gorm.DeletedAt) bool {
+	return deletedAt.Valid
+}
+
+// softDelete 生成软删除时间
+func softDelete() gorm.DeletedAt {
+	return gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
+
+// touch 模拟GORM自动维护的创建和更新时间
+func touch(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt != nil && createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil {
+		*updatedAt = now
+	}
+}
+
+// UserRepository 用户仓储的内存实现
+type UserRepository struct{ s *Store }
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// CreateUser 创建新用户
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&user.CreatedAt, &user.UpdatedAt)
+	stored := *user
+	r.s.users[user.ID] = &stored
+	return nil
+}
+
+// GetUserByEmail 根据邮箱获取用户
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.Email == email && !isDeleted(user.DeletedAt) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetUserByID 根据ID获取用户
+func (r *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	user, ok := r.s.users[id]
+	if !ok || isDeleted(user.DeletedAt) {
+		return nil, nil
+	}
+	found := *user
+	return &found, nil
+}
+
+// UpdateUser 更新用户信息
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &user.UpdatedAt)
+	stored := *user
+	r.s.users[user.ID] = &stored
+	return nil
+}
+
+// DeleteUser 软删除用户
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if user, ok := r.s.users[id]; ok {
+		user.DeletedAt = softDelete()
+	}
+	return nil
+}
+
+// EmailExists 检查邮箱是否已存在
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	user, err := r.GetUserByEmail(ctx, email)
+	return user != nil, err
+}
+
+// UpdatePassword 更新用户密码并清除重置标记
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if user, ok := r.s.users[id]; ok {
+		user.PasswordHash = passwordHash
+		user.PasswordResetRequired = false
+		touch(nil, &user.UpdatedAt)
+	}
+	return nil
+}
+
+// SetPasswordResetRequired 设置用户是否需要重置密码
+func (r *UserRepository) SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if user, ok := r.s.users[id]; ok {
+		user.PasswordResetRequired = required
+		touch(nil, &user.UpdatedAt)
+	}
+	return nil
+}
+
+// ProjectRepository 项目仓储的内存实现
+type ProjectRepository struct{ s *Store }
+
+var _ repository.ProjectRepository = (*ProjectRepository)(nil)
+
+// CreateProject 创建项目
+func (r *ProjectRepository) CreateProject(ctx context.Context, project *models.Project) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := project.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&project.CreatedAt, &project.UpdatedAt)
+	stored := *project
+	r.s.projects[project.ID] = &stored
+	r.s.recordProject(&stored)
+	return nil
+}
+
+// GetProjectByID 根据ID获取用户的项目
+func (r *ProjectRepository) GetProjectByID(ctx context.Context, userID, id uuid.UUID) (*models.Project, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	project, ok := r.s.projects[id]
+	if !ok || project.UserID != userID || isDeleted(project.DeletedAt) {
+		return nil, nil
+	}
+	found := *project
+	return &found, nil
+}
+
+// ListProjects 按分页请求获取用户的项目
+func (r *ProjectRepository) ListProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	projects := make([]*models.Project, 0)
+	for _, project := range r.s.projects {
+		if project.UserID == userID && !isDeleted(project.DeletedAt) {
+			found := *project
+			projects = append(projects, &found)
+		}
+	}
+	return paginate(projects, page, func(project *models.Project) (interface{}, uuid.UUID) {
+		return repository.ProjectSortValue(project, page.Sort.Field.Name), project.ID
+	}), nil
+}
+
+// UpdateProject 更新项目
+func (r *ProjectRepository) UpdateProject(ctx context.Context, project *models.Project) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &project.UpdatedAt)
+	stored := *project
+	r.s.projects[project.ID] = &stored
+	r.s.recordProject(&stored)
+	return nil
+}
+
+// DeleteProject 软删除项目及其下所有任务
+func (r *ProjectRepository) DeleteProject(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	project, ok := r.s.projects[id]
+	if !ok || project.UserID != userID || isDeleted(project.DeletedAt) {
+		return nil
+	}
+
+	deletedAt := softDelete()
+	project.DeletedAt = deletedAt
+	r.s.recordProject(project)
+	r.s.softDeleteTasks(deletedAt, func(task *models.Task) bool {
+		return task.ProjectID == id && task.UserID == userID
+	})
+	return nil
+}
+
+// ProjectNameExists 检查用户下是否存在同名项目
+func (r *ProjectRepository) ProjectNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, project := range r.s.projects {
+		if project.UserID == userID && project.Name == name && project.ID != excludeID && !isDeleted(project.DeletedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TaskRepository 任务仓储的内存实现
+type TaskRepository struct{ s *Store }
+
+var _ repository.TaskRepository = (*TaskRepository)(nil)
+
+// CreateTask 创建任务
+func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := task.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&task.CreatedAt, &task.UpdatedAt)
+	r.s.tasks[task.ID] = r.s.stripTask(task)
+	r.s.recordTask(r.s.tasks[task.ID])
+	return nil
+}
+
+// GetTaskByID 根据ID获取用户的任务，同时加载标签和提醒
+func (r *TaskRepository) GetTaskByID(ctx context.Context, userID, id uuid.UUID) (*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	task, ok := r.s.tasks[id]
+	if !ok || task.UserID != userID || isDeleted(task.DeletedAt) {
+		return nil, nil
+	}
+
+	found := *task
+	found.Labels = r.s.taskLabelsOf(id)
+	found.Reminders = r.s.remindersOf(id)
+	return &found, nil
+}
+
+// GetTaskByICalUID 按导入时的iCalendar UID获取任务
+func (r *TaskRepository) GetTaskByICalUID(ctx context.Context, userID uuid.UUID, uid string) (*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, task := range r.s.tasks {
+		if task.UserID == userID && task.ICalUID == uid && !isDeleted(task.DeletedAt) {
+			found := *task
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListTasks 按过滤条件和分页请求获取任务，同时加载标签
+func (r *TaskRepository) ListTasks(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter, page pagination.Request) ([]*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tasks := make([]*models.Task, 0)
+	for _, task := range r.s.tasks {
+		if task.UserID != userID || isDeleted(task.DeletedAt) || !r.s.matchTaskFilter(task, filter) {
+			continue
+		}
+		found := *task
+		found.Labels = r.s.taskLabelsOf(task.ID)
+		tasks = append(tasks, &found)
+	}
+	return paginate(tasks, page, func(task *models.Task) (interface{}, uuid.UUID) {
+		return repository.TaskSortValue(task, page.Sort.Field.Name), task.ID
+	}), nil
+}
+
+// ListTasksWithDetails 按过滤条件获取全部任务，同时加载标签、提醒和循环例外
+func (r *TaskRepository) ListTasksWithDetails(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tasks := make([]*models.Task, 0)
+	for _, task := range r.s.tasks {
+		if task.UserID != userID || isDeleted(task.DeletedAt) || !r.s.matchTaskFilter(task, filter) {
+			continue
+		}
+		found := *task
+		found.Labels = r.s.taskLabelsOf(task.ID)
+		found.Reminders = r.s.remindersOf(task.ID)
+		found.Exceptions = r.s.exceptionsOf(task.ID)
+		tasks = append(tasks, &found)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID.String() < tasks[j].ID.String()
+	})
+	return tasks, nil
+}
+
+// SearchTasks 全文搜索任务，按不区分大小写的子串匹配，标题命中的权重最高
+func (r *TaskRepository) SearchTasks(ctx context.Context, userID uuid.UUID, search repository.TaskSearch) ([]*repository.TaskSearchHit, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	hits := make([]*repository.TaskSearchHit, 0)
+	for _, task := range r.s.tasks {
+		if task.UserID != userID || isDeleted(task.DeletedAt) || !r.s.matchTaskFilter(task, search.TaskFilter) {
+			continue
+		}
+
+		labels := r.s.taskLabelsOf(task.ID)
+		names := make([]string, 0, len(labels))
+		for _, label := range labels {
+			names = append(names, label.Name)
+		}
+		fields := []struct {
+			text   string
+			weight float64
+		}{
+			{strings.ToLower(task.Title), 1},
+			{strings.ToLower(strings.Join(names, " ")), 0.4},
+			{strings.ToLower(task.Description), 0.2},
+		}
+		all := fields[0].text + " " + fields[1].text + " " + fields[2].text
+
+		matched := search.Text != "" && strings.Contains(all, search.Text)
+		if !matched && len(search.Terms) > 0 {
+			matched = true
+			for _, term := range search.Terms {
+				if !strings.Contains(all, term) {
+					matched = false
+					break
+				}
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		var rank float64
+		for _, field := range fields {
+			for _, term := range search.Terms {
+				if strings.Contains(field.text, term) {
+					rank += field.weight
+				}
+			}
+		}
+
+		found := *task
+		found.Labels = labels
+		hits = append(hits, &repository.TaskSearchHit{Task: &found, Rank: rank})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Task.UpdatedAt.After(hits[j].Task.UpdatedAt)
+	})
+	if search.Limit > 0 && len(hits) > search.Limit {
+		hits = hits[:search.Limit]
+	}
+	return hits, nil
+}
+
+// UpdateTask 更新任务
+func (r *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &task.UpdatedAt)
+	r.s.tasks[task.ID] = r.s.stripTask(task)
+	r.s.recordTask(r.s.tasks[task.ID])
+	return nil
+}
+
+// DeleteTask 软删除任务及其子任务
+func (r *TaskRepository) DeleteTask(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.softDeleteTasks(softDelete(), func(task *models.Task) bool {
+		return task.UserID == userID && (task.ID == id || (task.ParentID != nil && *task.ParentID == id))
+	})
+	return nil
+}
+
+// SetTaskLabels 用labelIDs替换任务的全部标签
+func (r *TaskRepository) SetTaskLabels(ctx context.Context, taskID uuid.UUID, labelIDs []uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	set := make(map[uuid.UUID]bool, len(labelIDs))
+	for _, id := range labelIDs {
+		set[id] = true
+	}
+	r.s.taskLabels[taskID] = set
+	if task, ok := r.s.tasks[taskID]; ok {
+		r.s.recordTask(task)
+	}
+	return nil
+}
+
+// CreateRecurrenceException 为循环任务添加例外
+func (r *TaskRepository) CreateRecurrenceException(ctx context.Context, exception *models.TaskRecurrenceException) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := exception.BeforeCreate(nil); err != nil {
+		return err
+	}
+	stored := *exception
+	stored.RecurringTask = models.Task{}
+	stored.NewTask = nil
+	r.s.exceptions[exception.ID] = &stored
+	r.s.recordException(&stored)
+	return nil
+}
+
+// matchTaskFilter 判断任务是否满足过滤条件，调用方需持有锁
+func (s *Store) matchTaskFilter(task *models.Task, f repository.TaskFilter) bool {
+	if f.ProjectID != nil && task.ProjectID != *f.ProjectID {
+		return false
+	}
+	if f.ParentID != nil && (task.ParentID == nil || *task.ParentID != *f.ParentID) {
+		return false
+	}
+	if f.TopLevel && task.ParentID != nil {
+		return false
+	}
+	if f.Status != nil && task.Status != *f.Status {
+		return false
+	}
+	if f.Priority != nil && task.Priority != *f.Priority {
+		return false
+	}
+	if f.DueFrom != nil && (task.DueTime == nil || task.DueTime.Before(*f.DueFrom)) {
+		return false
+	}
+	if f.DueTo != nil && (task.DueTime == nil || !task.DueTime.Before(*f.DueTo)) {
+		return false
+	}
+	if f.Expr != nil {
+		subject := filter.Subject{Task: task}
+		if project, ok := s.projects[task.ProjectID]; ok {
+			subject.ProjectName = project.Name
+		}
+		for _, label := range s.taskLabelsOf(task.ID) {
+			subject.LabelNames = append(subject.LabelNames, label.Name)
+		}
+		return filter.Match(f.Expr, f.Now, subject)
+	}
+	return true
+}
+
+// softDeleteTasks 软删除满足条件的任务及其提醒，使用同一个删除时间，调用方需持有锁
+func (s *Store) softDeleteTasks(deletedAt gorm.DeletedAt, match func(task *models.Task) bool) {
+	for _, task := range s.tasks {
+		if isDeleted(task.DeletedAt) || !match(task) {
+			continue
+		}
+		task.DeletedAt = deletedAt
+		s.recordTask(task)
+		for _, reminder := range s.reminders {
+			if reminder.TaskID == task.ID && !isDeleted(reminder.DeletedAt) {
+				reminder.DeletedAt = deletedAt
+				s.recordReminder(reminder)
+			}
+		}
+	}
+}
+
+// stripTask 复制任务并去掉关联数据，关联关系由各自的表维护
+func (s *Store) stripTask(task *models.Task) *models.Task {
+	stored := *task
+	stored.User = models.User{}
+	stored.Project = models.Project{}
+	stored.Parent = nil
+	stored.SubTasks = nil
+	stored.Labels = nil
+	stored.Reminders = nil
+	stored.Exceptions = nil
+	return &stored
+}
+
+// taskLabelsOf 获取任务未删除的标签，调用方需持有锁
+func (s *Store) taskLabelsOf(taskID uuid.UUID) []models.Label {
+	labels := make([]models.Label, 0)
+	for labelID := range s.taskLabels[taskID] {
+		if label, ok := s.labels[labelID]; ok && !isDeleted(label.DeletedAt) {
+			labels = append(labels, *label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+// remindersOf 获取任务未删除的提醒，调用方需持有锁
+func (s *Store) remindersOf(taskID uuid.UUID) []models.Reminder {
+	reminders := make([]models.Reminder, 0)
+	for _, reminder := range s.reminders {
+		if reminder.TaskID == taskID && !isDeleted(reminder.DeletedAt) {
+			reminders = append(reminders, *reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+	})
+	return reminders
+}
+
+// exceptionsOf 获取循环任务未删除的例外，按原始实例时间排序，调用方需持有锁
+func (s *Store) exceptionsOf(taskID uuid.UUID) []models.TaskRecurrenceException {
+	exceptions := make([]models.TaskRecurrenceException, 0)
+	for _, exception := range s.exceptions {
+		if exception.RecurringTaskID == taskID && !isDeleted(exception.DeletedAt) {
+			exceptions = append(exceptions, *exception)
+		}
+	}
+	sort.Slice(exceptions, func(i, j int) bool {
+		return exceptions[i].OriginalTime.Before(exceptions[j].OriginalTime)
+	})
+	return exceptions
+}
+
+// LabelRepository 标签仓储的内存实现
+type LabelRepository struct{ s *Store }
+
+var _ repository.LabelRepository = (*LabelRepository)(nil)
+
+// CreateLabel 创建标签
+func (r *LabelRepository) CreateLabel(ctx context.Context, label *models.Label) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := label.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&label.CreatedAt, nil)
+	stored := *label
+	stored.Tasks = nil
+	r.s.labels[label.ID] = &stored
+	r.s.recordLabel(&stored)
+	return nil
+}
+
+// GetLabelByID 根据ID获取用户的标签
+func (r *LabelRepository) GetLabelByID(ctx context.Context, userID, id uuid.UUID) (*models.Label, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	label, ok := r.s.labels[id]
+	if !ok || label.UserID != userID || isDeleted(label.DeletedAt) {
+		return nil, nil
+	}
+	found := *label
+	return &found, nil
+}
+
+// GetLabelsByIDs 获取属于用户的指定标签
+func (r *LabelRepository) GetLabelsByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*models.Label, error) {
+	labels := make([]*models.Label, 0, len(ids))
+	for _, id := range ids {
+		label, err := r.GetLabelByID(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if label != nil {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+// ListLabels 按分页请求获取用户的标签
+func (r *LabelRepository) ListLabels(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Label, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	labels := make([]*models.Label, 0)
+	for _, label := range r.s.labels {
+		if label.UserID == userID && !isDeleted(label.DeletedAt) {
+			found := *label
+			labels = append(labels, &found)
+		}
+	}
+	return paginate(labels, page, func(label *models.Label) (interface{}, uuid.UUID) {
+		return repository.LabelSortValue(label, page.Sort.Field.Name), label.ID
+	}), nil
+}
+
+// UpdateLabel 更新标签
+func (r *LabelRepository) UpdateLabel(ctx context.Context, label *models.Label) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *label
+	stored.Tasks = nil
+	r.s.labels[label.ID] = &stored
+	r.s.recordLabel(&stored)
+	r.s.recordTasksWithLabel(label.ID)
+	return nil
+}
+
+// DeleteLabel 软删除标签并解除与任务的关联
+func (r *LabelRepository) DeleteLabel(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	label, ok := r.s.labels[id]
+	if !ok || label.UserID != userID || isDeleted(label.DeletedAt) {
+		return nil
+	}
+	label.DeletedAt = softDelete()
+	r.s.recordLabel(label)
+	r.s.recordTasksWithLabel(id)
+	for _, set := range r.s.taskLabels {
+		delete(set, id)
+	}
+	return nil
+}
+
+// LabelNameExists 检查用户下是否存在同名标签
+func (r *LabelRepository) LabelNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, label := range r.s.labels {
+		if label.UserID == userID && label.Name == name && label.ID != excludeID && !isDeleted(label.DeletedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReminderRepository 提醒仓储的内存实现
+type ReminderRepository struct{ s *Store }
+
+var _ repository.ReminderRepository = (*ReminderRepository)(nil)
+
+// CreateReminder 创建提醒
+func (r *ReminderRepository) CreateReminder(ctx context.Context, reminder *models.Reminder) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := reminder.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&reminder.CreatedAt, nil)
+	stored := *reminder
+	stored.Task = models.Task{}
+	r.s.reminders[reminder.ID] = &stored
+	r.s.recordReminder(&stored)
+	return nil
+}
+
+// GetReminderByID 根据ID获取任务的提醒
+func (r *ReminderRepository) GetReminderByID(ctx context.Context, taskID, id uuid.UUID) (*models.Reminder, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	reminder, ok := r.s.reminders[id]
+	if !ok || reminder.TaskID != taskID || isDeleted(reminder.DeletedAt) {
+		return nil, nil
+	}
+	found := *reminder
+	return &found, nil
+}
+
+// ListReminders 按分页请求获取任务的提醒
+func (r *ReminderRepository) ListReminders(ctx context.Context, taskID uuid.UUID, page pagination.Request) ([]*models.Reminder, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	reminders := make([]*models.Reminder, 0)
+	for _, reminder := range r.s.remindersOf(taskID) {
+		reminder := reminder
+		reminders = append(reminders, &reminder)
+	}
+	return paginate(reminders, page, func(reminder *models.Reminder) (interface{}, uuid.UUID) {
+		return repository.ReminderSortValue(reminder, page.Sort.Field.Name), reminder.ID
+	}), nil
+}
+
+// DeleteReminder 软删除提醒
+func (r *ReminderRepository) DeleteReminder(ctx context.Context, taskID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if reminder, ok := r.s.reminders[id]; ok && reminder.TaskID == taskID {
+		reminder.DeletedAt = softDelete()
+		r.s.recordReminder(reminder)
+	}
+	return nil
+}
+
+// CountPending 统计尚未到达提醒时间的提醒数量
+func (r *ReminderRepository) CountPending(ctx context.Context) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	now := time.Now()
+	var count int64
+	for _, reminder := range r.s.reminders {
+		if !isDeleted(reminder.DeletedAt) && !reminder.RemindAt.Before(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListDueReminders 获取提醒时间在(from, to]之间、所属任务未删除且未完成的提醒
+func (r *ReminderRepository) ListDueReminders(ctx context.Context, from, to time.Time) ([]*models.Reminder, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	reminders := make([]*models.Reminder, 0)
+	for _, reminder := range r.s.reminders {
+		if isDeleted(reminder.DeletedAt) || !reminder.RemindAt.After(from) || reminder.RemindAt.After(to) {
+			continue
+		}
+		task, ok := r.s.tasks[reminder.TaskID]
+		if !ok || isDeleted(task.DeletedAt) || task.IsCompleted() {
+			continue
+		}
+		found := *reminder
+		found.Task = *task
+		reminders = append(reminders, &found)
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		}
+		return reminders[i].ID.String() < reminders[j].ID.String()
+	})
+	return reminders, nil
+}
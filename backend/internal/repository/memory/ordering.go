@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// sortKey 参与手动排序的记录
+type sortKey struct {
+	id  uuid.UUID
+	key string
+}
+
+// neighborKey 在keys中查找与key相邻的排序键，与数据库实现保持一致
+func neighborKey(keys []sortKey, key string, next bool) string {
+	found := ""
+	for _, k := range keys {
+		switch {
+		case next && k.key > key && (found == "" || k.key < found):
+			found = k.key
+		case !next && (key == "" || k.key < key) && k.key > found:
+			found = k.key
+		}
+	}
+	return found
+}
+
+// sortedIDs 按排序键和ID排序后返回ID
+func sortedIDs(keys []sortKey) []uuid.UUID {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].key != keys[j].key {
+			return keys[i].key < keys[j].key
+		}
+		return bytes.Compare(keys[i].id[:], keys[j].id[:]) < 0
+	})
+	ids := make([]uuid.UUID, len(keys))
+	for i, k := range keys {
+		ids[i] = k.id
+	}
+	return ids
+}
+
+// projectKeys 获取用户未删除项目的排序键，调用方需持有锁
+func (s *Store) projectKeys(userID uuid.UUID) []sortKey {
+	keys := make([]sortKey, 0)
+	for _, project := range s.projects {
+		if project.UserID == userID && !isDeleted(project.DeletedAt) {
+			keys = append(keys, sortKey{id: project.ID, key: project.SortOrder})
+		}
+	}
+	return keys
+}
+
+// ProjectSortOrderNeighbor 获取与key相邻的项目排序键
+func (r *ProjectRepository) ProjectSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := r.s.projectKeys(userID)
+	for i, k := range keys {
+		if k.id == excludeID {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	return neighborKey(keys, key, next), nil
+}
+
+// ListUsersWithLongProjectSortOrder 获取存在过长项目排序键的用户
+func (r *ProjectRepository) ListUsersWithLongProjectSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	seen := make(map[uuid.UUID]bool)
+	userIDs := make([]uuid.UUID, 0)
+	for _, project := range r.s.projects {
+		if !isDeleted(project.DeletedAt) && len(project.SortOrder) > maxLen && !seen[project.UserID] {
+			seen[project.UserID] = true
+			userIDs = append(userIDs, project.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// ListProjectIDsBySortOrder 按排序键获取用户全部项目的ID
+func (r *ProjectRepository) ListProjectIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return sortedIDs(r.s.projectKeys(userID)), nil
+}
+
+// SetProjectSortOrders 批量修改项目的排序键
+func (r *ProjectRepository) SetProjectSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, key := range orders {
+		if project, ok := r.s.projects[id]; ok {
+			project.SortOrder = key
+			r.s.recordProject(project)
+		}
+	}
+	return nil
+}
+
+// inTaskScope 判断任务是否属于排序范围
+func inTaskScope(task *models.Task, scope repository.TaskScope) bool {
+	if task.UserID != scope.UserID || task.ProjectID != scope.ProjectID || isDeleted(task.DeletedAt) {
+		return false
+	}
+	if scope.ParentID == nil {
+		return task.ParentID == nil
+	}
+	return task.ParentID != nil && *task.ParentID == *scope.ParentID
+}
+
+// taskKeys 获取同级任务的排序键，调用方需持有锁
+func (s *Store) taskKeys(scope repository.TaskScope, excludeID uuid.UUID) []sortKey {
+	keys := make([]sortKey, 0)
+	for _, task := range s.tasks {
+		if task.ID != excludeID && inTaskScope(task, scope) {
+			keys = append(keys, sortKey{id: task.ID, key: task.SortOrder})
+		}
+	}
+	return keys
+}
+
+// TaskSortOrderNeighbor 获取同级任务中与key相邻的排序键
+func (r *TaskRepository) TaskSortOrderNeighbor(ctx context.Context, scope repository.TaskScope, key string, next bool, excludeID uuid.UUID) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return neighborKey(r.s.taskKeys(scope, excludeID), key, next), nil
+}
+
+// ListTaskScopesWithLongSortOrder 获取存在过长任务排序键的排序范围
+func (r *TaskRepository) ListTaskScopesWithLongSortOrder(ctx context.Context, maxLen int) ([]repository.TaskScope, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	type scopeKey struct{ userID, projectID, parentID uuid.UUID }
+	seen := make(map[scopeKey]bool)
+	scopes := make([]repository.TaskScope, 0)
+	for _, task := range r.s.tasks {
+		if isDeleted(task.DeletedAt) || len(task.SortOrder) <= maxLen {
+			continue
+		}
+		k := scopeKey{userID: task.UserID, projectID: task.ProjectID}
+		if task.ParentID != nil {
+			k.parentID = *task.ParentID
+		}
+		if !seen[k] {
+			seen[k] = true
+			scope := repository.TaskScope{UserID: task.UserID, ProjectID: task.ProjectID}
+			if task.ParentID != nil {
+				parentID := *task.ParentID
+				scope.ParentID = &parentID
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// ListTaskIDsBySortOrder 按排序键获取同级任务的ID
+func (r *TaskRepository) ListTaskIDsBySortOrder(ctx context.Context, scope repository.TaskScope) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return sortedIDs(r.s.taskKeys(scope, uuid.Nil)), nil
+}
+
+// SetTaskSortOrders 批量修改任务的排序键
+func (r *TaskRepository) SetTaskSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, key := range orders {
+		if task, ok := r.s.tasks[id]; ok {
+			task.SortOrder = key
+			r.s.recordTask(task)
+		}
+	}
+	return nil
+}
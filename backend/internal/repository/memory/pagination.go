@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"bytes"
+	"sort"
+
+	"ticktick-backend/internal/pagination"
+
+	"github.com/google/uuid"
+)
+
+// paginate 与数据库实现保持一致：按排序字段和ID排序，跳过游标之前的记录，多返回一条用于判断是否还有下一页
+func paginate[T any](items []T, page pagination.Request, position func(T) (interface{}, uuid.UUID)) []T {
+	if page.Sort.Field.Name == "" {
+		return items
+	}
+
+	compare := func(av interface{}, aid uuid.UUID, bv interface{}, bid uuid.UUID) int {
+		if c := pagination.Compare(av, bv, page.Sort.Desc); c != 0 {
+			return c
+		}
+		return bytes.Compare(aid[:], bid[:])
+	}
+	sort.Slice(items, func(i, j int) bool {
+		av, aid := position(items[i])
+		bv, bid := position(items[j])
+		return compare(av, aid, bv, bid) < 0
+	})
+
+	if after := page.After; after != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			value, id := position(items[i])
+			return compare(value, id, after.Value, after.ID) > 0
+		})
+		items = items[start:]
+	}
+	if page.Limit > 0 && len(items) > page.Limit+1 {
+		items = items[:page.Limit+1]
+	}
+	return items
+}
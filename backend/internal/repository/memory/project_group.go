@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ProjectGroupRepository 项目分组仓储的内存实现
+type ProjectGroupRepository struct{ s *Store }
+
+var _ repository.ProjectGroupRepository = (*ProjectGroupRepository)(nil)
+
+// CreateProjectGroup 创建分组
+func (r *ProjectGroupRepository) CreateProjectGroup(ctx context.Context, group *models.ProjectGroup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := group.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&group.CreatedAt, &group.UpdatedAt)
+	stored := *group
+	r.s.projectGroups[group.ID] = &stored
+	return nil
+}
+
+// GetProjectGroupByID 根据ID获取用户的分组
+func (r *ProjectGroupRepository) GetProjectGroupByID(ctx context.Context, userID, id uuid.UUID) (*models.ProjectGroup, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	group, ok := r.s.projectGroups[id]
+	if !ok || group.UserID != userID {
+		return nil, nil
+	}
+	found := *group
+	return &found, nil
+}
+
+// ListProjectGroups 按分页请求获取用户的分组
+func (r *ProjectGroupRepository) ListProjectGroups(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.ProjectGroup, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	groups := make([]*models.ProjectGroup, 0)
+	for _, group := range r.s.projectGroups {
+		if group.UserID == userID {
+			found := *group
+			groups = append(groups, &found)
+		}
+	}
+	return paginate(groups, page, func(group *models.ProjectGroup) (interface{}, uuid.UUID) {
+		return repository.ProjectGroupSortValue(group, page.Sort.Field.Name), group.ID
+	}), nil
+}
+
+// UpdateProjectGroup 更新分组
+func (r *ProjectGroupRepository) UpdateProjectGroup(ctx context.Context, group *models.ProjectGroup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &group.UpdatedAt)
+	stored := *group
+	r.s.projectGroups[group.ID] = &stored
+	return nil
+}
+
+// DeleteProjectGroup 删除分组，其中的项目（包括回收站中的）变为未分组
+func (r *ProjectGroupRepository) DeleteProjectGroup(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	group, ok := r.s.projectGroups[id]
+	if !ok || group.UserID != userID {
+		return nil
+	}
+	for _, project := range r.s.projects {
+		if project.GroupID != nil && *project.GroupID == id {
+			project.GroupID = nil
+			r.s.recordProject(project)
+		}
+	}
+	delete(r.s.projectGroups, id)
+	return nil
+}
+
+// ProjectGroupNameExists 检查用户下是否存在同名分组
+func (r *ProjectGroupRepository) ProjectGroupNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, group := range r.s.projectGroups {
+		if group.UserID == userID && group.Name == name && group.ID != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// projectGroupKeys 获取用户分组的排序键，调用方需持有锁
+func (s *Store) projectGroupKeys(userID uuid.UUID) []sortKey {
+	keys := make([]sortKey, 0)
+	for _, group := range s.projectGroups {
+		if group.UserID == userID {
+			keys = append(keys, sortKey{id: group.ID, key: group.SortOrder})
+		}
+	}
+	return keys
+}
+
+// ProjectGroupSortOrderNeighbor 获取用户分组中与key相邻的排序键
+func (r *ProjectGroupRepository) ProjectGroupSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := r.s.projectGroupKeys(userID)
+	for i, k := range keys {
+		if k.id == excludeID {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	return neighborKey(keys, key, next), nil
+}
+
+// ListUsersWithLongProjectGroupSortOrder 获取存在过长分组排序键的用户
+func (r *ProjectGroupRepository) ListUsersWithLongProjectGroupSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	seen := make(map[uuid.UUID]bool)
+	userIDs := make([]uuid.UUID, 0)
+	for _, group := range r.s.projectGroups {
+		if len(group.SortOrder) > maxLen && !seen[group.UserID] {
+			seen[group.UserID] = true
+			userIDs = append(userIDs, group.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// ListProjectGroupIDsBySortOrder 按排序键获取用户全部分组的ID
+func (r *ProjectGroupRepository) ListProjectGroupIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return sortedIDs(r.s.projectGroupKeys(userID)), nil
+}
+
+// SetProjectGroupSortOrders 批量修改分组的排序键
+func (r *ProjectGroupRepository) SetProjectGroupSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, key := range orders {
+		if group, ok := r.s.projectGroups[id]; ok {
+			group.SortOrder = key
+		}
+	}
+	return nil
+}
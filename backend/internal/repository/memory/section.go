@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// SectionRepository 项目分栏仓储的内存实现
+type SectionRepository struct{ s *Store }
+
+var _ repository.SectionRepository = (*SectionRepository)(nil)
+
+// CreateSection 创建分栏
+func (r *SectionRepository) CreateSection(ctx context.Context, section *models.Section) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := section.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&section.CreatedAt, &section.UpdatedAt)
+	stored := *section
+	r.s.sections[section.ID] = &stored
+	return nil
+}
+
+// GetSectionByID 根据ID获取用户的分栏
+func (r *SectionRepository) GetSectionByID(ctx context.Context, userID, id uuid.UUID) (*models.Section, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	section, ok := r.s.sections[id]
+	if !ok || section.UserID != userID {
+		return nil, nil
+	}
+	found := *section
+	return &found, nil
+}
+
+// ListSections 按分页请求获取项目的分栏
+func (r *SectionRepository) ListSections(ctx context.Context, userID, projectID uuid.UUID, page pagination.Request) ([]*models.Section, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	sections := make([]*models.Section, 0)
+	for _, section := range r.s.sections {
+		if section.UserID == userID && section.ProjectID == projectID {
+			found := *section
+			sections = append(sections, &found)
+		}
+	}
+	return paginate(sections, page, func(section *models.Section) (interface{}, uuid.UUID) {
+		return repository.SectionSortValue(section, page.Sort.Field.Name), section.ID
+	}), nil
+}
+
+// UpdateSection 更新分栏
+func (r *SectionRepository) UpdateSection(ctx context.Context, section *models.Section) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &section.UpdatedAt)
+	stored := *section
+	r.s.sections[section.ID] = &stored
+	return nil
+}
+
+// DeleteSection 删除分栏，其中的任务（包括回收站中的）移到默认分栏
+func (r *SectionRepository) DeleteSection(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	section, ok := r.s.sections[id]
+	if !ok || section.UserID != userID {
+		return nil
+	}
+	for _, task := range r.s.tasks {
+		if task.SectionID != nil && *task.SectionID == id {
+			task.SectionID = nil
+			r.s.recordTask(task)
+		}
+	}
+	delete(r.s.sections, id)
+	return nil
+}
+
+// sectionKeys 获取项目分栏的排序键，调用方需持有锁
+func (s *Store) sectionKeys(projectID uuid.UUID) []sortKey {
+	keys := make([]sortKey, 0)
+	for _, section := range s.sections {
+		if section.ProjectID == projectID {
+			keys = append(keys, sortKey{id: section.ID, key: section.SortOrder})
+		}
+	}
+	return keys
+}
+
+// SectionSortOrderNeighbor 获取项目中与key相邻的分栏排序键
+func (r *SectionRepository) SectionSortOrderNeighbor(ctx context.Context, projectID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := r.s.sectionKeys(projectID)
+	for i, k := range keys {
+		if k.id == excludeID {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	return neighborKey(keys, key, next), nil
+}
+
+// ListProjectsWithLongSectionSortOrder 获取存在过长分栏排序键的项目
+func (r *SectionRepository) ListProjectsWithLongSectionSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	seen := make(map[uuid.UUID]bool)
+	projectIDs := make([]uuid.UUID, 0)
+	for _, section := range r.s.sections {
+		if len(section.SortOrder) > maxLen && !seen[section.ProjectID] {
+			seen[section.ProjectID] = true
+			projectIDs = append(projectIDs, section.ProjectID)
+		}
+	}
+	return projectIDs, nil
+}
+
+// ListSectionIDsBySortOrder 按排序键获取项目全部分栏的ID
+func (r *SectionRepository) ListSectionIDsBySortOrder(ctx context.Context, projectID uuid.UUID) ([]uuid.UUID, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return sortedIDs(r.s.sectionKeys(projectID)), nil
+}
+
+// SetSectionSortOrders 批量修改分栏的排序键
+func (r *SectionRepository) SetSectionSortOrders(ctx context.Context, orders map[uuid.UUID]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, key := range orders {
+		if section, ok := r.s.sections[id]; ok {
+			section.SortOrder = key
+		}
+	}
+	return nil
+}
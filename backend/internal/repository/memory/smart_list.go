@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// SmartListRepository 智能清单仓储的内存实现
+type SmartListRepository struct{ s *Store }
+
+var _ repository.SmartListRepository = (*SmartListRepository)(nil)
+
+// CreateSmartList 创建智能清单
+func (r *SmartListRepository) CreateSmartList(ctx context.Context, list *models.SmartList) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := list.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&list.CreatedAt, &list.UpdatedAt)
+	stored := *list
+	r.s.smartLists[list.ID] = &stored
+	return nil
+}
+
+// GetSmartListByID 根据ID获取用户的智能清单
+func (r *SmartListRepository) GetSmartListByID(ctx context.Context, userID, id uuid.UUID) (*models.SmartList, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	list, ok := r.s.smartLists[id]
+	if !ok || list.UserID != userID {
+		return nil, nil
+	}
+	found := *list
+	return &found, nil
+}
+
+// ListSmartLists 按分页请求获取用户的智能清单
+func (r *SmartListRepository) ListSmartLists(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.SmartList, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	lists := make([]*models.SmartList, 0)
+	for _, list := range r.s.smartLists {
+		if list.UserID == userID {
+			found := *list
+			lists = append(lists, &found)
+		}
+	}
+	return paginate(lists, page, func(list *models.SmartList) (interface{}, uuid.UUID) {
+		return repository.SmartListSortValue(list, page.Sort.Field.Name), list.ID
+	}), nil
+}
+
+// UpdateSmartList 更新智能清单
+func (r *SmartListRepository) UpdateSmartList(ctx context.Context, list *models.SmartList) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &list.UpdatedAt)
+	stored := *list
+	r.s.smartLists[list.ID] = &stored
+	return nil
+}
+
+// DeleteSmartList 删除智能清单
+func (r *SmartListRepository) DeleteSmartList(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if list, ok := r.s.smartLists[id]; ok && list.UserID == userID {
+		delete(r.s.smartLists, id)
+	}
+	return nil
+}
+
+// SmartListNameExists 检查用户下是否存在同名智能清单
+func (r *SmartListRepository) SmartListNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, list := range r.s.smartLists {
+		if list.UserID == userID && list.Name == name && list.ID != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// syncKey 变更日志的键，与数据库中sync_changes的主键对应
+type syncKey struct {
+	entityType string
+	id         uuid.UUID
+}
+
+// recordChange 递增用户的变更序号并记录实体最近一次变更，调用方需持有写锁
+// 对应PostgreSQL中由触发器维护的变更日志，各仓储在写入时调用
+func (s *Store) recordChange(userID uuid.UUID, entityType string, id uuid.UUID, deleted bool) {
+	if userID == uuid.Nil {
+		return
+	}
+	s.syncSeqs[userID]++
+	s.syncChanges[syncKey{entityType, id}] = &models.SyncChange{
+		EntityType: entityType,
+		EntityID:   id,
+		UserID:     userID,
+		Seq:        s.syncSeqs[userID],
+		Deleted:    deleted,
+		ChangedAt:  time.Now(),
+	}
+}
+
+// recordProject 记录项目的变更，调用方需持有写锁
+func (s *Store) recordProject(project *models.Project) {
+	s.recordChange(project.UserID, models.SyncEntityProject, project.ID, isDeleted(project.DeletedAt))
+}
+
+// recordLabel 记录标签的变更，调用方需持有写锁
+func (s *Store) recordLabel(label *models.Label) {
+	s.recordChange(label.UserID, models.SyncEntityLabel, label.ID, isDeleted(label.DeletedAt))
+}
+
+// recordTask 记录任务的变更，调用方需持有写锁
+func (s *Store) recordTask(task *models.Task) {
+	s.recordChange(task.UserID, models.SyncEntityTask, task.ID, isDeleted(task.DeletedAt))
+}
+
+// recordTasksWithLabel 标签改名或删除时记录关联的任务，对应数据库中tasks.label_names的同步，调用方需持有写锁
+func (s *Store) recordTasksWithLabel(labelID uuid.UUID) {
+	for taskID, set := range s.taskLabels {
+		if task, ok := s.tasks[taskID]; ok && set[labelID] {
+			s.recordTask(task)
+		}
+	}
+}
+
+// recordReminder 记录提醒的变更，用户通过所属任务确定，调用方需持有写锁
+func (s *Store) recordReminder(reminder *models.Reminder) {
+	if task, ok := s.tasks[reminder.TaskID]; ok {
+		s.recordChange(task.UserID, models.SyncEntityReminder, reminder.ID, isDeleted(reminder.DeletedAt))
+	}
+}
+
+// recordException 记录循环例外的变更，用户通过循环任务确定，调用方需持有写锁
+func (s *Store) recordException(exception *models.TaskRecurrenceException) {
+	if task, ok := s.tasks[exception.RecurringTaskID]; ok {
+		s.recordChange(task.UserID, models.SyncEntityException, exception.ID, isDeleted(exception.DeletedAt))
+	}
+}
+
+// SyncRepository 增量同步仓储的内存实现
+type SyncRepository struct{ s *Store }
+
+var _ repository.SyncRepository = (*SyncRepository)(nil)
+
+// GetSyncSeq 获取用户当前的变更序号
+func (r *SyncRepository) GetSyncSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return r.s.syncSeqs[userID], nil
+}
+
+// ListSyncChanges 获取用户序号大于since的变更
+func (r *SyncRepository) ListSyncChanges(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*models.SyncChange, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	changes := make([]*models.SyncChange, 0)
+	for _, change := range r.s.syncChanges {
+		if change.UserID == userID && change.Seq > since {
+			found := *change
+			changes = append(changes, &found)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Seq < changes[j].Seq
+	})
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// GetSyncChange 获取实体最近一次变更
+func (r *SyncRepository) GetSyncChange(ctx context.Context, entityType string, id uuid.UUID) (*models.SyncChange, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	change, ok := r.s.syncChanges[syncKey{entityType, id}]
+	if !ok {
+		return nil, nil
+	}
+	found := *change
+	return &found, nil
+}
+
+// GetSyncEntities 按实体类型和ID批量获取用户未删除的实体
+func (r *SyncRepository) GetSyncEntities(ctx context.Context, userID uuid.UUID, ids map[string][]uuid.UUID) (*repository.SyncEntities, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	entities := &repository.SyncEntities{}
+	for _, id := range ids[models.SyncEntityProject] {
+		if project, ok := r.s.projects[id]; ok && project.UserID == userID && !isDeleted(project.DeletedAt) {
+			found := *project
+			entities.Projects = append(entities.Projects, &found)
+		}
+	}
+	for _, id := range ids[models.SyncEntityLabel] {
+		if label, ok := r.s.labels[id]; ok && label.UserID == userID && !isDeleted(label.DeletedAt) {
+			found := *label
+			entities.Labels = append(entities.Labels, &found)
+		}
+	}
+	for _, id := range ids[models.SyncEntityTask] {
+		if task, ok := r.s.tasks[id]; ok && task.UserID == userID && !isDeleted(task.DeletedAt) {
+			found := *task
+			found.Labels = r.s.taskLabelsOf(id)
+			entities.Tasks = append(entities.Tasks, &found)
+		}
+	}
+	for _, id := range ids[models.SyncEntityReminder] {
+		if reminder, ok := r.s.reminders[id]; ok && !isDeleted(reminder.DeletedAt) && r.s.ownsTask(userID, reminder.TaskID) {
+			found := *reminder
+			entities.Reminders = append(entities.Reminders, &found)
+		}
+	}
+	for _, id := range ids[models.SyncEntityException] {
+		if exception, ok := r.s.exceptions[id]; ok && !isDeleted(exception.DeletedAt) && r.s.ownsTask(userID, exception.RecurringTaskID) {
+			found := *exception
+			entities.Exceptions = append(entities.Exceptions, &found)
+		}
+	}
+	return entities, nil
+}
+
+// ownsTask 判断任务是否属于用户且未删除，调用方需持有锁
+func (s *Store) ownsTask(userID, taskID uuid.UUID) bool {
+	task, ok := s.tasks[taskID]
+	return ok && task.UserID == userID && !isDeleted(task.DeletedAt)
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrashRepository 回收站仓储的内存实现
+type TrashRepository struct{ s *Store }
+
+var _ repository.TrashRepository = (*TrashRepository)(nil)
+
+// ListDeletedProjects 按分页请求获取用户已删除的项目
+func (r *TrashRepository) ListDeletedProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	projects := make([]*models.Project, 0)
+	for _, project := range r.s.projects {
+		if project.UserID == userID && isDeleted(project.DeletedAt) {
+			found := *project
+			projects = append(projects, &found)
+		}
+	}
+	return paginate(projects, page, func(project *models.Project) (interface{}, uuid.UUID) {
+		return repository.TrashedProjectSortValue(project, page.Sort.Field.Name), project.ID
+	}), nil
+}
+
+// ListDeletedTasks 按分页请求获取回收站中的任务
+func (r *TrashRepository) ListDeletedTasks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tasks := make([]*models.Task, 0)
+	for _, task := range r.s.tasks {
+		if task.UserID != userID || !isDeleted(task.DeletedAt) {
+			continue
+		}
+		// 随项目或父任务一起删除的任务不单独列出
+		if project, ok := r.s.projects[task.ProjectID]; ok && isDeleted(project.DeletedAt) {
+			continue
+		}
+		if task.ParentID != nil {
+			if parent, ok := r.s.tasks[*task.ParentID]; ok && isDeleted(parent.DeletedAt) {
+				continue
+			}
+		}
+
+		found := *task
+		found.Labels = r.s.taskLabelsOf(task.ID)
+		tasks = append(tasks, &found)
+	}
+	return paginate(tasks, page, func(task *models.Task) (interface{}, uuid.UUID) {
+		return repository.TrashedTaskSortValue(task, page.Sort.Field.Name), task.ID
+	}), nil
+}
+
+// GetDeletedProject 获取用户已删除的项目
+func (r *TrashRepository) GetDeletedProject(ctx context.Context, userID, id uuid.UUID) (*models.Project, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	project, ok := r.s.projects[id]
+	if !ok || project.UserID != userID || !isDeleted(project.DeletedAt) {
+		return nil, nil
+	}
+	found := *project
+	return &found, nil
+}
+
+// GetDeletedTask 获取用户已删除的任务
+func (r *TrashRepository) GetDeletedTask(ctx context.Context, userID, id uuid.UUID) (*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	task, ok := r.s.tasks[id]
+	if !ok || task.UserID != userID || !isDeleted(task.DeletedAt) {
+		return nil, nil
+	}
+	found := *task
+	return &found, nil
+}
+
+// RestoreProject 恢复项目以及随项目一起删除的任务和提醒
+func (r *TrashRepository) RestoreProject(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	project, ok := r.s.projects[id]
+	if !ok || project.UserID != userID || !isDeleted(project.DeletedAt) {
+		return nil
+	}
+
+	deletedAt := project.DeletedAt.Time
+	project.DeletedAt = gorm.DeletedAt{}
+	r.s.recordProject(project)
+	r.s.restoreTasks(deletedAt, func(task *models.Task) bool {
+		return task.UserID == userID && task.ProjectID == id
+	})
+	return nil
+}
+
+// RestoreTask 恢复任务以及随任务一起删除的子任务和提醒
+func (r *TrashRepository) RestoreTask(ctx context.Context, userID, id uuid.UUID, detachParent bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.tasks[id]
+	if !ok || task.UserID != userID || !isDeleted(task.DeletedAt) {
+		return nil
+	}
+
+	r.s.restoreTasks(task.DeletedAt.Time, func(t *models.Task) bool {
+		return t.UserID == userID && (t.ID == id || (t.ParentID != nil && *t.ParentID == id))
+	})
+	if detachParent {
+		task.ParentID = nil
+		r.s.recordTask(task)
+	}
+	return nil
+}
+
+// PurgeProject 彻底删除回收站中的项目及其所有任务
+func (r *TrashRepository) PurgeProject(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	project, ok := r.s.projects[id]
+	if !ok || project.UserID != userID || !isDeleted(project.DeletedAt) {
+		return nil
+	}
+
+	delete(r.s.projects, id)
+	r.s.purgeTasks(func(task *models.Task) bool {
+		return task.UserID == userID && task.ProjectID == id
+	})
+	r.s.purgeSections(func(projectID uuid.UUID) bool { return projectID == id })
+	return nil
+}
+
+// PurgeTask 彻底删除回收站中的任务及其子任务
+func (r *TrashRepository) PurgeTask(ctx context.Context, userID, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.purgeTasks(func(task *models.Task) bool {
+		return task.UserID == userID && isDeleted(task.DeletedAt) &&
+			(task.ID == id || (task.ParentID != nil && *task.ParentID == id))
+	})
+	return nil
+}
+
+// EmptyTrash 彻底删除用户所有已软删除的数据
+func (r *TrashRepository) EmptyTrash(ctx context.Context, userID uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.purgeTasks(func(task *models.Task) bool {
+		return task.UserID == userID && isDeleted(task.DeletedAt)
+	})
+	for id, reminder := range r.s.reminders {
+		if task, ok := r.s.tasks[reminder.TaskID]; ok && task.UserID == userID && isDeleted(reminder.DeletedAt) {
+			delete(r.s.reminders, id)
+		}
+	}
+	for id, label := range r.s.labels {
+		if label.UserID == userID && isDeleted(label.DeletedAt) {
+			delete(r.s.labels, id)
+		}
+	}
+	for id, project := range r.s.projects {
+		if project.UserID == userID && isDeleted(project.DeletedAt) {
+			r.s.purgeSections(func(projectID uuid.UUID) bool { return projectID == id })
+			delete(r.s.projects, id)
+		}
+	}
+	return nil
+}
+
+// PurgeDeletedBefore 彻底删除所有在cutoff之前软删除的数据
+func (r *TrashRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	expired := func(deletedAt gorm.DeletedAt) bool {
+		return isDeleted(deletedAt) && deletedAt.Time.Before(cutoff)
+	}
+
+	purged := map[string]int64{
+		"tasks": r.s.purgeTasks(func(task *models.Task) bool { return expired(task.DeletedAt) }),
+	}
+	for id, reminder := range r.s.reminders {
+		if expired(reminder.DeletedAt) {
+			delete(r.s.reminders, id)
+			purged["reminders"]++
+		}
+	}
+	for id, label := range r.s.labels {
+		if expired(label.DeletedAt) {
+			delete(r.s.labels, id)
+			purged["labels"]++
+		}
+	}
+	for id, project := range r.s.projects {
+		if expired(project.DeletedAt) {
+			purged["sections"] += r.s.purgeSections(func(projectID uuid.UUID) bool { return projectID == id })
+			delete(r.s.projects, id)
+			purged["projects"]++
+		}
+	}
+	return purged, nil
+}
+
+// restoreTasks 恢复满足条件且与deletedAt同时删除的任务及其提醒，调用方需持有锁
+func (s *Store) restoreTasks(deletedAt time.Time, match func(task *models.Task) bool) {
+	for _, task := range s.tasks {
+		if !isDeleted(task.DeletedAt) || !task.DeletedAt.Time.Equal(deletedAt) || !match(task) {
+			continue
+		}
+		task.DeletedAt = gorm.DeletedAt{}
+		s.recordTask(task)
+		for _, reminder := range s.reminders {
+			if reminder.TaskID == task.ID && isDeleted(reminder.DeletedAt) && reminder.DeletedAt.Time.Equal(deletedAt) {
+				reminder.DeletedAt = gorm.DeletedAt{}
+				s.recordReminder(reminder)
+			}
+		}
+	}
+}
+
+// purgeTasks 彻底删除满足条件的任务及其标签关联、提醒和循环例外，返回删除的任务数，调用方需持有锁
+func (s *Store) purgeTasks(match func(task *models.Task) bool) int64 {
+	var purged int64
+	for id, task := range s.tasks {
+		if !match(task) {
+			continue
+		}
+		delete(s.tasks, id)
+		delete(s.taskLabels, id)
+		for reminderID, reminder := range s.reminders {
+			if reminder.TaskID == id {
+				delete(s.reminders, reminderID)
+			}
+		}
+		for exceptionID, exception := range s.exceptions {
+			if exception.RecurringTaskID == id {
+				// 任务已在回收站中，未删除的例外在这里才留下墓碑
+				if !isDeleted(exception.DeletedAt) {
+					s.recordChange(task.UserID, models.SyncEntityException, exceptionID, true)
+				}
+				delete(s.exceptions, exceptionID)
+			}
+		}
+		purged++
+	}
+	return purged
+}
+
+// purgeSections 删除满足条件的项目下的分栏，返回删除的分栏数，调用方需持有锁
+func (s *Store) purgeSections(match func(projectID uuid.UUID) bool) int64 {
+	var purged int64
+	for id, section := range s.sections {
+		if match(section.ProjectID) {
+			delete(s.sections, id)
+			purged++
+		}
+	}
+	return purged
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// UserSettingsRepository 用户设置仓储的内存实现
+type UserSettingsRepository struct{ s *Store }
+
+var _ repository.UserSettingsRepository = (*UserSettingsRepository)(nil)
+
+// GetUserSettings 获取用户设置
+func (r *UserSettingsRepository) GetUserSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	settings, ok := r.s.userSettings[userID]
+	if !ok {
+		return nil, nil
+	}
+	found := *settings
+	return &found, nil
+}
+
+// SaveUserSettings 保存用户设置，已存在时保留创建时间
+func (r *UserSettingsRepository) SaveUserSettings(ctx context.Context, settings *models.UserSettings) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if existing, ok := r.s.userSettings[settings.UserID]; ok {
+		settings.CreatedAt = existing.CreatedAt
+	}
+	touch(&settings.CreatedAt, &settings.UpdatedAt)
+	stored := *settings
+	r.s.userSettings[settings.UserID] = &stored
+	return nil
+}
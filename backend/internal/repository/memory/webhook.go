@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepository Webhook仓储的内存实现
+type WebhookRepository struct{ s *Store }
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// copyWebhook 复制端点，订阅的事件列表不与存储共享
+func copyWebhook(webhook *models.Webhook) *models.Webhook {
+	found := *webhook
+	found.Events = slices.Clone(webhook.Events)
+	return &found
+}
+
+// CreateWebhook 创建端点
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := webhook.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&webhook.CreatedAt, &webhook.UpdatedAt)
+	r.s.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+// GetWebhookByID 根据ID获取用户的端点
+func (r *WebhookRepository) GetWebhookByID(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	webhook, ok := r.s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return nil, nil
+	}
+	return copyWebhook(webhook), nil
+}
+
+// GetWebhook 按ID获取端点，不校验所属用户
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	webhook, ok := r.s.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWebhook(webhook), nil
+}
+
+// ListWebhooks 按分页请求获取用户的端点
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Webhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	webhooks := make([]*models.Webhook, 0)
+	for _, webhook := range r.s.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	return paginate(webhooks, page, func(webhook *models.Webhook) (interface{}, uuid.UUID) {
+		return repository.WebhookSortValue(webhook, page.Sort.Field.Name), webhook.ID
+	}), nil
+}
+
+// UpdateWebhook 更新端点
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	touch(nil, &webhook.UpdatedAt)
+	r.s.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+// DeleteWebhook 删除用户的端点及其投递记录
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	webhook, ok := r.s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return false, nil
+	}
+	delete(r.s.webhooks, id)
+	for deliveryID, delivery := range r.s.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.s.deliveries, deliveryID)
+		}
+	}
+	return true, nil
+}
+
+// RecordWebhookSuccess 清零端点的连续失败次数
+func (r *WebhookRepository) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if webhook, ok := r.s.webhooks[id]; ok {
+		webhook.FailureCount = 0
+	}
+	return nil
+}
+
+// RecordWebhookFailure 连续失败次数加一，达到上限时停用端点
+func (r *WebhookRepository) RecordWebhookFailure(ctx context.Context, id uuid.UUID, disableAfter int, at time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	webhook, ok := r.s.webhooks[id]
+	if !ok {
+		return false, nil
+	}
+	webhook.FailureCount++
+	if disableAfter <= 0 || !webhook.Enabled || webhook.FailureCount < disableAfter {
+		return false, nil
+	}
+	webhook.Enabled = false
+	webhook.DisabledAt = &at
+	return true, nil
+}
+
+// CreateWebhookDelivery 创建投递记录
+func (r *WebhookRepository) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := delivery.BeforeCreate(nil); err != nil {
+		return err
+	}
+	touch(&delivery.CreatedAt, nil)
+	stored := *delivery
+	r.s.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+// GetWebhookDelivery 按ID获取投递记录
+func (r *WebhookRepository) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	delivery, ok := r.s.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *delivery
+	return &found, nil
+}
+
+// ListWebhookDeliveries 按分页请求获取端点的投递记录
+func (r *WebhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, page pagination.Request) ([]*models.WebhookDelivery, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for _, delivery := range r.s.deliveries {
+		if delivery.WebhookID == webhookID {
+			found := *delivery
+			deliveries = append(deliveries, &found)
+		}
+	}
+	return paginate(deliveries, page, func(delivery *models.WebhookDelivery) (interface{}, uuid.UUID) {
+		return repository.WebhookDeliverySortValue(delivery, page.Sort.Field.Name), delivery.ID
+	}), nil
+}
+
+// UpdateWebhookDelivery 更新投递记录
+func (r *WebhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *delivery
+	r.s.deliveries[delivery.ID] = &stored
+	return nil
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ticktick-backend/internal/filter"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+
+	"github.com/google/uuid"
+)
+
+// 所有查询方法在记录不存在时返回 (nil, nil)，与原有DAL的约定保持一致
+// 按用户划分的数据在查询时都需要传入userID，防止越权访问
+
+// Transactor 事务管理接口
+type Transactor interface {
+	// WithTx 在事务中执行fn，fn中的仓储调用需使用传入的ctx才会加入事务
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserRepository 用户数据访问接口
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error
+}
+
+// ProjectRepository 项目数据访问接口
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, project *models.Project) error
+	GetProjectByID(ctx context.Context, userID, id uuid.UUID) (*models.Project, error)
+	// ListProjects 按分页请求获取项目，最多返回page.Limit+1条以便判断是否还有下一页
+	ListProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) error
+	// DeleteProject 软删除项目及其下所有任务
+	DeleteProject(ctx context.Context, userID, id uuid.UUID) error
+	// ProjectNameExists 检查用户下是否存在同名项目，excludeID用于更新时排除自身
+	ProjectNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error)
+	// ProjectSortOrderNeighbor 获取与key相邻的项目排序键，next为true时取后一个，否则取前一个
+	// key为空时分别取第一个和最后一个，excludeID对应的项目不参与比较，没有相邻项目时返回空字符串
+	ProjectSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error)
+	// ListUsersWithLongProjectSortOrder 获取存在排序键长度超过maxLen的项目的用户
+	ListUsersWithLongProjectSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error)
+	// ListProjectIDsBySortOrder 按排序键获取用户全部项目的ID
+	ListProjectIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	// SetProjectSortOrders 批量修改项目的排序键，不更新修改时间
+	SetProjectSortOrders(ctx context.Context, orders map[uuid.UUID]string) error
+}
+
+// TaskScope 任务手动排序的范围：同一项目下同一父任务的子任务，ParentID为nil表示顶层任务
+type TaskScope struct {
+	UserID    uuid.UUID
+	ProjectID uuid.UUID
+	ParentID  *uuid.UUID
+}
+
+// TaskFilter 任务列表过滤条件，nil表示不过滤
+type TaskFilter struct {
+	ProjectID *uuid.UUID
+	ParentID  *uuid.UUID
+	TopLevel  bool // 只返回顶层任务
+	Status    *models.TaskStatus
+	Priority  *int
+	DueFrom   *time.Time
+	DueTo     *time.Time
+	// Expr 筛选表达式，相对日期和日期边界以Now为基准
+	Expr filter.Expr
+	Now  time.Time
+}
+
+// TaskSearch 任务全文搜索条件
+type TaskSearch struct {
+	TaskFilter
+	// Terms 已切分的查询词，只包含字母和数字，每个词按前缀匹配且需全部命中
+	Terms []string
+	// Text 小写的原始查询文本，按子串匹配，用于中文等无法分词的内容
+	Text  string
+	Limit int
+}
+
+// 高亮片段中命中部分的起止标记，使用控制字符而不是HTML标签，由服务层转义文本后再转换为<mark>
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// TaskSearchHit 任务搜索结果
+type TaskSearchHit struct {
+	Task *models.Task
+	Rank float64
+	// TitleSnippet、DescriptionSnippet 未转义的原文片段，命中部分用HighlightStart和HighlightStop包裹，实现不支持时为空
+	TitleSnippet       string
+	DescriptionSnippet string
+}
+
+// TaskRepository 任务数据访问接口
+type TaskRepository interface {
+	CreateTask(ctx context.Context, task *models.Task) error
+	// GetTaskByID 获取任务，同时加载标签和提醒
+	GetTaskByID(ctx context.Context, userID, id uuid.UUID) (*models.Task, error)
+	// GetTaskByICalUID 按导入时的iCalendar UID获取用户未删除的任务，不存在时返回nil
+	GetTaskByICalUID(ctx context.Context, userID uuid.UUID, uid string) (*models.Task, error)
+	// ListTasks 按过滤条件和分页请求获取任务，同时加载标签，最多返回page.Limit+1条
+	ListTasks(ctx context.Context, userID uuid.UUID, filter TaskFilter, page pagination.Request) ([]*models.Task, error)
+	// ListTasksWithDetails 按过滤条件获取全部任务，同时加载标签、提醒和循环例外，按创建时间排序
+	// 用于导出等需要完整数据的场景，不分页
+	ListTasksWithDetails(ctx context.Context, userID uuid.UUID, filter TaskFilter) ([]*models.Task, error)
+	// SearchTasks 全文搜索任务标题、描述和标签名称，按相关度排序
+	SearchTasks(ctx context.Context, userID uuid.UUID, search TaskSearch) ([]*TaskSearchHit, error)
+	UpdateTask(ctx context.Context, task *models.Task) error
+	// DeleteTask 软删除任务及其子任务
+	DeleteTask(ctx context.Context, userID, id uuid.UUID) error
+	// SetTaskLabels 用labelIDs替换任务的全部标签
+	SetTaskLabels(ctx context.Context, taskID uuid.UUID, labelIDs []uuid.UUID) error
+	// CreateRecurrenceException 为循环任务添加例外：删除某次实例，或用另一个任务替代该实例
+	CreateRecurrenceException(ctx context.Context, exception *models.TaskRecurrenceException) error
+	// TaskSortOrderNeighbor 获取同级任务中与key相邻的排序键，next为true时取后一个，否则取前一个
+	// key为空时分别取第一个和最后一个，excludeID对应的任务不参与比较，没有相邻任务时返回空字符串
+	TaskSortOrderNeighbor(ctx context.Context, scope TaskScope, key string, next bool, excludeID uuid.UUID) (string, error)
+	// ListTaskScopesWithLongSortOrder 获取存在排序键长度超过maxLen的任务的排序范围
+	ListTaskScopesWithLongSortOrder(ctx context.Context, maxLen int) ([]TaskScope, error)
+	// ListTaskIDsBySortOrder 按排序键获取同级任务的ID
+	ListTaskIDsBySortOrder(ctx context.Context, scope TaskScope) ([]uuid.UUID, error)
+	// SetTaskSortOrders 批量修改任务的排序键，不更新修改时间
+	SetTaskSortOrders(ctx context.Context, orders map[uuid.UUID]string) error
+}
+
+// LabelRepository 标签数据访问接口
+type LabelRepository interface {
+	CreateLabel(ctx context.Context, label *models.Label) error
+	GetLabelByID(ctx context.Context, userID, id uuid.UUID) (*models.Label, error)
+	// GetLabelsByIDs 获取属于用户的指定标签，不存在的ID会被忽略
+	GetLabelsByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*models.Label, error)
+	// ListLabels 按分页请求获取标签，最多返回page.Limit+1条
+	ListLabels(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Label, error)
+	UpdateLabel(ctx context.Context, label *models.Label) error
+	// DeleteLabel 软删除标签并解除与任务的关联
+	DeleteLabel(ctx context.Context, userID, id uuid.UUID) error
+	LabelNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error)
+}
+
+// SmartListRepository 智能清单数据访问接口
+type SmartListRepository interface {
+	CreateSmartList(ctx context.Context, list *models.SmartList) error
+	GetSmartListByID(ctx context.Context, userID, id uuid.UUID) (*models.SmartList, error)
+	// ListSmartLists 按分页请求获取用户的智能清单，最多返回page.Limit+1条
+	ListSmartLists(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.SmartList, error)
+	UpdateSmartList(ctx context.Context, list *models.SmartList) error
+	DeleteSmartList(ctx context.Context, userID, id uuid.UUID) error
+	// SmartListNameExists 检查用户下是否存在同名清单，excludeID用于更新时排除自身
+	SmartListNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error)
+}
+
+// SectionRepository 项目分栏数据访问接口
+type SectionRepository interface {
+	CreateSection(ctx context.Context, section *models.Section) error
+	GetSectionByID(ctx context.Context, userID, id uuid.UUID) (*models.Section, error)
+	// ListSections 按分页请求获取项目的分栏，最多返回page.Limit+1条
+	ListSections(ctx context.Context, userID, projectID uuid.UUID, page pagination.Request) ([]*models.Section, error)
+	UpdateSection(ctx context.Context, section *models.Section) error
+	// DeleteSection 删除分栏，其中的任务（包括回收站中的）移到默认分栏
+	DeleteSection(ctx context.Context, userID, id uuid.UUID) error
+	// SectionSortOrderNeighbor 获取项目中与key相邻的分栏排序键，规则与ProjectSortOrderNeighbor相同
+	SectionSortOrderNeighbor(ctx context.Context, projectID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error)
+	// ListProjectsWithLongSectionSortOrder 获取存在排序键长度超过maxLen的分栏的项目
+	ListProjectsWithLongSectionSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error)
+	// ListSectionIDsBySortOrder 按排序键获取项目全部分栏的ID
+	ListSectionIDsBySortOrder(ctx context.Context, projectID uuid.UUID) ([]uuid.UUID, error)
+	// SetSectionSortOrders 批量修改分栏的排序键，不更新修改时间
+	SetSectionSortOrders(ctx context.Context, orders map[uuid.UUID]string) error
+}
+
+// ProjectGroupRepository 项目分组数据访问接口
+type ProjectGroupRepository interface {
+	CreateProjectGroup(ctx context.Context, group *models.ProjectGroup) error
+	GetProjectGroupByID(ctx context.Context, userID, id uuid.UUID) (*models.ProjectGroup, error)
+	// ListProjectGroups 按分页请求获取用户的分组，最多返回page.Limit+1条
+	ListProjectGroups(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.ProjectGroup, error)
+	UpdateProjectGroup(ctx context.Context, group *models.ProjectGroup) error
+	// DeleteProjectGroup 删除分组，其中的项目（包括回收站中的）变为未分组
+	DeleteProjectGroup(ctx context.Context, userID, id uuid.UUID) error
+	// ProjectGroupNameExists 检查用户下是否存在同名分组，excludeID用于更新时排除自身
+	ProjectGroupNameExists(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error)
+	// ProjectGroupSortOrderNeighbor 获取用户分组中与key相邻的排序键，规则与ProjectSortOrderNeighbor相同
+	ProjectGroupSortOrderNeighbor(ctx context.Context, userID uuid.UUID, key string, next bool, excludeID uuid.UUID) (string, error)
+	// ListUsersWithLongProjectGroupSortOrder 获取存在排序键长度超过maxLen的分组的用户
+	ListUsersWithLongProjectGroupSortOrder(ctx context.Context, maxLen int) ([]uuid.UUID, error)
+	// ListProjectGroupIDsBySortOrder 按排序键获取用户全部分组的ID
+	ListProjectGroupIDsBySortOrder(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	// SetProjectGroupSortOrders 批量修改分组的排序键，不更新修改时间
+	SetProjectGroupSortOrders(ctx context.Context, orders map[uuid.UUID]string) error
+}
+
+// CalendarFeedRepository 日历订阅源数据访问接口
+type CalendarFeedRepository interface {
+	CreateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error
+	GetCalendarFeedByID(ctx context.Context, userID, id uuid.UUID) (*models.CalendarFeed, error)
+	// GetCalendarFeedByToken 按订阅令牌获取订阅源，不存在时返回nil
+	GetCalendarFeedByToken(ctx context.Context, token string) (*models.CalendarFeed, error)
+	// ListCalendarFeeds 按分页请求获取用户的订阅源，最多返回page.Limit+1条
+	ListCalendarFeeds(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.CalendarFeed, error)
+	UpdateCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error
+	DeleteCalendarFeed(ctx context.Context, userID, id uuid.UUID) error
+	// CalendarFeedExists 检查用户是否已有该范围的订阅源，projectID为nil表示全部项目
+	CalendarFeedExists(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) (bool, error)
+}
+
+// AppPasswordRepository 应用专用密码数据访问接口
+type AppPasswordRepository interface {
+	CreateAppPassword(ctx context.Context, password *models.AppPassword) error
+	// GetAppPasswordByHash 按密码摘要获取应用专用密码，不存在时返回nil
+	GetAppPasswordByHash(ctx context.Context, tokenHash string) (*models.AppPassword, error)
+	// ListAppPasswords 按分页请求获取用户的应用专用密码，最多返回page.Limit+1条
+	ListAppPasswords(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.AppPassword, error)
+	// TouchAppPassword 记录密码最近一次使用的时间
+	TouchAppPassword(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	// DeleteAppPassword 删除用户的应用专用密码，返回是否存在
+	DeleteAppPassword(ctx context.Context, userID, id uuid.UUID) (bool, error)
+}
+
+// WebhookRepository Webhook端点及其投递记录数据访问接口
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhookByID(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error)
+	// GetWebhook 按ID获取端点，不校验所属用户，供投递队列使用，不存在时返回nil
+	GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error)
+	// ListWebhooks 按分页请求获取用户的端点，最多返回page.Limit+1条
+	ListWebhooks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	// DeleteWebhook 删除用户的端点及其投递记录，返回是否存在
+	DeleteWebhook(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	// RecordWebhookSuccess 投递成功后清零端点的连续失败次数
+	RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error
+	// RecordWebhookFailure 端点的连续失败次数加一，达到disableAfter时停用端点，返回本次是否停用了端点
+	RecordWebhookFailure(ctx context.Context, id uuid.UUID, disableAfter int, at time.Time) (bool, error)
+
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetWebhookDelivery 按ID获取投递记录，不存在时返回nil
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+	// ListWebhookDeliveries 按分页请求获取端点的投递记录，最多返回page.Limit+1条
+	ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, page pagination.Request) ([]*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// SyncEntities 按ID批量加载的同步实体
+type SyncEntities struct {
+	Projects   []*models.Project
+	Labels     []*models.Label
+	Tasks      []*models.Task // 同时加载标签
+	Reminders  []*models.Reminder
+	Exceptions []*models.TaskRecurrenceException
+}
+
+// SyncRepository 增量同步数据访问接口
+// 变更日志由数据层在写入项目、标签、任务、提醒和循环例外时维护，这里只负责读取
+type SyncRepository interface {
+	// GetSyncSeq 获取用户当前的变更序号，没有任何变更时返回0
+	GetSyncSeq(ctx context.Context, userID uuid.UUID) (int64, error)
+	// ListSyncChanges 获取用户序号大于since的变更，按序号排序，最多返回limit条
+	ListSyncChanges(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*models.SyncChange, error)
+	// GetSyncChange 获取实体最近一次变更，不校验所属用户，不存在时返回nil
+	GetSyncChange(ctx context.Context, entityType string, id uuid.UUID) (*models.SyncChange, error)
+	// GetSyncEntities 按实体类型和ID批量获取用户未删除的实体，不存在的ID会被忽略
+	GetSyncEntities(ctx context.Context, userID uuid.UUID, ids map[string][]uuid.UUID) (*SyncEntities, error)
+}
+
+// UserSettingsRepository 用户设置数据访问接口
+type UserSettingsRepository interface {
+	// GetUserSettings 获取用户设置，用户尚未保存过设置时返回nil
+	GetUserSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error)
+	// SaveUserSettings 保存用户设置，不存在时创建
+	SaveUserSettings(ctx context.Context, settings *models.UserSettings) error
+}
+
+// ReminderRepository 提醒数据访问接口
+type ReminderRepository interface {
+	CreateReminder(ctx context.Context, reminder *models.Reminder) error
+	GetReminderByID(ctx context.Context, taskID, id uuid.UUID) (*models.Reminder, error)
+	// ListReminders 按分页请求获取任务的提醒，最多返回page.Limit+1条
+	ListReminders(ctx context.Context, taskID uuid.UUID, page pagination.Request) ([]*models.Reminder, error)
+	DeleteReminder(ctx context.Context, taskID, id uuid.UUID) error
+	// CountPending 统计尚未到达提醒时间的提醒数量
+	CountPending(ctx context.Context) (int64, error)
+	// ListDueReminders 获取提醒时间在(from, to]之间、所属任务未删除且未完成的提醒，附带所属任务，按提醒时间排序
+	ListDueReminders(ctx context.Context, from, to time.Time) ([]*models.Reminder, error)
+}
+
+// TrashRepository 回收站数据访问接口
+// 级联删除时项目、任务和提醒使用相同的deleted_at，恢复时据此只恢复同一次删除的数据
+type TrashRepository interface {
+	// ListDeletedProjects 按分页请求获取用户已删除的项目，最多返回page.Limit+1条
+	ListDeletedProjects(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Project, error)
+	// ListDeletedTasks 按分页请求获取回收站中的任务，最多返回page.Limit+1条
+	// 所属项目或父任务也已删除的任务随它们一起展示，不单独列出
+	ListDeletedTasks(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]*models.Task, error)
+	GetDeletedProject(ctx context.Context, userID, id uuid.UUID) (*models.Project, error)
+	GetDeletedTask(ctx context.Context, userID, id uuid.UUID) (*models.Task, error)
+	// RestoreProject 恢复项目以及随项目一起删除的任务和提醒
+	RestoreProject(ctx context.Context, userID, id uuid.UUID) error
+	// RestoreTask 恢复任务以及随任务一起删除的子任务和提醒，detachParent为true时将任务移到顶层
+	RestoreTask(ctx context.Context, userID, id uuid.UUID, detachParent bool) error
+	// PurgeProject 彻底删除回收站中的项目及其所有任务和分栏
+	PurgeProject(ctx context.Context, userID, id uuid.UUID) error
+	// PurgeTask 彻底删除回收站中的任务及其子任务
+	PurgeTask(ctx context.Context, userID, id uuid.UUID) error
+	// EmptyTrash 彻底删除用户所有已软删除的数据
+	EmptyTrash(ctx context.Context, userID uuid.UUID) error
+	// PurgeDeletedBefore 彻底删除所有在cutoff之前软删除的数据，返回各表删除的行数
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (map[string]int64, error)
+}
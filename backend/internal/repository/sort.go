@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+)
+
+// ProjectSortFields 项目列表支持的排序字段
+var ProjectSortFields = []pagination.Field{
+	{Name: "created", Column: "projects.created_at", Type: pagination.TimeValue},
+	{Name: "name", Column: "projects.name", Type: pagination.StringValue},
+	{Name: "manual", Column: "projects.sort_order", Type: pagination.StringValue},
+}
+
+// TaskSortFields 任务列表支持的排序字段
+var TaskSortFields = []pagination.Field{
+	{Name: "due", Column: "tasks.due_time", Type: pagination.TimeValue, Nullable: true},
+	{Name: "priority", Column: "tasks.priority", Type: pagination.IntValue},
+	{Name: "created", Column: "tasks.created_at", Type: pagination.TimeValue},
+	{Name: "manual", Column: "tasks.sort_order", Type: pagination.StringValue},
+}
+
+// LabelSortFields 标签列表支持的排序字段
+var LabelSortFields = []pagination.Field{
+	{Name: "name", Column: "labels.name", Type: pagination.StringValue},
+	{Name: "created", Column: "labels.created_at", Type: pagination.TimeValue},
+}
+
+// ReminderSortFields 提醒列表支持的排序字段
+var ReminderSortFields = []pagination.Field{
+	{Name: "remindAt", Column: "reminders.remind_at", Type: pagination.TimeValue},
+	{Name: "created", Column: "reminders.created_at", Type: pagination.TimeValue},
+}
+
+// WebhookDeliverySortFields Webhook投递记录支持的排序字段
+var WebhookDeliverySortFields = []pagination.Field{
+	{Name: "created", Column: "webhook_deliveries.created_at", Type: pagination.TimeValue},
+}
+
+// SmartListSortFields 智能清单列表支持的排序字段
+var SmartListSortFields = []pagination.Field{
+	{Name: "created", Column: "smart_lists.created_at", Type: pagination.TimeValue},
+	{Name: "name", Column: "smart_lists.name", Type: pagination.StringValue},
+}
+
+// SectionSortFields 分栏列表支持的排序字段
+var SectionSortFields = []pagination.Field{
+	{Name: "manual", Column: "sections.sort_order", Type: pagination.StringValue},
+	{Name: "created", Column: "sections.created_at", Type: pagination.TimeValue},
+}
+
+// ProjectGroupSortFields 项目分组列表支持的排序字段
+var ProjectGroupSortFields = []pagination.Field{
+	{Name: "manual", Column: "project_groups.sort_order", Type: pagination.StringValue},
+	{Name: "name", Column: "project_groups.name", Type: pagination.StringValue},
+	{Name: "created", Column: "project_groups.created_at", Type: pagination.TimeValue},
+}
+
+// CalendarFeedSortFields 日历订阅源列表支持的排序字段
+var CalendarFeedSortFields = []pagination.Field{
+	{Name: "created", Column: "calendar_feeds.created_at", Type: pagination.TimeValue},
+}
+
+// AppPasswordSortFields 应用专用密码列表支持的排序字段
+var AppPasswordSortFields = []pagination.Field{
+	{Name: "created", Column: "app_passwords.created_at", Type: pagination.TimeValue},
+	{Name: "lastUsed", Column: "app_passwords.last_used_at", Type: pagination.TimeValue, Nullable: true},
+}
+
+// WebhookSortFields Webhook端点列表支持的排序字段
+var WebhookSortFields = []pagination.Field{
+	{Name: "created", Column: "webhooks.created_at", Type: pagination.TimeValue},
+}
+
+// TrashedProjectSortFields 回收站项目列表支持的排序字段
+var TrashedProjectSortFields = []pagination.Field{
+	{Name: "deleted", Column: "projects.deleted_at", Type: pagination.TimeValue},
+}
+
+// TrashedTaskSortFields 回收站任务列表支持的排序字段
+var TrashedTaskSortFields = []pagination.Field{
+	{Name: "deleted", Column: "tasks.deleted_at", Type: pagination.TimeValue},
+}
+
+// ProjectSortValue 获取项目在排序字段上的值，用于生成游标
+func ProjectSortValue(project *models.Project, field string) interface{} {
+	switch field {
+	case "name":
+		return project.Name
+	case "manual":
+		return project.SortOrder
+	}
+	return project.CreatedAt
+}
+
+// TaskSortValue 获取任务在排序字段上的值，用于生成游标
+func TaskSortValue(task *models.Task, field string) interface{} {
+	switch field {
+	case "due":
+		return task.DueTime
+	case "priority":
+		return task.Priority
+	case "manual":
+		return task.SortOrder
+	}
+	return task.CreatedAt
+}
+
+// LabelSortValue 获取标签在排序字段上的值，用于生成游标
+func LabelSortValue(label *models.Label, field string) interface{} {
+	if field == "name" {
+		return label.Name
+	}
+	return label.CreatedAt
+}
+
+// ReminderSortValue 获取提醒在排序字段上的值，用于生成游标
+func ReminderSortValue(reminder *models.Reminder, field string) interface{} {
+	if field == "remindAt" {
+		return reminder.RemindAt
+	}
+	return reminder.CreatedAt
+}
+
+// WebhookDeliverySortValue 获取投递记录在排序字段上的值，用于生成游标
+func WebhookDeliverySortValue(delivery *models.WebhookDelivery, field string) interface{} {
+	return delivery.CreatedAt
+}
+
+// SmartListSortValue 获取智能清单在排序字段上的值，用于生成游标
+func SmartListSortValue(list *models.SmartList, field string) interface{} {
+	if field == "name" {
+		return list.Name
+	}
+	return list.CreatedAt
+}
+
+// SectionSortValue 获取分栏在排序字段上的值，用于生成游标
+func SectionSortValue(section *models.Section, field string) interface{} {
+	if field == "manual" {
+		return section.SortOrder
+	}
+	return section.CreatedAt
+}
+
+// ProjectGroupSortValue 获取项目分组在排序字段上的值，用于生成游标
+func ProjectGroupSortValue(group *models.ProjectGroup, field string) interface{} {
+	switch field {
+	case "manual":
+		return group.SortOrder
+	case "name":
+		return group.Name
+	}
+	return group.CreatedAt
+}
+
+// CalendarFeedSortValue 获取订阅源在排序字段上的值，用于生成游标
+func CalendarFeedSortValue(feed *models.CalendarFeed, field string) interface{} {
+	return feed.CreatedAt
+}
+
+// AppPasswordSortValue 获取应用专用密码在排序字段上的值，用于生成游标
+func AppPasswordSortValue(password *models.AppPassword, field string) interface{} {
+	if field == "lastUsed" {
+		return password.LastUsedAt
+	}
+	return password.CreatedAt
+}
+
+// WebhookSortValue 获取端点在排序字段上的值，用于生成游标
+func WebhookSortValue(webhook *models.Webhook, field string) interface{} {
+	return webhook.CreatedAt
+}
+
+// TrashedProjectSortValue 获取回收站项目在排序字段上的值，用于生成游标
+func TrashedProjectSortValue(project *models.Project, field string) interface{} {
+	return project.DeletedAt.Time
+}
+
+// TrashedTaskSortValue 获取回收站任务在排序字段上的值，用于生成游标
+func TrashedTaskSortValue(task *models.Task, field string) interface{} {
+	return task.DeletedAt.Time
+}
@@ -0,0 +1,274 @@
+// Package router 负责组装Gin路由，与main分离以便在测试中使用内存仓储启动完整的HTTP服务
+package router
+
+import (
+	"net/http"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/handlers"
+	"ticktick-backend/internal/metrics"
+	"ticktick-backend/internal/middleware"
+	"ticktick-backend/internal/services"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers 路由依赖的所有处理器
+type Handlers struct {
+	Auth        *handlers.AuthHandler
+	Monitor     *handlers.MonitorHandler
+	Project     *handlers.ProjectHandler
+	Task        *handlers.TaskHandler
+	Label       *handlers.LabelHandler
+	Trash       *handlers.TrashHandler
+	SmartList   *handlers.SmartListHandler
+	Section     *handlers.SectionHandler
+	Group       *handlers.ProjectGroupHandler
+	Settings    *handlers.SettingsHandler
+	Calendar    *handlers.CalendarHandler
+	Feed        *handlers.CalendarFeedHandler
+	AppPassword *handlers.AppPasswordHandler
+	CalDAV      *handlers.CalDAVHandler
+	Import      *handlers.ImportHandler
+	AccountData *handlers.AccountDataHandler
+	Webhook     *handlers.WebhookHandler
+	EventStream *handlers.EventStreamHandler
+	Sync        *handlers.SyncHandler
+}
+
+// New 创建Gin路由器并注册所有路由，appPasswords用于CalDAV的HTTP Basic认证
+func New(cfg *config.Config, tokenStore *services.TokenStore, appPasswords *services.AppPasswordService, h *Handlers) *gin.Engine {
+	router := gin.Default()
+	if cfg.Metrics.Enabled {
+		router.Use(metrics.GinMiddleware())
+	}
+
+	// 配置CORS中间件 - 允许所有端口
+	corsConfig := cors.Config{
+		AllowOrigins:     []string{"http://localhost:5273", "http://127.0.0.1:5174"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.DeviceNameHeader},
+		AllowCredentials: true,
+	}
+	router.Use(cors.New(corsConfig))
+
+	// Prometheus指标端点，配置了独立监听地址时由单独的服务提供
+	if cfg.Metrics.Enabled && cfg.Metrics.ListenAddr == "" && cfg.Metrics.Token != "" {
+		router.GET("/metrics", metrics.GinHandler(cfg.Metrics.Token))
+	}
+
+	// 存活和就绪探针
+	router.GET("/livez", h.Monitor.Livez)
+	router.GET("/readyz", h.Monitor.Readyz)
+
+	// CalDAV路由，使用应用专用密码认证；OPTIONS不需要认证，供客户端探测服务能力
+	router.GET("/.well-known/caldav", h.CalDAV.WellKnown)
+	router.Handle("PROPFIND", "/.well-known/caldav", h.CalDAV.WellKnown)
+	router.OPTIONS(handlers.CalDAVPrefix+"/*path", h.CalDAV.Options)
+	caldav := router.Group(handlers.CalDAVPrefix)
+	caldav.Use(middleware.BasicAuthMiddleware(appPasswords, "CalDAV"))
+	{
+		for _, method := range []string{"PROPFIND", "REPORT", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete} {
+			caldav.Handle(method, "/*path", h.CalDAV.Handle)
+		}
+	}
+
+	// API路由组
+	api := router.Group("/api/v1")
+
+	// 健康检查端点
+	api.GET("/health", h.Monitor.GetHealth)
+	api.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	})
+
+	// 认证路由（不需要JWT验证）
+	authGroup := api.Group("/auth")
+	{
+		authGroup.POST("/register", h.Auth.Register)
+		authGroup.POST("/login", h.Auth.Login)
+		authGroup.POST("/refresh", h.Auth.RefreshToken)
+		authGroup.POST("/logout", h.Auth.Logout)
+		authGroup.GET("/signin-alerts/:token/reject", h.Auth.GetSignInAlert)
+		authGroup.POST("/signin-alerts/:token/reject", h.Auth.RejectSignIn)
+		authGroup.POST("/reset-password", h.Auth.ResetPassword)
+	}
+
+	// 日历订阅内容，由日历应用定期拉取，使用路径中的令牌认证
+	api.GET("/feeds/:token", h.Feed.ServeFeed)
+	api.HEAD("/feeds/:token", h.Feed.ServeFeed)
+
+	// 账户导出文件下载，使用路径中的令牌认证，链接有效期有限
+	api.GET("/exports/:token", h.AccountData.DownloadExport)
+
+	// 需要认证的路由
+	protected := api.Group("")
+	protected.Use(middleware.AuthMiddleware(cfg, tokenStore))
+	{
+		// 用户信息路由
+		protected.GET("/profile", h.Auth.GetProfile)
+
+		// 实时变更流，以Server-Sent Events推送当前用户的数据变更
+		protected.GET("/events", h.EventStream.Stream)
+
+		// 增量同步路由，供离线优先的客户端拉取变更和批量提交修改
+		protected.GET("/sync", h.Sync.Pull)
+		protected.POST("/sync", h.Sync.Push)
+
+		// 用户设置路由
+		protected.GET("/settings", h.Settings.GetSettings)
+		protected.PATCH("/settings", h.Settings.UpdateSettings)
+
+		// 账户数据导出和恢复路由
+		account := protected.Group("/account")
+		{
+			account.POST("/exports", h.AccountData.StartExport)
+			account.GET("/exports/:id", h.AccountData.GetExportJob)
+			account.POST("/import", h.AccountData.ImportAccount)
+		}
+
+		// 会话管理路由
+		protected.GET("/sessions", h.Auth.GetSessions)
+		protected.PATCH("/sessions/:tokenId", h.Auth.RenameSession)
+		protected.DELETE("/sessions/:tokenId", h.Auth.RevokeSession)
+		protected.POST("/logout-all", h.Auth.LogoutAll)
+
+		// 管理员功能（实际项目中需要权限验证）
+		protected.POST("/revoke-token", h.Auth.RevokeToken)
+
+		// 监控相关路由
+		monitor := protected.Group("/monitor")
+		{
+			monitor.GET("/health", h.Monitor.GetHealth)
+			monitor.GET("/stats", h.Monitor.GetStats)
+			monitor.GET("/data", h.Monitor.GetMonitoringData)
+			monitor.POST("/cleanup", h.Monitor.ForceCleanup)
+			monitor.GET("/token/:tokenId", h.Monitor.GetTokenInfo)
+			monitor.GET("/user/:userId/sessions", h.Monitor.GetUserSessions)
+			monitor.DELETE("/user/:userId/sessions", h.Monitor.RevokeUserAllSessions)
+			monitor.GET("/metrics", h.Monitor.GetSystemMetrics)
+		}
+
+		// 项目路由
+		projects := protected.Group("/projects")
+		{
+			projects.GET("", h.Project.ListProjects)
+			projects.POST("", h.Project.CreateProject)
+			projects.GET("/:id", h.Project.GetProject)
+			projects.PUT("/:id", h.Project.UpdateProject)
+			projects.DELETE("/:id", h.Project.DeleteProject)
+			projects.POST("/:id/move", h.Project.MoveProject)
+			projects.GET("/:id/export/ics", h.Calendar.ExportProjectICS)
+			projects.POST("/:id/import/ics", h.Calendar.ImportProjectICS)
+
+			// 项目分栏和看板路由
+			projects.GET("/:id/board", h.Section.GetBoard)
+			projects.GET("/:id/sections", h.Section.ListSections)
+			projects.POST("/:id/sections", h.Section.CreateSection)
+			projects.PUT("/:id/sections/:sectionId", h.Section.UpdateSection)
+			projects.DELETE("/:id/sections/:sectionId", h.Section.DeleteSection)
+			projects.POST("/:id/sections/:sectionId/move", h.Section.MoveSection)
+		}
+
+		// 导出路由
+		protected.GET("/export/ics", h.Calendar.ExportICS)
+
+		// 备份导入路由
+		imports := protected.Group("/imports")
+		{
+			imports.POST("", h.Import.StartImport)
+			imports.GET("/:id", h.Import.GetImportJob)
+		}
+
+		// 应用专用密码路由
+		appPasswords := protected.Group("/app-passwords")
+		{
+			appPasswords.GET("", h.AppPassword.ListAppPasswords)
+			appPasswords.POST("", h.AppPassword.CreateAppPassword)
+			appPasswords.DELETE("/:id", h.AppPassword.DeleteAppPassword)
+		}
+
+		// Webhook路由
+		webhooks := protected.Group("/webhooks")
+		{
+			webhooks.GET("", h.Webhook.ListWebhooks)
+			webhooks.POST("", h.Webhook.CreateWebhook)
+			webhooks.GET("/:id", h.Webhook.GetWebhook)
+			webhooks.PATCH("/:id", h.Webhook.UpdateWebhook)
+			webhooks.DELETE("/:id", h.Webhook.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", h.Webhook.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", h.Webhook.Redeliver)
+		}
+
+		// 日历订阅管理路由
+		feeds := protected.Group("/calendar-feeds")
+		{
+			feeds.GET("", h.Feed.ListFeeds)
+			feeds.POST("", h.Feed.CreateFeed)
+			feeds.POST("/:id/rotate", h.Feed.RotateFeed)
+			feeds.DELETE("/:id", h.Feed.DeleteFeed)
+		}
+
+		// 项目分组路由
+		groups := protected.Group("/project-groups")
+		{
+			groups.GET("", h.Group.ListProjectGroups)
+			groups.POST("", h.Group.CreateProjectGroup)
+			groups.PUT("/:id", h.Group.UpdateProjectGroup)
+			groups.DELETE("/:id", h.Group.DeleteProjectGroup)
+			groups.POST("/:id/move", h.Group.MoveProjectGroup)
+		}
+
+		// 任务路由
+		tasks := protected.Group("/tasks")
+		{
+			tasks.GET("", h.Task.ListTasks)
+			tasks.GET("/search", h.Task.SearchTasks)
+			tasks.GET("/:id", h.Task.GetTask)
+			tasks.POST("", h.Task.CreateTask)
+			tasks.PUT("/:id", h.Task.UpdateTask)
+			tasks.DELETE("/:id", h.Task.DeleteTask)
+			tasks.POST("/:id/complete", h.Task.CompleteTask)
+			tasks.POST("/:id/reopen", h.Task.ReopenTask)
+			tasks.POST("/:id/move", h.Task.MoveTask)
+
+			// 任务提醒路由
+			tasks.GET("/:id/reminders", h.Task.ListReminders)
+			tasks.POST("/:id/reminders", h.Task.CreateReminder)
+			tasks.DELETE("/:id/reminders/:reminderId", h.Task.DeleteReminder)
+		}
+
+		// 标签路由
+		labels := protected.Group("/labels")
+		{
+			labels.GET("", h.Label.ListLabels)
+			labels.POST("", h.Label.CreateLabel)
+			labels.PUT("/:id", h.Label.UpdateLabel)
+			labels.DELETE("/:id", h.Label.DeleteLabel)
+		}
+
+		// 智能清单路由
+		smartLists := protected.Group("/smart-lists")
+		{
+			smartLists.GET("", h.SmartList.ListSmartLists)
+			smartLists.POST("", h.SmartList.CreateSmartList)
+			smartLists.PUT("/:id", h.SmartList.UpdateSmartList)
+			smartLists.DELETE("/:id", h.SmartList.DeleteSmartList)
+			smartLists.GET("/:id/tasks", h.SmartList.ListSmartListTasks)
+		}
+
+		// 回收站路由
+		trash := protected.Group("/trash")
+		{
+			trash.GET("", h.Trash.ListTrash)
+			trash.DELETE("", h.Trash.EmptyTrash)
+			trash.POST("/projects/:id/restore", h.Trash.RestoreProject)
+			trash.DELETE("/projects/:id", h.Trash.DeleteProject)
+			trash.POST("/tasks/:id/restore", h.Trash.RestoreTask)
+			trash.DELETE("/tasks/:id", h.Trash.DeleteTask)
+		}
+	}
+
+	return router
+}
@@ -0,0 +1,2487 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/handlers"
+	"ticktick-backend/internal/middleware"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+	"ticktick-backend/internal/repository/memory"
+	"ticktick-backend/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// testServer 使用内存仓储和miniredis启动的完整路由
+type testServer struct {
+	t         *testing.T
+	router    *gin.Engine
+	store     *memory.Store
+	webhooks  *services.WebhookService
+	reminders *services.ReminderDispatcher
+	notifier  *recordingNotifier
+}
+
+// recordingNotifier 记录发送的安全通知，测试从中取出邮件里的链接
+type recordingNotifier struct {
+	mu      sync.Mutex
+	signIns []*services.NewSignInNotification
+	resets  []*services.PasswordResetNotification
+}
+
+func (n *recordingNotifier) NotifyNewSignIn(notification *services.NewSignInNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.signIns = append(n.signIns, notification)
+	return nil
+}
+
+func (n *recordingNotifier) NotifyPasswordReset(notification *services.PasswordResetNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.resets = append(n.resets, notification)
+	return nil
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+
+	cfg := config.LoadConfig()
+	cfg.Redis.Host = mr.Host()
+	cfg.Redis.Port = mr.Port()
+	cfg.Metrics.Enabled = false
+	cfg.JWT.SecretKey = "test-secret"
+
+	redisService, err := services.NewRedisService(&cfg.Redis)
+	if err != nil {
+		t.Fatalf("连接miniredis失败: %v", err)
+	}
+	t.Cleanup(func() { redisService.Close() })
+
+	store := memory.NewStore()
+	tokenStore := services.NewTokenStore(redisService)
+	tokenMonitor := services.NewTokenMonitor(tokenStore, redisService)
+	healthChecker := services.NewHealthChecker(time.Second)
+
+	userService := services.NewUserService(store.Users())
+	notifier := &recordingNotifier{}
+	signInAlerts := services.NewSignInAlertService(redisService, tokenStore, userService, notifier, cfg)
+	settingsService := services.NewSettingsService(store.UserSettings())
+	calendarService := services.NewCalendarService(store, store.Tasks(), store.Projects(), store.Labels(), store.Reminders(), settingsService)
+	appPasswordService := services.NewAppPasswordService(store.AppPasswords(), store.Users())
+	eventBus := services.NewEventBus()
+	webhookService := services.NewWebhookService(store.Webhooks(), redisService, cfg)
+	eventBus.Subscribe(webhookService.HandleEvent)
+	eventStream := services.NewEventStream(redisService, cfg)
+	eventBus.Subscribe(eventStream.HandleEvent)
+	eventStream.Start()
+	t.Cleanup(eventStream.Stop)
+	projectService := services.NewProjectService(store.Projects(), store.ProjectGroups(), eventBus)
+	taskService := services.NewTaskService(store, store.Tasks(), store.Projects(), store.Labels(), store.Sections(), eventBus)
+	labelService := services.NewLabelService(store.Labels(), eventBus)
+	reminderService := services.NewReminderService(store.Reminders(), store.Tasks(), eventBus)
+
+	r := New(cfg, tokenStore, appPasswordService, &Handlers{
+		Auth:        handlers.NewAuthHandler(userService, tokenStore, signInAlerts, cfg),
+		Monitor:     handlers.NewMonitorHandler(tokenMonitor, tokenStore, healthChecker),
+		Project:     handlers.NewProjectHandler(projectService),
+		Task:        handlers.NewTaskHandler(taskService, reminderService),
+		Label:       handlers.NewLabelHandler(labelService),
+		Trash:       handlers.NewTrashHandler(services.NewTrashService(store.Trash(), store.Projects(), store.Tasks())),
+		SmartList:   handlers.NewSmartListHandler(services.NewSmartListService(store.SmartLists(), store.Tasks(), settingsService)),
+		Section:     handlers.NewSectionHandler(services.NewSectionService(store, store.Sections(), store.Projects(), store.Tasks())),
+		Group:       handlers.NewProjectGroupHandler(services.NewProjectGroupService(store.ProjectGroups())),
+		Settings:    handlers.NewSettingsHandler(settingsService),
+		Calendar:    handlers.NewCalendarHandler(calendarService),
+		Feed:        handlers.NewCalendarFeedHandler(services.NewCalendarFeedService(store.CalendarFeeds(), store.Projects(), calendarService, cfg)),
+		AppPassword: handlers.NewAppPasswordHandler(appPasswordService),
+		CalDAV:      handlers.NewCalDAVHandler(services.NewCalDAVService(store, store.Tasks(), store.Projects(), store.Reminders(), calendarService, redisService)),
+		Import:      handlers.NewImportHandler(services.NewImportService(store, store.Projects(), store.Tasks(), store.Labels(), settingsService, redisService)),
+		AccountData: handlers.NewAccountDataHandler(services.NewAccountDataService(store, store.Users(), store.ProjectGroups(), store.Projects(), store.Sections(), store.Labels(), store.SmartLists(), store.Tasks(), store.Reminders(), settingsService, redisService, cfg)),
+		Webhook:     handlers.NewWebhookHandler(webhookService),
+		EventStream: handlers.NewEventStreamHandler(eventStream, tokenStore),
+		Sync:        handlers.NewSyncHandler(services.NewSyncService(store.Sync(), projectService, labelService, taskService, reminderService)),
+	})
+
+	return &testServer{
+		t:         t,
+		router:    r,
+		store:     store,
+		webhooks:  webhookService,
+		reminders: services.NewReminderDispatcher(store.Reminders(), eventBus, redisService, time.Minute),
+		notifier:  notifier,
+	}
+}
+
+// do 发送请求并解析JSON响应
+func (s *testServer) do(method, path, token string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("序列化请求失败: %v", err)
+		}
+	}
+	w := s.doRaw(method, path, token, "application/json", data)
+
+	var resp map[string]interface{}
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			s.t.Fatalf("%s %s 响应不是JSON: %s", method, path, w.Body.String())
+		}
+	}
+	return w.Code, resp
+}
+
+// doRaw 发送原始请求体并返回未解析的响应，用于非JSON的接口
+func (s *testServer) doRaw(method, path, token, contentType string, body []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// mustDo 发送请求并断言状态码
+func (s *testServer) mustDo(method, path, token string, body interface{}, want int) map[string]interface{} {
+	s.t.Helper()
+	code, resp := s.do(method, path, token, body)
+	if code != want {
+		s.t.Fatalf("%s %s 状态码 = %d, 期望 %d, 响应: %v", method, path, code, want, resp)
+	}
+	return resp
+}
+
+// register 注册用户并返回访问令牌
+func (s *testServer) register(email string) string {
+	s.t.Helper()
+	resp := s.mustDo(http.MethodPost, "/api/v1/auth/register", "", map[string]string{
+		"email":     email,
+		"password":  "secret123",
+		"firstName": "Test",
+		"lastName":  "User",
+	}, http.StatusCreated)
+	return resp["accessToken"].(string)
+}
+
+// object 取出响应中的对象字段
+func object(resp map[string]interface{}, key string) map[string]interface{} {
+	obj, _ := resp[key].(map[string]interface{})
+	return obj
+}
+
+// list 取出响应中的数组字段
+func list(resp map[string]interface{}, key string) []interface{} {
+	items, _ := resp[key].([]interface{})
+	return items
+}
+
+func TestAuthFlow(t *testing.T) {
+	s := newTestServer(t)
+
+	token := s.register("alice@example.com")
+	s.mustDo(http.MethodPost, "/api/v1/auth/register", "", map[string]string{
+		"email": "alice@example.com", "password": "secret123", "firstName": "A", "lastName": "B",
+	}, http.StatusConflict)
+
+	profile := s.mustDo(http.MethodGet, "/api/v1/profile", token, nil, http.StatusOK)
+	if got := object(profile, "user")["email"]; got != "alice@example.com" {
+		t.Fatalf("profile email = %v", got)
+	}
+
+	s.mustDo(http.MethodPost, "/api/v1/auth/login", "", map[string]string{
+		"email": "alice@example.com", "password": "wrong-password",
+	}, http.StatusUnauthorized)
+	login := s.mustDo(http.MethodPost, "/api/v1/auth/login", "", map[string]string{
+		"email": "alice@example.com", "password": "secret123",
+	}, http.StatusOK)
+	if login["accessToken"] == "" {
+		t.Fatal("登录未返回accessToken")
+	}
+
+	sessions := s.mustDo(http.MethodGet, "/api/v1/sessions", token, nil, http.StatusOK)
+	if n := len(list(sessions, "sessions")); n != 2 {
+		t.Fatalf("会话数量 = %d, 期望 2", n)
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	t.Setenv("REDIS_MAX_SESSIONS_PER_USER", "5")
+	t.Setenv("REDIS_MAX_SESSIONS_PER_ROLE", "user:2,admin:10")
+	s := newTestServer(t)
+	first := s.register("grace@example.com")
+	credentials := map[string]string{"email": "grace@example.com", "password": "secret123"}
+	login := func() map[string]interface{} {
+		t.Helper()
+		return s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusOK)
+	}
+
+	second := login()
+	if evicted := list(second, "evictedSessions"); len(evicted) != 0 {
+		t.Fatalf("未超出上限时淘汰了会话: %v", evicted)
+	}
+
+	// 使用第一个会话后，第二个会话成为最久未使用的会话
+	time.Sleep(5 * time.Millisecond)
+	s.mustDo(http.MethodGet, "/api/v1/profile", first, nil, http.StatusOK)
+	third := login()
+	evicted := list(third, "evictedSessions")
+	if len(evicted) != 1 || evicted[0].(map[string]interface{})["tokenId"] != second["tokenID"] {
+		t.Fatalf("淘汰的会话 = %v, 期望 %v", evicted, second["tokenID"])
+	}
+
+	resp := s.mustDo(http.MethodGet, "/api/v1/profile", second["accessToken"].(string), nil, http.StatusUnauthorized)
+	if resp["reason"] != services.RevokeReasonSessionLimit {
+		t.Fatalf("被淘汰会话的响应 = %v", resp)
+	}
+	s.mustDo(http.MethodGet, "/api/v1/profile", first, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/profile", third["accessToken"].(string), nil, http.StatusOK)
+	if n := len(list(s.mustDo(http.MethodGet, "/api/v1/sessions", first, nil, http.StatusOK), "sessions")); n != 2 {
+		t.Fatalf("会话数量 = %d, 期望 2", n)
+	}
+}
+
+func TestSignInRejection(t *testing.T) {
+	s := newTestServer(t)
+	s.register("frank@example.com")
+	credentials := map[string]string{"email": "frank@example.com", "password": "secret123"}
+	s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusOK)
+
+	// 在新设备上登录，发送"不是我本人"链接
+	data, _ := json.Marshal(credentials)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	var login map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("新设备登录 = %d %s", w.Code, w.Body.String())
+	}
+	suspicious := login["accessToken"].(string)
+	if len(s.notifier.signIns) != 1 {
+		t.Fatalf("新设备登录通知数量 = %d, 期望 1", len(s.notifier.signIns))
+	}
+	rejectURL, _ := url.Parse(s.notifier.signIns[0].RejectURL)
+
+	// 链接扫描和预取只能看到登录信息，不改变任何状态
+	for i := 0; i < 2; i++ {
+		alert := s.mustDo(http.MethodGet, rejectURL.Path, "", nil, http.StatusOK)
+		if object(alert, "deviceInfo")["browser"] != "Chrome" {
+			t.Fatalf("登录提醒 = %v", alert)
+		}
+	}
+	s.mustDo(http.MethodGet, "/api/v1/profile", suspicious, nil, http.StatusOK)
+	s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusOK)
+
+	// 确认后撤销可疑会话并要求重置密码，重置链接只通过邮件发送
+	rejected := s.mustDo(http.MethodPost, rejectURL.Path, "", nil, http.StatusOK)
+	if _, ok := rejected["resetToken"]; ok {
+		t.Fatalf("响应不应包含重置令牌: %v", rejected)
+	}
+	s.mustDo(http.MethodPost, rejectURL.Path, "", nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, rejectURL.Path, "", nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/api/v1/profile", suspicious, nil, http.StatusUnauthorized)
+	if resp := s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusForbidden); resp["code"] != "password_reset_required" {
+		t.Fatalf("重置密码前登录 = %v", resp)
+	}
+
+	if len(s.notifier.resets) != 1 {
+		t.Fatalf("重置密码通知数量 = %d, 期望 1", len(s.notifier.resets))
+	}
+	resetURL, _ := url.Parse(s.notifier.resets[0].ResetURL)
+	resetToken := resetURL.Query().Get("token")
+	s.mustDo(http.MethodPost, "/api/v1/auth/reset-password", "", map[string]string{"token": "invalid", "newPassword": "newsecret456"}, http.StatusBadRequest)
+	s.mustDo(http.MethodPost, "/api/v1/auth/reset-password", "", map[string]string{"token": resetToken, "newPassword": "newsecret456"}, http.StatusOK)
+	s.mustDo(http.MethodPost, "/api/v1/auth/reset-password", "", map[string]string{"token": resetToken, "newPassword": "another789"}, http.StatusBadRequest)
+
+	s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusUnauthorized)
+	credentials["password"] = "newsecret456"
+	s.mustDo(http.MethodPost, "/api/v1/auth/login", "", credentials, http.StatusOK)
+}
+
+func TestProtectedRoutesRequireAuth(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, path := range []string{"/api/v1/profile", "/api/v1/projects", "/api/v1/tasks", "/api/v1/labels"} {
+		s.mustDo(http.MethodGet, path, "", nil, http.StatusUnauthorized)
+		s.mustDo(http.MethodGet, path, "invalid-token", nil, http.StatusUnauthorized)
+	}
+}
+
+func TestProjectCRUD(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("bob@example.com")
+
+	created := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusCreated), "project")
+	id := created["id"].(string)
+	if created["color"] != services.DefaultProjectColor {
+		t.Fatalf("默认颜色 = %v", created["color"])
+	}
+
+	s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{}, http.StatusBadRequest)
+
+	updated := object(s.mustDo(http.MethodPut, "/api/v1/projects/"+id, token, map[string]string{"name": "Office", "color": "#FF0000"}, http.StatusOK), "project")
+	if updated["name"] != "Office" || updated["color"] != "#FF0000" {
+		t.Fatalf("更新后的项目 = %v", updated)
+	}
+
+	projects := s.mustDo(http.MethodGet, "/api/v1/projects", token, nil, http.StatusOK)
+	if n := len(list(projects, "projects")); n != 1 {
+		t.Fatalf("项目数量 = %d, 期望 1", n)
+	}
+
+	task := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": id, "title": "Write report"}, http.StatusCreated), "task")
+
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+id, token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/projects/"+id, token, nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+task["id"].(string), token, nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/api/v1/projects/not-a-uuid", token, nil, http.StatusBadRequest)
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("carol@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Home"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "urgent"}, http.StatusCreated), "label")["id"].(string)
+
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	task := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID,
+		"title":     "Clean kitchen",
+		"dueTime":   due,
+		"labelIds":  []string{labelID},
+	}, http.StatusCreated), "task")
+	taskID := task["id"].(string)
+	if task["priority"].(float64) != services.DefaultTaskPriority {
+		t.Fatalf("默认优先级 = %v", task["priority"])
+	}
+	if n := len(list(task, "labels")); n != 1 {
+		t.Fatalf("标签数量 = %d, 期望 1", n)
+	}
+
+	subtask := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID,
+		"parentId":  taskID,
+		"title":     "Wipe counters",
+	}, http.StatusCreated), "task")
+	s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID,
+		"parentId":  subtask["id"],
+		"title":     "Too deep",
+	}, http.StatusBadRequest)
+
+	children := s.mustDo(http.MethodGet, "/api/v1/tasks?parentId="+taskID, token, nil, http.StatusOK)
+	if n := len(list(children, "tasks")); n != 1 {
+		t.Fatalf("子任务数量 = %d, 期望 1", n)
+	}
+
+	// 显式传null清空截止时间，未提供的字段保持不变
+	updated := object(s.mustDo(http.MethodPut, "/api/v1/tasks/"+taskID, token, map[string]interface{}{
+		"priority": 1,
+		"dueTime":  nil,
+		"labelIds": []string{},
+	}, http.StatusOK), "task")
+	if updated["dueTime"] != nil || updated["priority"].(float64) != 1 || updated["title"] != "Clean kitchen" {
+		t.Fatalf("更新后的任务 = %v", updated)
+	}
+	if n := len(list(updated, "labels")); n != 0 {
+		t.Fatalf("标签数量 = %d, 期望 0", n)
+	}
+
+	completed := object(s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/complete", token, nil, http.StatusOK), "task")
+	if completed["status"] != "completed" || completed["completedAt"] == nil {
+		t.Fatalf("完成后的任务 = %v", completed)
+	}
+	done := s.mustDo(http.MethodGet, "/api/v1/tasks?status=completed", token, nil, http.StatusOK)
+	if n := len(list(done, "tasks")); n != 1 {
+		t.Fatalf("已完成任务数量 = %d, 期望 1", n)
+	}
+	reopened := object(s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/reopen", token, nil, http.StatusOK), "task")
+	if reopened["status"] != "incomplete" {
+		t.Fatalf("重新打开后的状态 = %v", reopened["status"])
+	}
+
+	s.mustDo(http.MethodGet, "/api/v1/tasks?status=unknown", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?dueFrom=tomorrow", token, nil, http.StatusBadRequest)
+
+	s.mustDo(http.MethodDelete, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+subtask["id"].(string), token, nil, http.StatusNotFound)
+}
+
+func TestListPagination(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("pages@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	for i, priority := range []int{3, 1, 4, 1, 2} {
+		task := map[string]interface{}{"projectId": projectID, "title": fmt.Sprintf("Task %d", i), "priority": priority}
+		if i%2 == 0 {
+			task["dueTime"] = due.Add(time.Duration(i) * time.Hour)
+		}
+		s.mustDo(http.MethodPost, "/api/v1/tasks", token, task, http.StatusCreated)
+	}
+
+	// 逐页读取，直到没有下一页
+	readAll := func(query string) []map[string]interface{} {
+		var items []map[string]interface{}
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			path := "/api/v1/tasks?limit=2&" + query
+			if cursor != "" {
+				path += "&cursor=" + cursor
+			}
+			resp := s.mustDo(http.MethodGet, path, token, nil, http.StatusOK)
+			for _, item := range list(resp, "tasks") {
+				items = append(items, item.(map[string]interface{}))
+			}
+			if resp["nextCursor"] == nil {
+				return items
+			}
+			cursor = resp["nextCursor"].(string)
+		}
+		t.Fatalf("分页未结束: %s", query)
+		return nil
+	}
+
+	byPriority := readAll("sort=-priority")
+	if len(byPriority) != 5 {
+		t.Fatalf("按优先级分页读取到 %d 条, 期望 5", len(byPriority))
+	}
+	for i := 1; i < len(byPriority); i++ {
+		if byPriority[i-1]["priority"].(float64) < byPriority[i]["priority"].(float64) {
+			t.Fatalf("优先级降序错误: %v", byPriority)
+		}
+	}
+
+	// 没有截止时间的任务排在最后
+	byDue := readAll("sort=due")
+	if len(byDue) != 5 || byDue[2]["dueTime"] == nil || byDue[3]["dueTime"] != nil {
+		t.Fatalf("按截止时间分页结果 = %v", byDue)
+	}
+
+	trimmed := list(s.mustDo(http.MethodGet, "/api/v1/tasks?fields=title", token, nil, http.StatusOK), "tasks")
+	if item := trimmed[0].(map[string]interface{}); len(item) != 2 || item["id"] == nil || item["title"] == nil {
+		t.Fatalf("字段裁剪结果 = %v", item)
+	}
+
+	first := s.mustDo(http.MethodGet, "/api/v1/tasks?limit=1&sort=created", token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?sort=created&cursor="+first["nextCursor"].(string), token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?sort=due&cursor="+first["nextCursor"].(string), token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?cursor=garbage", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?sort=color", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/tasks?fields=secret", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/projects?limit=500", token, nil, http.StatusBadRequest)
+}
+
+func TestSecondaryListPagination(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("more-pages@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	for _, name := range []string{"A", "B", "C"} {
+		s.mustDo(http.MethodPost, "/api/v1/projects/"+projectID+"/sections", token, map[string]string{"name": name}, http.StatusCreated)
+		s.mustDo(http.MethodPost, "/api/v1/project-groups", token, map[string]string{"name": name}, http.StatusCreated)
+		s.mustDo(http.MethodPost, "/api/v1/smart-lists", token, map[string]string{"name": name, "query": "priority:1"}, http.StatusCreated)
+		s.mustDo(http.MethodPost, "/api/v1/app-passwords", token, map[string]string{"name": name}, http.StatusCreated)
+		s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{
+			"url": "https://93.184.216.34/" + name, "events": []string{"task.created"},
+		}, http.StatusCreated)
+		taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": name}, http.StatusCreated), "task")["id"].(string)
+		s.mustDo(http.MethodDelete, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{}, http.StatusCreated)
+	s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{"projectId": projectID}, http.StatusCreated)
+
+	// 每页1条逐页读取，返回各页的字段值
+	readAll := func(path, key, field string) []string {
+		var values []string
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			sep := "?"
+			if strings.Contains(path, "?") {
+				sep = "&"
+			}
+			page := path + sep + "limit=1"
+			if cursor != "" {
+				page += "&cursor=" + cursor
+			}
+			resp := s.mustDo(http.MethodGet, page, token, nil, http.StatusOK)
+			for _, item := range list(resp, key) {
+				values = append(values, fmt.Sprint(item.(map[string]interface{})[field]))
+			}
+			if resp["nextCursor"] == nil {
+				return values
+			}
+			cursor = resp["nextCursor"].(string)
+		}
+		t.Fatalf("分页未结束: %s", path)
+		return nil
+	}
+
+	for _, tt := range []struct {
+		path, key, field string
+		want             string
+	}{
+		{"/api/v1/projects/" + projectID + "/sections", "sections", "name", "[A B C]"},
+		{"/api/v1/project-groups", "groups", "name", "[A B C]"},
+		{"/api/v1/app-passwords", "appPasswords", "name", "[A B C]"},
+		{"/api/v1/webhooks", "webhooks", "url", "[https://93.184.216.34/A https://93.184.216.34/B https://93.184.216.34/C]"},
+		{"/api/v1/trash", "tasks", "title", "[C B A]"},
+	} {
+		if got := fmt.Sprint(readAll(tt.path, tt.key, tt.field)); got != tt.want {
+			t.Fatalf("%s 分页结果 = %s, 期望 %s", tt.path, got, tt.want)
+		}
+	}
+	if got := readAll("/api/v1/calendar-feeds", "feeds", "id"); len(got) != 2 {
+		t.Fatalf("日历订阅分页结果 = %v", got)
+	}
+
+	// 内置清单只出现在第一页开头，不占用limit
+	lists := readAll("/api/v1/smart-lists", "smartLists", "name")
+	if len(lists) != 5 || fmt.Sprint(lists[2:]) != "[A B C]" {
+		t.Fatalf("智能清单分页结果 = %v", lists)
+	}
+	if first := s.mustDo(http.MethodGet, "/api/v1/smart-lists?limit=1", token, nil, http.StatusOK); len(list(first, "smartLists")) != 3 {
+		t.Fatalf("智能清单第一页 = %v", first)
+	}
+
+	// 回收站中的项目和任务分别分页
+	for _, name := range []string{"X", "Y"} {
+		id := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": name}, http.StatusCreated), "project")["id"].(string)
+		s.mustDo(http.MethodDelete, "/api/v1/projects/"+id, token, nil, http.StatusOK)
+	}
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+projectID, token, nil, http.StatusOK)
+	if got := fmt.Sprint(readAll("/api/v1/trash?type=projects", "projects", "name")); got != "[Inbox Y X]" {
+		t.Fatalf("回收站项目分页结果 = %s", got)
+	}
+	if trash := s.mustDo(http.MethodGet, "/api/v1/trash?limit=1", token, nil, http.StatusOK); len(list(trash, "tasks")) != 0 || trash["projects"] != nil || trash["nextCursor"] != nil {
+		t.Fatalf("删除项目后的回收站任务 = %v", trash)
+	}
+	s.mustDo(http.MethodGet, "/api/v1/trash?type=labels", token, nil, http.StatusBadRequest)
+
+	s.mustDo(http.MethodGet, "/api/v1/webhooks?sort=url", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/trash?cursor=garbage", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/app-passwords?fields=tokenHash", token, nil, http.StatusBadRequest)
+}
+
+func TestManualOrdering(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("order@example.com")
+
+	createProject := func(name string) string {
+		return object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": name}, http.StatusCreated), "project")["id"].(string)
+	}
+	projectID := createProject("Inbox")
+	ids := map[string]string{}
+	for _, title := range []string{"A", "B", "C"} {
+		task := s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": title}, http.StatusCreated)
+		ids[title] = object(task, "task")["id"].(string)
+	}
+
+	// order 返回任务标题拼接成的顺序，按项目查询时只保留顶层任务
+	order := func(path string) string {
+		var titles []string
+		for _, item := range list(s.mustDo(http.MethodGet, path, token, nil, http.StatusOK), "tasks") {
+			task := item.(map[string]interface{})
+			if strings.Contains(path, "projectId") && task["parentId"] != nil {
+				continue
+			}
+			titles = append(titles, task["title"].(string))
+		}
+		return strings.Join(titles, "")
+	}
+	topLevel := "/api/v1/tasks?sort=manual&projectId=" + projectID
+	move := func(title string, body map[string]string, want int) {
+		s.mustDo(http.MethodPost, "/api/v1/tasks/"+ids[title]+"/move", token, body, want)
+	}
+
+	if got := order(topLevel); got != "ABC" {
+		t.Fatalf("新任务应按创建顺序排在末尾, 实际 %s", got)
+	}
+	move("C", map[string]string{"afterId": ids["A"], "beforeId": ids["B"]}, http.StatusOK)
+	if got := order(topLevel); got != "ACB" {
+		t.Fatalf("移动到A和B之间后顺序为 %s", got)
+	}
+	move("A", map[string]string{}, http.StatusOK)
+	move("B", map[string]string{"beforeId": ids["C"]}, http.StatusOK)
+	if got := order(topLevel); got != "BCA" {
+		t.Fatalf("移动到末尾和C之前后顺序为 %s", got)
+	}
+
+	// 以子任务为参照时移动到对应父任务下
+	sub := s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "parentId": ids["B"], "title": "S"}, http.StatusCreated)
+	ids["S"] = object(sub, "task")["id"].(string)
+	move("A", map[string]string{"afterId": ids["S"]}, http.StatusOK)
+	if got := order("/api/v1/tasks?sort=manual&parentId=" + ids["B"]); got != "SA" {
+		t.Fatalf("子任务顺序为 %s", got)
+	}
+	if got := order(topLevel); got != "BC" {
+		t.Fatalf("顶层任务顺序为 %s", got)
+	}
+
+	move("C", map[string]string{"afterId": ids["C"]}, http.StatusBadRequest)
+	move("C", map[string]string{"afterId": ids["B"], "beforeId": ids["S"]}, http.StatusBadRequest)
+
+	// 反复插入到同一位置后由后台任务缩短排序键，顺序保持不变
+	for i := 0; i < 40; i++ {
+		title := fmt.Sprintf("N%d", i)
+		task := s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": title}, http.StatusCreated)
+		ids[title] = object(task, "task")["id"].(string)
+		move(title, map[string]string{"afterId": ids["B"], "beforeId": ids["C"]}, http.StatusOK)
+	}
+	before := order(topLevel + "&limit=200")
+	rebalancer := services.NewOrderRebalancer(s.store, s.store.Projects(), s.store.Tasks(), s.store.Sections(), s.store.ProjectGroups(), &config.OrderingConfig{MaxKeyLength: 2, RebalanceInterval: time.Hour})
+	rebalancer.Rebalance(context.Background())
+	if after := order(topLevel + "&limit=200"); after != before {
+		t.Fatalf("重新平衡后顺序改变: %s -> %s", before, after)
+	}
+	for _, item := range list(s.mustDo(http.MethodGet, topLevel+"&limit=200", token, nil, http.StatusOK), "tasks") {
+		if key := item.(map[string]interface{})["sortOrder"].(string); len(key) > 2 {
+			t.Fatalf("重新平衡后排序键仍然过长: %q", key)
+		}
+	}
+
+	// 项目默认按手动顺序返回
+	second, third := createProject("Work"), createProject("Personal")
+	s.mustDo(http.MethodPost, "/api/v1/projects/"+third+"/move", token, map[string]string{"beforeId": projectID}, http.StatusOK)
+	var projects []string
+	for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/projects", token, nil, http.StatusOK), "projects") {
+		projects = append(projects, item.(map[string]interface{})["id"].(string))
+	}
+	if strings.Join(projects, ",") != strings.Join([]string{third, projectID, second}, ",") {
+		t.Fatalf("项目顺序错误: %v", projects)
+	}
+}
+
+func TestProjectSections(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("sections@example.com")
+
+	project := s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Board"}, http.StatusCreated)
+	projectID := object(project, "project")["id"].(string)
+	sectionsPath := "/api/v1/projects/" + projectID + "/sections"
+
+	sectionIDs := map[string]string{}
+	for _, name := range []string{"Todo", "Doing", "Done"} {
+		section := s.mustDo(http.MethodPost, sectionsPath, token, map[string]string{"name": name}, http.StatusCreated)
+		sectionIDs[name] = object(section, "section")["id"].(string)
+	}
+	s.mustDo(http.MethodPost, sectionsPath+"/"+sectionIDs["Done"]+"/move", token, map[string]string{"beforeId": sectionIDs["Todo"]}, http.StatusOK)
+
+	taskIDs := map[string]string{}
+	for _, item := range []struct{ title, section string }{{"A", "Todo"}, {"B", "Todo"}, {"C", "Doing"}, {"D", ""}} {
+		body := map[string]string{"projectId": projectID, "title": item.title}
+		if item.section != "" {
+			body["sectionId"] = sectionIDs[item.section]
+		}
+		task := s.mustDo(http.MethodPost, "/api/v1/tasks", token, body, http.StatusCreated)
+		taskIDs[item.title] = object(task, "task")["id"].(string)
+	}
+
+	// 其他项目的分栏不能使用
+	other := s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Other"}, http.StatusCreated)
+	otherID := object(other, "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": otherID, "title": "X", "sectionId": sectionIDs["Todo"]}, http.StatusBadRequest)
+
+	// 将B移到Doing分栏中C之前
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskIDs["B"]+"/move", token, map[string]string{"beforeId": taskIDs["C"]}, http.StatusOK)
+	// 将A直接移到Done分栏末尾
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskIDs["A"]+"/move", token, map[string]string{"sectionId": sectionIDs["Done"]}, http.StatusOK)
+	s.mustDo(http.MethodPut, sectionsPath+"/"+sectionIDs["Done"], token, map[string]bool{"collapsed": true}, http.StatusOK)
+
+	// board 返回看板中各列的名称、折叠状态和任务标题
+	board := func() []string {
+		var columns []string
+		for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/projects/"+projectID+"/board", token, nil, http.StatusOK), "sections") {
+			column := item.(map[string]interface{})
+			titles := column["name"].(string) + ":"
+			if column["collapsed"].(bool) {
+				titles += "(collapsed)"
+			}
+			for _, task := range column["tasks"].([]interface{}) {
+				titles += task.(map[string]interface{})["title"].(string)
+			}
+			columns = append(columns, titles)
+		}
+		return columns
+	}
+	if got := strings.Join(board(), " "); got != "默认分栏:D Done:(collapsed)A Todo: Doing:BC" {
+		t.Fatalf("看板内容错误: %s", got)
+	}
+
+	// 删除分栏后其中的任务回到默认分栏
+	s.mustDo(http.MethodDelete, sectionsPath+"/"+sectionIDs["Doing"], token, nil, http.StatusOK)
+	if got := strings.Join(board(), " "); got != "默认分栏:BCD Done:(collapsed)A Todo:" {
+		t.Fatalf("删除分栏后看板内容错误: %s", got)
+	}
+	task := object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskIDs["B"], token, nil, http.StatusOK), "task")
+	if task["sectionId"] != nil {
+		t.Fatalf("删除分栏后任务仍属于该分栏: %v", task["sectionId"])
+	}
+	s.mustDo(http.MethodPut, sectionsPath+"/"+sectionIDs["Doing"], token, map[string]string{"name": "Gone"}, http.StatusNotFound)
+	if got := list(s.mustDo(http.MethodGet, sectionsPath, token, nil, http.StatusOK), "sections"); len(got) != 2 {
+		t.Fatalf("分栏数量错误: %d", len(got))
+	}
+}
+
+func TestProjectGroups(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("groups@example.com")
+
+	groupIDs := map[string]string{}
+	for _, name := range []string{"Work", "Life"} {
+		group := s.mustDo(http.MethodPost, "/api/v1/project-groups", token, map[string]string{"name": name}, http.StatusCreated)
+		groupIDs[name] = object(group, "group")["id"].(string)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/project-groups", token, map[string]string{"name": "Work"}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/api/v1/project-groups/"+groupIDs["Life"]+"/move", token, map[string]string{"beforeId": groupIDs["Work"]}, http.StatusOK)
+
+	projectIDs := map[string]string{}
+	for _, item := range []struct{ name, group string }{{"A", "Work"}, {"B", ""}, {"C", "Work"}, {"D", "Life"}} {
+		body := map[string]string{"name": item.name}
+		if item.group != "" {
+			body["groupId"] = groupIDs[item.group]
+		}
+		project := s.mustDo(http.MethodPost, "/api/v1/projects", token, body, http.StatusCreated)
+		projectIDs[item.name] = object(project, "project")["id"].(string)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "E", "groupId": projectIDs["A"]}, http.StatusBadRequest)
+
+	// 以Work中的A为参照把B移入分组，再把D直接移出分组
+	s.mustDo(http.MethodPost, "/api/v1/projects/"+projectIDs["B"]+"/move", token, map[string]string{"beforeId": projectIDs["A"]}, http.StatusOK)
+	s.mustDo(http.MethodPost, "/api/v1/projects/"+projectIDs["D"]+"/move", token, map[string]interface{}{"groupId": nil}, http.StatusOK)
+	s.mustDo(http.MethodPut, "/api/v1/project-groups/"+groupIDs["Work"], token, map[string]bool{"collapsed": true}, http.StatusOK)
+
+	// sidebar 返回侧边栏结构的文本表示
+	sidebar := func() string {
+		resp := s.mustDo(http.MethodGet, "/api/v1/projects?grouped=true", token, nil, http.StatusOK)
+		names := func(items []interface{}) string {
+			var out string
+			for _, item := range items {
+				out += item.(map[string]interface{})["name"].(string)
+			}
+			return out
+		}
+		var parts []string
+		for _, item := range list(resp, "groups") {
+			group := item.(map[string]interface{})
+			part := group["name"].(string) + ":"
+			if group["collapsed"].(bool) {
+				part += "(collapsed)"
+			}
+			parts = append(parts, part+names(group["projects"].([]interface{})))
+		}
+		return strings.Join(append(parts, "-:"+names(list(resp, "ungrouped"))), " ")
+	}
+	if got := sidebar(); got != "Life: Work:(collapsed)BAC -:D" {
+		t.Fatalf("侧边栏结构错误: %s", got)
+	}
+
+	// 删除分组后其中的项目变为未分组，不会被删除
+	s.mustDo(http.MethodDelete, "/api/v1/project-groups/"+groupIDs["Work"], token, nil, http.StatusOK)
+	if got := sidebar(); got != "Life: -:BACD" {
+		t.Fatalf("删除分组后侧边栏结构错误: %s", got)
+	}
+	project := object(s.mustDo(http.MethodGet, "/api/v1/projects/"+projectIDs["A"], token, nil, http.StatusOK), "project")
+	if project["groupId"] != nil {
+		t.Fatalf("删除分组后项目仍属于该分组: %v", project["groupId"])
+	}
+	s.mustDo(http.MethodPut, "/api/v1/projects/"+projectIDs["A"], token, map[string]string{"groupId": groupIDs["Work"]}, http.StatusBadRequest)
+	s.mustDo(http.MethodPut, "/api/v1/projects/"+projectIDs["A"], token, map[string]string{"groupId": groupIDs["Life"]}, http.StatusOK)
+	if got := sidebar(); got != "Life:A -:BCD" {
+		t.Fatalf("修改分组后侧边栏结构错误: %s", got)
+	}
+}
+
+func TestUserSettings(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("settings@example.com")
+
+	settings := object(s.mustDo(http.MethodGet, "/api/v1/settings", token, nil, http.StatusOK), "settings")
+	if settings["timeZone"] != "Asia/Shanghai" || settings["weekStart"] != float64(1) || settings["updatedAt"] != nil {
+		t.Fatalf("默认设置错误: %v", settings)
+	}
+
+	// 嵌套对象按字段合并，未提供的字段保持不变
+	settings = object(s.mustDo(http.MethodPatch, "/api/v1/settings", token, map[string]interface{}{
+		"timeZone":               "America/New_York",
+		"defaultReminderOffsets": []int{60, 0},
+		"quietHours":             map[string]bool{"enabled": true},
+	}, http.StatusOK), "settings")
+	quiet := settings["quietHours"].(map[string]interface{})
+	if settings["timeZone"] != "America/New_York" || quiet["enabled"] != true || quiet["start"] != "22:00" || settings["locale"] != "zh-CN" {
+		t.Fatalf("部分更新后设置错误: %v", settings)
+	}
+	if fmt.Sprint(settings["defaultReminderOffsets"]) != "[0 60]" {
+		t.Fatalf("默认提醒应按提前时间排序: %v", settings["defaultReminderOffsets"])
+	}
+
+	for _, body := range []map[string]interface{}{
+		{"timeZone": "Mars/Olympus"},
+		{"weekStart": 7},
+		{"locale": "fr-FR"},
+		{"quietHours": map[string]string{"start": "25:00"}},
+		{"defaultReminderOffsets": []int{10, 10}},
+		{"theme": "dark"},
+		{"notificationChannels": map[string]string{"email": "yes"}},
+	} {
+		s.mustDo(http.MethodPatch, "/api/v1/settings", token, body, http.StatusBadRequest)
+	}
+	settings = object(s.mustDo(http.MethodGet, "/api/v1/settings", token, nil, http.StatusOK), "settings")
+	if settings["timeZone"] != "America/New_York" || settings["updatedAt"] == nil {
+		t.Fatalf("校验失败的更新不应生效: %v", settings)
+	}
+
+	// 旧文档中缺少的字段取当前版本的默认值
+	userID := object(s.mustDo(http.MethodGet, "/api/v1/profile", token, nil, http.StatusOK), "user")["id"].(string)
+	err := s.store.UserSettings().SaveUserSettings(context.Background(), &models.UserSettings{
+		UserID:   uuid.MustParse(userID),
+		Version:  1,
+		Document: `{"timeZone":"Europe/Berlin"}`,
+	})
+	if err != nil {
+		t.Fatalf("保存设置失败: %v", err)
+	}
+	settings = object(s.mustDo(http.MethodGet, "/api/v1/settings", token, nil, http.StatusOK), "settings")
+	if settings["timeZone"] != "Europe/Berlin" || settings["dateFormat"] != "YYYY-MM-DD" || settings["notificationChannels"].(map[string]interface{})["email"] != true {
+		t.Fatalf("旧版本设置应补全默认值: %v", settings)
+	}
+}
+
+func TestICSExport(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("ics@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusCreated), "project")["id"].(string)
+	otherID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Home"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "a,b"}, http.StatusCreated), "label")["id"].(string)
+
+	createTask := func(body map[string]interface{}) string {
+		return object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, body, http.StatusCreated), "task")["id"].(string)
+	}
+	title := strings.Repeat("每日站会", 10)
+	standup := createTask(map[string]interface{}{
+		"projectId":   projectID,
+		"title":       title,
+		"description": "第一行\n议程; 进展",
+		"priority":    1,
+		"dueTime":     "2030-01-01T01:00:00Z",
+		"rruleString": "RRULE:FREQ=DAILY;COUNT=10",
+		"labelIds":    []string{labelID},
+	})
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+standup+"/reminders", token, map[string]string{"remindAt": "2030-01-01T00:45:00Z"}, http.StatusCreated)
+	createTask(map[string]interface{}{
+		"projectId": projectID,
+		"title":     "评审会",
+		"startTime": "2030-01-02T06:00:00Z",
+		"dueTime":   "2030-01-02T07:00:00Z",
+	})
+	createTask(map[string]interface{}{"projectId": otherID, "title": "买菜"})
+
+	// 删除第2次实例，用另一个任务替代第3次实例
+	moved := createTask(map[string]interface{}{"projectId": projectID, "title": "改期的站会", "dueTime": "2030-01-03T03:00:00Z"})
+	standupID, movedID := uuid.MustParse(standup), uuid.MustParse(moved)
+	for _, exception := range []*models.TaskRecurrenceException{
+		{RecurringTaskID: standupID, OriginalTime: time.Date(2030, 1, 2, 1, 0, 0, 0, time.UTC)},
+		{RecurringTaskID: standupID, OriginalTime: time.Date(2030, 1, 3, 1, 0, 0, 0, time.UTC), NewTaskID: &movedID},
+	} {
+		if err := s.store.Tasks().CreateRecurrenceException(context.Background(), exception); err != nil {
+			t.Fatalf("创建循环例外失败: %v", err)
+		}
+	}
+
+	w := s.doRaw(http.MethodGet, "/api/v1/projects/"+projectID+"/export/ics", token, "", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("导出失败: %d %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Fatalf("日历应以CRLF结尾: %q", body)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("行未折叠: %q", line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(body, "\r\n ", "")
+	for _, want := range []string{
+		"X-WR-CALNAME:Work",
+		"BEGIN:VTODO\r\nUID:" + standup,
+		"SUMMARY:" + title,
+		"DESCRIPTION:第一行\\n议程\\; 进展",
+		"DUE:20300101T010000Z",
+		"RRULE:FREQ=DAILY;COUNT=10",
+		"EXDATE:20300102T010000Z",
+		"PRIORITY:1",
+		"CATEGORIES:a\\,b",
+		"TRIGGER;RELATED=END:-PT15M",
+		"UID:" + standup + "\r\nDTSTAMP:",
+		"SUMMARY:改期的站会",
+		"RECURRENCE-ID:20300103T010000Z",
+		"BEGIN:VEVENT",
+		"DTSTART:20300102T060000Z\r\nDTEND:20300102T070000Z",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Fatalf("导出内容缺少 %q:\n%s", want, unfolded)
+		}
+	}
+	if strings.Contains(unfolded, "UID:"+moved) || strings.Contains(unfolded, "买菜") {
+		t.Fatalf("替代实例应使用循环任务的UID，且不应包含其他项目的任务:\n%s", unfolded)
+	}
+
+	all := s.doRaw(http.MethodGet, "/api/v1/export/ics", token, "", nil).Body.String()
+	if strings.Count(all, "BEGIN:VTODO") != 3 || !strings.Contains(all, "SUMMARY:买菜") {
+		t.Fatalf("全部导出内容错误:\n%s", all)
+	}
+	if w := s.doRaw(http.MethodGet, "/api/v1/projects/"+uuid.NewString()+"/export/ics", token, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("导出不存在的项目应返回404, 实际 %d", w.Code)
+	}
+}
+
+func TestICSImport(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("ics-import@example.com")
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Imported"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "工作"}, http.StatusCreated)
+
+	// 自定义时区不是IANA名称，只能按内嵌的VTIMEZONE换算：夏令时UTC-4，冬令时UTC-5
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Test//EN",
+		"BEGIN:VTIMEZONE",
+		"TZID:Custom/Eastern",
+		"BEGIN:DAYLIGHT",
+		"DTSTART:20070311T020000",
+		"TZOFFSETFROM:-0500",
+		"TZOFFSETTO:-0400",
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"END:DAYLIGHT",
+		"BEGIN:STANDARD",
+		"DTSTART:20071104T020000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0500",
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"SUMMARY:每日站会\\, 同步",
+		" 进展",
+		"DTSTART;TZID=Custom/Eastern:20300701T090000",
+		"DURATION:PT30M",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"EXDATE;TZID=\"Custom/Eastern\":20300702T090000",
+		"CATEGORIES:工作,会议",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"RECURRENCE-ID;TZID=Custom/Eastern:20300703T090000",
+		"SUMMARY:改期的站会",
+		"DTSTART;TZID=Custom/Eastern:20300703T100000",
+		"DTEND;TZID=Custom/Eastern:20300703T103000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:ghost@example.com",
+		"RECURRENCE-ID:20300101T000000Z",
+		"SUMMARY:孤立的实例",
+		"DTSTART:20300101T000000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:winter@example.com",
+		"SUMMARY:冬季会议",
+		"DTSTART;TZID=Custom/Eastern:20300115T090000",
+		"DTEND;TZID=Custom/Eastern:20300115T100000",
+		"CATEGORIES:工作",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:report@example.com",
+		"SUMMARY:交报告",
+		"DUE;VALUE=DATE:20300110",
+		"PRIORITY:5",
+		"STATUS:COMPLETED",
+		"BEGIN:VALARM",
+		"TRIGGER;RELATED=END:-PT1H",
+		"END:VALARM",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT5M",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:untitled@example.com",
+		"DUE:20300110T000000Z",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}
+	// 混用CRLF和LF换行
+	data := []byte(strings.Join(lines[:20], "\r\n") + "\n" + strings.Join(lines[20:], "\n") + "\n")
+
+	importICS := func(contentType string, body []byte, want int) map[string]interface{} {
+		t.Helper()
+		w := s.doRaw(http.MethodPost, "/api/v1/projects/"+projectID+"/import/ics", token, contentType, body)
+		if w.Code != want {
+			t.Fatalf("导入状态码 = %d, 期望 %d: %s", w.Code, want, w.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("响应不是JSON: %s", w.Body.String())
+		}
+		return resp
+	}
+
+	result := object(importICS("text/calendar", data, http.StatusOK), "result")
+	if result["imported"] != float64(4) || result["skipped"] != float64(0) || result["failed"] != float64(2) {
+		t.Fatalf("导入统计错误: %v", result)
+	}
+	items := make(map[string]map[string]interface{})
+	for _, item := range result["items"].([]interface{}) {
+		item := item.(map[string]interface{})
+		key := item["uid"].(string)
+		if item["recurrenceId"] != nil {
+			key += "#" + item["recurrenceId"].(string)
+		}
+		items[key] = item
+	}
+	if items["untitled@example.com"]["status"] != "failed" || items["untitled@example.com"]["error"] != "缺少SUMMARY" {
+		t.Fatalf("缺少标题的条目应导入失败: %v", items["untitled@example.com"])
+	}
+	if items["ghost@example.com#2030-01-01T00:00:00Z"]["status"] != "failed" {
+		t.Fatalf("找不到循环任务的替代实例应导入失败: %v", items)
+	}
+	if warnings, _ := items["report@example.com"]["warnings"].([]interface{}); len(warnings) != 1 {
+		t.Fatalf("无法换算的提醒应记录为警告: %v", items["report@example.com"])
+	}
+
+	getTask := func(key string) map[string]interface{} {
+		id := items[key]["taskId"].(string)
+		return object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+id, token, nil, http.StatusOK), "task")
+	}
+	standup := getTask("standup@example.com")
+	if standup["title"] != "每日站会, 同步进展" || standup["icalUid"] != "standup@example.com" {
+		t.Fatalf("折叠行应被展开并反转义: %v", standup)
+	}
+	if standup["startTime"] != "2030-07-01T13:00:00Z" || standup["dueTime"] != "2030-07-01T13:30:00Z" || standup["rruleString"] != "RRULE:FREQ=DAILY;COUNT=5" {
+		t.Fatalf("夏令时换算或循环规则错误: %v", standup)
+	}
+	if reminders := standup["reminders"].([]interface{}); len(reminders) != 1 || reminders[0].(map[string]interface{})["remindAt"] != "2030-07-01T12:50:00Z" {
+		t.Fatalf("VALARM应转换为提醒: %v", standup["reminders"])
+	}
+	if len(standup["labels"].([]interface{})) != 2 {
+		t.Fatalf("CATEGORIES应转换为标签: %v", standup["labels"])
+	}
+	if winter := getTask("winter@example.com"); winter["startTime"] != "2030-01-15T14:00:00Z" {
+		t.Fatalf("冬令时换算错误: %v", winter)
+	}
+	report := getTask("report@example.com")
+	if report["dueTime"] != "2030-01-09T16:00:00Z" || report["priority"] != float64(2) || report["status"] != "completed" {
+		t.Fatalf("全天待办应按用户时区解析: %v", report)
+	}
+	if labels := list(s.mustDo(http.MethodGet, "/api/v1/labels", token, nil, http.StatusOK), "labels"); len(labels) != 2 {
+		t.Fatalf("同名标签应复用: %v", labels)
+	}
+
+	// 导出时保留原UID，例外和替代实例随循环任务一起导出
+	w := s.doRaw(http.MethodGet, "/api/v1/projects/"+projectID+"/export/ics", token, "", nil)
+	unfolded := strings.ReplaceAll(w.Body.String(), "\r\n ", "")
+	for _, want := range []string{
+		"UID:standup@example.com",
+		"EXDATE:20300702T130000Z",
+		"RECURRENCE-ID:20300703T130000Z",
+		"DTSTART:20300703T140000Z",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Fatalf("导出内容缺少 %q:\n%s", want, unfolded)
+		}
+	}
+
+	// 通过multipart重复导入，已导入的UID及其替代实例被跳过
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", "calendar.ics")
+	if err != nil {
+		t.Fatalf("创建表单失败: %v", err)
+	}
+	part.Write(data)
+	form.Close()
+	result = object(importICS(form.FormDataContentType(), buf.Bytes(), http.StatusOK), "result")
+	if result["imported"] != float64(0) || result["skipped"] != float64(4) || result["failed"] != float64(2) {
+		t.Fatalf("重复导入应跳过已导入的条目: %v", result)
+	}
+
+	importICS("text/calendar", []byte("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n"), http.StatusBadRequest)
+	if w := s.doRaw(http.MethodPost, "/api/v1/projects/"+uuid.NewString()+"/import/ics", token, "text/calendar", data); w.Code != http.StatusNotFound {
+		t.Fatalf("导入到不存在的项目应返回404: %d", w.Code)
+	}
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("feeds@example.com")
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusCreated), "project")["id"].(string)
+	otherID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Home"}, http.StatusCreated), "project")["id"].(string)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	at := func(days, hours int) string {
+		return today.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour).Format(time.RFC3339)
+	}
+	createTask := func(body map[string]interface{}) string {
+		return object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, body, http.StatusCreated), "task")["id"].(string)
+	}
+	standup := createTask(map[string]interface{}{
+		"projectId":   projectID,
+		"title":       "站会",
+		"startTime":   at(1, 1),
+		"dueTime":     at(1, 2),
+		"rruleString": "RRULE:FREQ=DAILY;COUNT=5",
+	})
+	createTask(map[string]interface{}{"projectId": projectID, "title": "交周报", "dueTime": at(2, 10)})
+	createTask(map[string]interface{}{"projectId": projectID, "title": "明年的事", "dueTime": at(400, 0)})
+	createTask(map[string]interface{}{"projectId": projectID, "title": "没有时间"})
+	createTask(map[string]interface{}{"projectId": otherID, "title": "买菜", "dueTime": at(1, 0)})
+
+	// 删除第2次实例
+	exception := &models.TaskRecurrenceException{RecurringTaskID: uuid.MustParse(standup), OriginalTime: today.AddDate(0, 0, 2).Add(time.Hour)}
+	if err := s.store.Tasks().CreateRecurrenceException(context.Background(), exception); err != nil {
+		t.Fatalf("创建循环例外失败: %v", err)
+	}
+
+	all := object(s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{}, http.StatusCreated), "feed")
+	if !strings.HasPrefix(all["webcalUrl"].(string), "webcal://") || !strings.HasSuffix(all["url"].(string), ".ics") {
+		t.Fatalf("订阅地址格式错误: %v", all)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{"projectId": uuid.NewString()}, http.StatusNotFound)
+	work := object(s.mustDo(http.MethodPost, "/api/v1/calendar-feeds", token, map[string]interface{}{"projectId": projectID}, http.StatusCreated), "feed")
+	if feeds := list(s.mustDo(http.MethodGet, "/api/v1/calendar-feeds", token, nil, http.StatusOK), "feeds"); len(feeds) != 2 {
+		t.Fatalf("应有2个订阅: %v", feeds)
+	}
+
+	// 订阅地址不需要登录
+	fetch := func(feed map[string]interface{}, etag string) *httptest.ResponseRecorder {
+		t.Helper()
+		u, err := url.Parse(feed["url"].(string))
+		if err != nil {
+			t.Fatalf("订阅地址无效: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, u.Path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := fetch(work, "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") || w.Header().Get("ETag") == "" {
+		t.Fatalf("获取订阅失败: %d %v", w.Code, w.Header())
+	}
+	body := strings.ReplaceAll(w.Body.String(), "\r\n ", "")
+	if n := strings.Count(body, "SUMMARY:站会"); n != 4 {
+		t.Fatalf("循环任务应展开为4次实例（删除1次），实际 %d:\n%s", n, body)
+	}
+	for _, want := range []string{
+		"UID:" + standup + "-" + today.AddDate(0, 0, 1).Add(time.Hour).Format("20060102T150405Z"),
+		"DTSTART:" + today.AddDate(0, 0, 5).Add(time.Hour).Format("20060102T150405Z"),
+		"SUMMARY:交周报",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("订阅内容缺少 %q:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"UID:" + standup + "-" + today.AddDate(0, 0, 2).Add(time.Hour).Format("20060102T150405Z"), "明年的事", "没有时间", "买菜", "BEGIN:VTODO"} {
+		if strings.Contains(body, unwanted) {
+			t.Fatalf("订阅内容不应包含 %q:\n%s", unwanted, body)
+		}
+	}
+	if w := fetch(all, ""); !strings.Contains(w.Body.String(), "买菜") {
+		t.Fatalf("全部任务的订阅应包含其他项目的任务")
+	}
+
+	etag := w.Header().Get("ETag")
+	if w := fetch(work, etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("内容未变化时应返回304: %d", w.Code)
+	}
+	s.mustDo(http.MethodPut, "/api/v1/tasks/"+standup, token, map[string]string{"title": "晨会"}, http.StatusOK)
+	if w := fetch(work, etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("任务修改后ETag应变化: %d", w.Code)
+	}
+
+	rotated := object(s.mustDo(http.MethodPost, "/api/v1/calendar-feeds/"+work["id"].(string)+"/rotate", token, nil, http.StatusOK), "feed")
+	if rotated["url"] == work["url"] || fetch(work, "").Code != http.StatusNotFound || fetch(rotated, "").Code != http.StatusOK {
+		t.Fatalf("重新生成后旧地址应失效")
+	}
+	s.mustDo(http.MethodDelete, "/api/v1/calendar-feeds/"+all["id"].(string), token, nil, http.StatusOK)
+	if fetch(all, "").Code != http.StatusNotFound {
+		t.Fatalf("删除后订阅地址应失效")
+	}
+}
+
+func TestCalDAV(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("dav@example.com")
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work", "color": "#FF0000"}, http.StatusCreated), "project")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{"projectId": projectID, "title": "写周报"}, http.StatusCreated), "task")["id"].(string)
+
+	created := object(s.mustDo(http.MethodPost, "/api/v1/app-passwords", token, map[string]string{"name": "iPhone"}, http.StatusCreated), "appPassword")
+	password := created["password"].(string)
+	passwords := list(s.mustDo(http.MethodGet, "/api/v1/app-passwords", token, nil, http.StatusOK), "appPasswords")
+	if len(passwords) != 1 || passwords[0].(map[string]interface{})["password"] != nil {
+		t.Fatalf("列表不应返回密码: %v", passwords)
+	}
+
+	dav := func(method, path, user, pass string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+	do := func(method, path string, headers map[string]string, body string, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		w := dav(method, path, "DAV@example.com", strings.ToUpper(password), headers, body)
+		if w.Code != want {
+			t.Fatalf("%s %s 状态码 = %d, 期望 %d: %s", method, path, w.Code, want, w.Body.String())
+		}
+		return w
+	}
+
+	// 服务发现和认证
+	if w := dav(http.MethodGet, "/.well-known/caldav", "", "", nil, ""); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/caldav/" {
+		t.Fatalf("服务发现应重定向到/caldav/: %d %v", w.Code, w.Header())
+	}
+	if w := dav(http.MethodOptions, "/caldav/", "", "", nil, ""); !strings.Contains(w.Header().Get("DAV"), "calendar-access") {
+		t.Fatalf("OPTIONS应声明calendar-access: %v", w.Header())
+	}
+	if w := dav("PROPFIND", "/caldav/", "", "", nil, ""); w.Code != http.StatusUnauthorized || !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Fatalf("未认证应返回401和Basic质询: %d %v", w.Code, w.Header())
+	}
+	if w := dav("PROPFIND", "/caldav/", "dav@example.com", "secret123", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("登录密码不能用于CalDAV: %d", w.Code)
+	}
+	if w := dav("PROPFIND", "/caldav/", "other@example.com", password, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("用户名与密码不匹配应返回401: %d", w.Code)
+	}
+
+	// 发现日历
+	w := do("PROPFIND", "/caldav/", map[string]string{"Depth": "0"}, `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:current-user-principal/><c:calendar-home-set/></d:prop></d:propfind>`, http.StatusMultiStatus)
+	if body := w.Body.String(); !strings.Contains(body, "/caldav/principal/") || !strings.Contains(body, "/caldav/calendars/") {
+		t.Fatalf("PROPFIND应返回主体和日历主目录: %s", body)
+	}
+	calendarPath := "/caldav/calendars/" + projectID + "/"
+	w = do("PROPFIND", "/caldav/calendars/", map[string]string{"Depth": "1"}, `<propfind xmlns="DAV:"><prop><resourcetype/><displayname/><getctag xmlns="http://calendarserver.org/ns/"/><foo xmlns="urn:x"/></prop></propfind>`, http.StatusMultiStatus)
+	body := w.Body.String()
+	for _, want := range []string{calendarPath, "<displayname xmlns=\"DAV:\">Work</displayname>", `<calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`, "urn:ticktick-backend:sync:", `<foo xmlns="urn:x"/></prop><status>HTTP/1.1 404 Not Found</status>`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("日历列表缺少 %q: %s", want, body)
+		}
+	}
+
+	// 首次同步
+	syncBody := func(token string) string {
+		return `<sync-collection xmlns="DAV:"><sync-token>` + token + `</sync-token><sync-level>1</sync-level><prop><getetag/></prop></sync-collection>`
+	}
+	tokenPattern := regexp.MustCompile(`<sync-token>([^<]+)</sync-token>`)
+	w = do("REPORT", calendarPath, nil, syncBody(""), http.StatusMultiStatus)
+	if !strings.Contains(w.Body.String(), calendarPath+taskID+".ics") {
+		t.Fatalf("首次同步应返回全部资源: %s", w.Body.String())
+	}
+	syncToken := tokenPattern.FindStringSubmatch(w.Body.String())[1]
+
+	// 创建资源
+	ics := func(summary string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nBEGIN:VTODO\r\nUID:apple-1\r\nSUMMARY:" + summary +
+			"\r\nDUE:20300102T090000Z\r\nCATEGORIES:家里\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;RELATED=END:-PT30M\r\nEND:VALARM\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+	resourcePath := calendarPath + "apple-1.ics"
+	w = do(http.MethodPut, resourcePath, map[string]string{"If-None-Match": "*", "Content-Type": "text/calendar"}, ics("买牛奶"), http.StatusCreated)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("创建资源应返回ETag")
+	}
+	do(http.MethodPut, resourcePath, map[string]string{"If-None-Match": "*"}, ics("买牛奶"), http.StatusPreconditionFailed)
+	do(http.MethodPut, calendarPath+"dup.ics", nil, ics("重复UID"), http.StatusForbidden)
+
+	tasks := list(s.mustDo(http.MethodGet, "/api/v1/tasks?projectId="+projectID, token, nil, http.StatusOK), "tasks")
+	if len(tasks) != 2 {
+		t.Fatalf("应有2个任务: %v", tasks)
+	}
+
+	w = do(http.MethodGet, resourcePath, nil, "", http.StatusOK)
+	if w.Header().Get("ETag") != etag || !strings.Contains(w.Body.String(), "BEGIN:VTODO") || !strings.Contains(w.Body.String(), "CATEGORIES:家里") || !strings.Contains(w.Body.String(), "TRIGGER;RELATED=END:-PT30M") {
+		t.Fatalf("GET内容不正确: %v\n%s", w.Header(), w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "METHOD:") {
+		t.Fatalf("CalDAV资源不应包含METHOD: %s", w.Body.String())
+	}
+
+	// 更新资源，ETag不匹配时返回412
+	do(http.MethodPut, resourcePath, map[string]string{"If-Match": `"stale"`}, ics("买豆浆"), http.StatusPreconditionFailed)
+	w = do(http.MethodPut, resourcePath, map[string]string{"If-Match": etag}, ics("买豆浆"), http.StatusNoContent)
+	if newETag := w.Header().Get("ETag"); newETag == "" || newETag == etag {
+		t.Fatalf("更新后ETag应变化: %q", newETag)
+	}
+
+	// 多个资源获取
+	w = do("REPORT", calendarPath, map[string]string{"Depth": "1"}, `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop><d:href>`+resourcePath+`</d:href><d:href>`+calendarPath+`missing.ics</d:href></c:calendar-multiget>`, http.StatusMultiStatus)
+	body = w.Body.String()
+	if !strings.Contains(body, "SUMMARY:买豆浆") || !strings.Contains(body, "<href>"+calendarPath+"missing.ics</href><status>HTTP/1.1 404 Not Found</status>") {
+		t.Fatalf("multiget结果不正确: %s", body)
+	}
+
+	// 按时间范围查询
+	query := func(start, end string) string {
+		return `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop><c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"><c:time-range start="` + start + `" end="` + end + `"/></c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+	}
+	if body := do("REPORT", calendarPath, nil, query("20300101T000000Z", "20300103T000000Z"), http.StatusMultiStatus).Body.String(); !strings.Contains(body, "apple-1.ics") {
+		t.Fatalf("时间范围内的任务应匹配: %s", body)
+	}
+	if body := do("REPORT", calendarPath, nil, query("20310101T000000Z", "20310103T000000Z"), http.StatusMultiStatus).Body.String(); strings.Contains(body, "apple-1.ics") || !strings.Contains(body, taskID+".ics") {
+		t.Fatalf("时间范围外的任务不应匹配，没有时间的任务总是匹配: %s", body)
+	}
+
+	// 删除后增量同步
+	s.mustDo(http.MethodDelete, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+	w = do("REPORT", calendarPath, nil, syncBody(syncToken), http.StatusMultiStatus)
+	body = w.Body.String()
+	if !strings.Contains(body, "<href>"+calendarPath+taskID+".ics</href><status>HTTP/1.1 404 Not Found</status>") || !strings.Contains(body, "apple-1.ics") {
+		t.Fatalf("增量同步应返回删除和新增的资源: %s", body)
+	}
+	syncToken = tokenPattern.FindStringSubmatch(body)[1]
+	if body := do("REPORT", calendarPath, nil, syncBody(syncToken), http.StatusMultiStatus).Body.String(); strings.Contains(body, "<response>") {
+		t.Fatalf("没有变化时不应返回资源: %s", body)
+	}
+	w = do("REPORT", calendarPath, nil, syncBody("urn:ticktick-backend:sync:unknown"), http.StatusForbidden)
+	if !strings.Contains(w.Body.String(), "valid-sync-token") {
+		t.Fatalf("未知令牌应返回valid-sync-token: %s", w.Body.String())
+	}
+
+	// 删除资源
+	w = do(http.MethodGet, resourcePath, nil, "", http.StatusOK)
+	do(http.MethodDelete, resourcePath, map[string]string{"If-Match": `"stale"`}, "", http.StatusPreconditionFailed)
+	do(http.MethodDelete, resourcePath, map[string]string{"If-Match": w.Header().Get("ETag")}, "", http.StatusNoContent)
+	do(http.MethodGet, resourcePath, nil, "", http.StatusNotFound)
+
+	// 删除应用专用密码后无法认证
+	s.mustDo(http.MethodDelete, "/api/v1/app-passwords/"+created["id"].(string), token, nil, http.StatusOK)
+	if w := dav("PROPFIND", "/caldav/", "dav@example.com", password, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("删除后的应用专用密码不能认证: %d", w.Code)
+	}
+}
+
+func TestImports(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("imports@example.com")
+	workID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "工作"}, http.StatusCreated)
+
+	tickTick := strings.Join([]string{
+		`"Date: 2024-03-01+0000"`,
+		`"Version: 7.1"`,
+		`"Folder Name","List Name","Title","Kind","Tags","Content","Is Check list","Start Date","Due Date","Reminder","Repeat","Priority","Status","Created Time","Completed Time","Order","Timezone","Is All Day","Is Floating","Column Name","Column Order","View Mode","taskId","parentId"`,
+		`"","Work","写报告","TEXT","工作,重要","详细内容","N","","2024-03-01T00:00:00+0000","","FREQ=WEEKLY;INTERVAL=1","5","0","","","","Asia/Shanghai","true","false","","","list","1",""`,
+		`"","Work","收集数据","TEXT","","","N","","","","","0","2","","2024-02-01T10:00:00+0000","","UTC","false","false","","","list","2","1"`,
+		`"","Shopping","买菜","CHECKLIST","","清单` + "\n▫牛奶\n▪鸡蛋" + `","Y","","","","every full moon","3","0","","","","UTC","false","false","","","list","3",""`,
+	}, "\n")
+
+	upload := func(query, contentType string, body []byte, want int) map[string]interface{} {
+		t.Helper()
+		w := s.doRaw(http.MethodPost, "/api/v1/imports?"+query, token, contentType, body)
+		if w.Code != want {
+			t.Fatalf("导入状态码 = %d, 期望 %d: %s", w.Code, want, w.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("响应不是JSON: %s", w.Body.String())
+		}
+		return resp
+	}
+	wait := func(job map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		path := "/api/v1/imports/" + job["id"].(string)
+		for i := 0; i < 200; i++ {
+			job = object(s.mustDo(http.MethodGet, path, token, nil, http.StatusOK), "job")
+			if job["status"] == "completed" || job["status"] == "failed" {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("导入未结束: %v", job)
+		return nil
+	}
+
+	// 试运行只返回预览
+	preview := object(upload("source=ticktick&dryRun=true", "text/csv", []byte(tickTick), http.StatusOK), "preview")
+	projects := preview["projects"].([]interface{})
+	if len(projects) != 2 || projects[0].(map[string]interface{})["exists"] != true || projects[1].(map[string]interface{})["tasks"] != float64(3) {
+		t.Fatalf("预览的项目错误: %v", projects)
+	}
+	if preview["tasks"] != float64(5) || fmt.Sprint(preview["newLabels"]) != "[重要]" || len(preview["warnings"].([]interface{})) != 1 {
+		t.Fatalf("预览错误: %v", preview)
+	}
+	if n := len(list(s.mustDo(http.MethodGet, "/api/v1/projects", token, nil, http.StatusOK), "projects")); n != 1 {
+		t.Fatalf("试运行不应创建项目: %d", n)
+	}
+
+	job := wait(object(upload("source=ticktick", "text/csv", []byte(tickTick), http.StatusAccepted), "job"))
+	if job["status"] != "completed" || job["processed"] != float64(5) || job["tasksCreated"] != float64(5) || job["projectsCreated"] != float64(1) || job["labelsCreated"] != float64(1) {
+		t.Fatalf("导入结果错误: %v", job)
+	}
+
+	byTitle := make(map[string]map[string]interface{})
+	for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/tasks?limit=200", token, nil, http.StatusOK), "tasks") {
+		task := item.(map[string]interface{})
+		byTitle[task["title"].(string)] = task
+	}
+	report := byTitle["写报告"]
+	if report["projectId"] != workID || report["priority"] != float64(1) || report["rruleString"] != "RRULE:FREQ=WEEKLY;INTERVAL=1" || len(report["labels"].([]interface{})) != 2 {
+		t.Fatalf("任务转换错误: %v", report)
+	}
+	if report["dueTime"] != "2024-02-29T16:00:00Z" {
+		t.Fatalf("全天任务应为用户时区的零点: %v", report["dueTime"])
+	}
+	if sub := byTitle["收集数据"]; sub["parentId"] != report["id"] || sub["status"] != "completed" {
+		t.Fatalf("子任务错误: %v", sub)
+	}
+	if byTitle["牛奶"]["parentId"] != byTitle["买菜"]["id"] || byTitle["鸡蛋"]["status"] != "completed" || byTitle["买菜"]["description"] != "清单" {
+		t.Fatalf("检查项应转换为子任务: %v", byTitle["买菜"])
+	}
+
+	// Todoist的JSON备份通过multipart上传
+	todoist := `{"projects":[{"id":"100","name":"Errands","color":"berry_red"}],"items":[` +
+		`{"id":1,"project_id":"100","content":"Pay rent @home","priority":4,"labels":["bills"],"due":{"date":"2024-03-05","string":"every month","is_recurring":true}},` +
+		`{"id":2,"project_id":"100","parent_id":1,"content":"Transfer","priority":1,"checked":true,"completed_at":"2024-03-01T08:00:00Z"}]}`
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", "todoist.json")
+	if err != nil {
+		t.Fatalf("创建表单失败: %v", err)
+	}
+	part.Write([]byte(todoist))
+	form.Close()
+	job = wait(object(upload("source=todoist", form.FormDataContentType(), buf.Bytes(), http.StatusAccepted), "job"))
+	if job["tasksCreated"] != float64(2) || job["labelsCreated"] != float64(2) {
+		t.Fatalf("导入结果错误: %v", job)
+	}
+	for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/projects", token, nil, http.StatusOK), "projects") {
+		if project := item.(map[string]interface{}); project["name"] == "Errands" && project["color"] != "#B8255F" {
+			t.Fatalf("项目颜色错误: %v", project)
+		}
+	}
+	for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/tasks?limit=200", token, nil, http.StatusOK), "tasks") {
+		task := item.(map[string]interface{})
+		switch task["title"] {
+		case "Pay rent":
+			if task["priority"] != float64(1) || task["rruleString"] != "RRULE:FREQ=MONTHLY" || task["dueTime"] != "2024-03-04T16:00:00Z" || len(task["labels"].([]interface{})) != 2 {
+				t.Fatalf("Todoist任务转换错误: %v", task)
+			}
+		case "Transfer":
+			if task["priority"] != float64(4) || task["completedAt"] != "2024-03-01T08:00:00Z" {
+				t.Fatalf("Todoist子任务转换错误: %v", task)
+			}
+		}
+	}
+
+	upload("source=evernote", "text/csv", []byte(tickTick), http.StatusBadRequest)
+	upload("source=ticktick", "text/csv", []byte("hello"), http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/imports/"+uuid.NewString(), token, nil, http.StatusNotFound)
+}
+
+func TestAccountExportImport(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("export@example.com")
+
+	s.mustDo(http.MethodPatch, "/api/v1/settings", token, map[string]string{"timeZone": "Europe/Berlin"}, http.StatusOK)
+	groupID := object(s.mustDo(http.MethodPost, "/api/v1/project-groups", token, map[string]string{"name": "工作"}, http.StatusCreated), "group")["id"].(string)
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]interface{}{"name": "Work", "color": "#FF0000", "groupId": groupID}, http.StatusCreated), "project")["id"].(string)
+	sectionID := object(s.mustDo(http.MethodPost, "/api/v1/projects/"+projectID+"/sections", token, map[string]string{"name": "Doing"}, http.StatusCreated), "section")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "urgent"}, http.StatusCreated), "label")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/smart-lists", token, map[string]string{"name": "紧急", "query": "label:urgent"}, http.StatusCreated)
+
+	parentID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID, "sectionId": sectionID, "title": "写报告", "priority": 1, "labelIds": []string{labelID},
+	}, http.StatusCreated), "task")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+parentID+"/reminders", token, map[string]string{"remindAt": "2030-01-01T09:00:00Z"}, http.StatusCreated)
+	childID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{"projectId": projectID, "parentId": parentID, "title": "收集数据"}, http.StatusCreated), "task")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+childID+"/complete", token, nil, http.StatusOK)
+
+	// 通过iCalendar导入带循环例外的任务
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//EN",
+		"BEGIN:VTODO", "UID:standup", "SUMMARY:站会", "DTSTART:20300101T090000Z", "RRULE:FREQ=DAILY;COUNT=5", "EXDATE:20300102T090000Z", "END:VTODO",
+		"BEGIN:VTODO", "UID:standup", "RECURRENCE-ID:20300103T090000Z", "SUMMARY:站会（改期）", "DTSTART:20300103T100000Z", "END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+	if w := s.doRaw(http.MethodPost, "/api/v1/projects/"+projectID+"/import/ics", token, "text/calendar", []byte(ics)); w.Code != http.StatusOK {
+		t.Fatalf("导入日历失败: %s", w.Body.String())
+	}
+
+	export := func() string {
+		job := object(s.mustDo(http.MethodPost, "/api/v1/account/exports", token, nil, http.StatusAccepted), "job")
+		path := "/api/v1/account/exports/" + job["id"].(string)
+		for i := 0; i < 200 && job["status"] != "completed" && job["status"] != "failed"; i++ {
+			time.Sleep(10 * time.Millisecond)
+			job = object(s.mustDo(http.MethodGet, path, token, nil, http.StatusOK), "job")
+		}
+		if job["status"] != "completed" || job["expiresAt"] == nil {
+			t.Fatalf("导出未完成: %v", job)
+		}
+		created, _ := time.Parse(time.RFC3339Nano, job["createdAt"].(string))
+		finished, _ := time.Parse(time.RFC3339Nano, job["finishedAt"].(string))
+		if finished.Before(created) {
+			t.Fatalf("完成时间 %v 早于创建时间 %v", finished, created)
+		}
+		downloadURL, err := url.Parse(job["downloadUrl"].(string))
+		if err != nil {
+			t.Fatalf("下载链接无效: %v", job["downloadUrl"])
+		}
+		return downloadURL.Path
+	}
+	previous := export()
+	downloadPath := export()
+	w := s.doRaw(http.MethodGet, downloadPath, "", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("下载导出文件失败: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	data := w.Body.Bytes()
+	// 每个用户只保留最近一次导出的文件
+	if w := s.doRaw(http.MethodGet, previous, "", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("重新导出后旧的下载链接应返回404: %d", w.Code)
+	}
+	if w := s.doRaw(http.MethodGet, "/api/v1/exports/"+strings.Repeat("0", 64), "", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("无效的下载令牌应返回404: %d", w.Code)
+	}
+
+	// 恢复到新账户
+	other := s.register("restore@example.com")
+	w = s.doRaw(http.MethodPost, "/api/v1/account/import", other, "application/zip", data)
+	if w.Code != http.StatusOK {
+		t.Fatalf("导入账户数据失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if counts := object(object(resp, "result"), "counts"); counts["tasks"] != float64(4) || counts["projects"] != float64(1) || counts["smartLists"] != float64(1) {
+		t.Fatalf("导入数量错误: %v", counts)
+	}
+
+	if tz := object(s.mustDo(http.MethodGet, "/api/v1/settings", other, nil, http.StatusOK), "settings")["timeZone"]; tz != "Europe/Berlin" {
+		t.Fatalf("设置未恢复: %v", tz)
+	}
+	project := list(s.mustDo(http.MethodGet, "/api/v1/projects", other, nil, http.StatusOK), "projects")[0].(map[string]interface{})
+	groups := list(s.mustDo(http.MethodGet, "/api/v1/project-groups", other, nil, http.StatusOK), "groups")
+	if project["id"] == projectID || project["color"] != "#FF0000" || len(groups) != 1 || project["groupId"] != groups[0].(map[string]interface{})["id"] {
+		t.Fatalf("项目或分组未正确恢复: %v %v", project, groups)
+	}
+	sections := list(s.mustDo(http.MethodGet, "/api/v1/projects/"+project["id"].(string)+"/sections", other, nil, http.StatusOK), "sections")
+	labels := list(s.mustDo(http.MethodGet, "/api/v1/labels", other, nil, http.StatusOK), "labels")
+	if len(sections) != 1 || len(labels) != 1 || labels[0].(map[string]interface{})["id"] == labelID {
+		t.Fatalf("分栏或标签未正确恢复: %v %v", sections, labels)
+	}
+
+	byTitle := make(map[string]map[string]interface{})
+	for _, item := range list(s.mustDo(http.MethodGet, "/api/v1/tasks?limit=200", other, nil, http.StatusOK), "tasks") {
+		task := item.(map[string]interface{})
+		byTitle[task["title"].(string)] = task
+	}
+	report := object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+byTitle["写报告"]["id"].(string), other, nil, http.StatusOK), "task")
+	if report["id"] == parentID || report["projectId"] != project["id"] || report["sectionId"] != sections[0].(map[string]interface{})["id"] || report["priority"] != float64(1) {
+		t.Fatalf("任务未正确恢复: %v", report)
+	}
+	if len(report["labels"].([]interface{})) != 1 || len(report["reminders"].([]interface{})) != 1 {
+		t.Fatalf("任务的标签或提醒未恢复: %v", report)
+	}
+	if child := byTitle["收集数据"]; child["parentId"] != report["id"] || child["status"] != "completed" || child["completedAt"] == nil {
+		t.Fatalf("子任务未正确恢复: %v", child)
+	}
+
+	userID := uuid.MustParse(object(s.mustDo(http.MethodGet, "/api/v1/profile", other, nil, http.StatusOK), "user")["id"].(string))
+	restored, err := s.store.Tasks().ListTasksWithDetails(context.Background(), userID, repository.TaskFilter{})
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	for _, task := range restored {
+		if task.Title != "站会" {
+			continue
+		}
+		if len(task.Exceptions) != 2 {
+			t.Fatalf("循环例外未恢复: %v", task.Exceptions)
+		}
+		for _, e := range task.Exceptions {
+			if e.NewTaskID != nil && *e.NewTaskID != uuid.MustParse(byTitle["站会（改期）"]["id"].(string)) {
+				t.Fatalf("循环例外应指向新的替代任务: %v", e)
+			}
+		}
+	}
+
+	w = s.doRaw(http.MethodPost, "/api/v1/account/import", other, "application/zip", data)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("导入到已有数据的账户应返回409: %d", w.Code)
+	}
+	w = s.doRaw(http.MethodPost, "/api/v1/account/import", s.register("empty@example.com"), "application/zip", []byte("not a zip"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("无效的导出文件应返回400: %d", w.Code)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "2")
+	t.Setenv("WEBHOOK_RETRY_BASE_DELAY", "1ms")
+	t.Setenv("WEBHOOK_DISABLE_AFTER", "3")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+	s := newTestServer(t)
+	token := s.register("hooks@example.com")
+
+	// 接收端校验签名并记录收到的事件
+	var (
+		mu       sync.Mutex
+		received []map[string]interface{}
+		status   = http.StatusOK
+		secret   string
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		want := "sha256=" + services.SignWebhookPayload(secret, r.Header.Get(services.WebhookTimestampHeader), body)
+		if got := r.Header.Get(services.WebhookSignatureHeader); got != want {
+			t.Errorf("签名 = %q, 期望 %q", got, want)
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal(body, &event); err != nil || event["type"] != r.Header.Get(services.WebhookEventHeader) {
+			t.Errorf("请求体与事件头不一致: %s", body)
+		}
+		received = append(received, event)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer receiver.Close()
+	setStatus := func(code int) {
+		mu.Lock()
+		status = code
+		mu.Unlock()
+	}
+	process := func() []map[string]interface{} {
+		t.Helper()
+		if _, err := s.webhooks.ProcessQueue(context.Background()); err != nil {
+			t.Fatalf("处理投递队列失败: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events := received
+		received = nil
+		return events
+	}
+
+	s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{"url": "ftp://example.com/hook", "events": []string{"task.created"}}, http.StatusBadRequest)
+	s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{"url": receiver.URL, "events": []string{"task.deleted"}}, http.StatusBadRequest)
+	created := object(s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"task.completed", "task.created", "project.deleted", "reminder.fired", "task.created"},
+	}, http.StatusCreated), "webhook")
+	secret, _ = created["secret"].(string)
+	if len(secret) != 64 || len(list(created, "events")) != 4 {
+		t.Fatalf("创建的Webhook = %v", created)
+	}
+	webhookPath := "/api/v1/webhooks/" + created["id"].(string)
+	webhooks := s.mustDo(http.MethodGet, "/api/v1/webhooks", token, nil, http.StatusOK)
+	if hooks := list(webhooks, "webhooks"); len(hooks) != 1 || hooks[0].(map[string]interface{})["secret"] != nil {
+		t.Fatalf("列表不应返回签名密钥: %v", webhooks)
+	}
+	s.mustDo(http.MethodGet, webhookPath, s.register("other@example.com"), nil, http.StatusNotFound)
+
+	// 未订阅的task.updated不投递
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Hooks"}, http.StatusCreated), "project")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Deploy"}, http.StatusCreated), "task")["id"].(string)
+	s.mustDo(http.MethodPut, "/api/v1/tasks/"+taskID, token, map[string]string{"title": "Deploy v2"}, http.StatusOK)
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/complete", token, nil, http.StatusOK)
+	if depth, err := s.webhooks.QueueDepth(); err != nil || depth != 2 {
+		t.Fatalf("投递队列长度 = %d, %v, 期望 2", depth, err)
+	}
+	events := process()
+	if depth, _ := s.webhooks.QueueDepth(); depth != 0 {
+		t.Fatalf("投递后队列长度 = %d, 期望 0", depth)
+	}
+	if len(events) != 2 {
+		t.Fatalf("收到 %d 个事件, 期望 2: %v", len(events), events)
+	}
+	types := map[interface{}]map[string]interface{}{}
+	for _, event := range events {
+		types[event["type"]] = object(event, "data")
+	}
+	if types["task.created"]["title"] != "Deploy" || types["task.completed"]["status"] != "completed" {
+		t.Fatalf("事件内容不正确: %v", events)
+	}
+
+	deliveries := list(s.mustDo(http.MethodGet, webhookPath+"/deliveries", token, nil, http.StatusOK), "deliveries")
+	if len(deliveries) != 2 {
+		t.Fatalf("投递记录数量 = %d, 期望 2", len(deliveries))
+	}
+	for _, item := range deliveries {
+		delivery := item.(map[string]interface{})
+		if delivery["status"] != "succeeded" || delivery["responseCode"] != float64(200) || delivery["attempts"] != float64(1) {
+			t.Fatalf("投递记录 = %v", delivery)
+		}
+	}
+
+	// 到达提醒时间的提醒只触发一次，已完成任务的提醒不触发
+	reminderTaskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Standup"}, http.StatusCreated), "task")["id"].(string)
+	process()
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+reminderTaskID+"/reminders", token, map[string]interface{}{"remindAt": time.Now().Add(-10 * time.Second)}, http.StatusCreated)
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/reminders", token, map[string]interface{}{"remindAt": time.Now().Add(-10 * time.Second)}, http.StatusCreated)
+	now := time.Now()
+	if n := s.reminders.Dispatch(context.Background(), now); n != 1 {
+		t.Fatalf("触发的提醒数量 = %d, 期望 1", n)
+	}
+	if n := s.reminders.Dispatch(context.Background(), now.Add(time.Second)); n != 0 {
+		t.Fatalf("提醒不应重复触发: %d", n)
+	}
+	if events := process(); len(events) != 1 || events[0]["type"] != "reminder.fired" || object(object(events[0], "data"), "task")["id"] != reminderTaskID {
+		t.Fatalf("提醒事件 = %v", events)
+	}
+
+	// 失败后按退避重试，次数用尽后标记为失败
+	setStatus(http.StatusInternalServerError)
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+projectID, token, nil, http.StatusOK)
+	process()
+	failed := list(s.mustDo(http.MethodGet, webhookPath+"/deliveries?limit=1", token, nil, http.StatusOK), "deliveries")[0].(map[string]interface{})
+	if failed["eventType"] != "project.deleted" || failed["status"] != "pending" || failed["responseCode"] != float64(500) || failed["nextAttemptAt"] == nil {
+		t.Fatalf("第一次失败后的投递记录 = %v", failed)
+	}
+	time.Sleep(10 * time.Millisecond)
+	process()
+	failed = list(s.mustDo(http.MethodGet, webhookPath+"/deliveries?limit=1", token, nil, http.StatusOK), "deliveries")[0].(map[string]interface{})
+	if failed["status"] != "failed" || failed["attempts"] != float64(2) {
+		t.Fatalf("重试用尽后的投递记录 = %v", failed)
+	}
+
+	// 重新投递再次失败，连续失败达到3次后自动停用
+	redeliverPath := webhookPath + "/deliveries/" + failed["id"].(string) + "/redeliver"
+	redelivery := object(s.mustDo(http.MethodPost, redeliverPath, token, nil, http.StatusAccepted), "delivery")
+	if redelivery["redeliveryOf"] != failed["id"] || object(redelivery, "payload")["type"] != "project.deleted" {
+		t.Fatalf("重新投递 = %v", redelivery)
+	}
+	process()
+	webhook := object(s.mustDo(http.MethodGet, webhookPath, token, nil, http.StatusOK), "webhook")
+	if webhook["enabled"] != false || webhook["disabledAt"] == nil || webhook["failureCount"] != float64(3) {
+		t.Fatalf("连续失败后应自动停用: %v", webhook)
+	}
+	s.mustDo(http.MethodPost, redeliverPath, token, nil, http.StatusConflict)
+
+	// 重新启用后清零失败次数，重新投递成功
+	setStatus(http.StatusNoContent)
+	webhook = object(s.mustDo(http.MethodPatch, webhookPath, token, map[string]bool{"enabled": true}, http.StatusOK), "webhook")
+	if webhook["enabled"] != true || webhook["failureCount"] != float64(0) || webhook["disabledAt"] != nil {
+		t.Fatalf("重新启用后的Webhook = %v", webhook)
+	}
+	s.mustDo(http.MethodPost, redeliverPath, token, nil, http.StatusAccepted)
+	if events := process(); len(events) != 1 || events[0]["type"] != "project.deleted" {
+		t.Fatalf("重新投递的事件 = %v", events)
+	}
+	latest := list(s.mustDo(http.MethodGet, webhookPath+"/deliveries?limit=1", token, nil, http.StatusOK), "deliveries")[0].(map[string]interface{})
+	if latest["status"] != "succeeded" || latest["responseCode"] != float64(204) {
+		t.Fatalf("重新投递的记录 = %v", latest)
+	}
+
+	s.mustDo(http.MethodDelete, webhookPath, token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, webhookPath+"/deliveries", token, nil, http.StatusNotFound)
+}
+
+func TestWebhookPrivateTargets(t *testing.T) {
+	// 模拟只能从内网访问的服务，不应收到任何请求
+	var internalHits atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits.Add(1)
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+
+	s := newTestServer(t)
+	token := s.register("ssrf@example.com")
+	for _, target := range []string{
+		"http://127.0.0.1/hook",
+		internal.URL,
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]:6379/",
+		"http://[::ffff:127.0.0.1]/",
+		"http://localhost:5432/",
+	} {
+		s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{"url": target, "events": []string{"task.created"}}, http.StatusBadRequest)
+	}
+
+	// 注册后域名被重新解析到内网地址时，投递在建立连接时被拒绝
+	created := object(s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{
+		"url": "http://93.184.216.34/hook", "events": []string{"task.created"},
+	}, http.StatusCreated), "webhook")
+	s.mustDo(http.MethodPatch, "/api/v1/webhooks/"+created["id"].(string), token, map[string]string{"url": "http://192.168.1.1/"}, http.StatusBadRequest)
+	webhook, err := s.store.Webhooks().GetWebhook(context.Background(), uuid.MustParse(created["id"].(string)))
+	if err != nil || webhook == nil {
+		t.Fatalf("查询Webhook失败: %v", err)
+	}
+	webhook.URL = internal.URL
+	if err := s.store.Webhooks().UpdateWebhook(context.Background(), webhook); err != nil {
+		t.Fatalf("更新Webhook失败: %v", err)
+	}
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "SSRF"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Probe"}, http.StatusCreated)
+	if _, err := s.webhooks.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("处理投递队列失败: %v", err)
+	}
+	delivery := list(s.mustDo(http.MethodGet, "/api/v1/webhooks/"+created["id"].(string)+"/deliveries", token, nil, http.StatusOK), "deliveries")[0].(map[string]interface{})
+	if internalHits.Load() != 0 || delivery["responseCode"] != nil || !strings.Contains(delivery["error"].(string), "不允许投递到内网地址") {
+		t.Fatalf("投递到内网地址 = %v", delivery)
+	}
+
+	// 不跟随重定向，即使允许内网地址，重定向的目标也不会收到请求
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+	s = newTestServer(t)
+	token = s.register("redirect@example.com")
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/admin", http.StatusFound)
+	}))
+	defer redirector.Close()
+	created = object(s.mustDo(http.MethodPost, "/api/v1/webhooks", token, map[string]interface{}{
+		"url": redirector.URL, "events": []string{"task.created"},
+	}, http.StatusCreated), "webhook")
+	projectID = object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Redirect"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Probe"}, http.StatusCreated)
+	if _, err := s.webhooks.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("处理投递队列失败: %v", err)
+	}
+	delivery = list(s.mustDo(http.MethodGet, "/api/v1/webhooks/"+created["id"].(string)+"/deliveries", token, nil, http.StatusOK), "deliveries")[0].(map[string]interface{})
+	if internalHits.Load() != 0 || delivery["responseCode"] != float64(http.StatusFound) || delivery["status"] == "succeeded" {
+		t.Fatalf("重定向的投递 = %v", delivery)
+	}
+}
+
+// sseEvent 变更流中收到的一条SSE事件
+type sseEvent struct {
+	id    string
+	event string
+	data  map[string]interface{}
+}
+
+func TestEventStream(t *testing.T) {
+	t.Setenv("EVENT_STREAM_REPLAY_SIZE", "3")
+	s := newTestServer(t)
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+	ctx, cancelAll := context.WithCancel(context.Background())
+	defer cancelAll()
+
+	token := s.register("stream@example.com")
+	otherToken := s.register("stream-other@example.com")
+
+	// connect 建立变更流连接，返回接收事件的通道和断开函数
+	connect := func(token, lastEventID string) (<-chan sseEvent, context.CancelFunc) {
+		t.Helper()
+		connCtx, cancel := context.WithCancel(ctx)
+		req, _ := http.NewRequestWithContext(connCtx, http.MethodGet, srv.URL+"/api/v1/events", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			cancel()
+			t.Fatalf("连接变更流失败: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			cancel()
+			t.Fatalf("变更流响应 = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		events := make(chan sseEvent, 16)
+		go func() {
+			defer resp.Body.Close()
+			defer close(events)
+			scanner := bufio.NewScanner(resp.Body)
+			var current sseEvent
+			for scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case line == "":
+					if current.id != "" {
+						events <- current
+					}
+					current = sseEvent{}
+				case strings.HasPrefix(line, "id: "):
+					current.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					current.event = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data)
+				}
+			}
+		}()
+		return events, cancel
+	}
+	next := func(events <-chan sseEvent) sseEvent {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("变更流被意外关闭")
+			}
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("等待变更流事件超时")
+		}
+		return sseEvent{}
+	}
+	expect := func(events <-chan sseEvent, id, eventType string) map[string]interface{} {
+		t.Helper()
+		event := next(events)
+		if event.id != id || event.event != "" || event.data["type"] != eventType {
+			t.Fatalf("事件 = %+v, 期望 id=%s type=%s", event, id, eventType)
+		}
+		return object(event.data, "data")
+	}
+
+	s.mustDo(http.MethodGet, "/api/v1/events?lastEventId=abc", token, nil, http.StatusBadRequest)
+
+	events, disconnect := connect(token, "")
+	if ready := next(events); ready.event != "ready" || ready.id != "0" {
+		t.Fatalf("首个事件 = %+v, 期望ready", ready)
+	}
+	otherEvents, _ := connect(otherToken, "")
+	next(otherEvents)
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Live"}, http.StatusCreated), "project")["id"].(string)
+	if data := expect(events, "1", "project.created"); data["id"] != projectID {
+		t.Fatalf("project.created 数据 = %v", data)
+	}
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "live"}, http.StatusCreated), "label")["id"].(string)
+	expect(events, "2", "label.created")
+
+	// 其他用户只收到自己的事件
+	s.mustDo(http.MethodPost, "/api/v1/labels", otherToken, map[string]string{"name": "mine"}, http.StatusCreated)
+	expect(otherEvents, "1", "label.created")
+
+	// 断开期间的变更在重连时按Last-Event-ID补发
+	disconnect()
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Offline"}, http.StatusCreated), "task")["id"].(string)
+	s.mustDo(http.MethodDelete, "/api/v1/labels/"+labelID, token, nil, http.StatusOK)
+	events, disconnect = connect(token, "2")
+	if ready := next(events); ready.event != "ready" || ready.id != "4" {
+		t.Fatalf("重连后的首个事件 = %+v", ready)
+	}
+	if data := expect(events, "3", "task.created"); data["id"] != taskID {
+		t.Fatalf("补发的task.created = %v", data)
+	}
+	if data := expect(events, "4", "label.deleted"); data["id"] != labelID {
+		t.Fatalf("补发的label.deleted = %v", data)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/complete", token, nil, http.StatusOK)
+	expect(events, "5", "task.completed")
+	disconnect()
+
+	// 缺失的事件超出补发缓冲时要求客户端重新同步
+	events, _ = connect(token, "1")
+	if reset := next(events); reset.event != "reset" || reset.id != "5" || reset.data["seq"] != float64(5) {
+		t.Fatalf("超出补发缓冲时应发送reset: %+v", reset)
+	}
+	s.mustDo(http.MethodDelete, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+	if data := expect(events, "6", "task.deleted"); data["id"] != taskID {
+		t.Fatalf("task.deleted 数据 = %v", data)
+	}
+}
+
+func TestEventStreamEndsWhenTokenExpires(t *testing.T) {
+	s := newTestServer(t)
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+
+	// 用同一会话签发一个很快过期的访问令牌
+	claims := &middleware.JWTClaims{}
+	if _, err := jwt.ParseWithClaims(s.register("stream-expiry@example.com"), claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	}); err != nil {
+		t.Fatalf("解析访问令牌失败: %v", err)
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(2 * time.Second))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("签发访问令牌失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("连接变更流失败: %v %v", resp, err)
+	}
+	defer resp.Body.Close()
+
+	// 令牌过期后服务端关闭连接，读取正常结束而不是等到客户端超时
+	start := time.Now()
+	io.Copy(io.Discard, resp.Body)
+	if ctx.Err() != nil {
+		t.Fatal("令牌过期后变更流没有结束")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("变更流在 %s 后才结束", elapsed)
+	}
+}
+
+func TestDeltaSync(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("sync@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Existing"}, http.StatusCreated)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "work"}, http.StatusCreated), "label")["id"].(string)
+
+	// 首次同步返回全部数据
+	full := s.mustDo(http.MethodGet, "/api/v1/sync", token, nil, http.StatusOK)
+	if len(list(full, "projects")) != 1 || len(list(full, "tasks")) != 1 || len(list(full, "labels")) != 1 || full["hasMore"] != false {
+		t.Fatalf("首次同步 = %v", full)
+	}
+	syncToken := full["syncToken"].(string)
+	s.mustDo(http.MethodGet, "/api/v1/sync?since=abc", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/sync?since=999999", token, nil, http.StatusBadRequest)
+	if page := s.mustDo(http.MethodGet, "/api/v1/sync?limit=1", token, nil, http.StatusOK); page["hasMore"] != true || len(list(page, "projects")) != 1 {
+		t.Fatalf("分页同步 = %v", page)
+	}
+
+	// 增量同步只返回变更，删除以墓碑返回
+	s.mustDo(http.MethodDelete, "/api/v1/labels/"+labelID, token, nil, http.StatusOK)
+	delta := s.mustDo(http.MethodGet, "/api/v1/sync?since="+syncToken, token, nil, http.StatusOK)
+	deleted := list(delta, "deleted")
+	if len(list(delta, "projects")) != 0 || len(deleted) != 1 || deleted[0].(map[string]interface{})["id"] != labelID {
+		t.Fatalf("增量同步 = %v", delta)
+	}
+	if again := s.mustDo(http.MethodGet, "/api/v1/sync?since="+delta["syncToken"].(string), token, nil, http.StatusOK); len(list(again, "deleted")) != 0 {
+		t.Fatalf("没有新变更时 = %v", again)
+	}
+
+	// 使用客户端生成的ID创建，重复提交是安全的
+	newProject, newTask := uuid.NewString(), uuid.NewString()
+	create := map[string]interface{}{"mutations": []map[string]interface{}{
+		{"type": "project", "id": newProject, "op": "upsert", "fields": map[string]interface{}{"name": "Offline"}},
+		{"type": "task", "id": newTask, "op": "upsert", "fields": map[string]interface{}{"projectId": newProject, "title": "Original", "status": "completed"}},
+		{"type": "reminder", "id": uuid.NewString(), "op": "upsert", "fields": map[string]interface{}{"taskId": newTask, "remindAt": time.Now().Add(time.Hour)}},
+		{"type": "label", "id": uuid.NewString(), "op": "upsert", "fields": map[string]interface{}{"color": "#fff"}},
+	}}
+	statuses := func(resp map[string]interface{}) []interface{} {
+		var result []interface{}
+		for _, r := range list(resp, "results") {
+			result = append(result, r.(map[string]interface{})["status"])
+		}
+		return result
+	}
+	for i := 0; i < 2; i++ {
+		resp := s.mustDo(http.MethodPost, "/api/v1/sync", token, create, http.StatusOK)
+		if got := fmt.Sprint(statuses(resp)); got != "[applied applied applied rejected]" {
+			t.Fatalf("第%d次提交 = %v", i+1, resp)
+		}
+	}
+	task := object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+newTask, token, nil, http.StatusOK), "task")
+	if task["status"] != "completed" || len(list(task, "reminders")) != 1 {
+		t.Fatalf("同步创建的任务 = %v", task)
+	}
+
+	// 服务端修改标题后，客户端修改其他字段直接合并，修改同一字段时按策略处理冲突
+	baseToken := s.mustDo(http.MethodGet, "/api/v1/sync", token, nil, http.StatusOK)["syncToken"].(string)
+	s.mustDo(http.MethodPut, "/api/v1/tasks/"+newTask, token, map[string]string{"title": "Server"}, http.StatusOK)
+	update := func(strategy string, updatedAt time.Time, fields, base map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp := s.mustDo(http.MethodPost, "/api/v1/sync", token, map[string]interface{}{
+			"conflictStrategy": strategy,
+			"mutations": []map[string]interface{}{{
+				"type": "task", "id": newTask, "op": "upsert", "baseToken": baseToken,
+				"updatedAt": updatedAt, "fields": fields, "base": base,
+			}},
+		}, http.StatusOK)
+		return list(resp, "results")[0].(map[string]interface{})
+	}
+	if result := update("lww", time.Now().Add(-time.Hour), map[string]interface{}{"priority": 1}, map[string]interface{}{"priority": 4}); result["status"] != "applied" {
+		t.Fatalf("不冲突的字段 = %v", result)
+	}
+	result := update("lww", time.Now().Add(-time.Hour), map[string]interface{}{"title": "Client"}, map[string]interface{}{"title": "Original"})
+	conflicts := list(result, "conflicts")
+	if result["status"] != "conflict" || len(conflicts) != 1 || conflicts[0].(map[string]interface{})["resolution"] != "server" {
+		t.Fatalf("服务端较新时 = %v", result)
+	}
+	result = update("report", time.Now().Add(time.Hour), map[string]interface{}{"title": "Client"}, map[string]interface{}{"title": "Original"})
+	if conflicts := list(result, "conflicts"); len(conflicts) != 1 || conflicts[0].(map[string]interface{})["serverValue"] != "Server" {
+		t.Fatalf("report策略 = %v", result)
+	}
+	task = object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+newTask, token, nil, http.StatusOK), "task")
+	if task["title"] != "Server" || task["priority"] != float64(1) {
+		t.Fatalf("冲突未采用客户端的值时 = %v", task)
+	}
+	result = update("lww", time.Now().Add(time.Hour), map[string]interface{}{"title": "Client"}, map[string]interface{}{"title": "Original"})
+	if conflicts := list(result, "conflicts"); len(conflicts) != 1 || conflicts[0].(map[string]interface{})["resolution"] != "client" {
+		t.Fatalf("客户端较新时 = %v", result)
+	}
+	if task = object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+newTask, token, nil, http.StatusOK), "task"); task["title"] != "Client" {
+		t.Fatalf("客户端胜出后的任务 = %v", task)
+	}
+
+	// 其他用户不能使用已存在的ID
+	other := s.register("sync-other@example.com")
+	resp := s.mustDo(http.MethodPost, "/api/v1/sync", other, map[string]interface{}{"mutations": []map[string]interface{}{
+		{"type": "project", "id": newProject, "op": "upsert", "fields": map[string]interface{}{"name": "Stolen"}},
+	}}, http.StatusOK)
+	if got := fmt.Sprint(statuses(resp)); got != "[rejected]" {
+		t.Fatalf("使用其他用户的ID = %v", resp)
+	}
+
+	// 删除可以重复提交，删除后的修改被拒绝
+	remove := map[string]interface{}{"mutations": []map[string]interface{}{
+		{"type": "task", "id": newTask, "op": "delete"},
+		{"type": "task", "id": newTask, "op": "delete"},
+		{"type": "task", "id": newTask, "op": "upsert", "fields": map[string]interface{}{"title": "Revived"}},
+	}}
+	if got := fmt.Sprint(statuses(s.mustDo(http.MethodPost, "/api/v1/sync", token, remove, http.StatusOK))); got != "[applied applied rejected]" {
+		t.Fatalf("删除后的处理结果 = %v", got)
+	}
+	delta = s.mustDo(http.MethodGet, "/api/v1/sync?since="+baseToken, token, nil, http.StatusOK)
+	tombstones := map[interface{}]bool{}
+	for _, item := range list(delta, "deleted") {
+		tombstones[item.(map[string]interface{})["type"]] = true
+	}
+	if !tombstones["task"] || !tombstones["reminder"] || len(list(delta, "tasks")) != 0 {
+		t.Fatalf("删除任务后的增量同步 = %v", delta)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/sync", token, map[string]interface{}{"mutations": []interface{}{}}, http.StatusBadRequest)
+}
+
+func TestTaskReminders(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("dave@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]string{"projectId": projectID, "title": "Call mom"}, http.StatusCreated), "task")["id"].(string)
+
+	base := "/api/v1/tasks/" + taskID + "/reminders"
+	reminder := object(s.mustDo(http.MethodPost, base, token, map[string]interface{}{"remindAt": time.Now().Add(time.Hour)}, http.StatusCreated), "reminder")
+	s.mustDo(http.MethodPost, base, token, map[string]interface{}{}, http.StatusBadRequest)
+
+	reminders := s.mustDo(http.MethodGet, base, token, nil, http.StatusOK)
+	if n := len(list(reminders, "reminders")); n != 1 {
+		t.Fatalf("提醒数量 = %d, 期望 1", n)
+	}
+	task := object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK), "task")
+	if n := len(list(task, "reminders")); n != 1 {
+		t.Fatalf("任务详情中的提醒数量 = %d, 期望 1", n)
+	}
+
+	s.mustDo(http.MethodDelete, base+"/"+reminder["id"].(string), token, nil, http.StatusOK)
+	s.mustDo(http.MethodDelete, base+"/"+reminder["id"].(string), token, nil, http.StatusNotFound)
+}
+
+func TestLabelCRUD(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("erin@example.com")
+
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "work"}, http.StatusCreated), "label")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "work"}, http.StatusConflict)
+
+	renamed := object(s.mustDo(http.MethodPut, "/api/v1/labels/"+labelID, token, map[string]string{"name": "office"}, http.StatusOK), "label")
+	if renamed["name"] != "office" {
+		t.Fatalf("重命名后的标签 = %v", renamed)
+	}
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID, "title": "Tagged", "labelIds": []string{labelID},
+	}, http.StatusCreated), "task")["id"].(string)
+
+	s.mustDo(http.MethodDelete, "/api/v1/labels/"+labelID, token, nil, http.StatusOK)
+	task := object(s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK), "task")
+	if n := len(list(task, "labels")); n != 0 {
+		t.Fatalf("删除标签后任务仍有 %d 个标签", n)
+	}
+}
+
+func TestTaskSearch(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("search@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "groceries"}, http.StatusCreated), "label")["id"].(string)
+	for _, task := range []map[string]interface{}{
+		{"projectId": projectID, "title": "Buy milk", "priority": 1},
+		{"projectId": projectID, "title": "Pick up parcel", "labelIds": []string{labelID}},
+		{"projectId": projectID, "title": "明天开会", "description": "讨论下个季度的预算安排"},
+		{"projectId": projectID, "title": "<img src=x onerror=alert(1)>报销发票"},
+	} {
+		s.mustDo(http.MethodPost, "/api/v1/tasks", token, task, http.StatusCreated)
+	}
+
+	search := func(query string) []interface{} {
+		return list(s.mustDo(http.MethodGet, "/api/v1/tasks/search?"+query, token, nil, http.StatusOK), "tasks")
+	}
+
+	// 前缀匹配，标题命中部分高亮
+	results := search("q=mil")
+	if len(results) != 1 {
+		t.Fatalf("搜索 mil 结果数量 = %d, 期望 1", len(results))
+	}
+	if highlight := results[0].(map[string]interface{})["highlight"].(map[string]interface{}); highlight["title"] != "Buy <mark>mil</mark>k" {
+		t.Fatalf("标题高亮 = %v", highlight["title"])
+	}
+
+	if results := search("q=grocer"); len(results) != 1 || results[0].(map[string]interface{})["title"] != "Pick up parcel" {
+		t.Fatalf("按标签名称搜索结果 = %v", results)
+	}
+
+	// 中文按子串匹配，描述返回高亮片段
+	results = search("q=" + url.QueryEscape("预算"))
+	if len(results) != 1 {
+		t.Fatalf("中文搜索结果数量 = %d, 期望 1", len(results))
+	}
+	if highlight := results[0].(map[string]interface{})["highlight"].(map[string]interface{}); !strings.Contains(highlight["description"].(string), "<mark>预算</mark>") {
+		t.Fatalf("描述高亮 = %v", highlight["description"])
+	}
+
+	// 高亮内容中的用户文本做HTML转义，只保留<mark>标签
+	results = search("q=" + url.QueryEscape("发票"))
+	if len(results) != 1 {
+		t.Fatalf("搜索发票结果数量 = %d, 期望 1", len(results))
+	}
+	if highlight := results[0].(map[string]interface{})["highlight"].(map[string]interface{}); highlight["title"] != "&lt;img src=x onerror=alert(1)&gt;报销<mark>发票</mark>" {
+		t.Fatalf("标题高亮 = %v", highlight["title"])
+	}
+
+	if results := search("q=p&priority=1"); len(results) != 0 {
+		t.Fatalf("按优先级过滤后结果数量 = %d, 期望 0", len(results))
+	}
+	s.mustDo(http.MethodGet, "/api/v1/tasks/search", token, nil, http.StatusBadRequest)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/search?q=milk&dueFrom=soon", token, nil, http.StatusBadRequest)
+}
+
+func TestSmartLists(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("smart@example.com")
+
+	workID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Work"}, http.StatusCreated), "project")["id"].(string)
+	personalID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Personal"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "focus"}, http.StatusCreated), "label")["id"].(string)
+
+	now := time.Now()
+	for _, task := range []map[string]interface{}{
+		{"projectId": workID, "title": "Ship release", "priority": 1, "dueTime": now, "labelIds": []string{labelID}},
+		{"projectId": workID, "title": "Plan sprint", "priority": 1, "dueTime": now.AddDate(0, 0, 3), "labelIds": []string{labelID}},
+		{"projectId": personalID, "title": "Dentist", "priority": 1, "dueTime": now.AddDate(0, 0, 2), "labelIds": []string{labelID}},
+		{"projectId": workID, "title": "Someday", "priority": 1},
+	} {
+		s.mustDo(http.MethodPost, "/api/v1/tasks", token, task, http.StatusCreated)
+	}
+
+	titles := func(resp map[string]interface{}) []string {
+		var result []string
+		for _, task := range list(resp, "tasks") {
+			result = append(result, task.(map[string]interface{})["title"].(string))
+		}
+		return result
+	}
+
+	lists := list(s.mustDo(http.MethodGet, "/api/v1/smart-lists", token, nil, http.StatusOK), "smartLists")
+	if len(lists) != 2 {
+		t.Fatalf("内置清单数量 = %d, 期望 2", len(lists))
+	}
+	if got := titles(s.mustDo(http.MethodGet, "/api/v1/smart-lists/today/tasks", token, nil, http.StatusOK)); len(got) != 1 || got[0] != "Ship release" {
+		t.Fatalf("今天清单 = %v", got)
+	}
+	if got := titles(s.mustDo(http.MethodGet, "/api/v1/smart-lists/next7days/tasks", token, nil, http.StatusOK)); len(got) != 3 {
+		t.Fatalf("最近7天清单 = %v", got)
+	}
+
+	created := object(s.mustDo(http.MethodPost, "/api/v1/smart-lists", token, map[string]string{
+		"name":  "Focus",
+		"query": "due:<=7d AND priority:1 AND label:focus AND NOT project:personal",
+	}, http.StatusCreated), "smartList")
+	listID := created["id"].(string)
+	if got := titles(s.mustDo(http.MethodGet, "/api/v1/smart-lists/"+listID+"/tasks", token, nil, http.StatusOK)); len(got) != 2 {
+		t.Fatalf("自定义清单 = %v", got)
+	}
+
+	s.mustDo(http.MethodPost, "/api/v1/smart-lists", token, map[string]string{"name": "Focus", "query": "due:none"}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/api/v1/smart-lists", token, map[string]string{"name": "Broken", "query": "due:<=7d AND (priority:1"}, http.StatusBadRequest)
+	s.mustDo(http.MethodPut, "/api/v1/smart-lists/"+listID, token, map[string]string{"name": "Focus", "query": "due:none"}, http.StatusOK)
+	if got := titles(s.mustDo(http.MethodGet, "/api/v1/smart-lists/"+listID+"/tasks", token, nil, http.StatusOK)); len(got) != 1 || got[0] != "Someday" {
+		t.Fatalf("修改后的清单 = %v", got)
+	}
+
+	// 任务列表也可以直接使用筛选表达式
+	if got := titles(s.mustDo(http.MethodGet, "/api/v1/tasks?filter="+url.QueryEscape("project:Personal OR due:none"), token, nil, http.StatusOK)); len(got) != 2 {
+		t.Fatalf("临时筛选结果 = %v", got)
+	}
+	s.mustDo(http.MethodGet, "/api/v1/tasks?filter="+url.QueryEscape("color:red"), token, nil, http.StatusBadRequest)
+
+	s.mustDo(http.MethodDelete, "/api/v1/smart-lists/"+listID, token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/smart-lists/"+listID+"/tasks", token, nil, http.StatusNotFound)
+}
+
+func TestSmartListsUseUserTimeZone(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("smart-tz@example.com")
+
+	// 选择当前日期与UTC不同的时区：UTC 10点以后UTC+14已是第二天，之前UTC-12还是前一天
+	zone := "Etc/GMT+12"
+	if time.Now().UTC().Hour() >= 10 {
+		zone = "Etc/GMT-14"
+	}
+	s.mustDo(http.MethodPatch, "/api/v1/settings", token, map[string]string{"timeZone": zone}, http.StatusOK)
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Fatalf("加载时区失败: %v", err)
+	}
+	y, m, d := time.Now().In(loc).Date()
+	if y2, m2, d2 := time.Now().UTC().Date(); y == y2 && m == m2 && d == d2 {
+		t.Fatalf("时区 %s 与UTC日期相同", zone)
+	}
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Inbox"}, http.StatusCreated), "project")["id"].(string)
+	for _, task := range []map[string]interface{}{
+		{"projectId": projectID, "title": "Late today", "dueTime": time.Date(y, m, d, 23, 0, 0, 0, loc)},
+		{"projectId": projectID, "title": "Early tomorrow", "dueTime": time.Date(y, m, d+1, 1, 0, 0, 0, loc)},
+	} {
+		s.mustDo(http.MethodPost, "/api/v1/tasks", token, task, http.StatusCreated)
+	}
+
+	// 按UTC计算时两个任务中总有一个落在错误的日期
+	tasks := list(s.mustDo(http.MethodGet, "/api/v1/smart-lists/today/tasks", token, nil, http.StatusOK), "tasks")
+	if len(tasks) != 1 || tasks[0].(map[string]interface{})["title"] != "Late today" {
+		t.Fatalf("时区 %s 的今天清单 = %v", zone, tasks)
+	}
+}
+
+func TestUsersCannotAccessOthersData(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("owner@example.com")
+	other := s.register("other@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", owner, map[string]string{"name": "Private"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", owner, map[string]string{"name": "secret"}, http.StatusCreated), "label")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", owner, map[string]string{"projectId": projectID, "title": "Hidden"}, http.StatusCreated), "task")["id"].(string)
+
+	s.mustDo(http.MethodGet, "/api/v1/projects/"+projectID, other, nil, http.StatusNotFound)
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+projectID, other, nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskID, other, nil, http.StatusNotFound)
+	s.mustDo(http.MethodPut, "/api/v1/tasks/"+taskID, other, map[string]string{"title": "Mine"}, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskID+"/reminders", other, nil, http.StatusNotFound)
+	s.mustDo(http.MethodDelete, "/api/v1/labels/"+labelID, other, nil, http.StatusNotFound)
+
+	// 不能把任务建到别人的项目里，也不能挂别人的标签
+	s.mustDo(http.MethodPost, "/api/v1/tasks", other, map[string]string{"projectId": projectID, "title": "Intrude"}, http.StatusNotFound)
+	otherProject := object(s.mustDo(http.MethodPost, "/api/v1/projects", other, map[string]string{"name": "Private"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks", other, map[string]interface{}{
+		"projectId": otherProject, "title": "Steal label", "labelIds": []string{labelID},
+	}, http.StatusNotFound)
+
+	tasks := s.mustDo(http.MethodGet, "/api/v1/tasks", other, nil, http.StatusOK)
+	if n := len(list(tasks, "tasks")); n != 0 {
+		t.Fatalf("其他用户看到 %d 个任务", n)
+	}
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	s := newTestServer(t)
+	token := s.register("frank@example.com")
+
+	projectID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Garden"}, http.StatusCreated), "project")["id"].(string)
+	labelID := object(s.mustDo(http.MethodPost, "/api/v1/labels", token, map[string]string{"name": "outside"}, http.StatusCreated), "label")["id"].(string)
+	taskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID, "title": "Plant roses", "labelIds": []string{labelID},
+	}, http.StatusCreated), "task")["id"].(string)
+	subtaskID := object(s.mustDo(http.MethodPost, "/api/v1/tasks", token, map[string]interface{}{
+		"projectId": projectID, "parentId": taskID, "title": "Buy seeds",
+	}, http.StatusCreated), "task")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/tasks/"+taskID+"/reminders", token, map[string]interface{}{"remindAt": time.Now().Add(time.Hour)}, http.StatusCreated)
+
+	// 删除任务后回收站只列出父任务，子任务随父任务恢复
+	s.mustDo(http.MethodDelete, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+	trash := s.mustDo(http.MethodGet, "/api/v1/trash", token, nil, http.StatusOK)
+	if n := len(list(trash, "tasks")); n != 1 {
+		t.Fatalf("回收站任务数量 = %d, 期望 1", n)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/trash/tasks/"+subtaskID+"/restore", token, nil, http.StatusOK)
+	s.mustDo(http.MethodDelete, "/api/v1/tasks/"+subtaskID, token, nil, http.StatusOK)
+
+	restored := object(s.mustDo(http.MethodPost, "/api/v1/trash/tasks/"+taskID+"/restore", token, nil, http.StatusOK), "task")
+	if len(list(restored, "labels")) != 1 || len(list(restored, "reminders")) != 1 {
+		t.Fatalf("恢复后的任务 = %v", restored)
+	}
+	// 单独删除的子任务不会随父任务恢复
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+subtaskID, token, nil, http.StatusNotFound)
+	s.mustDo(http.MethodPost, "/api/v1/trash/tasks/"+taskID+"/restore", token, nil, http.StatusNotFound)
+
+	// 项目删除后，其中的任务不能单独恢复
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+projectID, token, nil, http.StatusOK)
+	trash = s.mustDo(http.MethodGet, "/api/v1/trash", token, nil, http.StatusOK)
+	projects := s.mustDo(http.MethodGet, "/api/v1/trash?type=projects", token, nil, http.StatusOK)
+	if len(list(projects, "projects")) != 1 || len(list(trash, "tasks")) != 0 {
+		t.Fatalf("删除项目后的回收站 = %v %v", projects, trash)
+	}
+	s.mustDo(http.MethodPost, "/api/v1/trash/tasks/"+taskID+"/restore", token, nil, http.StatusConflict)
+
+	// 存在同名项目时不能恢复
+	otherID := object(s.mustDo(http.MethodPost, "/api/v1/projects", token, map[string]string{"name": "Garden"}, http.StatusCreated), "project")["id"].(string)
+	s.mustDo(http.MethodPost, "/api/v1/trash/projects/"+projectID+"/restore", token, nil, http.StatusConflict)
+	s.mustDo(http.MethodDelete, "/api/v1/projects/"+otherID, token, nil, http.StatusOK)
+	s.mustDo(http.MethodDelete, "/api/v1/trash/projects/"+otherID, token, nil, http.StatusOK)
+
+	s.mustDo(http.MethodPost, "/api/v1/trash/projects/"+projectID+"/restore", token, nil, http.StatusOK)
+	s.mustDo(http.MethodGet, "/api/v1/tasks/"+taskID, token, nil, http.StatusOK)
+
+	// 清空回收站后单独删除的子任务也被彻底删除
+	s.mustDo(http.MethodDelete, "/api/v1/trash", token, nil, http.StatusOK)
+	s.mustDo(http.MethodPost, "/api/v1/trash/tasks/"+subtaskID+"/restore", token, nil, http.StatusNotFound)
+	trash = s.mustDo(http.MethodGet, "/api/v1/trash", token, nil, http.StatusOK)
+	projects = s.mustDo(http.MethodGet, "/api/v1/trash?type=projects", token, nil, http.StatusOK)
+	if len(list(projects, "projects")) != 0 || len(list(trash, "tasks")) != 0 {
+		t.Fatalf("清空后的回收站 = %v %v", projects, trash)
+	}
+}
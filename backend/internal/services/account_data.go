@@ -0,0 +1,709 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/archive"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrAccountExportNotFound = errors.New("导出任务不存在或下载链接已过期")
+	ErrAccountNotEmpty       = errors.New("只能导入到没有任何项目、标签和智能清单的账户")
+	ErrAccountExportTooLarge = errors.New("导出文件超过大小限制")
+)
+
+const (
+	// accountExportTTL 导出任务和导出文件的保留时间，过期后下载链接失效
+	accountExportTTL = 24 * time.Hour
+	// maxAccountExportSize 导出文件保存在Redis中，超过此大小时导出失败
+	maxAccountExportSize = 20 << 20
+)
+
+// AccountExportJob 账户数据导出任务，完成后DownloadURL在ExpiresAt之前有效
+type AccountExportJob struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	Size        int        `json:"size,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// AccountImportResult 账户数据导入结果，各类数据的导入数量
+type AccountImportResult struct {
+	ExportedAt time.Time      `json:"exportedAt"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// AccountDataService 账户数据的完整导出和恢复
+// 导出在后台生成zip文件并保存在Redis中，通过带随机令牌的链接下载，每个用户只保留最近一次导出的文件；导入在一个事务中完成，
+// 所有数据使用新的ID，引用关系按导出文件中的ID重建
+type AccountDataService struct {
+	tx         repository.Transactor
+	users      repository.UserRepository
+	groups     repository.ProjectGroupRepository
+	projects   repository.ProjectRepository
+	sections   repository.SectionRepository
+	labels     repository.LabelRepository
+	smartLists repository.SmartListRepository
+	tasks      repository.TaskRepository
+	reminders  repository.ReminderRepository
+	settings   *SettingsService
+	redis      *RedisService
+	config     *config.Config
+
+	activeExports atomic.Int64 // 本实例中等待或正在执行的导出任务数
+}
+
+// NewAccountDataService 创建账户数据服务实例
+func NewAccountDataService(tx repository.Transactor, users repository.UserRepository, groups repository.ProjectGroupRepository, projects repository.ProjectRepository, sections repository.SectionRepository, labels repository.LabelRepository, smartLists repository.SmartListRepository, tasks repository.TaskRepository, reminders repository.ReminderRepository, settings *SettingsService, redis *RedisService, cfg *config.Config) *AccountDataService {
+	return &AccountDataService{
+		tx:         tx,
+		users:      users,
+		groups:     groups,
+		projects:   projects,
+		sections:   sections,
+		labels:     labels,
+		smartLists: smartLists,
+		tasks:      tasks,
+		reminders:  reminders,
+		settings:   settings,
+		redis:      redis,
+		config:     cfg,
+	}
+}
+
+// StartExport 创建导出任务并在后台生成导出文件
+func (s *AccountDataService) StartExport(ctx context.Context, userID uuid.UUID) (*AccountExportJob, error) {
+	now := time.Now()
+	job := &AccountExportJob{ID: uuid.New(), Status: JobPending, CreatedAt: now, UpdatedAt: now}
+	if err := s.saveJob(userID, job); err != nil {
+		return nil, err
+	}
+
+	// 导出不随请求结束而取消，返回副本避免与后台更新并发访问
+	resp := *job
+	s.activeExports.Add(1)
+	go s.runExport(context.Background(), userID, job)
+	return &resp, nil
+}
+
+// ActiveExports 返回本实例中等待或正在执行的导出任务数，用于队列长度指标
+func (s *AccountDataService) ActiveExports() int64 {
+	return s.activeExports.Load()
+}
+
+// GetExportJob 获取导出任务的状态
+func (s *AccountDataService) GetExportJob(ctx context.Context, userID, jobID uuid.UUID) (*AccountExportJob, error) {
+	data, err := s.redis.Get(s.jobKey(userID, jobID))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrAccountExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询导出任务失败: %w", err)
+	}
+	var job AccountExportJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("解析导出任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// Download 按下载令牌获取导出文件
+func (s *AccountDataService) Download(ctx context.Context, token string) ([]byte, error) {
+	data, err := s.redis.Get(s.fileKey(token))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrAccountExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取导出文件失败: %w", err)
+	}
+	return []byte(data), nil
+}
+
+// runExport 生成导出文件并保存下载令牌
+func (s *AccountDataService) runExport(ctx context.Context, userID uuid.UUID, job *AccountExportJob) {
+	defer s.activeExports.Add(-1)
+
+	job.Status = JobRunning
+	s.updateJob(userID, job)
+
+	err := s.export(ctx, userID, job)
+
+	now := time.Now()
+	job.Status, job.FinishedAt = JobCompleted, &now
+	if err != nil {
+		job.Status, job.Error = JobFailed, err.Error()
+	}
+	s.updateJob(userID, job)
+}
+
+func (s *AccountDataService) export(ctx context.Context, userID uuid.UUID, job *AccountExportJob) error {
+	account, err := s.collect(ctx, userID)
+	if err != nil {
+		return err
+	}
+	data, err := archive.Encode(account, time.Now())
+	if err != nil {
+		return fmt.Errorf("生成导出文件失败: %w", err)
+	}
+
+	if len(data) > maxAccountExportSize {
+		return fmt.Errorf("%w: %d MB", ErrAccountExportTooLarge, maxAccountExportSize>>20)
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return fmt.Errorf("生成下载令牌失败: %w", err)
+	}
+	if err := s.redis.Set(s.fileKey(token), data, accountExportTTL); err != nil {
+		return fmt.Errorf("保存导出文件失败: %w", err)
+	}
+	// 新文件替换该用户之前的导出文件，重复导出不会累积占用Redis
+	previous, err := s.redis.Get(s.latestFileKey(userID))
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("读取用户 %s 之前的导出文件失败: %v", userID, err)
+	}
+	if err := s.redis.Set(s.latestFileKey(userID), token, accountExportTTL); err != nil {
+		log.Printf("记录用户 %s 的导出文件失败: %v", userID, err)
+	}
+	if previous != "" && previous != token {
+		s.redis.Del(s.fileKey(previous))
+	}
+
+	expiresAt := time.Now().Add(accountExportTTL)
+	job.Size = len(data)
+	job.DownloadURL = strings.TrimSuffix(s.config.Server.PublicURL, "/") + "/api/v1/exports/" + token
+	job.ExpiresAt = &expiresAt
+	return nil
+}
+
+// collect 读取用户的全部数据，回收站中的数据不导出
+func (s *AccountDataService) collect(ctx context.Context, userID uuid.UUID) (*archive.Account, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, errors.New("用户不存在")
+	}
+	account := &archive.Account{
+		Profile:       archive.Profile{Email: user.Email, FirstName: user.FirstName, LastName: user.LastName, CreatedAt: user.CreatedAt},
+		ProjectGroups: make([]archive.ProjectGroup, 0),
+		Projects:      make([]archive.Project, 0),
+		Sections:      make([]archive.Section, 0),
+		Labels:        make([]archive.Label, 0),
+		SmartLists:    make([]archive.SmartList, 0),
+		Tasks:         make([]archive.Task, 0),
+	}
+
+	prefs, err := s.settings.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs.UpdatedAt != nil {
+		doc, err := json.Marshal(prefs.Document)
+		if err != nil {
+			return nil, fmt.Errorf("序列化设置失败: %w", err)
+		}
+		account.Settings = &archive.Settings{Version: prefs.Version, Document: doc}
+	}
+
+	req, err := (&pagination.Query{}).Request(repository.ProjectGroupSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	groups, err := s.groups.ListProjectGroups(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	for _, g := range groups {
+		account.ProjectGroups = append(account.ProjectGroups, archive.ProjectGroup{
+			ID: g.ID, Name: g.Name, SortOrder: g.SortOrder, Collapsed: g.Collapsed, CreatedAt: g.CreatedAt, UpdatedAt: g.UpdatedAt,
+		})
+	}
+
+	req, err = (&pagination.Query{}).Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	sectionReq, err := (&pagination.Query{}).Request(repository.SectionSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	sectionReq.Limit = 0
+	for _, p := range projects {
+		account.Projects = append(account.Projects, archive.Project{
+			ID: p.ID, Name: p.Name, Color: p.Color, GroupID: p.GroupID, SortOrder: p.SortOrder, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt,
+		})
+		sections, err := s.sections.ListSections(ctx, userID, p.ID, sectionReq)
+		if err != nil {
+			return nil, fmt.Errorf("查询分栏失败: %w", err)
+		}
+		for _, sec := range sections {
+			account.Sections = append(account.Sections, archive.Section{
+				ID: sec.ID, ProjectID: sec.ProjectID, Name: sec.Name, SortOrder: sec.SortOrder, Collapsed: sec.Collapsed, CreatedAt: sec.CreatedAt, UpdatedAt: sec.UpdatedAt,
+			})
+		}
+	}
+
+	req, err = (&pagination.Query{}).Request(repository.LabelSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	labels, err := s.labels.ListLabels(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	for _, l := range labels {
+		account.Labels = append(account.Labels, archive.Label{ID: l.ID, Name: l.Name, CreatedAt: l.CreatedAt})
+	}
+
+	req, err = (&pagination.Query{}).Request(repository.SmartListSortFields, "created")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	lists, err := s.smartLists.ListSmartLists(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询智能清单失败: %w", err)
+	}
+	for _, l := range lists {
+		account.SmartLists = append(account.SmartLists, archive.SmartList{ID: l.ID, Name: l.Name, Query: l.Query, CreatedAt: l.CreatedAt, UpdatedAt: l.UpdatedAt})
+	}
+
+	tasks, err := s.tasks.ListTasksWithDetails(ctx, userID, repository.TaskFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	exported := make(map[uuid.UUID]bool, len(tasks))
+	for _, t := range tasks {
+		exported[t.ID] = true
+	}
+	for _, t := range tasks {
+		account.Tasks = append(account.Tasks, archiveTask(t, exported))
+	}
+	return account, nil
+}
+
+// archiveTask 转换任务，指向未导出任务的替代例外视为删除的实例
+func archiveTask(t *models.Task, exported map[uuid.UUID]bool) archive.Task {
+	task := archive.Task{
+		ID:          t.ID,
+		ProjectID:   t.ProjectID,
+		ParentID:    t.ParentID,
+		SectionID:   t.SectionID,
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      string(t.Status),
+		Priority:    t.Priority,
+		StartTime:   t.StartTime,
+		DueTime:     t.DueTime,
+		CompletedAt: t.CompletedAt,
+		RRule:       t.RRuleString,
+		SortOrder:   t.SortOrder,
+		ICalUID:     t.ICalUID,
+		LabelIDs:    make([]uuid.UUID, 0, len(t.Labels)),
+		Reminders:   make([]time.Time, 0, len(t.Reminders)),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	for _, label := range t.Labels {
+		task.LabelIDs = append(task.LabelIDs, label.ID)
+	}
+	for _, reminder := range t.Reminders {
+		task.Reminders = append(task.Reminders, reminder.RemindAt)
+	}
+	for _, e := range t.Exceptions {
+		exception := archive.RecurrenceException{OriginalTime: e.OriginalTime}
+		if e.NewTaskID != nil && exported[*e.NewTaskID] {
+			exception.NewTaskID = e.NewTaskID
+		}
+		task.Exceptions = append(task.Exceptions, exception)
+	}
+	return task
+}
+
+// Import 将导出文件恢复到当前账户，账户中不能已有项目、分组、标签或智能清单
+// 用户资料只恢复姓名，邮箱和密码保持不变
+func (s *AccountDataService) Import(ctx context.Context, userID uuid.UUID, data []byte) (*AccountImportResult, error) {
+	manifest, account, err := archive.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEmpty(ctx, userID); err != nil {
+		return nil, err
+	}
+	r, err := newAccountRestore(userID, account)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		return s.restore(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AccountImportResult{ExportedAt: manifest.ExportedAt, Counts: account.Counts()}, nil
+}
+
+// checkEmpty 检查账户中是否已有数据
+func (s *AccountDataService) checkEmpty(ctx context.Context, userID uuid.UUID) error {
+	req, err := (&pagination.Query{}).Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return err
+	}
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return fmt.Errorf("查询项目失败: %w", err)
+	}
+	req, err = (&pagination.Query{}).Request(repository.LabelSortFields, "name")
+	if err != nil {
+		return err
+	}
+	labels, err := s.labels.ListLabels(ctx, userID, req)
+	if err != nil {
+		return fmt.Errorf("查询标签失败: %w", err)
+	}
+	req, err = (&pagination.Query{}).Request(repository.ProjectGroupSortFields, "manual")
+	if err != nil {
+		return err
+	}
+	groups, err := s.groups.ListProjectGroups(ctx, userID, req)
+	if err != nil {
+		return fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	req, err = (&pagination.Query{}).Request(repository.SmartListSortFields, "created")
+	if err != nil {
+		return err
+	}
+	lists, err := s.smartLists.ListSmartLists(ctx, userID, req)
+	if err != nil {
+		return fmt.Errorf("查询智能清单失败: %w", err)
+	}
+	if len(projects)+len(labels)+len(groups)+len(lists) > 0 {
+		return ErrAccountNotEmpty
+	}
+	return nil
+}
+
+// accountRestore 导入过程中导出ID到新ID的映射
+type accountRestore struct {
+	userID   uuid.UUID
+	account  *archive.Account
+	groups   map[uuid.UUID]uuid.UUID
+	projects map[uuid.UUID]uuid.UUID
+	sections map[uuid.UUID]uuid.UUID
+	labels   map[uuid.UUID]uuid.UUID
+	tasks    map[uuid.UUID]uuid.UUID
+	order    []*archive.Task // 父任务排在子任务之前
+}
+
+// newAccountRestore 预先分配新ID并校验引用关系，引用不存在或父任务形成环时返回ErrInvalidArchive
+func newAccountRestore(userID uuid.UUID, account *archive.Account) (*accountRestore, error) {
+	r := &accountRestore{
+		userID:   userID,
+		account:  account,
+		groups:   make(map[uuid.UUID]uuid.UUID),
+		projects: make(map[uuid.UUID]uuid.UUID),
+		sections: make(map[uuid.UUID]uuid.UUID),
+		labels:   make(map[uuid.UUID]uuid.UUID),
+		tasks:    make(map[uuid.UUID]uuid.UUID),
+	}
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", archive.ErrInvalidArchive, fmt.Sprintf(format, args...))
+	}
+	assign := func(ids map[uuid.UUID]uuid.UUID, id uuid.UUID, kind string) error {
+		if _, ok := ids[id]; ok {
+			return invalid("%s %s 重复", kind, id)
+		}
+		ids[id] = uuid.New()
+		return nil
+	}
+
+	for _, g := range account.ProjectGroups {
+		if err := assign(r.groups, g.ID, "项目分组"); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range account.Projects {
+		if err := assign(r.projects, p.ID, "项目"); err != nil {
+			return nil, err
+		}
+		if _, ok := r.groups[derefID(p.GroupID)]; p.GroupID != nil && !ok {
+			return nil, invalid("项目「%s」引用了不存在的分组", p.Name)
+		}
+	}
+	sectionProjects := make(map[uuid.UUID]uuid.UUID, len(account.Sections))
+	for _, sec := range account.Sections {
+		if err := assign(r.sections, sec.ID, "分栏"); err != nil {
+			return nil, err
+		}
+		if _, ok := r.projects[sec.ProjectID]; !ok {
+			return nil, invalid("分栏「%s」引用了不存在的项目", sec.Name)
+		}
+		sectionProjects[sec.ID] = sec.ProjectID
+	}
+	for _, l := range account.Labels {
+		if err := assign(r.labels, l.ID, "标签"); err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[uuid.UUID]*archive.Task, len(account.Tasks))
+	for i := range account.Tasks {
+		t := &account.Tasks[i]
+		if err := assign(r.tasks, t.ID, "任务"); err != nil {
+			return nil, err
+		}
+		byID[t.ID] = t
+	}
+	for _, t := range byID {
+		if _, ok := r.projects[t.ProjectID]; !ok {
+			return nil, invalid("任务「%s」引用了不存在的项目", t.Title)
+		}
+		if t.SectionID != nil && sectionProjects[*t.SectionID] != t.ProjectID {
+			return nil, invalid("任务「%s」引用了其他项目的分栏", t.Title)
+		}
+		if parent, ok := byID[derefID(t.ParentID)]; t.ParentID != nil && (!ok || parent.ProjectID != t.ProjectID) {
+			return nil, invalid("任务「%s」的父任务不存在", t.Title)
+		}
+		for _, id := range t.LabelIDs {
+			if _, ok := r.labels[id]; !ok {
+				return nil, invalid("任务「%s」引用了不存在的标签", t.Title)
+			}
+		}
+		for _, e := range t.Exceptions {
+			if _, ok := r.tasks[derefID(e.NewTaskID)]; e.NewTaskID != nil && !ok {
+				return nil, invalid("任务「%s」的循环例外引用了不存在的任务", t.Title)
+			}
+		}
+	}
+
+	// 按导出顺序排列，父任务先于子任务写入
+	state := make(map[uuid.UUID]int) // 1访问中，2已排序
+	var visit func(t *archive.Task) error
+	visit = func(t *archive.Task) error {
+		switch state[t.ID] {
+		case 1:
+			return invalid("任务「%s」的父任务形成循环", t.Title)
+		case 2:
+			return nil
+		}
+		state[t.ID] = 1
+		if t.ParentID != nil {
+			if err := visit(byID[*t.ParentID]); err != nil {
+				return err
+			}
+		}
+		state[t.ID] = 2
+		r.order = append(r.order, t)
+		return nil
+	}
+	for i := range account.Tasks {
+		if err := visit(&account.Tasks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// restore 按引用顺序写入全部数据
+func (s *AccountDataService) restore(ctx context.Context, r *accountRestore) error {
+	account := r.account
+	if account.Profile.FirstName != "" || account.Profile.LastName != "" {
+		user, err := s.users.GetUserByID(ctx, r.userID)
+		if err != nil {
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+		if user == nil {
+			return errors.New("用户不存在")
+		}
+		user.FirstName, user.LastName = account.Profile.FirstName, account.Profile.LastName
+		if err := s.users.UpdateUser(ctx, user); err != nil {
+			return fmt.Errorf("更新用户失败: %w", err)
+		}
+	}
+	if account.Settings != nil {
+		if err := s.settings.RestoreSettings(ctx, r.userID, account.Settings.Version, account.Settings.Document); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range account.ProjectGroups {
+		group := &models.ProjectGroup{
+			ID: r.groups[g.ID], UserID: r.userID, Name: g.Name, SortOrder: g.SortOrder, Collapsed: g.Collapsed, CreatedAt: g.CreatedAt, UpdatedAt: g.UpdatedAt,
+		}
+		if err := s.groups.CreateProjectGroup(ctx, group); err != nil {
+			return fmt.Errorf("创建项目分组失败: %w", err)
+		}
+	}
+	for _, p := range account.Projects {
+		project := &models.Project{
+			ID: r.projects[p.ID], UserID: r.userID, Name: p.Name, Color: p.Color, GroupID: mapID(r.groups, p.GroupID), SortOrder: p.SortOrder, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt,
+		}
+		if project.Color == "" {
+			project.Color = DefaultProjectColor
+		}
+		if err := s.projects.CreateProject(ctx, project); err != nil {
+			return fmt.Errorf("创建项目失败: %w", err)
+		}
+	}
+	for _, sec := range account.Sections {
+		section := &models.Section{
+			ID: r.sections[sec.ID], UserID: r.userID, ProjectID: r.projects[sec.ProjectID], Name: sec.Name, SortOrder: sec.SortOrder, Collapsed: sec.Collapsed, CreatedAt: sec.CreatedAt, UpdatedAt: sec.UpdatedAt,
+		}
+		if err := s.sections.CreateSection(ctx, section); err != nil {
+			return fmt.Errorf("创建分栏失败: %w", err)
+		}
+	}
+	for _, l := range account.Labels {
+		label := &models.Label{ID: r.labels[l.ID], UserID: r.userID, Name: l.Name, CreatedAt: l.CreatedAt}
+		if err := s.labels.CreateLabel(ctx, label); err != nil {
+			return fmt.Errorf("创建标签失败: %w", err)
+		}
+	}
+	for _, l := range account.SmartLists {
+		list := &models.SmartList{UserID: r.userID, Name: l.Name, Query: l.Query, CreatedAt: l.CreatedAt, UpdatedAt: l.UpdatedAt}
+		if err := s.smartLists.CreateSmartList(ctx, list); err != nil {
+			return fmt.Errorf("创建智能清单失败: %w", err)
+		}
+	}
+
+	for _, t := range r.order {
+		if err := s.restoreTask(ctx, r, t); err != nil {
+			return err
+		}
+	}
+	// 循环例外可能指向后写入的任务，全部任务写入后再创建
+	for _, t := range r.order {
+		for _, e := range t.Exceptions {
+			exception := &models.TaskRecurrenceException{RecurringTaskID: r.tasks[t.ID], OriginalTime: e.OriginalTime, NewTaskID: mapID(r.tasks, e.NewTaskID)}
+			if err := s.tasks.CreateRecurrenceException(ctx, exception); err != nil {
+				return fmt.Errorf("创建循环例外失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreTask 写入任务及其标签和提醒
+func (s *AccountDataService) restoreTask(ctx context.Context, r *accountRestore, t *archive.Task) error {
+	task := &models.Task{
+		ID:          r.tasks[t.ID],
+		UserID:      r.userID,
+		ProjectID:   r.projects[t.ProjectID],
+		ParentID:    mapID(r.tasks, t.ParentID),
+		SectionID:   mapID(r.sections, t.SectionID),
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      models.TaskStatus(t.Status),
+		Priority:    t.Priority,
+		StartTime:   t.StartTime,
+		DueTime:     t.DueTime,
+		CompletedAt: t.CompletedAt,
+		RRuleString: t.RRule,
+		SortOrder:   t.SortOrder,
+		ICalUID:     t.ICalUID,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if task.Status != models.TaskStatusCompleted {
+		task.Status, task.CompletedAt = models.TaskStatusIncomplete, nil
+	}
+	if task.Priority < 1 || task.Priority > 4 {
+		task.Priority = DefaultTaskPriority
+	}
+	if err := s.tasks.CreateTask(ctx, task); err != nil {
+		return fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	if len(t.LabelIDs) > 0 {
+		labelIDs := make([]uuid.UUID, 0, len(t.LabelIDs))
+		for _, id := range t.LabelIDs {
+			labelIDs = append(labelIDs, r.labels[id])
+		}
+		if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+			return fmt.Errorf("设置任务标签失败: %w", err)
+		}
+	}
+	for _, remindAt := range t.Reminders {
+		if err := s.reminders.CreateReminder(ctx, &models.Reminder{TaskID: task.ID, RemindAt: remindAt}); err != nil {
+			return fmt.Errorf("创建提醒失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateJob 保存导出任务，后台导出中保存失败只记录日志
+func (s *AccountDataService) updateJob(userID uuid.UUID, job *AccountExportJob) {
+	job.UpdatedAt = time.Now()
+	if err := s.saveJob(userID, job); err != nil {
+		log.Printf("保存导出任务%s失败: %v", job.ID, err)
+	}
+}
+
+func (s *AccountDataService) saveJob(userID uuid.UUID, job *AccountExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.Set(s.jobKey(userID, job.ID), data, accountExportTTL); err != nil {
+		return fmt.Errorf("保存导出任务失败: %w", err)
+	}
+	return nil
+}
+
+func (s *AccountDataService) jobKey(userID, jobID uuid.UUID) string {
+	return fmt.Sprintf("account:export:job:%s:%s", userID, jobID)
+}
+
+func (s *AccountDataService) fileKey(token string) string {
+	return "account:export:file:" + token
+}
+
+func (s *AccountDataService) latestFileKey(userID uuid.UUID) string {
+	return "account:export:latest:" + userID.String()
+}
+
+// mapID 将导出文件中的可选ID转换为新ID
+func mapID(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	mapped := ids[*id]
+	return &mapped
+}
+
+// derefID 取可选ID的值，nil时返回uuid.Nil
+func derefID(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrAppPasswordNotFound = errors.New("应用专用密码不存在")
+
+const (
+	// appPasswordAlphabet 应用专用密码的字符集，只用小写字母和数字便于在设备上手动输入
+	appPasswordAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	// appPasswordLength 应用专用密码的字符数，按每4个字符一组用短横线分隔展示
+	appPasswordLength = 16
+	// appPasswordTouchInterval 最近使用时间的更新间隔，避免客户端每次同步都写数据库
+	appPasswordTouchInterval = time.Hour
+)
+
+// AppPasswordService 应用专用密码服务，CalDAV客户端使用邮箱和应用专用密码进行HTTP Basic认证
+type AppPasswordService struct {
+	passwords repository.AppPasswordRepository
+	users     repository.UserRepository
+}
+
+// NewAppPasswordService 创建应用专用密码服务实例
+func NewAppPasswordService(passwords repository.AppPasswordRepository, users repository.UserRepository) *AppPasswordService {
+	return &AppPasswordService{
+		passwords: passwords,
+		users:     users,
+	}
+}
+
+// CreateAppPasswordRequest 创建应用专用密码请求结构，name用于区分不同设备
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// AppPasswordResponse 应用专用密码响应结构，Password只在创建时返回
+type AppPasswordResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Password   string     `json:"password,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ListAppPasswords 分页获取用户的应用专用密码，默认按创建时间排序
+func (s *AppPasswordService) ListAppPasswords(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*AppPasswordResponse], error) {
+	req, err := query.Request(repository.AppPasswordSortFields, "created")
+	if err != nil {
+		return nil, err
+	}
+
+	passwords, err := s.passwords.ListAppPasswords(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询应用专用密码失败: %w", err)
+	}
+	passwords, next := pagination.Trim(passwords, req, func(password *models.AppPassword) (interface{}, uuid.UUID) {
+		return repository.AppPasswordSortValue(password, req.Sort.Field.Name), password.ID
+	})
+
+	page := &pagination.Page[*AppPasswordResponse]{Items: make([]*AppPasswordResponse, 0, len(passwords)), NextCursor: next}
+	for _, password := range passwords {
+		page.Items = append(page.Items, toAppPasswordResponse(password))
+	}
+	return page, nil
+}
+
+// CreateAppPassword 生成新的应用专用密码，明文只在本次响应中返回
+func (s *AppPasswordService) CreateAppPassword(ctx context.Context, userID uuid.UUID, req *CreateAppPasswordRequest) (*AppPasswordResponse, error) {
+	plain, err := newAppPassword()
+	if err != nil {
+		return nil, fmt.Errorf("生成应用专用密码失败: %w", err)
+	}
+
+	password := &models.AppPassword{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hashAppPassword(plain),
+	}
+	if err := s.passwords.CreateAppPassword(ctx, password); err != nil {
+		return nil, fmt.Errorf("创建应用专用密码失败: %w", err)
+	}
+
+	resp := toAppPasswordResponse(password)
+	resp.Password = plain
+	return resp, nil
+}
+
+// DeleteAppPassword 删除应用专用密码，使用该密码的客户端随即无法认证
+func (s *AppPasswordService) DeleteAppPassword(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.passwords.DeleteAppPassword(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("删除应用专用密码失败: %w", err)
+	}
+	if !deleted {
+		return ErrAppPasswordNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验邮箱和应用专用密码，成功时返回用户ID
+// 密码忽略大小写和分隔用的短横线；用户名必须是密码所属用户的邮箱
+func (s *AppPasswordService) Authenticate(ctx context.Context, username, plain string) (uuid.UUID, bool, error) {
+	password, err := s.passwords.GetAppPasswordByHash(ctx, hashAppPassword(plain))
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("查询应用专用密码失败: %w", err)
+	}
+	if password == nil {
+		return uuid.Nil, false, nil
+	}
+
+	user, err := s.users.GetUserByID(ctx, password.UserID)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil || !strings.EqualFold(strings.TrimSpace(username), user.Email) {
+		return uuid.Nil, false, nil
+	}
+
+	now := time.Now()
+	if password.LastUsedAt == nil || now.Sub(*password.LastUsedAt) >= appPasswordTouchInterval {
+		if err := s.passwords.TouchAppPassword(ctx, password.ID, now); err != nil {
+			return uuid.Nil, false, fmt.Errorf("更新应用专用密码使用时间失败: %w", err)
+		}
+	}
+	return user.ID, true, nil
+}
+
+// newAppPassword 生成随机的应用专用密码，格式为xxxx-xxxx-xxxx-xxxx
+func newAppPassword() (string, error) {
+	buf := make([]byte, appPasswordLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, c := range buf {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		// 256不是36的整数倍，取模带来的偏差对16位密码的强度影响可以忽略
+		b.WriteByte(appPasswordAlphabet[int(c)%len(appPasswordAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// hashAppPassword 计算规范化后密码的摘要，去掉短横线和空白并转为小写
+func hashAppPassword(plain string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(plain)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// toAppPasswordResponse 转换为应用专用密码响应结构
+func toAppPasswordResponse(password *models.AppPassword) *AppPasswordResponse {
+	return &AppPasswordResponse{
+		ID:         password.ID,
+		Name:       password.Name,
+		LastUsedAt: password.LastUsedAt,
+		CreatedAt:  password.CreatedAt,
+	}
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/ical"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrCalDAVResourceNotFound = errors.New("日历资源不存在")
+	ErrCalDAVUIDConflict      = errors.New("该UID已被其他任务使用")
+	ErrInvalidSyncToken       = errors.New("同步令牌无效或已过期")
+)
+
+const (
+	// caldavSyncTokenPrefix 同步令牌的URI前缀，RFC 6578要求令牌是URI
+	caldavSyncTokenPrefix = "urn:ticktick-backend:sync:"
+	// caldavSnapshotTTL 同步快照的保留时间，超过后客户端需要重新完整同步
+	caldavSnapshotTTL = 30 * 24 * time.Hour
+	// caldavResourceSuffix 日历资源名称的后缀
+	caldavResourceSuffix = ".ics"
+)
+
+// CalDAVService CalDAV日历服务，每个项目是一个只包含VTODO的日历集合，每个顶层条目是一个日历资源
+// 循环任务的替代实例与循环任务放在同一个资源中；资源的ETag和集合的同步令牌都由内容计算
+type CalDAVService struct {
+	tx        repository.Transactor
+	tasks     repository.TaskRepository
+	projects  repository.ProjectRepository
+	reminders repository.ReminderRepository
+	calendar  *CalendarService
+	redis     *RedisService
+}
+
+// NewCalDAVService 创建CalDAV日历服务实例
+func NewCalDAVService(tx repository.Transactor, tasks repository.TaskRepository, projects repository.ProjectRepository, reminders repository.ReminderRepository, calendar *CalendarService, redis *RedisService) *CalDAVService {
+	return &CalDAVService{
+		tx:        tx,
+		tasks:     tasks,
+		projects:  projects,
+		reminders: reminders,
+		calendar:  calendar,
+		redis:     redis,
+	}
+}
+
+// CalDAVCalendar 日历集合及其当前的全部资源
+type CalDAVCalendar struct {
+	Project   *models.Project
+	Resources []*CalDAVResource // 按名称排序
+	byName    map[string]*CalDAVResource
+}
+
+// CalDAVResource 日历资源，Data是只包含一个UID的VCALENDAR
+type CalDAVResource struct {
+	Name      string
+	Data      []byte
+	ETag      string
+	UpdatedAt time.Time
+	task      *models.Task
+}
+
+// CalDAVPutResult 写入资源的结果，资源名称可能与请求路径不同（以UID命名）
+type CalDAVPutResult struct {
+	Name    string
+	Created bool
+	ETag    string
+}
+
+// ListCalendars 获取用户的全部项目，每个项目对应一个日历集合
+func (s *CalDAVService) ListCalendars(ctx context.Context, userID uuid.UUID) ([]*models.Project, error) {
+	req, err := (&pagination.Query{}).Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	return projects, nil
+}
+
+// Calendar 加载项目对应的日历集合，项目不存在时返回ErrProjectNotFound
+func (s *CalDAVService) Calendar(ctx context.Context, userID, projectID uuid.UUID) (*CalDAVCalendar, error) {
+	project, err := s.projects.GetProjectByID(ctx, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	tasks, err := s.tasks.ListTasksWithDetails(ctx, userID, repository.TaskFilter{ProjectID: &projectID})
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	overridden := make(map[uuid.UUID]bool)
+	for _, task := range tasks {
+		for _, exception := range task.Exceptions {
+			if exception.NewTaskID != nil && byID[*exception.NewTaskID] != nil {
+				overridden[*exception.NewTaskID] = true
+			}
+		}
+	}
+
+	cal := &CalDAVCalendar{Project: project, byName: make(map[string]*CalDAVResource)}
+	for _, task := range tasks {
+		if overridden[task.ID] {
+			continue // 随循环任务放在同一个资源中
+		}
+		res := newCalDAVResource(task, byID)
+		cal.Resources = append(cal.Resources, res)
+		cal.byName[res.Name] = res
+	}
+	sort.Slice(cal.Resources, func(i, j int) bool { return cal.Resources[i].Name < cal.Resources[j].Name })
+	return cal, nil
+}
+
+// Resource 按名称获取资源，不存在时返回nil
+func (cal *CalDAVCalendar) Resource(name string) *CalDAVResource {
+	return cal.byName[name]
+}
+
+// SyncToken 计算集合当前的同步令牌并保存快照，供之后的sync-collection报告计算变化
+// 令牌只由资源名称和ETag决定，内容不变时令牌不变，因此也用作getctag
+func (s *CalDAVService) SyncToken(ctx context.Context, userID uuid.UUID, cal *CalDAVCalendar) (string, error) {
+	snapshot := cal.snapshot()
+	hash := snapshotHash(snapshot)
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(s.snapshotKey(userID, cal.Project.ID, hash), data, caldavSnapshotTTL); err != nil {
+		return "", fmt.Errorf("保存同步快照失败: %w", err)
+	}
+	return caldavSyncTokenPrefix + hash, nil
+}
+
+// Changes 比较集合当前状态与token对应的快照，返回新增或修改的资源和已删除的资源名称
+// token为空时视为首次同步，返回全部资源；快照不存在时返回ErrInvalidSyncToken
+func (s *CalDAVService) Changes(ctx context.Context, userID uuid.UUID, cal *CalDAVCalendar, token string) ([]*CalDAVResource, []string, error) {
+	if token == "" {
+		return cal.Resources, nil, nil
+	}
+	hash, ok := strings.CutPrefix(token, caldavSyncTokenPrefix)
+	if !ok {
+		return nil, nil, ErrInvalidSyncToken
+	}
+	data, err := s.redis.Get(s.snapshotKey(userID, cal.Project.ID, hash))
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, ErrInvalidSyncToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取同步快照失败: %w", err)
+	}
+	var previous map[string]string
+	if err := json.Unmarshal([]byte(data), &previous); err != nil {
+		return nil, nil, ErrInvalidSyncToken
+	}
+
+	var changed []*CalDAVResource
+	for _, res := range cal.Resources {
+		if previous[res.Name] != res.ETag {
+			changed = append(changed, res)
+		}
+	}
+	var removed []string
+	for name := range previous {
+		if cal.byName[name] == nil {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed, nil
+}
+
+// PutResource 用iCalendar数据创建或更新资源，existing为nil时创建
+// 只处理不带RECURRENCE-ID的VTODO，替代实例需要通过任务接口修改；客户端修改的EXDATE会追加为循环例外
+func (s *CalDAVService) PutResource(ctx context.Context, userID uuid.UUID, cal *CalDAVCalendar, existing *CalDAVResource, data []byte) (*CalDAVPutResult, error) {
+	parsed, err := ical.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidICS, err)
+	}
+	var component *ical.Component
+	for _, c := range parsed.Children("VTODO") {
+		if c.Get("RECURRENCE-ID") == nil {
+			component = c
+			break
+		}
+	}
+	if component == nil {
+		return nil, fmt.Errorf("%w: 日历只支持VTODO", ErrInvalidICS)
+	}
+
+	imp, err := s.calendar.newImport(ctx, userID, cal.Project.ID, parsed)
+	if err != nil {
+		return nil, err
+	}
+	item := imp.newItem(component)
+	if item.UID == "" || len(item.UID) > 255 {
+		return nil, fmt.Errorf("%w: 缺少UID或UID过长", ErrInvalidICS)
+	}
+	entry, err := imp.entry(component, item)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidICS, err)
+	}
+
+	if existing != nil {
+		if item.UID != taskUID(existing.task) {
+			return nil, fmt.Errorf("%w: UID与资源不一致", ErrInvalidICS)
+		}
+		if err := s.updateEntry(ctx, imp, existing.task, entry, component.Get("COMPLETED") != nil); err != nil {
+			return nil, err
+		}
+		return s.putResult(ctx, userID, existing.task.ID, false)
+	}
+
+	if err := s.checkUID(ctx, userID, item.UID); err != nil {
+		return nil, err
+	}
+	entry.task.ICalUID = item.UID
+	if err := s.calendar.writeEntry(ctx, imp, entry, nil); err != nil {
+		return nil, err
+	}
+	return s.putResult(ctx, userID, entry.task.ID, true)
+}
+
+// DeleteResource 删除资源对应的任务及其子任务，循环任务的替代实例一并删除
+func (s *CalDAVService) DeleteResource(ctx context.Context, userID uuid.UUID, res *CalDAVResource) error {
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		for _, exception := range res.task.Exceptions {
+			if exception.NewTaskID == nil {
+				continue
+			}
+			if err := s.tasks.DeleteTask(ctx, userID, *exception.NewTaskID); err != nil {
+				return fmt.Errorf("删除替代任务失败: %w", err)
+			}
+		}
+		if err := s.tasks.DeleteTask(ctx, userID, res.task.ID); err != nil {
+			return fmt.Errorf("删除任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// MatchesTimeRange 判断资源是否与[start, end)有交集，参照RFC 4791 9.9对VTODO的规则简化：
+// 没有时间的任务和循环任务总是匹配，其余按开始到截止时间的区间判断
+func (res *CalDAVResource) MatchesTimeRange(start, end *time.Time) bool {
+	task := res.task
+	if task.IsRecurring() || (task.StartTime == nil && task.DueTime == nil) {
+		return true
+	}
+	from, to := task.StartTime, task.DueTime
+	if from == nil {
+		from = to
+	}
+	if to == nil {
+		to = from
+	}
+	if start != nil && to.Before(*start) {
+		return false
+	}
+	if end != nil && !from.Before(*end) {
+		return false
+	}
+	return true
+}
+
+// updateEntry 在一个事务中用解析出的数据更新任务，保留所属项目、父任务、分栏和排序
+// 标签和提醒按新数据整体替换；已完成的任务没有提供COMPLETED时保留原完成时间
+func (s *CalDAVService) updateEntry(ctx context.Context, imp *icsImport, task *models.Task, entry *icsEntry, hasCompletedAt bool) error {
+	parsed := entry.task
+	completedAt := task.CompletedAt
+	wasCompleted := task.IsCompleted()
+
+	task.Title = parsed.Title
+	task.Description = parsed.Description
+	task.Priority = parsed.Priority
+	task.StartTime, task.DueTime = parsed.StartTime, parsed.DueTime
+	task.RRuleString = parsed.RRuleString
+	task.Status, task.CompletedAt = parsed.Status, parsed.CompletedAt
+	if wasCompleted && task.IsCompleted() && !hasCompletedAt {
+		task.CompletedAt = completedAt
+	}
+
+	known := make(map[int64]bool, len(task.Exceptions))
+	for _, exception := range task.Exceptions {
+		known[exception.OriginalTime.Unix()] = true
+	}
+	reminders := task.Reminders
+	created := make(map[string]uuid.UUID)
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.tasks.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("更新任务失败: %w", err)
+		}
+
+		labelIDs, err := s.calendar.entryLabelIDs(ctx, imp, entry, created)
+		if err != nil {
+			return err
+		}
+		if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+			return fmt.Errorf("设置任务标签失败: %w", err)
+		}
+
+		for _, reminder := range reminders {
+			if err := s.reminders.DeleteReminder(ctx, task.ID, reminder.ID); err != nil {
+				return fmt.Errorf("删除提醒失败: %w", err)
+			}
+		}
+		for _, remindAt := range entry.reminders {
+			if err := s.reminders.CreateReminder(ctx, &models.Reminder{TaskID: task.ID, RemindAt: remindAt}); err != nil {
+				return fmt.Errorf("创建提醒失败: %w", err)
+			}
+		}
+
+		for _, exdate := range entry.exdates {
+			if known[exdate.Unix()] {
+				continue
+			}
+			exception := &models.TaskRecurrenceException{RecurringTaskID: task.ID, OriginalTime: exdate}
+			if err := s.tasks.CreateRecurrenceException(ctx, exception); err != nil {
+				return fmt.Errorf("创建循环例外失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name, id := range created {
+		imp.labels[name] = id
+	}
+	return nil
+}
+
+// checkUID 检查新资源的UID是否已被用户的其他任务使用，包括以任务ID作为UID导出的任务
+func (s *CalDAVService) checkUID(ctx context.Context, userID uuid.UUID, uid string) error {
+	existing, err := s.tasks.GetTaskByICalUID(ctx, userID, uid)
+	if err != nil {
+		return fmt.Errorf("查询任务失败: %w", err)
+	}
+	if existing == nil {
+		if id, err := uuid.Parse(uid); err == nil {
+			if existing, err = s.tasks.GetTaskByID(ctx, userID, id); err != nil {
+				return fmt.Errorf("查询任务失败: %w", err)
+			}
+		}
+	}
+	if existing != nil {
+		return ErrCalDAVUIDConflict
+	}
+	return nil
+}
+
+// putResult 重新加载写入后的资源，返回其名称和ETag
+func (s *CalDAVService) putResult(ctx context.Context, userID, taskID uuid.UUID, created bool) (*CalDAVPutResult, error) {
+	task, err := s.tasks.GetTaskByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+	cal, err := s.Calendar(ctx, userID, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	res := cal.Resource(caldavResourceName(task))
+	if res == nil {
+		return nil, ErrCalDAVResourceNotFound
+	}
+	return &CalDAVPutResult{Name: res.Name, Created: created, ETag: res.ETag}, nil
+}
+
+// snapshotKey 同步快照在Redis中的键
+func (s *CalDAVService) snapshotKey(userID, projectID uuid.UUID, hash string) string {
+	return fmt.Sprintf("caldav:sync:%s:%s:%s", userID, projectID, hash)
+}
+
+// snapshot 资源名称到ETag的映射
+func (cal *CalDAVCalendar) snapshot() map[string]string {
+	snapshot := make(map[string]string, len(cal.Resources))
+	for _, res := range cal.Resources {
+		snapshot[res.Name] = res.ETag
+	}
+	return snapshot
+}
+
+// snapshotHash 按名称排序后计算快照的摘要
+func snapshotHash(snapshot map[string]string) string {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\n", name, snapshot[name])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// newCalDAVResource 将任务及其替代实例序列化为日历资源
+// CalDAV资源不能包含METHOD属性，因此不复用导出时的日历头
+func newCalDAVResource(task *models.Task, byID map[uuid.UUID]*models.Task) *CalDAVResource {
+	cal := ical.NewComponent("VCALENDAR")
+	cal.Add("PRODID", icsProductID)
+	cal.Add("VERSION", "2.0")
+	cal.AddComponent(taskComponent(task, nil, byID, true))
+
+	updatedAt := task.UpdatedAt
+	for _, exception := range task.Exceptions {
+		if exception.NewTaskID == nil || byID[*exception.NewTaskID] == nil {
+			continue
+		}
+		override := byID[*exception.NewTaskID]
+		cal.AddComponent(taskComponent(override, &recurrenceOverride{master: task, originalTime: exception.OriginalTime}, byID, true))
+		if override.UpdatedAt.After(updatedAt) {
+			updatedAt = override.UpdatedAt
+		}
+	}
+
+	data := []byte(cal.String())
+	sum := sha256.Sum256(data)
+	return &CalDAVResource{
+		Name:      caldavResourceName(task),
+		Data:      data,
+		ETag:      `"` + hex.EncodeToString(sum[:16]) + `"`,
+		UpdatedAt: updatedAt,
+		task:      task,
+	}
+}
+
+// caldavResourceName 资源名称使用UID，UID包含路径分隔符时改用任务ID
+func caldavResourceName(task *models.Task) string {
+	uid := taskUID(task)
+	if strings.ContainsAny(uid, "/\\") {
+		uid = task.ID.String()
+	}
+	return uid + caldavResourceSuffix
+}
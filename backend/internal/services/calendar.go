@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/ical"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// icsProductID 导出日历的PRODID
+	icsProductID = "-//ticktick-backend//Tasks//ZH"
+	// icsAllTasksName 导出全部任务时的日历名称
+	icsAllTasksName = "所有任务"
+)
+
+// CalendarService iCalendar导入导出服务
+type CalendarService struct {
+	tx        repository.Transactor
+	tasks     repository.TaskRepository
+	projects  repository.ProjectRepository
+	labels    repository.LabelRepository
+	reminders repository.ReminderRepository
+	settings  *SettingsService
+}
+
+// NewCalendarService 创建iCalendar导入导出服务实例
+func NewCalendarService(tx repository.Transactor, tasks repository.TaskRepository, projects repository.ProjectRepository, labels repository.LabelRepository, reminders repository.ReminderRepository, settings *SettingsService) *CalendarService {
+	return &CalendarService{
+		tx:        tx,
+		tasks:     tasks,
+		projects:  projects,
+		labels:    labels,
+		reminders: reminders,
+		settings:  settings,
+	}
+}
+
+// recurrenceOverride 替代循环任务某次实例的任务，导出为带RECURRENCE-ID的实例
+type recurrenceOverride struct {
+	master       *models.Task
+	originalTime time.Time
+}
+
+// ExportICS 将用户的任务导出为iCalendar，projectID为nil时导出全部项目
+// 有开始和截止时间的任务导出为VEVENT，其余导出为VTODO
+func (s *CalendarService) ExportICS(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) ([]byte, error) {
+	cal, _, err := s.newCalendar(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.tasks.ListTasksWithDetails(ctx, userID, repository.TaskFilter{ProjectID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	overrides := make(map[uuid.UUID]recurrenceOverride)
+	for _, task := range tasks {
+		for _, exception := range task.Exceptions {
+			if exception.NewTaskID != nil && byID[*exception.NewTaskID] != nil {
+				overrides[*exception.NewTaskID] = recurrenceOverride{master: task, originalTime: exception.OriginalTime}
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if _, ok := overrides[task.ID]; ok {
+			continue // 随循环任务一起导出
+		}
+		cal.AddComponent(taskComponent(task, nil, byID, false))
+		for _, exception := range task.Exceptions {
+			if exception.NewTaskID == nil {
+				continue
+			}
+			if override, ok := overrides[*exception.NewTaskID]; ok && override.master == task {
+				cal.AddComponent(taskComponent(byID[*exception.NewTaskID], &override, byID, false))
+			}
+		}
+	}
+	return []byte(cal.String()), nil
+}
+
+// newCalendar 创建VCALENDAR并填写日历名称和用户时区，projectID不为nil时检查项目是否存在
+func (s *CalendarService) newCalendar(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) (*ical.Component, *SettingsResponse, error) {
+	name := icsAllTasksName
+	if projectID != nil {
+		project, err := s.projects.GetProjectByID(ctx, userID, *projectID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("查询项目失败: %w", err)
+		}
+		if project == nil {
+			return nil, nil, ErrProjectNotFound
+		}
+		name = project.Name
+	}
+
+	prefs, err := s.settings.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cal := ical.NewComponent("VCALENDAR")
+	cal.Add("PRODID", icsProductID)
+	cal.Add("VERSION", "2.0")
+	cal.Add("CALSCALE", "GREGORIAN")
+	cal.Add("METHOD", "PUBLISH")
+	cal.AddText("X-WR-CALNAME", name)
+	cal.AddText("X-WR-TIMEZONE", prefs.TimeZone)
+	return cal, prefs, nil
+}
+
+// isTimedTask 同时有开始和截止时间且截止晚于开始的任务视为日程，导出为VEVENT
+func isTimedTask(task *models.Task) bool {
+	return task.StartTime != nil && task.DueTime != nil && task.DueTime.After(*task.StartTime)
+}
+
+// taskComponent 将任务转换为VTODO或VEVENT，override不为nil时生成替代循环任务某次实例的组件
+// todoOnly为true时日程也输出为VTODO，用于只支持VTODO的CalDAV日历
+func taskComponent(task *models.Task, override *recurrenceOverride, byID map[uuid.UUID]*models.Task, todoOnly bool) *ical.Component {
+	master := task
+	if override != nil {
+		master = override.master
+	}
+	timed := !todoOnly && isTimedTask(master)
+
+	kind := "VTODO"
+	if timed {
+		kind = "VEVENT"
+	}
+	c := ical.NewComponent(kind)
+	c.AddText("UID", taskUID(master))
+	c.AddTime("DTSTAMP", task.UpdatedAt)
+	c.AddTime("CREATED", task.CreatedAt)
+	c.AddTime("LAST-MODIFIED", task.UpdatedAt)
+	c.AddText("SUMMARY", task.Title)
+	if task.Description != "" {
+		c.AddText("DESCRIPTION", task.Description)
+	}
+
+	start, due := task.StartTime, task.DueTime
+	if timed {
+		// 替代实例没有自己的时间时沿用原始实例的时间和循环任务的时长
+		if !isTimedTask(task) && override != nil {
+			s := override.originalTime
+			e := s.Add(master.DueTime.Sub(*master.StartTime))
+			start, due = &s, &e
+		}
+		c.AddTime("DTSTART", *start)
+		c.AddTime("DTEND", *due)
+	} else {
+		if start != nil && (due == nil || due.After(*start)) {
+			c.AddTime("DTSTART", *start)
+		}
+		if due != nil {
+			c.AddTime("DUE", *due)
+		}
+		if task.IsCompleted() {
+			c.Add("STATUS", "COMPLETED")
+			c.Add("PERCENT-COMPLETE", "100")
+			if task.CompletedAt != nil {
+				c.AddTime("COMPLETED", *task.CompletedAt)
+			}
+		} else {
+			c.Add("STATUS", "NEEDS-ACTION")
+		}
+	}
+
+	if priority := icalPriority(task.Priority); priority > 0 {
+		c.Add("PRIORITY", fmt.Sprint(priority))
+	}
+	if len(task.Labels) > 0 {
+		names := make([]string, 0, len(task.Labels))
+		for _, label := range task.Labels {
+			names = append(names, label.Name)
+		}
+		c.Add("CATEGORIES", ical.JoinText(names))
+	}
+	if task.ParentID != nil {
+		c.Add("RELATED-TO", task.ParentID.String())
+	}
+
+	if override != nil {
+		c.AddTime("RECURRENCE-ID", override.originalTime)
+	} else if task.IsRecurring() {
+		c.Add("RRULE", rruleValue(task.RRuleString))
+		// 被删除的实例，以及替代任务已不在导出范围内的实例
+		var exdates []string
+		for _, exception := range task.Exceptions {
+			if exception.NewTaskID == nil || byID[*exception.NewTaskID] == nil {
+				exdates = append(exdates, ical.FormatDateTime(exception.OriginalTime))
+			}
+		}
+		if len(exdates) > 0 {
+			c.Add("EXDATE", strings.Join(exdates, ","))
+		}
+	}
+
+	for _, reminder := range task.Reminders {
+		c.AddComponent(reminderAlarm(task, reminder, start, due, timed))
+	}
+	return c
+}
+
+// taskUID 导出时使用的UID，导入的任务保留原日历中的UID，使其他日历应用能识别为同一条目
+func taskUID(task *models.Task) string {
+	if task.ICalUID != "" {
+		return task.ICalUID
+	}
+	return task.ID.String()
+}
+
+// reminderAlarm 将提醒转换为VALARM，任务有时间时使用相对触发时间，使循环任务的每次实例都能提醒
+func reminderAlarm(task *models.Task, reminder models.Reminder, start, due *time.Time, timed bool) *ical.Component {
+	alarm := ical.NewComponent("VALARM")
+	alarm.Add("ACTION", "DISPLAY")
+	alarm.AddText("DESCRIPTION", task.Title)
+	switch {
+	case timed:
+		alarm.Add("TRIGGER", ical.FormatDuration(reminder.RemindAt.Sub(*start)))
+	case due != nil:
+		alarm.Add("TRIGGER", ical.FormatDuration(reminder.RemindAt.Sub(*due)), ical.Param{Name: "RELATED", Value: "END"})
+	case start != nil:
+		alarm.Add("TRIGGER", ical.FormatDuration(reminder.RemindAt.Sub(*start)))
+	default:
+		alarm.AddTime("TRIGGER", reminder.RemindAt, ical.Param{Name: "VALUE", Value: "DATE-TIME"})
+	}
+	return alarm
+}
+
+// icalPriority 将任务优先级（1最高，4为无优先级）映射到iCalendar的1-9（1最高，0表示未定义）
+func icalPriority(priority int) int {
+	switch priority {
+	case 1:
+		return 1
+	case 2:
+		return 5
+	case 3:
+		return 9
+	}
+	return 0
+}
+
+// rruleValue 取出循环规则的值，兼容带 RRULE: 前缀或包含DTSTART行的写法
+func rruleValue(rule string) string {
+	for _, line := range strings.Split(strings.ReplaceAll(rule, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > len("RRULE:") && strings.EqualFold(line[:len("RRULE:")], "RRULE:") {
+			return line[len("RRULE:"):]
+		}
+	}
+	return strings.TrimSpace(rule)
+}
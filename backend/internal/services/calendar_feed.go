@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/ical"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/recurrence"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCalendarFeedNotFound = errors.New("日历订阅不存在")
+	ErrCalendarFeedExists   = errors.New("该范围的日历订阅已存在")
+)
+
+const (
+	// feedRefreshInterval 建议日历应用拉取订阅的间隔
+	feedRefreshInterval = "PT1H"
+	// maxFeedOccurrences 每个循环任务在订阅中最多展开的实例数
+	maxFeedOccurrences = 500
+)
+
+// CalendarFeedService 日历订阅服务，订阅地址中的令牌代替登录凭证，可随时重新生成或删除
+type CalendarFeedService struct {
+	feeds    repository.CalendarFeedRepository
+	projects repository.ProjectRepository
+	calendar *CalendarService
+	config   *config.Config
+}
+
+// NewCalendarFeedService 创建日历订阅服务实例
+func NewCalendarFeedService(feeds repository.CalendarFeedRepository, projects repository.ProjectRepository, calendar *CalendarService, cfg *config.Config) *CalendarFeedService {
+	return &CalendarFeedService{
+		feeds:    feeds,
+		projects: projects,
+		calendar: calendar,
+		config:   cfg,
+	}
+}
+
+// CreateCalendarFeedRequest 创建订阅请求结构，projectId为空时订阅全部项目
+type CreateCalendarFeedRequest struct {
+	ProjectID *uuid.UUID `json:"projectId"`
+}
+
+// CalendarFeedResponse 订阅响应结构
+type CalendarFeedResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	ProjectID *uuid.UUID `json:"projectId"`
+	URL       string     `json:"url"`
+	WebcalURL string     `json:"webcalUrl"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// CalendarFeedContent 订阅内容，ETag由内容计算，内容不变时客户端可以使用缓存
+type CalendarFeedContent struct {
+	Data []byte
+	ETag string
+}
+
+// ListFeeds 分页获取用户的订阅，默认按创建时间排序
+func (s *CalendarFeedService) ListFeeds(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*CalendarFeedResponse], error) {
+	req, err := query.Request(repository.CalendarFeedSortFields, "created")
+	if err != nil {
+		return nil, err
+	}
+
+	feeds, err := s.feeds.ListCalendarFeeds(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询日历订阅失败: %w", err)
+	}
+	feeds, next := pagination.Trim(feeds, req, func(feed *models.CalendarFeed) (interface{}, uuid.UUID) {
+		return repository.CalendarFeedSortValue(feed, req.Sort.Field.Name), feed.ID
+	})
+
+	page := &pagination.Page[*CalendarFeedResponse]{Items: make([]*CalendarFeedResponse, 0, len(feeds)), NextCursor: next}
+	for _, feed := range feeds {
+		page.Items = append(page.Items, s.toResponse(feed))
+	}
+	return page, nil
+}
+
+// CreateFeed 为全部项目或单个项目创建订阅，每个范围只能有一个订阅
+func (s *CalendarFeedService) CreateFeed(ctx context.Context, userID uuid.UUID, req *CreateCalendarFeedRequest) (*CalendarFeedResponse, error) {
+	if req.ProjectID != nil {
+		project, err := s.projects.GetProjectByID(ctx, userID, *req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("查询项目失败: %w", err)
+		}
+		if project == nil {
+			return nil, ErrProjectNotFound
+		}
+	}
+
+	exists, err := s.feeds.CalendarFeedExists(ctx, userID, req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询日历订阅失败: %w", err)
+	}
+	if exists {
+		return nil, ErrCalendarFeedExists
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return nil, fmt.Errorf("生成订阅令牌失败: %w", err)
+	}
+	feed := &models.CalendarFeed{UserID: userID, ProjectID: req.ProjectID, Token: token}
+	if err := s.feeds.CreateCalendarFeed(ctx, feed); err != nil {
+		return nil, fmt.Errorf("创建日历订阅失败: %w", err)
+	}
+	return s.toResponse(feed), nil
+}
+
+// RotateFeed 重新生成订阅令牌，旧的订阅地址立即失效
+func (s *CalendarFeedService) RotateFeed(ctx context.Context, userID, id uuid.UUID) (*CalendarFeedResponse, error) {
+	feed, err := s.getFeed(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed.Token, err = newRandomToken(); err != nil {
+		return nil, fmt.Errorf("生成订阅令牌失败: %w", err)
+	}
+	if err := s.feeds.UpdateCalendarFeed(ctx, feed); err != nil {
+		return nil, fmt.Errorf("更新日历订阅失败: %w", err)
+	}
+	return s.toResponse(feed), nil
+}
+
+// DeleteFeed 删除订阅
+func (s *CalendarFeedService) DeleteFeed(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getFeed(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.feeds.DeleteCalendarFeed(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除日历订阅失败: %w", err)
+	}
+	return nil
+}
+
+// Feed 按令牌生成订阅内容，令牌无效或订阅的项目已删除时返回ErrCalendarFeedNotFound
+// 时间窗口按整天计算，同一天内任务没有变化时内容和ETag保持不变
+func (s *CalendarFeedService) Feed(ctx context.Context, token string) (*CalendarFeedContent, error) {
+	feed, err := s.feeds.GetCalendarFeedByToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("查询日历订阅失败: %w", err)
+	}
+	if feed == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -s.config.Feed.PastDays)
+	to := today.AddDate(0, 0, s.config.Feed.FutureDays+1)
+
+	data, err := s.calendar.FeedICS(ctx, feed.UserID, feed.ProjectID, from, to)
+	if errors.Is(err, ErrProjectNotFound) {
+		return nil, ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &CalendarFeedContent{Data: data, ETag: `"` + hex.EncodeToString(sum[:16]) + `"`}, nil
+}
+
+// getFeed 获取用户的订阅，不存在时返回ErrCalendarFeedNotFound
+func (s *CalendarFeedService) getFeed(ctx context.Context, userID, id uuid.UUID) (*models.CalendarFeed, error) {
+	feed, err := s.feeds.GetCalendarFeedByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询日历订阅失败: %w", err)
+	}
+	if feed == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+	return feed, nil
+}
+
+// toResponse 转换为订阅响应结构，同时给出https和webcal两种地址
+func (s *CalendarFeedService) toResponse(feed *models.CalendarFeed) *CalendarFeedResponse {
+	url := strings.TrimSuffix(s.config.Server.PublicURL, "/") + "/api/v1/feeds/" + feed.Token + ".ics"
+	webcal := url
+	if i := strings.Index(url, "://"); i >= 0 {
+		webcal = "webcal" + url[i:]
+	}
+	return &CalendarFeedResponse{
+		ID:        feed.ID,
+		ProjectID: feed.ProjectID,
+		URL:       url,
+		WebcalURL: webcal,
+		CreatedAt: feed.CreatedAt,
+		UpdatedAt: feed.UpdatedAt,
+	}
+}
+
+// FeedICS 生成订阅用的日历：[from, to)内有时间的任务，循环任务展开为各次实例
+// 日历应用通常不显示VTODO，因此所有任务都输出为VEVENT；被删除或替代的实例不展开，替代任务作为普通任务输出
+func (s *CalendarService) FeedICS(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID, from, to time.Time) ([]byte, error) {
+	cal, prefs, err := s.newCalendar(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cal.Add("REFRESH-INTERVAL", feedRefreshInterval, ical.Param{Name: "VALUE", Value: "DURATION"})
+	cal.Add("X-PUBLISHED-TTL", feedRefreshInterval)
+
+	loc, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	tasks, err := s.tasks.ListTasksWithDetails(ctx, userID, repository.TaskFilter{ProjectID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	for _, task := range tasks {
+		anchor := task.StartTime
+		if anchor == nil {
+			anchor = task.DueTime
+		}
+		if anchor == nil {
+			continue
+		}
+		var duration time.Duration
+		if isTimedTask(task) {
+			duration = task.DueTime.Sub(*task.StartTime)
+		}
+
+		var rule *recurrence.Rule
+		if task.IsRecurring() {
+			rule, _ = recurrence.Parse(task.RRuleString)
+		}
+		if rule == nil {
+			// 无法解析的循环规则按单次任务输出
+			if anchor.Add(duration).Before(from) || !anchor.Before(to) {
+				continue
+			}
+			cal.AddComponent(feedEvent(task, taskUID(task), *anchor, duration))
+			continue
+		}
+
+		skipped := make(map[int64]bool, len(task.Exceptions))
+		for _, exception := range task.Exceptions {
+			skipped[exception.OriginalTime.Unix()] = true
+		}
+		for _, at := range rule.Between(anchor.In(loc), from.Add(-duration), to, maxFeedOccurrences) {
+			if skipped[at.Unix()] {
+				continue
+			}
+			uid := taskUID(task) + "-" + ical.FormatDateTime(at)
+			cal.AddComponent(feedEvent(task, uid, at, duration))
+		}
+	}
+	return []byte(cal.String()), nil
+}
+
+// feedEvent 将任务或循环任务的一次实例转换为VEVENT，只有截止时间的任务输出为没有时长的日程
+// 提醒按相对原任务开始（或截止）时间的偏移输出，使每次实例都能提醒
+func feedEvent(task *models.Task, uid string, start time.Time, duration time.Duration) *ical.Component {
+	c := ical.NewComponent("VEVENT")
+	c.AddText("UID", uid)
+	c.AddTime("DTSTAMP", task.UpdatedAt)
+	c.AddTime("LAST-MODIFIED", task.UpdatedAt)
+	c.AddText("SUMMARY", task.Title)
+	if task.Description != "" {
+		c.AddText("DESCRIPTION", task.Description)
+	}
+	c.AddTime("DTSTART", start)
+	if duration > 0 {
+		c.AddTime("DTEND", start.Add(duration))
+	}
+	c.Add("TRANSP", "TRANSPARENT")
+	if len(task.Labels) > 0 {
+		names := make([]string, 0, len(task.Labels))
+		for _, label := range task.Labels {
+			names = append(names, label.Name)
+		}
+		c.Add("CATEGORIES", ical.JoinText(names))
+	}
+
+	anchor := task.StartTime
+	if anchor == nil {
+		anchor = task.DueTime
+	}
+	for _, reminder := range task.Reminders {
+		alarm := ical.NewComponent("VALARM")
+		alarm.Add("ACTION", "DISPLAY")
+		alarm.AddText("DESCRIPTION", task.Title)
+		alarm.Add("TRIGGER", ical.FormatDuration(reminder.RemindAt.Sub(*anchor)))
+		c.AddComponent(alarm)
+	}
+	return c
+}
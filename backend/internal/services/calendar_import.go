@@ -0,0 +1,517 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/ical"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidICS 上传的内容不是有效的iCalendar文件
+var ErrInvalidICS = errors.New("iCalendar文件无效")
+
+// 导入结果中单个条目的状态
+const (
+	ICSItemCreated = "created"
+	ICSItemSkipped = "skipped"
+	ICSItemFailed  = "failed"
+)
+
+// ICSImportItem 单个VTODO或VEVENT的导入结果
+type ICSImportItem struct {
+	UID          string     `json:"uid"`
+	RecurrenceID *time.Time `json:"recurrenceId,omitempty"`
+	Summary      string     `json:"summary"`
+	Status       string     `json:"status"`
+	TaskID       *uuid.UUID `json:"taskId,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Warnings     []string   `json:"warnings,omitempty"`
+}
+
+// ICSImportResult 导入报告，条目按文件中的顺序排列，替代实例排在所有循环任务之后
+type ICSImportResult struct {
+	Imported int              `json:"imported"`
+	Skipped  int              `json:"skipped"`
+	Failed   int              `json:"failed"`
+	Items    []*ICSImportItem `json:"items"`
+}
+
+// icsEntry 从VTODO或VEVENT解析出的待写入数据
+type icsEntry struct {
+	task         *models.Task
+	categories   []string
+	reminders    []time.Time
+	exdates      []time.Time
+	recurrenceID *time.Time
+}
+
+// icsImport 一次导入过程的状态
+type icsImport struct {
+	userID    uuid.UUID
+	projectID uuid.UUID
+	zones     *ical.TimeZones
+	labels    map[string]uuid.UUID
+	masters   map[string]*ICSImportItem // UID对应的循环任务（或普通任务）导入结果
+	result    *ICSImportResult
+}
+
+// ImportICS 将iCalendar中的VTODO和VEVENT导入到项目中，每个条目在单独的事务中写入
+// 已导入过的UID会被跳过；带RECURRENCE-ID的替代实例在主任务之后处理，转换为循环例外
+func (s *CalendarService) ImportICS(ctx context.Context, userID, projectID uuid.UUID, data []byte) (*ICSImportResult, error) {
+	project, err := s.projects.GetProjectByID(ctx, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	cal, err := ical.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidICS, err)
+	}
+	imp, err := s.newImport(ctx, userID, projectID, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []*ical.Component
+	for _, c := range cal.Components {
+		if c.Name != "VTODO" && c.Name != "VEVENT" {
+			continue
+		}
+		if c.Get("RECURRENCE-ID") != nil {
+			overrides = append(overrides, c)
+			continue
+		}
+		s.importMaster(ctx, imp, c)
+	}
+	for _, c := range overrides {
+		s.importOverride(ctx, imp, c)
+	}
+
+	if len(imp.result.Items) == 0 {
+		return nil, fmt.Errorf("%w: 没有可导入的任务或日程", ErrInvalidICS)
+	}
+	return imp.result, nil
+}
+
+// newImport 创建导入状态，未指定时区的时间按用户设置的时区解析
+func (s *CalendarService) newImport(ctx context.Context, userID, projectID uuid.UUID, cal *ical.Component) (*icsImport, error) {
+	prefs, err := s.settings.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	labels, err := labelsByName(ctx, s.labels, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &icsImport{
+		userID:    userID,
+		projectID: projectID,
+		zones:     ical.NewTimeZones(cal, loc),
+		labels:    labels,
+		masters:   make(map[string]*ICSImportItem),
+		result:    &ICSImportResult{Items: make([]*ICSImportItem, 0)},
+	}, nil
+}
+
+// importMaster 导入普通任务或循环任务本身
+func (s *CalendarService) importMaster(ctx context.Context, imp *icsImport, c *ical.Component) {
+	item := imp.newItem(c)
+	if item.UID == "" {
+		imp.fail(item, "缺少UID")
+		return
+	}
+	if _, ok := imp.masters[item.UID]; ok {
+		imp.fail(item, "UID在文件中重复出现")
+		return
+	}
+	imp.masters[item.UID] = item
+
+	existing, err := s.tasks.GetTaskByICalUID(ctx, imp.userID, item.UID)
+	if err != nil {
+		imp.fail(item, "查询已导入的任务失败")
+		return
+	}
+	if existing != nil {
+		item.Status, item.TaskID = ICSItemSkipped, &existing.ID
+		imp.result.Skipped++
+		return
+	}
+
+	entry, err := imp.entry(c, item)
+	if err != nil {
+		imp.fail(item, err.Error())
+		return
+	}
+	entry.task.ICalUID = item.UID
+	if err := s.writeEntry(ctx, imp, entry, nil); err != nil {
+		imp.fail(item, err.Error())
+		return
+	}
+	imp.created(item, entry.task.ID)
+}
+
+// importOverride 导入替代循环任务某次实例的组件：创建新任务并记录指向它的循环例外
+func (s *CalendarService) importOverride(ctx context.Context, imp *icsImport, c *ical.Component) {
+	item := imp.newItem(c)
+	master, ok := imp.masters[item.UID]
+	switch {
+	case item.UID == "":
+		imp.fail(item, "缺少UID")
+		return
+	case !ok:
+		imp.fail(item, "找不到RECURRENCE-ID对应的循环任务")
+		return
+	case master.Status == ICSItemSkipped:
+		// 循环任务已导入过，其替代实例也随之跳过
+		item.Status = ICSItemSkipped
+		imp.result.Skipped++
+		return
+	case master.Status == ICSItemFailed:
+		imp.fail(item, "对应的循环任务导入失败")
+		return
+	}
+
+	entry, err := imp.entry(c, item)
+	if err != nil {
+		imp.fail(item, err.Error())
+		return
+	}
+	if err := s.writeEntry(ctx, imp, entry, master.TaskID); err != nil {
+		imp.fail(item, err.Error())
+		return
+	}
+	imp.created(item, entry.task.ID)
+}
+
+// writeEntry 在一个事务中写入任务、标签、提醒和循环例外，masterID不为nil时任务作为该循环任务的替代实例
+// 新建的标签在事务提交后才加入缓存，避免引用被回滚的标签
+func (s *CalendarService) writeEntry(ctx context.Context, imp *icsImport, entry *icsEntry, masterID *uuid.UUID) error {
+	task := entry.task
+	created := make(map[string]uuid.UUID)
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		key, err := appendTaskSortOrder(ctx, s.tasks, scopeOf(imp.userID, imp.projectID, nil), uuid.Nil)
+		if err != nil {
+			return err
+		}
+		task.SortOrder = key
+		if err := s.tasks.CreateTask(ctx, task); err != nil {
+			return fmt.Errorf("创建任务失败: %w", err)
+		}
+
+		labelIDs, err := s.entryLabelIDs(ctx, imp, entry, created)
+		if err != nil {
+			return err
+		}
+		if len(labelIDs) > 0 {
+			if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+				return fmt.Errorf("设置任务标签失败: %w", err)
+			}
+		}
+
+		for _, remindAt := range entry.reminders {
+			if err := s.reminders.CreateReminder(ctx, &models.Reminder{TaskID: task.ID, RemindAt: remindAt}); err != nil {
+				return fmt.Errorf("创建提醒失败: %w", err)
+			}
+		}
+
+		for _, exdate := range entry.exdates {
+			exception := &models.TaskRecurrenceException{RecurringTaskID: task.ID, OriginalTime: exdate}
+			if err := s.tasks.CreateRecurrenceException(ctx, exception); err != nil {
+				return fmt.Errorf("创建循环例外失败: %w", err)
+			}
+		}
+		if masterID != nil {
+			exception := &models.TaskRecurrenceException{RecurringTaskID: *masterID, OriginalTime: *entry.recurrenceID, NewTaskID: &task.ID}
+			if err := s.tasks.CreateRecurrenceException(ctx, exception); err != nil {
+				return fmt.Errorf("创建循环例外失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name, id := range created {
+		imp.labels[name] = id
+	}
+	return nil
+}
+
+// entryLabelIDs 按名称查找条目的标签，不存在的标签在当前事务中创建并记录到created
+func (s *CalendarService) entryLabelIDs(ctx context.Context, imp *icsImport, entry *icsEntry, created map[string]uuid.UUID) ([]uuid.UUID, error) {
+	labelIDs := make([]uuid.UUID, 0, len(entry.categories))
+	for _, name := range entry.categories {
+		id, ok := imp.labels[name]
+		if !ok {
+			id, ok = created[name]
+		}
+		if !ok {
+			label := &models.Label{UserID: imp.userID, Name: name}
+			if err := s.labels.CreateLabel(ctx, label); err != nil {
+				return nil, fmt.Errorf("创建标签失败: %w", err)
+			}
+			id = label.ID
+			created[name] = id
+		}
+		labelIDs = append(labelIDs, id)
+	}
+	return labelIDs, nil
+}
+
+// labelsByName 获取用户全部标签，按名称索引
+func labelsByName(ctx context.Context, repo repository.LabelRepository, userID uuid.UUID) (map[string]uuid.UUID, error) {
+	req, err := (&pagination.Query{}).Request(repository.LabelSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	labels, err := repo.ListLabels(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	byName := make(map[string]uuid.UUID, len(labels))
+	for _, label := range labels {
+		byName[label.Name] = label.ID
+	}
+	return byName, nil
+}
+
+// newItem 创建条目的导入结果并加入报告
+func (imp *icsImport) newItem(c *ical.Component) *ICSImportItem {
+	item := &ICSImportItem{}
+	if uid := c.Get("UID"); uid != nil {
+		item.UID = strings.TrimSpace(uid.Text())
+	}
+	if summary := c.Get("SUMMARY"); summary != nil {
+		item.Summary = strings.TrimSpace(summary.Text())
+	}
+	if rid := c.Get("RECURRENCE-ID"); rid != nil {
+		if t, _, err := imp.zones.Time(rid); err == nil {
+			item.RecurrenceID = utcPtr(&t)
+		}
+	}
+	imp.result.Items = append(imp.result.Items, item)
+	return item
+}
+
+func (imp *icsImport) fail(item *ICSImportItem, message string) {
+	item.Status, item.Error = ICSItemFailed, message
+	imp.result.Failed++
+}
+
+func (imp *icsImport) created(item *ICSImportItem, taskID uuid.UUID) {
+	item.Status, item.TaskID = ICSItemCreated, &taskID
+	imp.result.Imported++
+}
+
+// warn 记录不影响导入的问题，如无法换算的提醒
+func (item *ICSImportItem) warn(format string, args ...any) {
+	item.Warnings = append(item.Warnings, fmt.Sprintf(format, args...))
+}
+
+// entry 将VTODO或VEVENT转换为任务数据
+// VTODO的DTSTART和DUE对应开始和截止时间；VEVENT的DTSTART和DTEND对应开始和截止时间，全天日程没有结束时间时持续一天
+func (imp *icsImport) entry(c *ical.Component, item *ICSImportItem) (*icsEntry, error) {
+	if item.Summary == "" {
+		return nil, errors.New("缺少SUMMARY")
+	}
+	task := &models.Task{
+		UserID:    imp.userID,
+		ProjectID: imp.projectID,
+		Title:     truncateRunes(item.Summary, 255),
+		Status:    models.TaskStatusIncomplete,
+		Priority:  taskPriorityFromICal(c.Get("PRIORITY")),
+	}
+	if description := c.Get("DESCRIPTION"); description != nil {
+		task.Description = description.Text()
+	}
+	entry := &icsEntry{task: task}
+
+	start, allDay, err := imp.optionalTime(c.Get("DTSTART"))
+	if err != nil {
+		return nil, err
+	}
+	endName := "DUE"
+	if c.Name == "VEVENT" {
+		endName = "DTEND"
+		if start == nil {
+			return nil, errors.New("缺少DTSTART")
+		}
+	}
+	end, _, err := imp.optionalTime(c.Get(endName))
+	if err != nil {
+		return nil, err
+	}
+	if duration := c.Get("DURATION"); end == nil && duration != nil && start != nil {
+		d, err := ical.ParseDuration(duration.Value)
+		if err != nil {
+			return nil, fmt.Errorf("DURATION: %v", err)
+		}
+		e := start.Add(d)
+		end = &e
+	}
+	if end == nil && c.Name == "VEVENT" && allDay {
+		e := start.AddDate(0, 0, 1)
+		end = &e
+	}
+	if start != nil && end != nil && end.Before(*start) {
+		return nil, fmt.Errorf("%s早于DTSTART", endName)
+	}
+	task.StartTime, task.DueTime = utcPtr(start), utcPtr(end)
+
+	status := c.Get("STATUS")
+	completed := c.Get("COMPLETED")
+	if (status != nil && strings.EqualFold(status.Value, "COMPLETED")) || completed != nil {
+		task.MarkCompleted()
+		if completed != nil {
+			if at, _, err := imp.zones.Time(completed); err == nil {
+				task.CompletedAt = utcPtr(&at)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, prop := range c.GetAll("CATEGORIES") {
+		for _, name := range prop.TextList() {
+			name = truncateRunes(strings.TrimSpace(name), 100)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				entry.categories = append(entry.categories, name)
+			}
+		}
+	}
+
+	if rid := c.Get("RECURRENCE-ID"); rid != nil {
+		t, _, err := imp.zones.Time(rid)
+		if err != nil {
+			return nil, err
+		}
+		entry.recurrenceID = utcPtr(&t)
+	} else if rrule := c.Get("RRULE"); rrule != nil {
+		task.RRuleString = "RRULE:" + strings.TrimSpace(rrule.Value)
+		for _, prop := range c.GetAll("EXDATE") {
+			times, err := imp.zones.TimeList(prop)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range times {
+				entry.exdates = append(entry.exdates, t.UTC())
+			}
+		}
+	}
+
+	entry.reminders = imp.alarms(c, item, start, end)
+	return entry, nil
+}
+
+// alarms 将VALARM转换为提醒时间，默认相对于开始时间，RELATED=END时相对于截止时间
+func (imp *icsImport) alarms(c *ical.Component, item *ICSImportItem, start, end *time.Time) []time.Time {
+	var reminders []time.Time
+	seen := make(map[time.Time]bool)
+	for i, alarm := range c.Children("VALARM") {
+		trigger := alarm.Get("TRIGGER")
+		if trigger == nil {
+			item.warn("第%d个提醒缺少TRIGGER，已忽略", i+1)
+			continue
+		}
+
+		var at time.Time
+		if strings.EqualFold(trigger.Param("VALUE"), "DATE-TIME") {
+			t, _, err := imp.zones.Time(trigger)
+			if err != nil {
+				item.warn("第%d个提醒的时间无效，已忽略", i+1)
+				continue
+			}
+			at = t
+		} else {
+			offset, err := ical.ParseDuration(trigger.Value)
+			if err != nil {
+				item.warn("第%d个提醒的时间无效，已忽略", i+1)
+				continue
+			}
+			base := start
+			if strings.EqualFold(trigger.Param("RELATED"), "END") {
+				base = end
+			}
+			if base == nil {
+				item.warn("第%d个提醒缺少参照时间，已忽略", i+1)
+				continue
+			}
+			at = base.Add(offset)
+		}
+
+		at = at.UTC()
+		if !seen[at] {
+			seen[at] = true
+			reminders = append(reminders, at)
+		}
+	}
+	return reminders
+}
+
+// optionalTime 解析可选的时间属性，属性不存在时返回nil
+func (imp *icsImport) optionalTime(prop *ical.Property) (*time.Time, bool, error) {
+	if prop == nil {
+		return nil, false, nil
+	}
+	t, allDay, err := imp.zones.Time(prop)
+	if err != nil {
+		return nil, false, err
+	}
+	return &t, allDay, nil
+}
+
+// taskPriorityFromICal 将iCalendar的PRIORITY（1-9，1最高，0未定义）映射到任务优先级
+func taskPriorityFromICal(prop *ical.Property) int {
+	if prop == nil {
+		return DefaultTaskPriority
+	}
+	var priority int
+	if _, err := fmt.Sscan(prop.Value, &priority); err != nil {
+		return DefaultTaskPriority
+	}
+	switch {
+	case priority >= 1 && priority <= 4:
+		return 1
+	case priority == 5:
+		return 2
+	case priority >= 6 && priority <= 9:
+		return 3
+	}
+	return DefaultTaskPriority
+}
+
+// utcPtr 返回UTC表示的时间副本
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 设备类型
+const (
+	DeviceTypeDesktop = "desktop"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeBot     = "bot"
+	DeviceTypeUnknown = "unknown"
+)
+
+// MaxDeviceNameLength 设备名称最大长度
+const MaxDeviceNameLength = 100
+
+// DeviceInfo 设备信息
+type DeviceInfo struct {
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browserVersion,omitempty"`
+	OS             string `json:"os"`
+	OSVersion      string `json:"osVersion,omitempty"`
+	DeviceType     string `json:"deviceType"`
+	IP             string `json:"ip"`
+	Name           string `json:"name,omitempty"` // 客户端提供或用户重命名的设备名称
+	UserAgent      string `json:"userAgent,omitempty"`
+}
+
+// String 返回便于展示和记录日志的设备描述
+func (d DeviceInfo) String() string {
+	if d.Name != "" {
+		return fmt.Sprintf("%s (%s)", d.Name, d.IP)
+	}
+
+	browser := d.Browser
+	if d.BrowserVersion != "" {
+		browser += " " + d.BrowserVersion
+	}
+	os := d.OS
+	if d.OSVersion != "" {
+		os += " " + d.OSVersion
+	}
+	return fmt.Sprintf("%s on %s (%s)", browser, os, d.IP)
+}
+
+// UnmarshalJSON 兼容旧版本以字符串形式存储的设备信息
+func (d *DeviceInfo) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*d = DeviceInfo{
+			Browser:    "Unknown",
+			OS:         "Unknown",
+			DeviceType: DeviceTypeUnknown,
+			Name:       legacy,
+		}
+		return nil
+	}
+
+	type alias DeviceInfo
+	var info alias
+	if err := json.Unmarshal(data, &info); err != nil {
+		return err
+	}
+	*d = DeviceInfo(info)
+	return nil
+}
+
+// browserRule 浏览器识别规则，按顺序匹配
+type browserRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// 基于Chromium的浏览器都会带上Chrome和Safari标识，因此需要先匹配更具体的标识
+var browserRules = []browserRule{
+	{"Edge", regexp.MustCompile(`(?:Edg|EdgA|EdgiOS|Edge)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|OPiOS|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"WeChat", regexp.MustCompile(`MicroMessenger/([\d.]+)`)},
+	{"UC Browser", regexp.MustCompile(`UCBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+var (
+	// botPattern 爬虫以"名称/版本"的形式标识自己（如Googlebot/2.1、DuckDuckBot-Https/1.1），只匹配完整的单词，
+	// 避免把名称中恰好包含这些字母的设备（如CUBOT手机）误判为爬虫
+	botPattern     = regexp.MustCompile(`(?i)\b(?:[\w-]*(?:bot|crawler|spider)[\w-]*/\d|bot|crawler|spider|slurp|facebookexternalhit|curl|wget|python-requests|go-http-client|postmanruntime)\b`)
+	windowsPattern = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	androidPattern = regexp.MustCompile(`Android ([\d.]+)`)
+	iosPattern     = regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)
+	macPattern     = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+)
+
+// windowsVersions Windows NT内核版本与发行版本的对应关系
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+// ParseUserAgent 解析User-Agent得到设备信息
+func ParseUserAgent(userAgent string) DeviceInfo {
+	info := DeviceInfo{
+		Browser:    "Unknown",
+		OS:         "Unknown",
+		DeviceType: DeviceTypeUnknown,
+		UserAgent:  userAgent,
+	}
+	if userAgent == "" {
+		return info
+	}
+
+	// 浏览器
+	for _, rule := range browserRules {
+		if m := rule.pattern.FindStringSubmatch(userAgent); m != nil {
+			info.Browser = rule.name
+			info.BrowserVersion = majorVersion(m[1])
+			break
+		}
+	}
+
+	// 操作系统，iOS上的UA同样包含"like Mac OS X"，需要先判断
+	switch {
+	case strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "iPod"):
+		info.OS = "iOS"
+		if m := iosPattern.FindStringSubmatch(userAgent); m != nil {
+			info.OSVersion = strings.ReplaceAll(m[1], "_", ".")
+		}
+	case strings.Contains(userAgent, "Android"):
+		info.OS = "Android"
+		if m := androidPattern.FindStringSubmatch(userAgent); m != nil {
+			info.OSVersion = m[1]
+		}
+	case strings.Contains(userAgent, "Windows"):
+		info.OS = "Windows"
+		if m := windowsPattern.FindStringSubmatch(userAgent); m != nil {
+			if version, ok := windowsVersions[m[1]]; ok {
+				info.OSVersion = version
+			}
+		}
+	case strings.Contains(userAgent, "CrOS"):
+		info.OS = "ChromeOS"
+	case strings.Contains(userAgent, "Macintosh") || strings.Contains(userAgent, "Mac OS X"):
+		info.OS = "macOS"
+		if m := macPattern.FindStringSubmatch(userAgent); m != nil {
+			info.OSVersion = strings.ReplaceAll(m[1], "_", ".")
+		}
+	case strings.Contains(userAgent, "Linux"):
+		info.OS = "Linux"
+	}
+
+	// 设备类型
+	switch {
+	case botPattern.MatchString(userAgent):
+		info.DeviceType = DeviceTypeBot
+	case strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "Tablet"):
+		info.DeviceType = DeviceTypeTablet
+	case info.OS == "Android" && !strings.Contains(userAgent, "Mobile"):
+		// Android平板的UA不包含Mobile标识
+		info.DeviceType = DeviceTypeTablet
+	case strings.Contains(userAgent, "Mobile") || strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPod"):
+		info.DeviceType = DeviceTypeMobile
+	case info.OS == "Windows" || info.OS == "macOS" || info.OS == "Linux" || info.OS == "ChromeOS":
+		info.DeviceType = DeviceTypeDesktop
+	}
+
+	return info
+}
+
+// NormalizeDeviceName 清理设备名称，去除首尾空白并限制长度
+func NormalizeDeviceName(name string) string {
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > MaxDeviceNameLength {
+		name = string(runes[:MaxDeviceNameLength])
+	}
+	return name
+}
+
+// majorVersion 只保留主版本号
+func majorVersion(version string) string {
+	if idx := strings.Index(version, "."); idx > 0 {
+		return version[:idx]
+	}
+	return version
+}
@@ -0,0 +1,121 @@
+package services
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name           string
+		userAgent      string
+		browser        string
+		browserVersion string
+		os             string
+		osVersion      string
+		deviceType     string
+	}{
+		{
+			name:      "Edge不识别为Chrome",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			browser:   "Edge", browserVersion: "120", os: "Windows", osVersion: "10", deviceType: DeviceTypeDesktop,
+		},
+		{
+			name:      "Android上的Edge",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 EdgA/120.0.2210.115",
+			browser:   "Edge", browserVersion: "120", os: "Android", osVersion: "14", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "Windows上的Chrome",
+			userAgent: "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
+			browser:   "Chrome", browserVersion: "109", os: "Windows", osVersion: "7", deviceType: DeviceTypeDesktop,
+		},
+		{
+			name:      "ChromeOS上的Chrome",
+			userAgent: "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			browser:   "Chrome", browserVersion: "120", os: "ChromeOS", deviceType: DeviceTypeDesktop,
+		},
+		{
+			name:      "macOS上的Safari",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15",
+			browser:   "Safari", browserVersion: "17", os: "macOS", osVersion: "10.15.7", deviceType: DeviceTypeDesktop,
+		},
+		{
+			name:      "Linux上的Firefox",
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			browser:   "Firefox", browserVersion: "121", os: "Linux", deviceType: DeviceTypeDesktop,
+		},
+		{
+			name:      "Android手机不识别为Linux",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			browser:   "Chrome", browserVersion: "120", os: "Android", osVersion: "13", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "Android平板",
+			userAgent: "Mozilla/5.0 (Linux; Android 12; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			browser:   "Chrome", browserVersion: "120", os: "Android", osVersion: "12", deviceType: DeviceTypeTablet,
+		},
+		{
+			name:      "Samsung Internet",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-A536B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			browser:   "Samsung Internet", browserVersion: "23", os: "Android", osVersion: "13", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "名称包含bot字母的手机不是爬虫",
+			userAgent: "Mozilla/5.0 (Linux; Android 10; CUBOT P40) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36",
+			browser:   "Chrome", browserVersion: "119", os: "Android", osVersion: "10", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "iPhone上的Safari",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			browser:   "Safari", browserVersion: "17", os: "iOS", osVersion: "17.2", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "iPhone上的Chrome",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			browser:   "Chrome", browserVersion: "120", os: "iOS", osVersion: "16.6", deviceType: DeviceTypeMobile,
+		},
+		{
+			name:      "iPad上的Firefox",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/121.0 Mobile/15E148 Safari/605.1.15",
+			browser:   "Firefox", browserVersion: "121", os: "iOS", osVersion: "17.1", deviceType: DeviceTypeTablet,
+		},
+		{
+			name:      "Googlebot",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			browser:   "Unknown", os: "Unknown", deviceType: DeviceTypeBot,
+		},
+		{
+			name:      "移动版Googlebot",
+			userAgent: "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.129 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			browser:   "Chrome", browserVersion: "120", os: "Android", osVersion: "6.0.1", deviceType: DeviceTypeBot,
+		},
+		{
+			name:      "DuckDuckBot",
+			userAgent: "DuckDuckBot-Https/1.1; (+https://duckduckgo.com/duckduckbot)",
+			browser:   "Unknown", os: "Unknown", deviceType: DeviceTypeBot,
+		},
+		{
+			name:      "curl",
+			userAgent: "curl/8.4.0",
+			browser:   "Unknown", os: "Unknown", deviceType: DeviceTypeBot,
+		},
+		{
+			name:      "空User-Agent",
+			userAgent: "",
+			browser:   "Unknown", os: "Unknown", deviceType: DeviceTypeUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseUserAgent(tt.userAgent)
+			if info.Browser != tt.browser || info.BrowserVersion != tt.browserVersion {
+				t.Errorf("浏览器 = %q %q, 期望 %q %q", info.Browser, info.BrowserVersion, tt.browser, tt.browserVersion)
+			}
+			if info.OS != tt.os || info.OSVersion != tt.osVersion {
+				t.Errorf("操作系统 = %q %q, 期望 %q %q", info.OS, info.OSVersion, tt.os, tt.osVersion)
+			}
+			if info.DeviceType != tt.deviceType {
+				t.Errorf("设备类型 = %q, 期望 %q", info.DeviceType, tt.deviceType)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ticktick-backend/config"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// eventStreamSeqPrefix 用户事件序号的键前缀，序号从1开始递增，不设过期时间
+	eventStreamSeqPrefix = "events:seq:"
+	// eventStreamBufferPrefix 用户最近事件的补发缓冲的键前缀
+	eventStreamBufferPrefix = "events:buffer:"
+	// eventStreamChannelPrefix 用户事件的发布频道前缀
+	eventStreamChannelPrefix = "events:user:"
+	// eventStreamSubscriberBuffer 每个连接待发送事件的缓冲数，写满说明客户端太慢，断开后由客户端重连补发
+	eventStreamSubscriberBuffer = 64
+	// defaultStreamHeartbeat 未配置心跳间隔时使用的默认值
+	defaultStreamHeartbeat = 25 * time.Second
+)
+
+// eventStreamPublishScript 分配序号、写入补发缓冲并发布，三步在同一脚本中完成以保证顺序一致
+const eventStreamPublishScript = `
+local seq = redis.call('INCR', KEYS[1])
+local message = '{"seq":' .. seq .. ',"event":' .. ARGV[1] .. '}'
+redis.call('RPUSH', KEYS[2], message)
+redis.call('LTRIM', KEYS[2], -tonumber(ARGV[2]), -1)
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+redis.call('PUBLISH', ARGV[4], message)
+return seq
+`
+
+// eventStreamReplayScript 同时读取当前序号和补发缓冲
+const eventStreamReplayScript = `
+return {redis.call('GET', KEYS[1]) or '0', redis.call('LRANGE', KEYS[2], 0, -1)}
+`
+
+// StreamMessage 变更流中的一条消息，Seq是用户内递增的序号，作为SSE的事件ID
+type StreamMessage struct {
+	Seq   int64           `json:"seq"`
+	Event json.RawMessage `json:"event"`
+}
+
+// StreamReplay 断线重连时需要补发的消息
+// Gap为true表示缺失的事件已超出补发缓冲，客户端需要重新拉取全部数据
+type StreamReplay struct {
+	Seq      int64 // 当前最新的序号
+	Messages []*StreamMessage
+	Gap      bool
+}
+
+// StreamSubscription 一个变更流连接的订阅，C在连接过慢或变更流停止时关闭
+type StreamSubscription struct {
+	C      <-chan *StreamMessage
+	ch     chan *StreamMessage
+	userID uuid.UUID
+	stream *EventStream
+}
+
+// Close 取消订阅
+func (sub *StreamSubscription) Close() {
+	sub.stream.remove(sub)
+}
+
+// EventStream 实时变更流，把事件总线上的事件推送给用户的所有连接
+// 事件经Redis发布订阅分发，每个实例只订阅一次频道再分发给本实例的连接，因此可以水平扩展；
+// 每个用户最近的事件保存在Redis列表中，客户端断线重连时按Last-Event-ID补发
+type EventStream struct {
+	redis       *RedisService
+	config      config.EventStreamConfig
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*StreamSubscription]struct{}
+	pubsub      *redis.PubSub
+	isRunning   bool
+}
+
+// NewEventStream 创建实时变更流
+func NewEventStream(redis *RedisService, cfg *config.Config) *EventStream {
+	return &EventStream{
+		redis:       redis,
+		config:      cfg.Stream,
+		subscribers: make(map[uuid.UUID]map[*StreamSubscription]struct{}),
+	}
+}
+
+// HeartbeatInterval 连接的心跳间隔，未配置时使用默认值
+func (s *EventStream) HeartbeatInterval() time.Duration {
+	if s.config.HeartbeatInterval <= 0 {
+		return defaultStreamHeartbeat
+	}
+	return s.config.HeartbeatInterval
+}
+
+// Start 订阅Redis频道并开始分发事件
+func (s *EventStream) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return
+	}
+
+	pubsub := s.redis.PSubscribe(context.Background(), eventStreamChannelPrefix+"*")
+	// 等待订阅确认，之后发布的事件不会丢失
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		log.Printf("订阅实时变更频道失败: %v", err)
+		pubsub.Close()
+		return
+	}
+
+	s.pubsub = pubsub
+	s.isRunning = true
+	log.Println("实时变更流启动")
+	go s.run(pubsub.Channel())
+}
+
+// Stop 停止分发并断开所有连接
+func (s *EventStream) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return
+	}
+
+	s.isRunning = false
+	s.pubsub.Close()
+	for userID, subs := range s.subscribers {
+		for sub := range subs {
+			close(sub.ch)
+		}
+		delete(s.subscribers, userID)
+	}
+	log.Println("实时变更流停止")
+}
+
+// run 把频道中的消息分发给对应用户的连接
+func (s *EventStream) run(messages <-chan *redis.Message) {
+	for msg := range messages {
+		userID, err := uuid.Parse(strings.TrimPrefix(msg.Channel, eventStreamChannelPrefix))
+		if err != nil {
+			continue
+		}
+		var message StreamMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			log.Printf("解析实时变更消息失败: %v", err)
+			continue
+		}
+		s.dispatch(userID, &message)
+	}
+}
+
+// dispatch 发送给用户的每个连接，缓冲已满的连接直接断开
+func (s *EventStream) dispatch(userID uuid.UUID, message *StreamMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers[userID] {
+		select {
+		case sub.ch <- message:
+		default:
+			close(sub.ch)
+			delete(s.subscribers[userID], sub)
+		}
+	}
+	if len(s.subscribers[userID]) == 0 {
+		delete(s.subscribers, userID)
+	}
+}
+
+// Subscribe 订阅用户的事件，应在补发之前订阅，重叠的事件按序号去重
+func (s *EventStream) Subscribe(userID uuid.UUID) *StreamSubscription {
+	ch := make(chan *StreamMessage, eventStreamSubscriberBuffer)
+	sub := &StreamSubscription{C: ch, ch: ch, userID: userID, stream: s}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isRunning {
+		close(ch)
+		return sub
+	}
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[*StreamSubscription]struct{})
+	}
+	s.subscribers[userID][sub] = struct{}{}
+	return sub
+}
+
+// remove 移除订阅，已因过慢被移除的订阅不重复关闭
+func (s *EventStream) remove(sub *StreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.subscribers[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.ch)
+	if len(subs) == 0 {
+		delete(s.subscribers, sub.userID)
+	}
+}
+
+// HandleEvent 为事件分配序号并发布，注册到事件总线上使用
+func (s *EventStream) HandleEvent(ctx context.Context, event *Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("序列化事件失败: %v", err)
+		return
+	}
+
+	userID := event.UserID.String()
+	_, err = s.redis.Eval(eventStreamPublishScript,
+		[]string{eventStreamSeqPrefix + userID, eventStreamBufferPrefix + userID},
+		string(data), s.config.ReplaySize, s.config.ReplayTTL.Milliseconds(), eventStreamChannelPrefix+userID)
+	if err != nil {
+		log.Printf("发布实时变更事件失败: %v", err)
+	}
+}
+
+// Replay 获取序号after之后的事件，after小于0表示不需要补发，只返回当前序号
+func (s *EventStream) Replay(ctx context.Context, userID uuid.UUID, after int64) (*StreamReplay, error) {
+	result, err := s.redis.Eval(eventStreamReplayScript,
+		[]string{eventStreamSeqPrefix + userID.String(), eventStreamBufferPrefix + userID.String()})
+	if err != nil {
+		return nil, fmt.Errorf("读取补发缓冲失败: %w", err)
+	}
+	values, _ := result.([]interface{})
+	if len(values) != 2 {
+		return nil, fmt.Errorf("读取补发缓冲失败: 返回值格式错误")
+	}
+	seq, err := strconv.ParseInt(fmt.Sprint(values[0]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("读取事件序号失败: %w", err)
+	}
+
+	replay := &StreamReplay{Seq: seq}
+	if after < 0 || after == seq {
+		return replay, nil
+	}
+	if after > seq {
+		// 客户端的序号比服务端新，说明序号被重置过
+		replay.Gap = true
+		return replay, nil
+	}
+
+	entries, _ := values[1].([]interface{})
+	for _, entry := range entries {
+		var message StreamMessage
+		if err := json.Unmarshal([]byte(fmt.Sprint(entry)), &message); err != nil {
+			return nil, fmt.Errorf("解析补发缓冲失败: %w", err)
+		}
+		if message.Seq > after {
+			replay.Messages = append(replay.Messages, &message)
+		}
+	}
+	if len(replay.Messages) == 0 || replay.Messages[0].Seq != after+1 {
+		replay.Gap = true
+		replay.Messages = nil
+	}
+	return replay, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 领域事件类型
+const (
+	EventTaskCreated     = "task.created"
+	EventTaskUpdated     = "task.updated"
+	EventTaskCompleted   = "task.completed"
+	EventTaskDeleted     = "task.deleted"
+	EventProjectCreated  = "project.created"
+	EventProjectUpdated  = "project.updated"
+	EventProjectDeleted  = "project.deleted"
+	EventLabelCreated    = "label.created"
+	EventLabelUpdated    = "label.updated"
+	EventLabelDeleted    = "label.deleted"
+	EventReminderCreated = "reminder.created"
+	EventReminderDeleted = "reminder.deleted"
+	EventReminderFired   = "reminder.fired"
+)
+
+// WebhookEventTypes Webhook可订阅的事件类型，其余事件只推送给实时变更流
+var WebhookEventTypes = []string{EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventProjectDeleted, EventReminderFired}
+
+// DeletedEntity 删除事件的数据，删除后只能提供ID
+type DeletedEntity struct {
+	ID     uuid.UUID  `json:"id"`
+	TaskID *uuid.UUID `json:"taskId,omitempty"` // 被删除提醒所属的任务
+}
+
+// Event 领域事件，Data是事件发生后相关数据的快照
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	UserID     uuid.UUID `json:"-"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+// EventHandler 事件处理函数，在发布事件的协程中同步调用，耗时的工作应自行排队
+type EventHandler func(ctx context.Context, event *Event)
+
+// EventBus 进程内的事件总线，服务在数据变更成功后发布事件，Webhook等订阅方据此通知外部系统
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe 注册事件处理函数
+func (b *EventBus) Subscribe(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，总线为nil时不做任何处理
+// 处理函数使用不随请求取消的上下文，请求结束后仍能完成入队
+func (b *EventBus) Publish(ctx context.Context, userID uuid.UUID, eventType string, data any) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	event := &Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 健康检查状态
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthCheckFunc 单项健康检查函数，返回nil表示正常
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthCheckResult 单项健康检查结果
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessReport 就绪检查报告
+type ReadinessReport struct {
+	Ready     bool                 `json:"ready"`
+	Checks    []*HealthCheckResult `json:"checks"`
+	CheckedAt time.Time            `json:"checkedAt"`
+}
+
+// namedHealthCheck 带名称的健康检查
+type namedHealthCheck struct {
+	name  string
+	check HealthCheckFunc
+}
+
+// HealthChecker 健康检查服务
+type HealthChecker struct {
+	mu        sync.RWMutex
+	checks    []namedHealthCheck
+	timeout   time.Duration
+	startedAt time.Time
+}
+
+// NewHealthChecker 创建健康检查服务，timeout为每项检查的超时时间
+func NewHealthChecker(timeout time.Duration) *HealthChecker {
+	return &HealthChecker{
+		timeout:   timeout,
+		startedAt: time.Now(),
+	}
+}
+
+// AddCheck 注册一项就绪检查
+func (h *HealthChecker) AddCheck(name string, check HealthCheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedHealthCheck{name: name, check: check})
+}
+
+// StartedAt 获取服务启动时间
+func (h *HealthChecker) StartedAt() time.Time {
+	return h.startedAt
+}
+
+// Uptime 获取服务运行时长
+func (h *HealthChecker) Uptime() time.Duration {
+	return time.Since(h.startedAt)
+}
+
+// Ready 并发执行所有就绪检查，任意一项失败则未就绪
+func (h *HealthChecker) Ready(ctx context.Context) *ReadinessReport {
+	h.mu.RLock()
+	checks := make([]namedHealthCheck, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+
+	results := make([]*HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c namedHealthCheck) {
+			defer wg.Done()
+			results[i] = h.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &ReadinessReport{
+		Ready:     true,
+		Checks:    results,
+		CheckedAt: time.Now(),
+	}
+	for _, result := range results {
+		if result.Status != HealthStatusUp {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// run 在超时限制内执行单项检查并记录耗时
+func (h *HealthChecker) run(ctx context.Context, c namedHealthCheck) *HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &HealthCheckResult{
+		Name:      c.name,
+		Status:    HealthStatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ticktick-backend/internal/importer"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrImportJobNotFound 导入任务不存在或已过期
+var ErrImportJobNotFound = errors.New("导入任务不存在")
+
+// 后台任务（导入、导出）的状态
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// importJobTTL 导入任务状态的保留时间
+const importJobTTL = 24 * time.Hour
+
+// ImportQuery 导入请求的查询参数
+type ImportQuery struct {
+	Source string `form:"source" binding:"required,oneof=ticktick todoist"`
+	DryRun bool   `form:"dryRun"` // 只解析并返回预览，不写入数据
+}
+
+// ImportPreviewProject 预览中的单个项目
+type ImportPreviewProject struct {
+	Name   string `json:"name"`
+	Exists bool   `json:"exists"` // 已存在同名项目，任务将导入到该项目中
+	Tasks  int    `json:"tasks"`  // 任务数量（包括子任务）
+}
+
+// ImportPreview 试运行结果，不写入任何数据
+type ImportPreview struct {
+	Source    string                  `json:"source"`
+	Projects  []*ImportPreviewProject `json:"projects"`
+	NewLabels []string                `json:"newLabels"`
+	Tasks     int                     `json:"tasks"`
+	Warnings  []string                `json:"warnings"`
+}
+
+// ImportJob 异步导入任务的进度，Processed达到Total时导入结束
+type ImportJob struct {
+	ID              uuid.UUID  `json:"id"`
+	Source          string     `json:"source"`
+	Status          string     `json:"status"`
+	Total           int        `json:"total"`
+	Processed       int        `json:"processed"`
+	ProjectsCreated int        `json:"projectsCreated"`
+	LabelsCreated   int        `json:"labelsCreated"`
+	TasksCreated    int        `json:"tasksCreated"`
+	TasksFailed     int        `json:"tasksFailed"`
+	Warnings        []string   `json:"warnings"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ImportService 从其他待办应用的备份导入数据
+// 备份在请求中同步解析，写入在后台进行，进度保存在Redis中供客户端轮询
+type ImportService struct {
+	tx       repository.Transactor
+	projects repository.ProjectRepository
+	tasks    repository.TaskRepository
+	labels   repository.LabelRepository
+	settings *SettingsService
+	redis    *RedisService
+
+	active atomic.Int64 // 本实例中等待或正在执行的导入任务数
+}
+
+// NewImportService 创建导入服务实例
+func NewImportService(tx repository.Transactor, projects repository.ProjectRepository, tasks repository.TaskRepository, labels repository.LabelRepository, settings *SettingsService, redis *RedisService) *ImportService {
+	return &ImportService{
+		tx:       tx,
+		projects: projects,
+		tasks:    tasks,
+		labels:   labels,
+		settings: settings,
+		redis:    redis,
+	}
+}
+
+// Preview 解析备份并返回将要导入的内容，同名项目会被复用
+func (s *ImportService) Preview(ctx context.Context, userID uuid.UUID, source, filename string, data []byte) (*ImportPreview, error) {
+	backup, err := s.parse(ctx, userID, source, filename, data)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.projectsByName(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := labelsByName(ctx, s.labels, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ImportPreview{
+		Source:    source,
+		Projects:  make([]*ImportPreviewProject, 0, len(backup.Projects)),
+		NewLabels: make([]string, 0),
+		Warnings:  nonNilStrings(backup.Warnings),
+	}
+	seen := make(map[string]bool)
+	var collect func(tasks []*importer.Task)
+	collect = func(tasks []*importer.Task) {
+		for _, task := range tasks {
+			for _, name := range task.Labels {
+				if _, ok := labels[name]; !ok && !seen[name] {
+					seen[name] = true
+					preview.NewLabels = append(preview.NewLabels, name)
+				}
+			}
+			collect(task.Subtasks)
+		}
+	}
+	for _, p := range backup.Projects {
+		_, exists := projects[p.Name]
+		preview.Projects = append(preview.Projects, &ImportPreviewProject{Name: p.Name, Exists: exists, Tasks: p.Count()})
+		preview.Tasks += p.Count()
+		collect(p.Tasks)
+	}
+	return preview, nil
+}
+
+// StartImport 解析备份并在后台开始导入，返回等待中的导入任务
+// 每个顶层任务及其子任务在单独的事务中写入，单个任务失败不影响其余任务
+func (s *ImportService) StartImport(ctx context.Context, userID uuid.UUID, source, filename string, data []byte) (*ImportJob, error) {
+	backup, err := s.parse(ctx, userID, source, filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &ImportJob{
+		ID:        uuid.New(),
+		Source:    source,
+		Status:    JobPending,
+		Warnings:  nonNilStrings(backup.Warnings),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, p := range backup.Projects {
+		job.Total += p.Count()
+	}
+	if err := s.saveJob(userID, job); err != nil {
+		return nil, err
+	}
+
+	// 导入不随请求结束而取消，返回副本避免与后台更新并发访问
+	resp := *job
+	resp.Warnings = slices.Clone(job.Warnings)
+	s.active.Add(1)
+	go s.run(context.Background(), userID, job, backup)
+	return &resp, nil
+}
+
+// ActiveJobs 返回本实例中等待或正在执行的导入任务数，用于队列长度指标
+func (s *ImportService) ActiveJobs() int64 {
+	return s.active.Load()
+}
+
+// GetImportJob 获取导入任务的进度
+func (s *ImportService) GetImportJob(ctx context.Context, userID, jobID uuid.UUID) (*ImportJob, error) {
+	data, err := s.redis.Get(s.jobKey(userID, jobID))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询导入任务失败: %w", err)
+	}
+	var job ImportJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("解析导入任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// parse 按用户设置的时区解析备份
+func (s *ImportService) parse(ctx context.Context, userID uuid.UUID, source, filename string, data []byte) (*importer.Backup, error) {
+	prefs, err := s.settings.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return importer.Parse(strings.ToLower(source), filename, data, loc)
+}
+
+// run 执行导入，每写入一个顶层任务更新一次进度
+func (s *ImportService) run(ctx context.Context, userID uuid.UUID, job *ImportJob, backup *importer.Backup) {
+	defer s.active.Add(-1)
+
+	job.Status = JobRunning
+	s.updateJob(userID, job)
+
+	projects, err := s.projectsByName(ctx, userID)
+	if err == nil {
+		var labels map[string]uuid.UUID
+		labels, err = labelsByName(ctx, s.labels, userID)
+		if err == nil {
+			s.importProjects(ctx, userID, job, backup, projects, labels)
+		}
+	}
+
+	now := time.Now()
+	job.Status, job.FinishedAt = JobCompleted, &now
+	if err != nil {
+		job.Status, job.Error = JobFailed, err.Error()
+	}
+	s.updateJob(userID, job)
+}
+
+// importProjects 依次导入各个项目，项目创建失败时跳过其中的全部任务
+func (s *ImportService) importProjects(ctx context.Context, userID uuid.UUID, job *ImportJob, backup *importer.Backup, projects, labels map[string]uuid.UUID) {
+	for _, p := range backup.Projects {
+		projectID, ok := projects[p.Name]
+		if !ok {
+			id, err := s.createProject(ctx, userID, p)
+			if err != nil {
+				job.warn("创建项目「%s」失败: %v", p.Name, err)
+				job.Processed += p.Count()
+				job.TasksFailed += p.Count()
+				s.updateJob(userID, job)
+				continue
+			}
+			projectID = id
+			projects[p.Name] = id
+			job.ProjectsCreated++
+		}
+
+		for _, task := range p.Tasks {
+			created := make(map[string]uuid.UUID)
+			err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+				return s.createTask(ctx, userID, projectID, nil, task, labels, created)
+			})
+			job.Processed += task.Count()
+			if err != nil {
+				job.warn("导入任务「%s」失败: %v", task.Title, err)
+				job.TasksFailed += task.Count()
+			} else {
+				job.TasksCreated += task.Count()
+				for name, id := range created {
+					labels[name] = id
+				}
+				job.LabelsCreated += len(created)
+			}
+			s.updateJob(userID, job)
+		}
+	}
+}
+
+// createProject 创建项目，排在末尾
+func (s *ImportService) createProject(ctx context.Context, userID uuid.UUID, p *importer.Project) (uuid.UUID, error) {
+	sortOrder, err := sortOrderBetween(ctx, nil, nil, projectNeighbor(s.projects, userID, uuid.Nil), nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	color := p.Color
+	if color == "" {
+		color = DefaultProjectColor
+	}
+	project := &models.Project{
+		UserID:    userID,
+		Name:      p.Name,
+		Color:     strings.ToUpper(color),
+		SortOrder: sortOrder,
+	}
+	if err := s.projects.CreateProject(ctx, project); err != nil {
+		return uuid.Nil, err
+	}
+	return project.ID, nil
+}
+
+// createTask 递归创建任务及其子任务，不存在的标签在当前事务中创建并记录到created
+func (s *ImportService) createTask(ctx context.Context, userID, projectID uuid.UUID, parentID *uuid.UUID, src *importer.Task, labels, created map[string]uuid.UUID) error {
+	sortOrder, err := appendTaskSortOrder(ctx, s.tasks, scopeOf(userID, projectID, parentID), uuid.Nil)
+	if err != nil {
+		return err
+	}
+	task := &models.Task{
+		UserID:      userID,
+		ProjectID:   projectID,
+		ParentID:    parentID,
+		Title:       src.Title,
+		Description: src.Description,
+		Status:      models.TaskStatusIncomplete,
+		Priority:    src.Priority,
+		StartTime:   src.StartTime,
+		DueTime:     src.DueTime,
+		RRuleString: src.RRule,
+		SortOrder:   sortOrder,
+	}
+	if src.Completed {
+		task.MarkCompleted()
+		if src.CompletedAt != nil {
+			task.CompletedAt = src.CompletedAt
+		}
+	}
+	if err := s.tasks.CreateTask(ctx, task); err != nil {
+		return fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	if len(src.Labels) > 0 {
+		labelIDs := make([]uuid.UUID, 0, len(src.Labels))
+		for _, name := range src.Labels {
+			id, ok := labels[name]
+			if !ok {
+				id, ok = created[name]
+			}
+			if !ok {
+				label := &models.Label{UserID: userID, Name: name}
+				if err := s.labels.CreateLabel(ctx, label); err != nil {
+					return fmt.Errorf("创建标签失败: %w", err)
+				}
+				id = label.ID
+				created[name] = id
+			}
+			labelIDs = append(labelIDs, id)
+		}
+		if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+			return fmt.Errorf("设置任务标签失败: %w", err)
+		}
+	}
+
+	for _, sub := range src.Subtasks {
+		if err := s.createTask(ctx, userID, projectID, &task.ID, sub, labels, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectsByName 获取用户全部项目，按名称索引
+func (s *ImportService) projectsByName(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error) {
+	req, err := (&pagination.Query{}).Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	byName := make(map[string]uuid.UUID, len(projects))
+	for _, project := range projects {
+		byName[project.Name] = project.ID
+	}
+	return byName, nil
+}
+
+// updateJob 保存进度，后台导入中保存失败只记录日志
+func (s *ImportService) updateJob(userID uuid.UUID, job *ImportJob) {
+	job.UpdatedAt = time.Now()
+	if err := s.saveJob(userID, job); err != nil {
+		log.Printf("保存导入任务%s的进度失败: %v", job.ID, err)
+	}
+}
+
+func (s *ImportService) saveJob(userID uuid.UUID, job *ImportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.Set(s.jobKey(userID, job.ID), data, importJobTTL); err != nil {
+		return fmt.Errorf("保存导入任务失败: %w", err)
+	}
+	return nil
+}
+
+func (s *ImportService) jobKey(userID, jobID uuid.UUID) string {
+	return fmt.Sprintf("import:job:%s:%s", userID, jobID)
+}
+
+// warn 记录不影响其余内容导入的问题
+func (job *ImportJob) warn(format string, args ...any) {
+	job.Warnings = append(job.Warnings, fmt.Sprintf(format, args...))
+}
+
+// nonNilStrings 保证JSON中输出[]而不是null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return make([]string, 0)
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLabelNotFound   = errors.New("标签不存在")
+	ErrLabelNameExists = errors.New("标签名称已存在")
+)
+
+// LabelService 标签服务
+type LabelService struct {
+	labels repository.LabelRepository
+	events *EventBus
+}
+
+// NewLabelService 创建标签服务实例，events为nil时不发布事件
+func NewLabelService(labels repository.LabelRepository, events *EventBus) *LabelService {
+	return &LabelService{
+		labels: labels,
+		events: events,
+	}
+}
+
+// LabelRequest 创建或更新标签请求结构
+type LabelRequest struct {
+	ID   uuid.UUID `json:"-"` // 创建时使用的ID，增量同步时由客户端生成，为空时自动生成
+	Name string    `json:"name" binding:"required,max=100"`
+}
+
+// LabelResponse 标签响应结构
+type LabelResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateLabel 创建标签
+func (s *LabelService) CreateLabel(ctx context.Context, userID uuid.UUID, req *LabelRequest) (*LabelResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(ctx, userID, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	label := &models.Label{
+		ID:     req.ID,
+		UserID: userID,
+		Name:   name,
+	}
+	if err := s.labels.CreateLabel(ctx, label); err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+	resp := toLabelResponse(label)
+	s.events.Publish(ctx, userID, EventLabelCreated, resp)
+	return resp, nil
+}
+
+// ListLabels 分页获取用户的标签列表，默认按名称排序
+func (s *LabelService) ListLabels(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*LabelResponse], error) {
+	req, err := query.Request(repository.LabelSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.labels.ListLabels(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	labels, next := pagination.Trim(labels, req, func(label *models.Label) (interface{}, uuid.UUID) {
+		return repository.LabelSortValue(label, req.Sort.Field.Name), label.ID
+	})
+
+	page := &pagination.Page[*LabelResponse]{Items: make([]*LabelResponse, 0, len(labels)), NextCursor: next}
+	for _, label := range labels {
+		page.Items = append(page.Items, toLabelResponse(label))
+	}
+	return page, nil
+}
+
+// UpdateLabel 重命名标签
+func (s *LabelService) UpdateLabel(ctx context.Context, userID, id uuid.UUID, req *LabelRequest) (*LabelResponse, error) {
+	label, err := s.getLabel(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name != label.Name {
+		if err := s.checkNameAvailable(ctx, userID, name, label.ID); err != nil {
+			return nil, err
+		}
+		label.Name = name
+		if err := s.labels.UpdateLabel(ctx, label); err != nil {
+			return nil, fmt.Errorf("更新标签失败: %w", err)
+		}
+		s.events.Publish(ctx, userID, EventLabelUpdated, toLabelResponse(label))
+	}
+	return toLabelResponse(label), nil
+}
+
+// DeleteLabel 删除标签
+func (s *LabelService) DeleteLabel(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getLabel(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if err := s.labels.DeleteLabel(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除标签失败: %w", err)
+	}
+	s.events.Publish(ctx, userID, EventLabelDeleted, &DeletedEntity{ID: id})
+	return nil
+}
+
+// getLabel 获取用户的标签，不存在时返回ErrLabelNotFound
+func (s *LabelService) getLabel(ctx context.Context, userID, id uuid.UUID) (*models.Label, error) {
+	label, err := s.labels.GetLabelByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	if label == nil {
+		return nil, ErrLabelNotFound
+	}
+	return label, nil
+}
+
+// checkNameAvailable 检查标签名称是否可用
+func (s *LabelService) checkNameAvailable(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) error {
+	exists, err := s.labels.LabelNameExists(ctx, userID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("检查标签名称失败: %w", err)
+	}
+	if exists {
+		return ErrLabelNameExists
+	}
+	return nil
+}
+
+// toLabelResponse 转换为标签响应结构
+func toLabelResponse(label *models.Label) *LabelResponse {
+	return &LabelResponse{
+		ID:        label.ID,
+		Name:      label.Name,
+		CreatedAt: label.CreatedAt,
+	}
+}
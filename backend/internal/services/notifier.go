@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"ticktick-backend/config"
+)
+
+// NewSignInNotification 新设备登录通知内容
+type NewSignInNotification struct {
+	Email      string
+	FirstName  string
+	DeviceInfo DeviceInfo
+	SignedInAt time.Time
+	NewDevice  bool   // 设备从未出现过
+	NewIP      bool   // IP从未出现过
+	RejectURL  string // "不是我本人"链接
+}
+
+// PasswordResetNotification 密码重置通知内容
+type PasswordResetNotification struct {
+	Email     string
+	FirstName string
+	ResetURL  string
+	ExpiresAt time.Time
+}
+
+// Notifier 安全通知发送接口
+type Notifier interface {
+	NotifyNewSignIn(n *NewSignInNotification) error
+	NotifyPasswordReset(n *PasswordResetNotification) error
+}
+
+// NewNotifier 根据配置创建通知服务，未配置SMTP时使用日志通知
+func NewNotifier(cfg *config.SMTPConfig) Notifier {
+	if cfg.Host == "" {
+		return &LogNotifier{}
+	}
+	return &SMTPNotifier{config: cfg}
+}
+
+// LogNotifier 只将通知写入日志，用于开发环境
+type LogNotifier struct{}
+
+// NotifyNewSignIn 记录新设备登录通知
+func (n *LogNotifier) NotifyNewSignIn(notification *NewSignInNotification) error {
+	log.Printf("新设备登录通知 -> %s: %s 于 %s 登录，如非本人操作请访问 %s",
+		notification.Email, notification.DeviceInfo.String(),
+		notification.SignedInAt.Format(time.RFC3339), notification.RejectURL)
+	return nil
+}
+
+// NotifyPasswordReset 记录密码重置通知
+func (n *LogNotifier) NotifyPasswordReset(notification *PasswordResetNotification) error {
+	log.Printf("密码重置通知 -> %s: 请在 %s 前访问 %s 重置密码",
+		notification.Email, notification.ExpiresAt.Format(time.RFC3339), notification.ResetURL)
+	return nil
+}
+
+// SMTPNotifier 通过SMTP发送邮件通知
+type SMTPNotifier struct {
+	config *config.SMTPConfig
+}
+
+// NotifyNewSignIn 发送新设备登录邮件
+func (n *SMTPNotifier) NotifyNewSignIn(notification *NewSignInNotification) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s，您好：\r\n\r\n", notification.FirstName)
+	fmt.Fprintf(&body, "您的账号于 %s 在一台新设备上登录：\r\n\r\n", notification.SignedInAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Fprintf(&body, "  设备：%s\r\n", notification.DeviceInfo.String())
+	fmt.Fprintf(&body, "  IP：%s\r\n\r\n", notification.DeviceInfo.IP)
+	fmt.Fprintf(&body, "如果这不是您本人的操作，请点击以下链接退出该设备并重置密码：\r\n%s\r\n", notification.RejectURL)
+
+	return n.send(notification.Email, "新设备登录提醒", body.String())
+}
+
+// NotifyPasswordReset 发送密码重置邮件
+func (n *SMTPNotifier) NotifyPasswordReset(notification *PasswordResetNotification) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s，您好：\r\n\r\n", notification.FirstName)
+	fmt.Fprintf(&body, "为了您的账号安全，请在 %s 前通过以下链接重置密码：\r\n%s\r\n",
+		notification.ExpiresAt.Format("2006-01-02 15:04:05 MST"), notification.ResetURL)
+
+	return n.send(notification.Email, "重置您的密码", body.String())
+}
+
+// send 发送纯文本邮件
+func (n *SMTPNotifier) send(to, subject, body string) error {
+	addr := n.config.Host + ":" + n.config.Port
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+
+	msg := "From: " + n.config.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	if err := smtp.SendMail(addr, auth, n.config.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package services
+
+import "encoding/json"
+
+// Optional 可选字段，用于局部更新时区分"未提供"和"显式设为null"
+type Optional[T any] struct {
+	Set   bool // 请求中是否出现该字段
+	Value *T   // 字段值，显式传入null时为nil
+}
+
+// UnmarshalJSON 字段出现在请求体中时才会被调用
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/repository"
+)
+
+// OrderRebalancer 排序键重新平衡任务
+// 反复在同一位置插入会使排序键越来越长，定期找出键过长的同级数据，按现有顺序重新分配较短的键
+type OrderRebalancer struct {
+	tx           repository.Transactor
+	projects     repository.ProjectRepository
+	tasks        repository.TaskRepository
+	sections     repository.SectionRepository
+	groups       repository.ProjectGroupRepository
+	maxKeyLength int
+	interval     time.Duration
+	stopChan     chan struct{}
+	mu           sync.Mutex
+	isRunning    bool
+}
+
+// NewOrderRebalancer 创建排序键重新平衡任务
+func NewOrderRebalancer(tx repository.Transactor, projects repository.ProjectRepository, tasks repository.TaskRepository, sections repository.SectionRepository, groups repository.ProjectGroupRepository, cfg *config.OrderingConfig) *OrderRebalancer {
+	return &OrderRebalancer{
+		tx:           tx,
+		projects:     projects,
+		tasks:        tasks,
+		sections:     sections,
+		groups:       groups,
+		maxKeyLength: cfg.MaxKeyLength,
+		interval:     cfg.RebalanceInterval,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start 启动重新平衡任务，未配置长度上限或间隔时不启动
+func (r *OrderRebalancer) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isRunning || r.maxKeyLength <= 0 || r.interval <= 0 {
+		return
+	}
+
+	r.isRunning = true
+	log.Printf("排序键重新平衡任务启动，长度上限 %d", r.maxKeyLength)
+	go r.run()
+}
+
+// Stop 停止重新平衡任务
+func (r *OrderRebalancer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRunning {
+		return
+	}
+
+	r.isRunning = false
+	close(r.stopChan)
+	log.Println("排序键重新平衡任务停止")
+}
+
+// run 启动时先执行一次，之后按间隔执行
+func (r *OrderRebalancer) run() {
+	r.Rebalance(context.Background())
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Rebalance(context.Background())
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// Rebalance 重新分配所有键过长的同级任务、项目、分栏和项目分组的排序键，每个范围在单独的事务中处理
+func (r *OrderRebalancer) Rebalance(ctx context.Context) {
+	scopes, err := r.tasks.ListTaskScopesWithLongSortOrder(ctx, r.maxKeyLength)
+	if err != nil {
+		log.Printf("查询需要重新平衡的任务失败: %v", err)
+		return
+	}
+	for _, scope := range scopes {
+		err := r.tx.WithTx(ctx, func(ctx context.Context) error {
+			_, err := rebalanceTaskScope(ctx, r.tasks, scope)
+			return err
+		})
+		if err != nil {
+			log.Printf("重新平衡项目 %s 的任务排序失败: %v", scope.ProjectID, err)
+		}
+	}
+
+	userIDs, err := r.projects.ListUsersWithLongProjectSortOrder(ctx, r.maxKeyLength)
+	if err != nil {
+		log.Printf("查询需要重新平衡的项目失败: %v", err)
+		return
+	}
+	for _, userID := range userIDs {
+		err := r.tx.WithTx(ctx, func(ctx context.Context) error {
+			_, err := rebalanceUserProjects(ctx, r.projects, userID)
+			return err
+		})
+		if err != nil {
+			log.Printf("重新平衡用户 %s 的项目排序失败: %v", userID, err)
+		}
+	}
+
+	projectIDs, err := r.sections.ListProjectsWithLongSectionSortOrder(ctx, r.maxKeyLength)
+	if err != nil {
+		log.Printf("查询需要重新平衡的分栏失败: %v", err)
+		return
+	}
+	for _, projectID := range projectIDs {
+		err := r.tx.WithTx(ctx, func(ctx context.Context) error {
+			_, err := rebalanceProjectSections(ctx, r.sections, projectID)
+			return err
+		})
+		if err != nil {
+			log.Printf("重新平衡项目 %s 的分栏排序失败: %v", projectID, err)
+		}
+	}
+
+	groupUserIDs, err := r.groups.ListUsersWithLongProjectGroupSortOrder(ctx, r.maxKeyLength)
+	if err != nil {
+		log.Printf("查询需要重新平衡的项目分组失败: %v", err)
+		return
+	}
+	for _, userID := range groupUserIDs {
+		err := r.tx.WithTx(ctx, func(ctx context.Context) error {
+			_, err := rebalanceUserProjectGroups(ctx, r.groups, userID)
+			return err
+		})
+		if err != nil {
+			log.Printf("重新平衡用户 %s 的项目分组排序失败: %v", userID, err)
+		}
+	}
+
+	if len(scopes) > 0 || len(userIDs) > 0 || len(projectIDs) > 0 || len(groupUserIDs) > 0 {
+		log.Printf("排序键重新平衡完成，任务范围 %d 个，用户 %d 个，项目分栏 %d 个，分组用户 %d 个", len(scopes), len(userIDs), len(projectIDs), len(groupUserIDs))
+	}
+}
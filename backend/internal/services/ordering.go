@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ticktick-backend/internal/ordering"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidMove 移动的目标位置无效
+var ErrInvalidMove = errors.New("移动位置无效")
+
+// MoveRequest 拖动排序请求，将记录放到afterId之后、beforeId之前
+// 只提供afterId时紧跟其后，只提供beforeId时紧挨其前，都不提供时移到末尾
+type MoveRequest struct {
+	AfterID  *uuid.UUID `json:"afterId"`
+	BeforeID *uuid.UUID `json:"beforeId"`
+}
+
+// sortAnchor 移动时参照的相邻记录
+type sortAnchor struct {
+	ID  uuid.UUID
+	Key string
+}
+
+// neighborFunc 查找同级中与key相邻的排序键，next为true时取后一个
+type neighborFunc func(ctx context.Context, key string, next bool) (string, error)
+
+// rebalanceFunc 重新分配同级数据的排序键，返回新的排序键
+type rebalanceFunc func(ctx context.Context) (map[uuid.UUID]string, error)
+
+// sortOrderBetween 计算放在after之后、before之前的排序键，只修改被移动的记录
+// 客户端看到的顺序可能已经过期，before不在after之后时以after为准；两者的键相同时先重新平衡同级数据
+func sortOrderBetween(ctx context.Context, after, before *sortAnchor, neighbor neighborFunc, rebalance rebalanceFunc) (string, error) {
+	if after != nil && before != nil && after.Key == before.Key {
+		orders, err := rebalance(ctx)
+		if err != nil {
+			return "", err
+		}
+		after.Key, before.Key = orders[after.ID], orders[before.ID]
+	}
+
+	var lower, upper string
+	var err error
+	switch {
+	case after != nil:
+		lower = after.Key
+		if before != nil && before.Key > lower {
+			upper = before.Key
+		} else {
+			upper, err = neighbor(ctx, lower, true)
+		}
+	case before != nil:
+		upper = before.Key
+		lower, err = neighbor(ctx, upper, false)
+	default:
+		lower, err = neighbor(ctx, "", false)
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询相邻排序键失败: %w", err)
+	}
+
+	key, err := ordering.Between(lower, upper)
+	if err != nil {
+		return "", fmt.Errorf("生成排序键失败: %w", err)
+	}
+	return key, nil
+}
+
+// spreadSortOrders 为按顺序排列的ID分配均匀分布的排序键
+func spreadSortOrders(ids []uuid.UUID) map[uuid.UUID]string {
+	keys := ordering.Spread(len(ids))
+	orders := make(map[uuid.UUID]string, len(ids))
+	for i, id := range ids {
+		orders[id] = keys[i]
+	}
+	return orders
+}
+
+// rebalanceTaskScope 保持现有顺序，重新分配同级任务的排序键
+func rebalanceTaskScope(ctx context.Context, tasks repository.TaskRepository, scope repository.TaskScope) (map[uuid.UUID]string, error) {
+	ids, err := tasks.ListTaskIDsBySortOrder(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("查询同级任务失败: %w", err)
+	}
+	orders := spreadSortOrders(ids)
+	if err := tasks.SetTaskSortOrders(ctx, orders); err != nil {
+		return nil, fmt.Errorf("更新任务排序失败: %w", err)
+	}
+	return orders, nil
+}
+
+// rebalanceUserProjects 保持现有顺序，重新分配用户项目的排序键
+func rebalanceUserProjects(ctx context.Context, projects repository.ProjectRepository, userID uuid.UUID) (map[uuid.UUID]string, error) {
+	ids, err := projects.ListProjectIDsBySortOrder(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	orders := spreadSortOrders(ids)
+	if err := projects.SetProjectSortOrders(ctx, orders); err != nil {
+		return nil, fmt.Errorf("更新项目排序失败: %w", err)
+	}
+	return orders, nil
+}
+
+// rebalanceUserProjectGroups 保持现有顺序，重新分配用户项目分组的排序键
+func rebalanceUserProjectGroups(ctx context.Context, groups repository.ProjectGroupRepository, userID uuid.UUID) (map[uuid.UUID]string, error) {
+	ids, err := groups.ListProjectGroupIDsBySortOrder(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	orders := spreadSortOrders(ids)
+	if err := groups.SetProjectGroupSortOrders(ctx, orders); err != nil {
+		return nil, fmt.Errorf("更新项目分组排序失败: %w", err)
+	}
+	return orders, nil
+}
+
+// rebalanceProjectSections 保持现有顺序，重新分配项目分栏的排序键
+func rebalanceProjectSections(ctx context.Context, sections repository.SectionRepository, projectID uuid.UUID) (map[uuid.UUID]string, error) {
+	ids, err := sections.ListSectionIDsBySortOrder(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询分栏失败: %w", err)
+	}
+	orders := spreadSortOrders(ids)
+	if err := sections.SetSectionSortOrders(ctx, orders); err != nil {
+		return nil, fmt.Errorf("更新分栏排序失败: %w", err)
+	}
+	return orders, nil
+}
+
+// taskNeighbor 返回同级任务的相邻排序键查询，excludeID通常是被移动的任务
+func taskNeighbor(tasks repository.TaskRepository, scope repository.TaskScope, excludeID uuid.UUID) neighborFunc {
+	return func(ctx context.Context, key string, next bool) (string, error) {
+		return tasks.TaskSortOrderNeighbor(ctx, scope, key, next, excludeID)
+	}
+}
+
+// projectNeighbor 返回用户项目的相邻排序键查询
+func projectNeighbor(projects repository.ProjectRepository, userID, excludeID uuid.UUID) neighborFunc {
+	return func(ctx context.Context, key string, next bool) (string, error) {
+		return projects.ProjectSortOrderNeighbor(ctx, userID, key, next, excludeID)
+	}
+}
+
+// appendTaskSortOrder 生成排在同级任务末尾的排序键
+func appendTaskSortOrder(ctx context.Context, tasks repository.TaskRepository, scope repository.TaskScope, excludeID uuid.UUID) (string, error) {
+	return sortOrderBetween(ctx, nil, nil, taskNeighbor(tasks, scope, excludeID), nil)
+}
+
+// scopeOf 获取任务所在的排序范围
+func scopeOf(userID, projectID uuid.UUID, parentID *uuid.UUID) repository.TaskScope {
+	return repository.TaskScope{UserID: userID, ProjectID: projectID, ParentID: parentID}
+}
+
+// sameScope 判断两个排序范围是否相同
+func sameScope(a, b repository.TaskScope) bool {
+	return a.UserID == b.UserID && a.ProjectID == b.ProjectID && sameID(a.ParentID, b.ParentID)
+}
+
+// sameID 判断两个可选ID是否相同，都为nil也视为相同（如默认分栏、未分组）
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
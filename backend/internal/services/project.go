@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProjectNotFound   = errors.New("项目不存在")
+	ErrProjectNameExists = errors.New("项目名称已存在")
+)
+
+// DefaultProjectColor 默认项目颜色
+const DefaultProjectColor = "#CCCCCC"
+
+// ProjectService 项目服务
+type ProjectService struct {
+	projects repository.ProjectRepository
+	groups   repository.ProjectGroupRepository
+	events   *EventBus
+}
+
+// NewProjectService 创建项目服务实例，events为nil时不发布事件
+func NewProjectService(projects repository.ProjectRepository, groups repository.ProjectGroupRepository, events *EventBus) *ProjectService {
+	return &ProjectService{
+		projects: projects,
+		groups:   groups,
+		events:   events,
+	}
+}
+
+// CreateProjectRequest 创建项目请求结构
+type CreateProjectRequest struct {
+	ID      uuid.UUID  `json:"-"` // 增量同步时使用客户端生成的ID，为空时自动生成
+	Name    string     `json:"name" binding:"required,max=255"`
+	Color   string     `json:"color" binding:"omitempty,hexcolor"`
+	GroupID *uuid.UUID `json:"groupId"`
+}
+
+// UpdateProjectRequest 更新项目请求结构，未提供的字段保持不变
+type UpdateProjectRequest struct {
+	Name    *string             `json:"name" binding:"omitempty,min=1,max=255"`
+	Color   *string             `json:"color" binding:"omitempty,hexcolor"`
+	GroupID Optional[uuid.UUID] `json:"groupId"` // 传null移出分组
+}
+
+// MoveProjectRequest 拖动排序项目请求，groupId用于在没有参照项目时移入指定分组，null表示移出分组
+// 提供了参照项目时项目移到参照项目所在的分组
+type MoveProjectRequest struct {
+	MoveRequest
+	GroupID Optional[uuid.UUID] `json:"groupId"`
+}
+
+// ProjectListQuery 项目列表查询参数，grouped为true时返回侧边栏使用的分组结构，不分页
+type ProjectListQuery struct {
+	Grouped bool `form:"grouped"`
+}
+
+// ProjectResponse 项目响应结构
+type ProjectResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Color     string     `json:"color"`
+	GroupID   *uuid.UUID `json:"groupId"`
+	SortOrder string     `json:"sortOrder"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// ProjectGroupTree 侧边栏中的分组及其中按手动顺序排列的项目
+type ProjectGroupTree struct {
+	*ProjectGroupResponse
+	Projects []*ProjectResponse `json:"projects"`
+}
+
+// GroupedProjectsResponse 侧边栏项目结构：按顺序排列的分组，以及未分组的项目
+type GroupedProjectsResponse struct {
+	Groups    []*ProjectGroupTree `json:"groups"`
+	Ungrouped []*ProjectResponse  `json:"ungrouped"`
+	Count     int                 `json:"count"`
+}
+
+// CreateProject 创建项目
+func (s *ProjectService) CreateProject(ctx context.Context, userID uuid.UUID, req *CreateProjectRequest) (*ProjectResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(ctx, userID, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkGroup(ctx, userID, req.GroupID); err != nil {
+		return nil, err
+	}
+
+	color := req.Color
+	if color == "" {
+		color = DefaultProjectColor
+	}
+
+	// 新项目排在末尾
+	sortOrder, err := sortOrderBetween(ctx, nil, nil, projectNeighbor(s.projects, userID, uuid.Nil), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &models.Project{
+		ID:        req.ID,
+		UserID:    userID,
+		Name:      name,
+		Color:     strings.ToUpper(color),
+		GroupID:   req.GroupID,
+		SortOrder: sortOrder,
+	}
+	if err := s.projects.CreateProject(ctx, project); err != nil {
+		return nil, fmt.Errorf("创建项目失败: %w", err)
+	}
+
+	return s.publishProject(ctx, userID, project, EventProjectCreated), nil
+}
+
+// GetProject 获取项目详情
+func (s *ProjectService) GetProject(ctx context.Context, userID, id uuid.UUID) (*ProjectResponse, error) {
+	project, err := s.getProject(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toProjectResponse(project), nil
+}
+
+// ListProjects 分页获取用户的项目列表，默认按手动排序
+func (s *ProjectService) ListProjects(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*ProjectResponse], error) {
+	req, err := query.Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	projects, next := pagination.Trim(projects, req, func(project *models.Project) (interface{}, uuid.UUID) {
+		return repository.ProjectSortValue(project, req.Sort.Field.Name), project.ID
+	})
+
+	page := &pagination.Page[*ProjectResponse]{Items: make([]*ProjectResponse, 0, len(projects)), NextCursor: next}
+	for _, project := range projects {
+		page.Items = append(page.Items, toProjectResponse(project))
+	}
+	return page, nil
+}
+
+// ListGroupedProjects 获取侧边栏使用的分组结构，分组和组内项目都按手动顺序排列
+// 项目的排序键在用户范围内全局有效，组内顺序即全局顺序中属于该分组的部分
+func (s *ProjectService) ListGroupedProjects(ctx context.Context, userID uuid.UUID) (*GroupedProjectsResponse, error) {
+	req, err := (&pagination.Query{}).Request(repository.ProjectGroupSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	groups, err := s.groups.ListProjectGroups(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+
+	req, err = (&pagination.Query{}).Request(repository.ProjectSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = 0
+	projects, err := s.projects.ListProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+
+	resp := &GroupedProjectsResponse{
+		Groups:    make([]*ProjectGroupTree, 0, len(groups)),
+		Ungrouped: make([]*ProjectResponse, 0),
+		Count:     len(projects),
+	}
+	byID := make(map[uuid.UUID]*ProjectGroupTree, len(groups))
+	for _, group := range groups {
+		tree := &ProjectGroupTree{ProjectGroupResponse: toProjectGroupResponse(group), Projects: make([]*ProjectResponse, 0)}
+		byID[group.ID] = tree
+		resp.Groups = append(resp.Groups, tree)
+	}
+	for _, project := range projects {
+		if project.GroupID != nil && byID[*project.GroupID] != nil {
+			tree := byID[*project.GroupID]
+			tree.Projects = append(tree.Projects, toProjectResponse(project))
+			continue
+		}
+		resp.Ungrouped = append(resp.Ungrouped, toProjectResponse(project))
+	}
+	return resp, nil
+}
+
+// UpdateProject 更新项目
+func (s *ProjectService) UpdateProject(ctx context.Context, userID, id uuid.UUID, req *UpdateProjectRequest) (*ProjectResponse, error) {
+	project, err := s.getProject(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != project.Name {
+			if err := s.checkNameAvailable(ctx, userID, name, project.ID); err != nil {
+				return nil, err
+			}
+			project.Name = name
+		}
+	}
+	if req.Color != nil {
+		project.Color = strings.ToUpper(*req.Color)
+	}
+	if req.GroupID.Set {
+		if err := s.checkGroup(ctx, userID, req.GroupID.Value); err != nil {
+			return nil, err
+		}
+		project.GroupID = req.GroupID.Value
+	}
+
+	if err := s.projects.UpdateProject(ctx, project); err != nil {
+		return nil, fmt.Errorf("更新项目失败: %w", err)
+	}
+	return s.publishProject(ctx, userID, project, EventProjectUpdated), nil
+}
+
+// MoveProject 拖动排序项目，只修改被移动项目的排序键，需要时同时修改所属分组
+func (s *ProjectService) MoveProject(ctx context.Context, userID, id uuid.UUID, req *MoveProjectRequest) (*ProjectResponse, error) {
+	project, err := s.getProject(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.moveAnchor(ctx, userID, project.ID, req.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.moveAnchor(ctx, userID, project.ID, req.BeforeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确定新的分组
+	anchor := after
+	if anchor == nil {
+		anchor = before
+	}
+	switch {
+	case after != nil && before != nil && !sameID(after.GroupID, before.GroupID):
+		return nil, fmt.Errorf("%w: afterId和beforeId不在同一分组", ErrInvalidMove)
+	case anchor != nil:
+		if req.GroupID.Set && !sameID(req.GroupID.Value, anchor.GroupID) {
+			return nil, fmt.Errorf("%w: groupId与参照项目所在分组不一致", ErrInvalidMove)
+		}
+		project.GroupID = anchor.GroupID
+	case req.GroupID.Set:
+		if err := s.checkGroup(ctx, userID, req.GroupID.Value); err != nil {
+			return nil, err
+		}
+		project.GroupID = req.GroupID.Value
+	}
+
+	var afterKey, beforeKey *sortAnchor
+	if after != nil {
+		afterKey = &sortAnchor{ID: after.ID, Key: after.SortOrder}
+	}
+	if before != nil {
+		beforeKey = &sortAnchor{ID: before.ID, Key: before.SortOrder}
+	}
+
+	rebalance := func(ctx context.Context) (map[uuid.UUID]string, error) {
+		return rebalanceUserProjects(ctx, s.projects, userID)
+	}
+	project.SortOrder, err = sortOrderBetween(ctx, afterKey, beforeKey, projectNeighbor(s.projects, userID, project.ID), rebalance)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.projects.UpdateProject(ctx, project); err != nil {
+		return nil, fmt.Errorf("移动项目失败: %w", err)
+	}
+	return s.publishProject(ctx, userID, project, EventProjectUpdated), nil
+}
+
+// moveAnchor 获取移动时参照的项目，参照项目不能是被移动的项目本身
+func (s *ProjectService) moveAnchor(ctx context.Context, userID, projectID uuid.UUID, anchorID *uuid.UUID) (*models.Project, error) {
+	if anchorID == nil {
+		return nil, nil
+	}
+	if *anchorID == projectID {
+		return nil, fmt.Errorf("%w: 不能以项目自身为参照", ErrInvalidMove)
+	}
+
+	anchor, err := s.projects.GetProjectByID(ctx, userID, *anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if anchor == nil {
+		return nil, fmt.Errorf("%w: 参照项目不存在", ErrInvalidMove)
+	}
+	return anchor, nil
+}
+
+// checkGroup 检查分组属于当前用户，groupID为空时表示未分组
+func (s *ProjectService) checkGroup(ctx context.Context, userID uuid.UUID, groupID *uuid.UUID) error {
+	if groupID == nil {
+		return nil
+	}
+	group, err := s.groups.GetProjectGroupByID(ctx, userID, *groupID)
+	if err != nil {
+		return fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	if group == nil {
+		return ErrInvalidProjectGroup
+	}
+	return nil
+}
+
+// DeleteProject 删除项目及其下所有任务
+func (s *ProjectService) DeleteProject(ctx context.Context, userID, id uuid.UUID) error {
+	project, err := s.getProject(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.projects.DeleteProject(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除项目失败: %w", err)
+	}
+	s.publishProject(ctx, userID, project, EventProjectDeleted)
+	return nil
+}
+
+// publishProject 发布项目事件，返回项目响应
+func (s *ProjectService) publishProject(ctx context.Context, userID uuid.UUID, project *models.Project, eventType string) *ProjectResponse {
+	resp := toProjectResponse(project)
+	s.events.Publish(ctx, userID, eventType, resp)
+	return resp
+}
+
+// getProject 获取用户的项目，不存在时返回ErrProjectNotFound
+func (s *ProjectService) getProject(ctx context.Context, userID, id uuid.UUID) (*models.Project, error) {
+	project, err := s.projects.GetProjectByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// checkNameAvailable 检查项目名称是否可用
+func (s *ProjectService) checkNameAvailable(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) error {
+	exists, err := s.projects.ProjectNameExists(ctx, userID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("检查项目名称失败: %w", err)
+	}
+	if exists {
+		return ErrProjectNameExists
+	}
+	return nil
+}
+
+// toProjectResponse 转换为项目响应结构
+func toProjectResponse(project *models.Project) *ProjectResponse {
+	return &ProjectResponse{
+		ID:        project.ID,
+		Name:      project.Name,
+		Color:     project.Color,
+		GroupID:   project.GroupID,
+		SortOrder: project.SortOrder,
+		CreatedAt: project.CreatedAt,
+		UpdatedAt: project.UpdatedAt,
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProjectGroupNotFound   = errors.New("项目分组不存在")
+	ErrProjectGroupNameExists = errors.New("项目分组名称已存在")
+	ErrInvalidProjectGroup    = errors.New("项目分组无效")
+)
+
+// ProjectGroupService 项目分组服务
+type ProjectGroupService struct {
+	groups repository.ProjectGroupRepository
+}
+
+// NewProjectGroupService 创建项目分组服务实例
+func NewProjectGroupService(groups repository.ProjectGroupRepository) *ProjectGroupService {
+	return &ProjectGroupService{
+		groups: groups,
+	}
+}
+
+// CreateProjectGroupRequest 创建分组请求结构
+type CreateProjectGroupRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// UpdateProjectGroupRequest 更新分组请求结构，未提供的字段保持不变
+type UpdateProjectGroupRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Collapsed *bool   `json:"collapsed"`
+}
+
+// ProjectGroupResponse 分组响应结构
+type ProjectGroupResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	SortOrder string    `json:"sortOrder"`
+	Collapsed bool      `json:"collapsed"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListProjectGroups 分页获取用户的分组，默认按手动顺序排列
+func (s *ProjectGroupService) ListProjectGroups(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*ProjectGroupResponse], error) {
+	req, err := query.Request(repository.ProjectGroupSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.groups.ListProjectGroups(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	groups, next := pagination.Trim(groups, req, func(group *models.ProjectGroup) (interface{}, uuid.UUID) {
+		return repository.ProjectGroupSortValue(group, req.Sort.Field.Name), group.ID
+	})
+
+	page := &pagination.Page[*ProjectGroupResponse]{Items: make([]*ProjectGroupResponse, 0, len(groups)), NextCursor: next}
+	for _, group := range groups {
+		page.Items = append(page.Items, toProjectGroupResponse(group))
+	}
+	return page, nil
+}
+
+// CreateProjectGroup 在末尾创建分组
+func (s *ProjectGroupService) CreateProjectGroup(ctx context.Context, userID uuid.UUID, req *CreateProjectGroupRequest) (*ProjectGroupResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(ctx, userID, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	sortOrder, err := sortOrderBetween(ctx, nil, nil, s.neighbor(userID, uuid.Nil), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.ProjectGroup{
+		UserID:    userID,
+		Name:      name,
+		SortOrder: sortOrder,
+	}
+	if err := s.groups.CreateProjectGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("创建项目分组失败: %w", err)
+	}
+	return toProjectGroupResponse(group), nil
+}
+
+// UpdateProjectGroup 修改分组名称或折叠状态
+func (s *ProjectGroupService) UpdateProjectGroup(ctx context.Context, userID, id uuid.UUID, req *UpdateProjectGroupRequest) (*ProjectGroupResponse, error) {
+	group, err := s.getGroup(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != group.Name {
+			if err := s.checkNameAvailable(ctx, userID, name, group.ID); err != nil {
+				return nil, err
+			}
+			group.Name = name
+		}
+	}
+	if req.Collapsed != nil {
+		group.Collapsed = *req.Collapsed
+	}
+
+	if err := s.groups.UpdateProjectGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("更新项目分组失败: %w", err)
+	}
+	return toProjectGroupResponse(group), nil
+}
+
+// MoveProjectGroup 拖动排序分组
+func (s *ProjectGroupService) MoveProjectGroup(ctx context.Context, userID, id uuid.UUID, req *MoveRequest) (*ProjectGroupResponse, error) {
+	group, err := s.getGroup(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.moveAnchor(ctx, userID, group.ID, req.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.moveAnchor(ctx, userID, group.ID, req.BeforeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rebalance := func(ctx context.Context) (map[uuid.UUID]string, error) {
+		return rebalanceUserProjectGroups(ctx, s.groups, userID)
+	}
+	group.SortOrder, err = sortOrderBetween(ctx, after, before, s.neighbor(userID, group.ID), rebalance)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.groups.UpdateProjectGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("移动项目分组失败: %w", err)
+	}
+	return toProjectGroupResponse(group), nil
+}
+
+// DeleteProjectGroup 删除分组，其中的项目变为未分组，不会被删除
+func (s *ProjectGroupService) DeleteProjectGroup(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getGroup(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if err := s.groups.DeleteProjectGroup(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除项目分组失败: %w", err)
+	}
+	return nil
+}
+
+// neighbor 返回用户分组的相邻排序键查询
+func (s *ProjectGroupService) neighbor(userID, excludeID uuid.UUID) neighborFunc {
+	return func(ctx context.Context, key string, next bool) (string, error) {
+		return s.groups.ProjectGroupSortOrderNeighbor(ctx, userID, key, next, excludeID)
+	}
+}
+
+// moveAnchor 获取移动时参照的分组，参照分组不能是被移动的分组本身
+func (s *ProjectGroupService) moveAnchor(ctx context.Context, userID, groupID uuid.UUID, anchorID *uuid.UUID) (*sortAnchor, error) {
+	if anchorID == nil {
+		return nil, nil
+	}
+	if *anchorID == groupID {
+		return nil, fmt.Errorf("%w: 不能以分组自身为参照", ErrInvalidMove)
+	}
+
+	anchor, err := s.groups.GetProjectGroupByID(ctx, userID, *anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	if anchor == nil {
+		return nil, fmt.Errorf("%w: 参照分组不存在", ErrInvalidMove)
+	}
+	return &sortAnchor{ID: anchor.ID, Key: anchor.SortOrder}, nil
+}
+
+// getGroup 获取用户的分组，不存在时返回ErrProjectGroupNotFound
+func (s *ProjectGroupService) getGroup(ctx context.Context, userID, id uuid.UUID) (*models.ProjectGroup, error) {
+	group, err := s.groups.GetProjectGroupByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目分组失败: %w", err)
+	}
+	if group == nil {
+		return nil, ErrProjectGroupNotFound
+	}
+	return group, nil
+}
+
+// checkNameAvailable 检查分组名称是否可用
+func (s *ProjectGroupService) checkNameAvailable(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) error {
+	exists, err := s.groups.ProjectGroupNameExists(ctx, userID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("检查项目分组名称失败: %w", err)
+	}
+	if exists {
+		return ErrProjectGroupNameExists
+	}
+	return nil
+}
+
+// toProjectGroupResponse 转换为分组响应结构
+func toProjectGroupResponse(group *models.ProjectGroup) *ProjectGroupResponse {
+	return &ProjectGroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		SortOrder: group.SortOrder,
+		Collapsed: group.Collapsed,
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+	}
+}
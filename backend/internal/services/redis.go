@@ -69,16 +69,31 @@ func (r *RedisService) Ping() error {
 	return r.client.Ping(r.ctx).Err()
 }
 
+// PingContext 在给定上下文内测试连接
+func (r *RedisService) PingContext(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Set 设置键值对
 func (r *RedisService) Set(key string, value interface{}, expiration time.Duration) error {
 	return r.client.Set(r.ctx, key, value, expiration).Err()
 }
 
+// SetNX 键不存在时设置键值对，返回是否设置成功
+func (r *RedisService) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, value, expiration).Result()
+}
+
 // Get 获取值
 func (r *RedisService) Get(key string) (string, error) {
 	return r.client.Get(r.ctx, key).Result()
 }
 
+// GetDel 获取值并删除键
+func (r *RedisService) GetDel(key string) (string, error) {
+	return r.client.GetDel(r.ctx, key).Result()
+}
+
 // Del 删除键
 func (r *RedisService) Del(keys ...string) error {
 	return r.client.Del(r.ctx, keys...).Err()
@@ -124,6 +139,31 @@ func (r *RedisService) SCard(key string) (int64, error) {
 	return r.client.SCard(r.ctx, key).Result()
 }
 
+// ZAdd 向有序集合添加成员，成员已存在时更新分数
+func (r *RedisService) ZAdd(key string, score float64, member interface{}) error {
+	return r.client.ZAdd(r.ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZRem 从有序集合移除成员
+func (r *RedisService) ZRem(key string, members ...interface{}) error {
+	return r.client.ZRem(r.ctx, key, members...).Err()
+}
+
+// ZCard 获取有序集合的成员数量
+func (r *RedisService) ZCard(key string) (int64, error) {
+	return r.client.ZCard(r.ctx, key).Result()
+}
+
+// LRange 获取列表指定范围的元素
+func (r *RedisService) LRange(key string, start, stop int64) ([]string, error) {
+	return r.client.LRange(r.ctx, key, start, stop).Result()
+}
+
+// PSubscribe 按模式订阅频道，调用方负责关闭返回的订阅
+func (r *RedisService) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	return r.client.PSubscribe(ctx, patterns...)
+}
+
 // HSet 设置哈希字段
 func (r *RedisService) HSet(key string, values ...interface{}) error {
 	return r.client.HSet(r.ctx, key, values...).Err()
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrReminderNotFound = errors.New("提醒不存在")
+
+// ReminderService 提醒服务
+type ReminderService struct {
+	reminders repository.ReminderRepository
+	tasks     repository.TaskRepository
+	events    *EventBus
+}
+
+// NewReminderService 创建提醒服务实例，events为nil时不发布事件
+func NewReminderService(reminders repository.ReminderRepository, tasks repository.TaskRepository, events *EventBus) *ReminderService {
+	return &ReminderService{
+		reminders: reminders,
+		tasks:     tasks,
+		events:    events,
+	}
+}
+
+// CreateReminderRequest 创建提醒请求结构
+type CreateReminderRequest struct {
+	ID       uuid.UUID `json:"-"` // 增量同步时使用客户端生成的ID，为空时自动生成
+	RemindAt time.Time `json:"remindAt" binding:"required"`
+}
+
+// CreateReminder 为任务创建提醒
+func (s *ReminderService) CreateReminder(ctx context.Context, userID, taskID uuid.UUID, req *CreateReminderRequest) (*ReminderResponse, error) {
+	if err := s.checkTask(ctx, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	reminder := &models.Reminder{
+		ID:       req.ID,
+		TaskID:   taskID,
+		RemindAt: req.RemindAt,
+	}
+	if err := s.reminders.CreateReminder(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("创建提醒失败: %w", err)
+	}
+	resp := toReminderResponse(reminder)
+	s.events.Publish(ctx, userID, EventReminderCreated, resp)
+	return resp, nil
+}
+
+// ListReminders 分页获取任务的提醒列表，默认按提醒时间排序
+func (s *ReminderService) ListReminders(ctx context.Context, userID, taskID uuid.UUID, query *pagination.Query) (*pagination.Page[*ReminderResponse], error) {
+	req, err := query.Request(repository.ReminderSortFields, "remindAt")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTask(ctx, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.reminders.ListReminders(ctx, taskID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	reminders, next := pagination.Trim(reminders, req, func(reminder *models.Reminder) (interface{}, uuid.UUID) {
+		return repository.ReminderSortValue(reminder, req.Sort.Field.Name), reminder.ID
+	})
+
+	page := &pagination.Page[*ReminderResponse]{Items: make([]*ReminderResponse, 0, len(reminders)), NextCursor: next}
+	for _, reminder := range reminders {
+		page.Items = append(page.Items, toReminderResponse(reminder))
+	}
+	return page, nil
+}
+
+// DeleteReminder 删除任务的提醒
+func (s *ReminderService) DeleteReminder(ctx context.Context, userID, taskID, id uuid.UUID) error {
+	if err := s.checkTask(ctx, userID, taskID); err != nil {
+		return err
+	}
+
+	reminder, err := s.reminders.GetReminderByID(ctx, taskID, id)
+	if err != nil {
+		return fmt.Errorf("查询提醒失败: %w", err)
+	}
+	if reminder == nil {
+		return ErrReminderNotFound
+	}
+
+	if err := s.reminders.DeleteReminder(ctx, taskID, id); err != nil {
+		return fmt.Errorf("删除提醒失败: %w", err)
+	}
+	s.events.Publish(ctx, userID, EventReminderDeleted, &DeletedEntity{ID: id, TaskID: &taskID})
+	return nil
+}
+
+// checkTask 检查任务是否属于用户，提醒的归属通过任务判断
+func (s *ReminderService) checkTask(ctx context.Context, userID, taskID uuid.UUID) error {
+	task, err := s.tasks.GetTaskByID(ctx, userID, taskID)
+	if err != nil {
+		return fmt.Errorf("查询任务失败: %w", err)
+	}
+	if task == nil {
+		return ErrTaskNotFound
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// reminderCheckpointKey 已检查到的提醒时间，多个实例共享，保证每个提醒只触发一次
+	reminderCheckpointKey = "reminder:dispatch:checkpoint"
+	// reminderMaxCatchUp 停机后恢复时最多补发多久之前的提醒
+	reminderMaxCatchUp = 24 * time.Hour
+)
+
+// reminderAdvanceScript 检查点仍是读取时的值时才推进，返回0表示已被其他实例推进
+const reminderAdvanceScript = `
+local current = redis.call('GET', KEYS[1])
+if (current or '') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`
+
+// ReminderFiredData reminder.fired事件的数据
+type ReminderFiredData struct {
+	ReminderID uuid.UUID     `json:"reminderId"`
+	RemindAt   time.Time     `json:"remindAt"`
+	Task       *TaskResponse `json:"task"`
+}
+
+// ReminderDispatcher 提醒触发任务，定期查找到达提醒时间的提醒并发布reminder.fired事件
+// 已完成或已删除任务的提醒不会触发
+type ReminderDispatcher struct {
+	reminders repository.ReminderRepository
+	events    *EventBus
+	redis     *RedisService
+	interval  time.Duration
+	stopChan  chan struct{}
+	mu        sync.Mutex
+	isRunning bool
+	heartbeat workerHeartbeat
+}
+
+// NewReminderDispatcher 创建提醒触发任务
+func NewReminderDispatcher(reminders repository.ReminderRepository, events *EventBus, redis *RedisService, interval time.Duration) *ReminderDispatcher {
+	return &ReminderDispatcher{
+		reminders: reminders,
+		events:    events,
+		redis:     redis,
+		interval:  interval,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start 启动提醒触发任务，未配置间隔时不启动
+func (d *ReminderDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isRunning || d.interval <= 0 {
+		return
+	}
+
+	d.isRunning = true
+	d.heartbeat.beat()
+	log.Printf("提醒触发任务启动，检查间隔 %s", d.interval)
+	go d.run()
+}
+
+// Stop 停止提醒触发任务
+func (d *ReminderDispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.isRunning {
+		return
+	}
+
+	d.isRunning = false
+	close(d.stopChan)
+	log.Println("提醒触发任务停止")
+}
+
+// run 启动时先执行一次，之后按间隔执行
+func (d *ReminderDispatcher) run() {
+	d.Dispatch(context.Background(), time.Now())
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.heartbeat.beat()
+			d.Dispatch(context.Background(), time.Now())
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+// CheckAlive 就绪检查：提醒触发任务停止或长时间没有执行时返回错误，未配置间隔（不启用）时不检查
+func (d *ReminderDispatcher) CheckAlive(ctx context.Context) error {
+	if d.interval <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	running := d.isRunning
+	d.mu.Unlock()
+	return d.heartbeat.check("提醒触发任务", running, 3*d.interval+time.Minute)
+}
+
+// Dispatch 触发上次检查点之后、now之前（含）到达的提醒，返回触发的数量
+// 首次运行没有检查点时从一个检查间隔之前开始
+func (d *ReminderDispatcher) Dispatch(ctx context.Context, now time.Time) int {
+	raw, err := d.redis.Get(reminderCheckpointKey)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("读取提醒检查点失败: %v", err)
+		return 0
+	}
+
+	from := now.Add(-d.interval)
+	if raw != "" {
+		if from, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			log.Printf("提醒检查点格式错误: %v", err)
+			from = now.Add(-d.interval)
+		}
+	}
+	if from.Before(now.Add(-reminderMaxCatchUp)) {
+		from = now.Add(-reminderMaxCatchUp)
+	}
+	if !now.After(from) {
+		return 0
+	}
+
+	// 先推进检查点再查询，多个实例同时运行时只有一个会触发这段时间内的提醒
+	advanced, err := d.redis.Eval(reminderAdvanceScript, []string{reminderCheckpointKey}, raw, now.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		log.Printf("更新提醒检查点失败: %v", err)
+		return 0
+	}
+	if n, _ := advanced.(int64); n == 0 {
+		return 0
+	}
+
+	reminders, err := d.reminders.ListDueReminders(ctx, from, now)
+	if err != nil {
+		log.Printf("查询到期提醒失败: %v", err)
+		return 0
+	}
+	for _, reminder := range reminders {
+		d.events.Publish(ctx, reminder.Task.UserID, EventReminderFired, &ReminderFiredData{
+			ReminderID: reminder.ID,
+			RemindAt:   reminder.RemindAt,
+			Task:       toTaskResponse(&reminder.Task),
+		})
+	}
+	return len(reminders)
+}
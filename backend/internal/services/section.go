@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSectionNotFound = errors.New("分栏不存在")
+	ErrInvalidSection  = errors.New("分栏无效")
+)
+
+// DefaultSectionName 默认分栏的名称，默认分栏不入库，包含所有未指定分栏的任务
+const DefaultSectionName = "默认分栏"
+
+// SectionService 项目分栏服务
+type SectionService struct {
+	tx       repository.Transactor
+	sections repository.SectionRepository
+	projects repository.ProjectRepository
+	tasks    repository.TaskRepository
+}
+
+// NewSectionService 创建项目分栏服务实例
+func NewSectionService(tx repository.Transactor, sections repository.SectionRepository, projects repository.ProjectRepository, tasks repository.TaskRepository) *SectionService {
+	return &SectionService{
+		tx:       tx,
+		sections: sections,
+		projects: projects,
+		tasks:    tasks,
+	}
+}
+
+// CreateSectionRequest 创建分栏请求结构
+type CreateSectionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// UpdateSectionRequest 更新分栏请求结构，未提供的字段保持不变
+type UpdateSectionRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Collapsed *bool   `json:"collapsed"`
+}
+
+// SectionResponse 分栏响应结构
+type SectionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	ProjectID uuid.UUID `json:"projectId"`
+	Name      string    `json:"name"`
+	SortOrder string    `json:"sortOrder"`
+	Collapsed bool      `json:"collapsed"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BoardColumn 看板中的一列，默认分栏的ID为null且始终排在第一列
+type BoardColumn struct {
+	ID        *uuid.UUID      `json:"id"`
+	Name      string          `json:"name"`
+	Collapsed bool            `json:"collapsed"`
+	Default   bool            `json:"default"`
+	Tasks     []*TaskResponse `json:"tasks"`
+}
+
+// BoardResponse 项目看板响应结构
+type BoardResponse struct {
+	Project  *ProjectResponse `json:"project"`
+	Sections []*BoardColumn   `json:"sections"`
+}
+
+// ListSections 分页获取项目的分栏，默认按手动顺序排列
+func (s *SectionService) ListSections(ctx context.Context, userID, projectID uuid.UUID, query *pagination.Query) (*pagination.Page[*SectionResponse], error) {
+	if _, err := s.getProject(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+	req, err := query.Request(repository.SectionSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := s.sections.ListSections(ctx, userID, projectID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询分栏失败: %w", err)
+	}
+	sections, next := pagination.Trim(sections, req, func(section *models.Section) (interface{}, uuid.UUID) {
+		return repository.SectionSortValue(section, req.Sort.Field.Name), section.ID
+	})
+
+	page := &pagination.Page[*SectionResponse]{Items: make([]*SectionResponse, 0, len(sections)), NextCursor: next}
+	for _, section := range sections {
+		page.Items = append(page.Items, toSectionResponse(section))
+	}
+	return page, nil
+}
+
+// CreateSection 在项目末尾创建分栏
+func (s *SectionService) CreateSection(ctx context.Context, userID, projectID uuid.UUID, req *CreateSectionRequest) (*SectionResponse, error) {
+	if _, err := s.getProject(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+
+	sortOrder, err := sortOrderBetween(ctx, nil, nil, s.neighbor(projectID, uuid.Nil), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &models.Section{
+		UserID:    userID,
+		ProjectID: projectID,
+		Name:      strings.TrimSpace(req.Name),
+		SortOrder: sortOrder,
+	}
+	if err := s.sections.CreateSection(ctx, section); err != nil {
+		return nil, fmt.Errorf("创建分栏失败: %w", err)
+	}
+	return toSectionResponse(section), nil
+}
+
+// UpdateSection 修改分栏名称或折叠状态
+func (s *SectionService) UpdateSection(ctx context.Context, userID, projectID, id uuid.UUID, req *UpdateSectionRequest) (*SectionResponse, error) {
+	section, err := s.getSection(ctx, userID, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		section.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Collapsed != nil {
+		section.Collapsed = *req.Collapsed
+	}
+
+	if err := s.sections.UpdateSection(ctx, section); err != nil {
+		return nil, fmt.Errorf("更新分栏失败: %w", err)
+	}
+	return toSectionResponse(section), nil
+}
+
+// MoveSection 拖动排序分栏
+func (s *SectionService) MoveSection(ctx context.Context, userID, projectID, id uuid.UUID, req *MoveRequest) (*SectionResponse, error) {
+	section, err := s.getSection(ctx, userID, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.moveAnchor(ctx, userID, section, req.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.moveAnchor(ctx, userID, section, req.BeforeID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		rebalance := func(ctx context.Context) (map[uuid.UUID]string, error) {
+			return rebalanceProjectSections(ctx, s.sections, projectID)
+		}
+		key, err := sortOrderBetween(ctx, after, before, s.neighbor(projectID, section.ID), rebalance)
+		if err != nil {
+			return err
+		}
+		section.SortOrder = key
+		if err := s.sections.UpdateSection(ctx, section); err != nil {
+			return fmt.Errorf("移动分栏失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toSectionResponse(section), nil
+}
+
+// DeleteSection 删除分栏，其中的任务移到默认分栏
+func (s *SectionService) DeleteSection(ctx context.Context, userID, projectID, id uuid.UUID) error {
+	if _, err := s.getSection(ctx, userID, projectID, id); err != nil {
+		return err
+	}
+
+	if err := s.sections.DeleteSection(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除分栏失败: %w", err)
+	}
+	return nil
+}
+
+// GetBoard 获取项目看板：默认分栏和各分栏中按手动顺序排列的顶层任务
+// 项目的全部顶层任务通过一次查询取出，再按分栏分组
+func (s *SectionService) GetBoard(ctx context.Context, userID, projectID uuid.UUID) (*BoardResponse, error) {
+	project, err := s.getProject(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionReq, err := (&pagination.Query{}).Request(repository.SectionSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	sectionReq.Limit = 0
+	sections, err := s.sections.ListSections(ctx, userID, projectID, sectionReq)
+	if err != nil {
+		return nil, fmt.Errorf("查询分栏失败: %w", err)
+	}
+
+	page, err := (&pagination.Query{Sort: "manual"}).Request(repository.TaskSortFields, "manual")
+	if err != nil {
+		return nil, err
+	}
+	page.Limit = 0
+	tasks, err := s.tasks.ListTasks(ctx, userID, repository.TaskFilter{ProjectID: &projectID, TopLevel: true}, page)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	columns := make([]*BoardColumn, 0, len(sections)+1)
+	columns = append(columns, &BoardColumn{Name: DefaultSectionName, Default: true, Tasks: make([]*TaskResponse, 0)})
+	byID := make(map[uuid.UUID]*BoardColumn, len(sections))
+	for _, section := range sections {
+		id := section.ID
+		column := &BoardColumn{ID: &id, Name: section.Name, Collapsed: section.Collapsed, Tasks: make([]*TaskResponse, 0)}
+		byID[id] = column
+		columns = append(columns, column)
+	}
+	for _, task := range tasks {
+		column := columns[0]
+		if task.SectionID != nil && byID[*task.SectionID] != nil {
+			column = byID[*task.SectionID]
+		}
+		column.Tasks = append(column.Tasks, toTaskResponse(task))
+	}
+
+	return &BoardResponse{Project: toProjectResponse(project), Sections: columns}, nil
+}
+
+// neighbor 返回项目分栏的相邻排序键查询
+func (s *SectionService) neighbor(projectID, excludeID uuid.UUID) neighborFunc {
+	return func(ctx context.Context, key string, next bool) (string, error) {
+		return s.sections.SectionSortOrderNeighbor(ctx, projectID, key, next, excludeID)
+	}
+}
+
+// moveAnchor 获取移动时参照的分栏，必须是同一项目中的其他分栏
+func (s *SectionService) moveAnchor(ctx context.Context, userID uuid.UUID, section *models.Section, anchorID *uuid.UUID) (*sortAnchor, error) {
+	if anchorID == nil {
+		return nil, nil
+	}
+	if *anchorID == section.ID {
+		return nil, fmt.Errorf("%w: 不能以分栏自身为参照", ErrInvalidMove)
+	}
+
+	anchor, err := s.sections.GetSectionByID(ctx, userID, *anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("查询分栏失败: %w", err)
+	}
+	if anchor == nil || anchor.ProjectID != section.ProjectID {
+		return nil, fmt.Errorf("%w: 参照分栏不存在", ErrInvalidMove)
+	}
+	return &sortAnchor{ID: anchor.ID, Key: anchor.SortOrder}, nil
+}
+
+// getProject 获取用户的项目，不存在时返回ErrProjectNotFound
+func (s *SectionService) getProject(ctx context.Context, userID, projectID uuid.UUID) (*models.Project, error) {
+	project, err := s.projects.GetProjectByID(ctx, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// getSection 获取项目中的分栏，不存在时返回ErrSectionNotFound
+func (s *SectionService) getSection(ctx context.Context, userID, projectID, id uuid.UUID) (*models.Section, error) {
+	if _, err := s.getProject(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+
+	section, err := s.sections.GetSectionByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询分栏失败: %w", err)
+	}
+	if section == nil || section.ProjectID != projectID {
+		return nil, ErrSectionNotFound
+	}
+	return section, nil
+}
+
+// toSectionResponse 转换为分栏响应结构
+func toSectionResponse(section *models.Section) *SectionResponse {
+	return &SectionResponse{
+		ID:        section.ID,
+		ProjectID: section.ProjectID,
+		Name:      section.Name,
+		SortOrder: section.SortOrder,
+		Collapsed: section.Collapsed,
+		CreatedAt: section.CreatedAt,
+		UpdatedAt: section.UpdatedAt,
+	}
+}
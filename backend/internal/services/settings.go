@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+	"ticktick-backend/internal/settings"
+
+	"github.com/google/uuid"
+)
+
+// SettingsService 用户设置服务
+type SettingsService struct {
+	settings repository.UserSettingsRepository
+}
+
+// NewSettingsService 创建用户设置服务实例
+func NewSettingsService(settings repository.UserSettingsRepository) *SettingsService {
+	return &SettingsService{
+		settings: settings,
+	}
+}
+
+// SettingsResponse 用户设置响应结构，包含完整的设置文档，未保存过的字段为默认值
+type SettingsResponse struct {
+	*settings.Document
+	Version   int        `json:"version"`
+	UpdatedAt *time.Time `json:"updatedAt"` // 从未保存过设置时为null
+}
+
+// GetSettings 获取用户设置，尚未保存过时返回默认设置
+func (s *SettingsService) GetSettings(ctx context.Context, userID uuid.UUID) (*SettingsResponse, error) {
+	doc, stored, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SettingsResponse{Document: doc, Version: settings.CurrentVersion}
+	if stored != nil {
+		resp.UpdatedAt = &stored.UpdatedAt
+	}
+	return resp, nil
+}
+
+// UpdateSettings 将部分设置合并到当前设置中，校验通过后按当前版本保存完整文档
+func (s *SettingsService) UpdateSettings(ctx context.Context, userID uuid.UUID, patch []byte) (*SettingsResponse, error) {
+	doc, _, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = settings.Patch(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, userID, doc)
+}
+
+// RestoreSettings 用导出文件中的设置替换当前设置，旧版本的文档先升级到当前版本
+func (s *SettingsService) RestoreSettings(ctx context.Context, userID uuid.UUID, version int, raw []byte) error {
+	doc, err := settings.Decode(version, raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", settings.ErrInvalidSettings, err)
+	}
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	_, err = s.save(ctx, userID, doc)
+	return err
+}
+
+// save 按当前版本保存完整的设置文档
+func (s *SettingsService) save(ctx context.Context, userID uuid.UUID, doc *settings.Document) (*SettingsResponse, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("序列化设置失败: %w", err)
+	}
+
+	stored := &models.UserSettings{
+		UserID:   userID,
+		Version:  settings.CurrentVersion,
+		Document: string(data),
+	}
+	if err := s.settings.SaveUserSettings(ctx, stored); err != nil {
+		return nil, fmt.Errorf("保存设置失败: %w", err)
+	}
+	return &SettingsResponse{Document: doc, Version: stored.Version, UpdatedAt: &stored.UpdatedAt}, nil
+}
+
+// load 读取用户已保存的设置并升级到当前版本，返回设置文档和数据库记录
+func (s *SettingsService) load(ctx context.Context, userID uuid.UUID) (*settings.Document, *models.UserSettings, error) {
+	stored, err := s.settings.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询设置失败: %w", err)
+	}
+	if stored == nil {
+		return settings.Defaults(), nil, nil
+	}
+
+	doc, err := settings.Decode(stored.Version, []byte(stored.Document))
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取设置失败: %w", err)
+	}
+	return doc, stored, nil
+}
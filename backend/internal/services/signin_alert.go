@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ticktick-backend/config"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 撤销原因
+const (
+	RevokeReasonSignInRejected = "signin_rejected" // 用户确认登录不是本人操作
+	RevokeReasonPasswordReset  = "password_reset"  // 密码重置后撤销所有会话
+)
+
+// ErrInvalidSecurityToken 安全链接无效或已过期
+var ErrInvalidSecurityToken = errors.New("链接无效或已过期")
+
+// SignInAlert 新设备登录提醒
+type SignInAlert struct {
+	UserID     uuid.UUID  `json:"userId"`
+	TokenID    string     `json:"tokenId"`
+	DeviceInfo DeviceInfo `json:"deviceInfo"`
+	NewDevice  bool       `json:"newDevice"`
+	NewIP      bool       `json:"newIp"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// passwordResetInfo 密码重置令牌信息
+type passwordResetInfo struct {
+	UserID    uuid.UUID `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SignInAlertService 新设备登录检测服务
+type SignInAlertService struct {
+	redis       *RedisService
+	tokenStore  *TokenStore
+	userService *UserService
+	notifier    Notifier
+	config      *config.Config
+}
+
+// NewSignInAlertService 创建新设备登录检测服务
+func NewSignInAlertService(redisService *RedisService, tokenStore *TokenStore, userService *UserService, notifier Notifier, cfg *config.Config) *SignInAlertService {
+	return &SignInAlertService{
+		redis:       redisService,
+		tokenStore:  tokenStore,
+		userService: userService,
+		notifier:    notifier,
+		config:      cfg,
+	}
+}
+
+// Redis键名生成函数
+func (s *SignInAlertService) knownDevicesKey(userID uuid.UUID) string {
+	return fmt.Sprintf("known_devices:%s", userID.String())
+}
+
+func (s *SignInAlertService) knownIPsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("known_ips:%s", userID.String())
+}
+
+func (s *SignInAlertService) signInAlertKey(token string) string {
+	return fmt.Sprintf("signin_alert:%s", token)
+}
+
+func (s *SignInAlertService) passwordResetKey(token string) string {
+	return fmt.Sprintf("password_reset:%s", token)
+}
+
+// deviceFingerprint 生成设备指纹，同一浏览器在同一系统上视为同一设备
+func deviceFingerprint(device DeviceInfo) string {
+	sum := sha256.Sum256([]byte(device.Browser + "|" + device.OS + "|" + device.DeviceType))
+	return hex.EncodeToString(sum[:8])
+}
+
+// RememberDevice 将设备和IP记录为用户的已知设备
+func (s *SignInAlertService) RememberDevice(userID uuid.UUID, device DeviceInfo) error {
+	now := time.Now().Unix()
+	ttl := s.config.Security.KnownDeviceTTL
+
+	pipe := s.redis.Pipeline()
+	ctx := s.redis.GetContext()
+	pipe.HSet(ctx, s.knownDevicesKey(userID), deviceFingerprint(device), now)
+	pipe.Expire(ctx, s.knownDevicesKey(userID), ttl)
+	if device.IP != "" {
+		pipe.HSet(ctx, s.knownIPsKey(userID), device.IP, now)
+		pipe.Expire(ctx, s.knownIPsKey(userID), ttl)
+	}
+
+	if _, err := s.redis.ExecutePipeline(pipe); err != nil {
+		return fmt.Errorf("记录已知设备失败: %w", err)
+	}
+	return nil
+}
+
+// CheckSignIn 检查登录是否来自新设备或新IP，是则发送提醒
+// 用户没有任何已知设备记录时（如首次登录）只记录设备，不发送提醒
+func (s *SignInAlertService) CheckSignIn(user *UserResponse, tokenID string, device DeviceInfo) (*SignInAlert, error) {
+	exists, err := s.redis.Exists(s.knownDevicesKey(user.ID))
+	if err != nil {
+		return nil, fmt.Errorf("检查已知设备失败: %w", err)
+	}
+	if exists == 0 {
+		return nil, s.RememberDevice(user.ID, device)
+	}
+
+	knownDevice, err := s.redis.HExists(s.knownDevicesKey(user.ID), deviceFingerprint(device))
+	if err != nil {
+		return nil, fmt.Errorf("检查已知设备失败: %w", err)
+	}
+	knownIP := true
+	if device.IP != "" {
+		knownIP, err = s.redis.HExists(s.knownIPsKey(user.ID), device.IP)
+		if err != nil {
+			return nil, fmt.Errorf("检查已知IP失败: %w", err)
+		}
+	}
+
+	if err := s.RememberDevice(user.ID, device); err != nil {
+		return nil, err
+	}
+	if knownDevice && knownIP {
+		return nil, nil
+	}
+
+	alert := &SignInAlert{
+		UserID:     user.ID,
+		TokenID:    tokenID,
+		DeviceInfo: device,
+		NewDevice:  !knownDevice,
+		NewIP:      !knownIP,
+		CreatedAt:  time.Now(),
+	}
+
+	token, err := s.storeWithRandomToken(s.signInAlertKey, alert, s.config.Security.SignInAlertTTL)
+	if err != nil {
+		return nil, fmt.Errorf("保存登录提醒失败: %w", err)
+	}
+
+	notification := &NewSignInNotification{
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		DeviceInfo: device,
+		SignedInAt: alert.CreatedAt,
+		NewDevice:  alert.NewDevice,
+		NewIP:      alert.NewIP,
+		RejectURL:  s.config.Server.PublicURL + "/api/v1/auth/signin-alerts/" + token + "/reject",
+	}
+	if err := s.notifier.NotifyNewSignIn(notification); err != nil {
+		// 通知失败不影响登录
+		log.Printf("发送新设备登录通知失败: %v", err)
+	}
+
+	return alert, nil
+}
+
+// GetSignInAlert 获取登录提醒供用户确认，不消耗令牌也不改变任何状态
+// 邮件客户端的链接扫描和预取只会触发这一步
+func (s *SignInAlertService) GetSignInAlert(alertToken string) (*SignInAlert, error) {
+	var alert SignInAlert
+	if err := s.load(s.signInAlertKey(alertToken), &alert, false); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// RejectSignIn 处理用户确认的"不是我本人"操作：撤销该会话、移除设备记录并要求重置密码
+// 重置密码的链接只通过邮件发送，持有提醒链接的人无法直接重置密码
+func (s *SignInAlertService) RejectSignIn(ctx context.Context, alertToken string) (*SignInAlert, error) {
+	var alert SignInAlert
+	if err := s.load(s.signInAlertKey(alertToken), &alert, true); err != nil {
+		return nil, err
+	}
+
+	// 撤销可疑会话，会话可能已经过期或被撤销
+	if err := s.tokenStore.RevokeUserSession(alert.UserID, alert.TokenID, RevokeReasonSignInRejected); err != nil {
+		log.Printf("撤销可疑会话失败: %v", err)
+	}
+
+	// 从已知设备中移除
+	if err := s.redis.HDel(s.knownDevicesKey(alert.UserID), deviceFingerprint(alert.DeviceInfo)); err != nil {
+		log.Printf("移除已知设备失败: %v", err)
+	}
+	if alert.NewIP && alert.DeviceInfo.IP != "" {
+		if err := s.redis.HDel(s.knownIPsKey(alert.UserID), alert.DeviceInfo.IP); err != nil {
+			log.Printf("移除已知IP失败: %v", err)
+		}
+	}
+
+	if err := s.userService.RequirePasswordReset(ctx, alert.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := s.IssuePasswordReset(ctx, alert.UserID); err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+// IssuePasswordReset 生成密码重置令牌并通过邮件发送重置链接
+func (s *SignInAlertService) IssuePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ttl := s.config.Security.PasswordResetTTL
+	info := &passwordResetInfo{UserID: userID, CreatedAt: time.Now()}
+	token, err := s.storeWithRandomToken(s.passwordResetKey, info, ttl)
+	if err != nil {
+		return fmt.Errorf("保存密码重置令牌失败: %w", err)
+	}
+
+	notification := &PasswordResetNotification{
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		ResetURL:  s.config.Server.PublicURL + "/reset-password?token=" + token,
+		ExpiresAt: info.CreatedAt.Add(ttl),
+	}
+	if err := s.notifier.NotifyPasswordReset(notification); err != nil {
+		log.Printf("发送密码重置通知失败: %v", err)
+	}
+
+	return nil
+}
+
+// ResetPassword 使用重置令牌设置新密码，并撤销该用户的所有会话
+func (s *SignInAlertService) ResetPassword(ctx context.Context, token string, newPassword string) (uuid.UUID, error) {
+	var info passwordResetInfo
+	if err := s.load(s.passwordResetKey(token), &info, true); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.userService.ResetPassword(ctx, info.UserID, newPassword); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.tokenStore.RevokeAllUserTokens(info.UserID, RevokeReasonPasswordReset); err != nil {
+		return uuid.Nil, fmt.Errorf("撤销用户会话失败: %w", err)
+	}
+
+	return info.UserID, nil
+}
+
+// storeWithRandomToken 以随机令牌为键存储数据
+func (s *SignInAlertService) storeWithRandomToken(keyFunc func(string) string, value interface{}, ttl time.Duration) (string, error) {
+	token, err := newRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(keyFunc(token), data, ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// load 读取一次性令牌对应的数据，consume为true时同时删除令牌
+func (s *SignInAlertService) load(key string, out interface{}, consume bool) error {
+	var data string
+	var err error
+	if consume {
+		data, err = s.redis.GetDel(key)
+	} else {
+		data, err = s.redis.Get(key)
+	}
+	if err != nil {
+		if err == redis.Nil {
+			return ErrInvalidSecurityToken
+		}
+		return fmt.Errorf("读取令牌失败: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(data), out); err != nil {
+		return fmt.Errorf("反序列化令牌信息失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/filter"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSmartListNotFound   = errors.New("智能清单不存在")
+	ErrSmartListNameExists = errors.New("智能清单名称已存在")
+	ErrInvalidFilter       = errors.New("筛选表达式无效")
+)
+
+// builtinSmartList 内置智能清单，与自定义清单使用同一套筛选引擎
+type builtinSmartList struct {
+	ID    string
+	Name  string
+	Query string
+}
+
+// builtinSmartLists 内置清单，逾期未完成的任务也会出现在其中
+var builtinSmartLists = []builtinSmartList{
+	{ID: "today", Name: "今天", Query: "due:<=today AND status:incomplete"},
+	{ID: "next7days", Name: "最近7天", Query: "due:<7d AND status:incomplete"},
+}
+
+// SmartListService 智能清单服务
+type SmartListService struct {
+	smartLists repository.SmartListRepository
+	tasks      repository.TaskRepository
+	settings   *SettingsService
+}
+
+// NewSmartListService 创建智能清单服务实例
+func NewSmartListService(smartLists repository.SmartListRepository, tasks repository.TaskRepository, settings *SettingsService) *SmartListService {
+	return &SmartListService{
+		smartLists: smartLists,
+		tasks:      tasks,
+		settings:   settings,
+	}
+}
+
+// SmartListRequest 创建或更新智能清单请求结构
+type SmartListRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Query string `json:"query" binding:"required,max=500"`
+}
+
+// SmartListResponse 智能清单响应结构，内置清单的ID为固定字符串
+type SmartListResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Query     string     `json:"query"`
+	Builtin   bool       `json:"builtin"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ListSmartLists 分页获取用户自定义的清单，默认按创建时间排序
+// 内置清单数量固定，放在第一页开头返回，不占用limit
+func (s *SmartListService) ListSmartLists(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*SmartListResponse], error) {
+	req, err := query.Request(repository.SmartListSortFields, "created")
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := s.smartLists.ListSmartLists(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询智能清单失败: %w", err)
+	}
+	lists, next := pagination.Trim(lists, req, func(list *models.SmartList) (interface{}, uuid.UUID) {
+		return repository.SmartListSortValue(list, req.Sort.Field.Name), list.ID
+	})
+
+	page := &pagination.Page[*SmartListResponse]{Items: make([]*SmartListResponse, 0, len(builtinSmartLists)+len(lists)), NextCursor: next}
+	if req.After == nil {
+		for _, builtin := range builtinSmartLists {
+			page.Items = append(page.Items, builtin.toResponse())
+		}
+	}
+	for _, list := range lists {
+		page.Items = append(page.Items, toSmartListResponse(list))
+	}
+	return page, nil
+}
+
+// CreateSmartList 创建智能清单
+func (s *SmartListService) CreateSmartList(ctx context.Context, userID uuid.UUID, req *SmartListRequest) (*SmartListResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	query := strings.TrimSpace(req.Query)
+	if _, err := parseFilter(query); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameAvailable(ctx, userID, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	list := &models.SmartList{
+		UserID: userID,
+		Name:   name,
+		Query:  query,
+	}
+	if err := s.smartLists.CreateSmartList(ctx, list); err != nil {
+		return nil, fmt.Errorf("创建智能清单失败: %w", err)
+	}
+	return toSmartListResponse(list), nil
+}
+
+// UpdateSmartList 修改智能清单的名称和筛选表达式
+func (s *SmartListService) UpdateSmartList(ctx context.Context, userID, id uuid.UUID, req *SmartListRequest) (*SmartListResponse, error) {
+	list, err := s.getSmartList(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	query := strings.TrimSpace(req.Query)
+	if _, err := parseFilter(query); err != nil {
+		return nil, err
+	}
+	if name != list.Name {
+		if err := s.checkNameAvailable(ctx, userID, name, list.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	list.Name = name
+	list.Query = query
+	if err := s.smartLists.UpdateSmartList(ctx, list); err != nil {
+		return nil, fmt.Errorf("更新智能清单失败: %w", err)
+	}
+	return toSmartListResponse(list), nil
+}
+
+// DeleteSmartList 删除智能清单
+func (s *SmartListService) DeleteSmartList(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getSmartList(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if err := s.smartLists.DeleteSmartList(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除智能清单失败: %w", err)
+	}
+	return nil
+}
+
+// ListSmartListTasks 分页获取满足智能清单条件的任务，id可以是内置清单的ID或自定义清单的UUID
+func (s *SmartListService) ListSmartListTasks(ctx context.Context, userID uuid.UUID, id string, query *pagination.Query) (*SmartListResponse, *pagination.Page[*TaskResponse], error) {
+	list, err := s.resolveSmartList(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expr, err := parseFilter(list.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 今天、7d等相对日期和日期边界按用户设置的时区计算
+	prefs, err := s.settings.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	page, err := listTaskPage(ctx, s.tasks, userID, repository.TaskFilter{Expr: expr, Now: time.Now().In(loc)}, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, page, nil
+}
+
+// resolveSmartList 根据ID查找内置清单或用户的自定义清单
+func (s *SmartListService) resolveSmartList(ctx context.Context, userID uuid.UUID, id string) (*SmartListResponse, error) {
+	for _, builtin := range builtinSmartLists {
+		if builtin.ID == id {
+			return builtin.toResponse(), nil
+		}
+	}
+
+	listID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrSmartListNotFound
+	}
+	list, err := s.getSmartList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	return toSmartListResponse(list), nil
+}
+
+// getSmartList 获取用户的智能清单，不存在时返回ErrSmartListNotFound
+func (s *SmartListService) getSmartList(ctx context.Context, userID, id uuid.UUID) (*models.SmartList, error) {
+	list, err := s.smartLists.GetSmartListByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询智能清单失败: %w", err)
+	}
+	if list == nil {
+		return nil, ErrSmartListNotFound
+	}
+	return list, nil
+}
+
+// checkNameAvailable 检查清单名称是否可用，不能与内置清单重名
+func (s *SmartListService) checkNameAvailable(ctx context.Context, userID uuid.UUID, name string, excludeID uuid.UUID) error {
+	for _, builtin := range builtinSmartLists {
+		if builtin.Name == name {
+			return ErrSmartListNameExists
+		}
+	}
+
+	exists, err := s.smartLists.SmartListNameExists(ctx, userID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("检查智能清单名称失败: %w", err)
+	}
+	if exists {
+		return ErrSmartListNameExists
+	}
+	return nil
+}
+
+// parseFilter 解析筛选表达式，语法错误包装为ErrInvalidFilter
+func parseFilter(query string) (filter.Expr, error) {
+	expr, err := filter.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return expr, nil
+}
+
+// toResponse 转换为智能清单响应结构
+func (b builtinSmartList) toResponse() *SmartListResponse {
+	return &SmartListResponse{
+		ID:      b.ID,
+		Name:    b.Name,
+		Query:   b.Query,
+		Builtin: true,
+	}
+}
+
+// toSmartListResponse 转换为智能清单响应结构
+func toSmartListResponse(list *models.SmartList) *SmartListResponse {
+	return &SmartListResponse{
+		ID:        list.ID.String(),
+		Name:      list.Name,
+		Query:     list.Query,
+		CreatedAt: &list.CreatedAt,
+		UpdatedAt: &list.UpdatedAt,
+	}
+}
@@ -0,0 +1,637 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSyncMutation 客户端提交的修改无效，只出现在单条修改的处理结果中
+var ErrInvalidSyncMutation = errors.New("同步修改无效")
+
+var (
+	errSyncEntityDeleted     = errors.New("记录已被删除")
+	errSyncIDConflict        = errors.New("该ID已被其他记录使用")
+	errSyncReminderImmutable = errors.New("提醒创建后不能修改，请删除后重新创建")
+)
+
+const (
+	// DefaultSyncLimit 每次拉取的默认变更条数
+	DefaultSyncLimit = 500
+	// MaxSyncLimit 每次拉取的变更条数上限
+	MaxSyncLimit = 1000
+)
+
+// 修改的操作类型
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+)
+
+// 冲突处理策略：lww按修改时间由后写入者胜出，report保留服务端的值并把冲突返回给客户端
+const (
+	SyncStrategyLWW    = "lww"
+	SyncStrategyReport = "report"
+)
+
+// 修改的处理结果
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict" // 存在冲突字段，其余字段已应用
+	SyncStatusRejected = "rejected"
+)
+
+// 冲突字段最终采用的值
+const (
+	SyncResolutionClient = "client"
+	SyncResolutionServer = "server"
+)
+
+// syncFields 各类型可以通过同步修改的字段，与对应响应结构中的字段名一致
+var syncFields = map[string][]string{
+	models.SyncEntityProject: {"name", "color", "groupId"},
+	models.SyncEntityLabel:   {"name"},
+	models.SyncEntityTask: {"projectId", "parentId", "sectionId", "title", "description", "priority",
+		"startTime", "dueTime", "rruleString", "labelIds", "status"},
+	models.SyncEntityReminder: {"taskId", "remindAt"},
+}
+
+// SyncService 增量同步服务，供离线优先的客户端拉取变更和批量提交本地修改
+// 变更序号由数据层在每次写入时递增，删除的记录以墓碑形式返回
+type SyncService struct {
+	sync      repository.SyncRepository
+	projects  *ProjectService
+	labels    *LabelService
+	tasks     *TaskService
+	reminders *ReminderService
+}
+
+// NewSyncService 创建增量同步服务，客户端的修改通过各实体的服务写入，校验和事件与普通接口一致
+func NewSyncService(sync repository.SyncRepository, projects *ProjectService, labels *LabelService, tasks *TaskService, reminders *ReminderService) *SyncService {
+	return &SyncService{
+		sync:      sync,
+		projects:  projects,
+		labels:    labels,
+		tasks:     tasks,
+		reminders: reminders,
+	}
+}
+
+// SyncPullQuery 拉取变更的查询参数，since为空表示首次同步
+type SyncPullQuery struct {
+	Since string `form:"since"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// SyncTombstone 已删除记录的墓碑
+type SyncTombstone struct {
+	Type string    `json:"type"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// RecurrenceExceptionResponse 循环例外响应结构，newTaskId为空表示删除了该次实例
+type RecurrenceExceptionResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	RecurringTaskID uuid.UUID  `json:"recurringTaskId"`
+	OriginalTime    time.Time  `json:"originalTime"`
+	NewTaskID       *uuid.UUID `json:"newTaskId,omitempty"`
+}
+
+// SyncPullResponse 拉取的变更，每个记录只返回最新状态
+// hasMore为true时应使用syncToken继续拉取，直到没有更多变更
+type SyncPullResponse struct {
+	SyncToken  string                         `json:"syncToken"`
+	HasMore    bool                           `json:"hasMore"`
+	Projects   []*ProjectResponse             `json:"projects"`
+	Labels     []*LabelResponse               `json:"labels"`
+	Tasks      []*TaskResponse                `json:"tasks"`
+	Reminders  []*ReminderResponse            `json:"reminders"`
+	Exceptions []*RecurrenceExceptionResponse `json:"exceptions"`
+	Deleted    []*SyncTombstone               `json:"deleted"`
+}
+
+// SyncPushRequest 批量提交客户端修改，修改按顺序逐条处理，每条的结果互不影响
+type SyncPushRequest struct {
+	ConflictStrategy string          `json:"conflictStrategy" binding:"omitempty,oneof=lww report"`
+	Mutations        []*SyncMutation `json:"mutations" binding:"required,min=1,max=200,dive"`
+}
+
+// SyncMutation 客户端的一条修改，ID由客户端生成，重复提交同一修改是安全的
+// Fields只包含修改过的字段；BaseToken是客户端修改时的同步令牌，Base是修改前的字段值，
+// 两者用于判断服务端是否也修改了同一字段；UpdatedAt是客户端修改的时间，用于最后写入者胜出
+type SyncMutation struct {
+	Type      string                     `json:"type" binding:"required,oneof=project label task reminder"`
+	ID        uuid.UUID                  `json:"id" binding:"required"`
+	Op        string                     `json:"op" binding:"required,oneof=upsert delete"`
+	BaseToken string                     `json:"baseToken"`
+	UpdatedAt *time.Time                 `json:"updatedAt"`
+	Fields    map[string]json.RawMessage `json:"fields"`
+	Base      map[string]json.RawMessage `json:"base"`
+}
+
+// SyncFieldConflict 客户端和服务端都修改了的字段
+type SyncFieldConflict struct {
+	Field       string          `json:"field"`
+	ClientValue json.RawMessage `json:"clientValue"`
+	ServerValue json.RawMessage `json:"serverValue"`
+	Resolution  string          `json:"resolution"`
+}
+
+// SyncMutationResult 一条修改的处理结果
+type SyncMutationResult struct {
+	Type      string               `json:"type"`
+	ID        uuid.UUID            `json:"id"`
+	Status    string               `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	Conflicts []*SyncFieldConflict `json:"conflicts,omitempty"`
+}
+
+// SyncPushResponse 批量提交的处理结果，与请求中的修改一一对应
+type SyncPushResponse struct {
+	Results []*SyncMutationResult `json:"results"`
+}
+
+// Pull 获取since之后的变更，since为空时返回全部数据且不包含墓碑
+func (s *SyncService) Pull(ctx context.Context, userID uuid.UUID, query *SyncPullQuery) (*SyncPullResponse, error) {
+	since, err := parseSyncToken(query.Since)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := s.sync.GetSyncSeq(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更序号失败: %w", err)
+	}
+	if since > seq {
+		return nil, ErrInvalidSyncToken
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultSyncLimit
+	}
+	changes, err := s.sync.ListSyncChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更失败: %w", err)
+	}
+
+	resp := &SyncPullResponse{
+		SyncToken:  formatSyncToken(since),
+		Projects:   make([]*ProjectResponse, 0),
+		Labels:     make([]*LabelResponse, 0),
+		Tasks:      make([]*TaskResponse, 0),
+		Reminders:  make([]*ReminderResponse, 0),
+		Exceptions: make([]*RecurrenceExceptionResponse, 0),
+		Deleted:    make([]*SyncTombstone, 0),
+	}
+	if len(changes) > limit {
+		resp.HasMore = true
+		changes = changes[:limit]
+	}
+	if len(changes) > 0 {
+		resp.SyncToken = formatSyncToken(changes[len(changes)-1].Seq)
+	}
+
+	ids := make(map[string][]uuid.UUID)
+	for _, change := range changes {
+		if change.Deleted {
+			resp.Deleted = append(resp.Deleted, &SyncTombstone{Type: change.EntityType, ID: change.EntityID})
+		} else {
+			ids[change.EntityType] = append(ids[change.EntityType], change.EntityID)
+		}
+	}
+	entities, err := s.sync.GetSyncEntities(ctx, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更的记录失败: %w", err)
+	}
+
+	found := make(map[uuid.UUID]bool)
+	for _, project := range entities.Projects {
+		resp.Projects = append(resp.Projects, toProjectResponse(project))
+		found[project.ID] = true
+	}
+	for _, label := range entities.Labels {
+		resp.Labels = append(resp.Labels, toLabelResponse(label))
+		found[label.ID] = true
+	}
+	for _, task := range entities.Tasks {
+		resp.Tasks = append(resp.Tasks, toTaskResponse(task))
+		found[task.ID] = true
+	}
+	for _, reminder := range entities.Reminders {
+		resp.Reminders = append(resp.Reminders, toReminderResponse(reminder))
+		found[reminder.ID] = true
+	}
+	for _, exception := range entities.Exceptions {
+		resp.Exceptions = append(resp.Exceptions, toRecurrenceExceptionResponse(exception))
+		found[exception.ID] = true
+	}
+	// 所属任务已删除的提醒和例外不再可见，同样作为删除返回
+	for _, change := range changes {
+		if !change.Deleted && !found[change.EntityID] {
+			resp.Deleted = append(resp.Deleted, &SyncTombstone{Type: change.EntityType, ID: change.EntityID})
+		}
+	}
+	// 首次同步时客户端没有任何数据，不需要墓碑
+	if since == 0 {
+		resp.Deleted = resp.Deleted[:0]
+	}
+	return resp, nil
+}
+
+// Push 按顺序应用客户端的修改
+// 每条修改单独写入，处理到一半出现内部错误时之前的修改已经生效，客户端可以原样重试整批修改
+func (s *SyncService) Push(ctx context.Context, userID uuid.UUID, req *SyncPushRequest) (*SyncPushResponse, error) {
+	strategy := req.ConflictStrategy
+	if strategy == "" {
+		strategy = SyncStrategyLWW
+	}
+
+	resp := &SyncPushResponse{Results: make([]*SyncMutationResult, 0, len(req.Mutations))}
+	for _, mutation := range req.Mutations {
+		result, err := s.apply(ctx, userID, mutation, strategy)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// apply 应用一条修改，客户端导致的错误记录在结果中，其余错误直接返回
+func (s *SyncService) apply(ctx context.Context, userID uuid.UUID, mutation *SyncMutation, strategy string) (*SyncMutationResult, error) {
+	result := &SyncMutationResult{Type: mutation.Type, ID: mutation.ID, Status: SyncStatusApplied}
+
+	change, err := s.sync.GetSyncChange(ctx, mutation.Type, mutation.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更记录失败: %w", err)
+	}
+
+	switch {
+	case change != nil && change.UserID != userID:
+		err = errSyncIDConflict
+	case mutation.Op == SyncOpDelete:
+		err = s.delete(ctx, userID, mutation, change)
+	case change != nil && change.Deleted:
+		err = errSyncEntityDeleted
+	default:
+		err = checkSyncFields(mutation)
+		if err == nil && change == nil {
+			err = s.create(ctx, userID, mutation)
+		} else if err == nil {
+			err = s.update(ctx, userID, mutation, change, strategy, result)
+		}
+	}
+	if err != nil {
+		if !isSyncClientError(err) {
+			return nil, err
+		}
+		result.Status = SyncStatusRejected
+		result.Error = err.Error()
+		result.Conflicts = nil
+	}
+	return result, nil
+}
+
+// create 使用客户端生成的ID创建记录
+func (s *SyncService) create(ctx context.Context, userID uuid.UUID, mutation *SyncMutation) error {
+	switch mutation.Type {
+	case models.SyncEntityProject:
+		req := &CreateProjectRequest{}
+		if err := decodeSyncFields(mutation.Fields, req); err != nil {
+			return err
+		}
+		req.ID = mutation.ID
+		_, err := s.projects.CreateProject(ctx, userID, req)
+		return err
+	case models.SyncEntityLabel:
+		req := &LabelRequest{}
+		if err := decodeSyncFields(mutation.Fields, req); err != nil {
+			return err
+		}
+		req.ID = mutation.ID
+		_, err := s.labels.CreateLabel(ctx, userID, req)
+		return err
+	case models.SyncEntityTask:
+		fields, status, err := splitTaskStatus(mutation.Fields)
+		if err != nil {
+			return err
+		}
+		req := &CreateTaskRequest{}
+		if err := decodeSyncFields(fields, req); err != nil {
+			return err
+		}
+		req.ID = mutation.ID
+		if _, err := s.tasks.CreateTask(ctx, userID, req); err != nil {
+			return err
+		}
+		if status == models.TaskStatusCompleted {
+			_, err = s.tasks.CompleteTask(ctx, userID, mutation.ID)
+		}
+		return err
+	default:
+		req := &struct {
+			TaskID uuid.UUID `json:"taskId" binding:"required"`
+			CreateReminderRequest
+		}{}
+		if err := decodeSyncFields(mutation.Fields, req); err != nil {
+			return err
+		}
+		req.ID = mutation.ID
+		_, err := s.reminders.CreateReminder(ctx, userID, req.TaskID, &req.CreateReminderRequest)
+		return err
+	}
+}
+
+// update 按字段合并客户端的修改
+// 记录在客户端的BaseToken之后没有变化，或字段的服务端值仍等于Base中的值时，直接采用客户端的值；
+// 否则服务端也修改了该字段，lww策略下客户端的修改时间晚于服务端时采用客户端的值，其余情况保留服务端的值
+func (s *SyncService) update(ctx context.Context, userID uuid.UUID, mutation *SyncMutation, change *models.SyncChange, strategy string, result *SyncMutationResult) error {
+	current, err := s.currentFields(ctx, userID, mutation.Type, mutation.ID)
+	if err != nil {
+		return err
+	}
+
+	concurrent := true
+	if mutation.BaseToken != "" {
+		base, err := parseSyncToken(mutation.BaseToken)
+		if err != nil {
+			return fmt.Errorf("%w: baseToken格式错误", ErrInvalidSyncMutation)
+		}
+		concurrent = change.Seq > base
+	}
+	clientWins := strategy == SyncStrategyLWW && mutation.UpdatedAt != nil && mutation.UpdatedAt.After(change.ChangedAt)
+
+	names := make([]string, 0, len(mutation.Fields))
+	for name := range mutation.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	accepted := make(map[string]json.RawMessage)
+	for _, name := range names {
+		value, server := mutation.Fields[name], current[name]
+		if syncValueEqual(value, server) {
+			continue
+		}
+		if base, ok := mutation.Base[name]; concurrent && (!ok || !syncValueEqual(base, server)) {
+			conflict := &SyncFieldConflict{Field: name, ClientValue: value, ServerValue: server, Resolution: SyncResolutionServer}
+			if clientWins {
+				conflict.Resolution = SyncResolutionClient
+			}
+			result.Conflicts = append(result.Conflicts, conflict)
+			if !clientWins {
+				continue
+			}
+		}
+		accepted[name] = value
+	}
+	if len(result.Conflicts) > 0 {
+		result.Status = SyncStatusConflict
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+
+	switch mutation.Type {
+	case models.SyncEntityProject:
+		req := &UpdateProjectRequest{}
+		if err := decodeSyncFields(accepted, req); err != nil {
+			return err
+		}
+		_, err = s.projects.UpdateProject(ctx, userID, mutation.ID, req)
+		return err
+	case models.SyncEntityLabel:
+		req := &LabelRequest{}
+		if err := decodeSyncFields(accepted, req); err != nil {
+			return err
+		}
+		_, err = s.labels.UpdateLabel(ctx, userID, mutation.ID, req)
+		return err
+	case models.SyncEntityTask:
+		fields, status, err := splitTaskStatus(accepted)
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			req := &UpdateTaskRequest{}
+			if err := decodeSyncFields(fields, req); err != nil {
+				return err
+			}
+			if _, err := s.tasks.UpdateTask(ctx, userID, mutation.ID, req); err != nil {
+				return err
+			}
+		}
+		switch status {
+		case models.TaskStatusCompleted:
+			_, err = s.tasks.CompleteTask(ctx, userID, mutation.ID)
+		case models.TaskStatusIncomplete:
+			_, err = s.tasks.ReopenTask(ctx, userID, mutation.ID)
+		}
+		return err
+	default:
+		return errSyncReminderImmutable
+	}
+}
+
+// delete 删除记录，删除优先于服务端并发的修改；记录已删除或不存在时视为成功，重复提交是安全的
+func (s *SyncService) delete(ctx context.Context, userID uuid.UUID, mutation *SyncMutation, change *models.SyncChange) error {
+	if change == nil || change.Deleted {
+		return nil
+	}
+
+	var err error
+	switch mutation.Type {
+	case models.SyncEntityProject:
+		err = s.projects.DeleteProject(ctx, userID, mutation.ID)
+	case models.SyncEntityLabel:
+		err = s.labels.DeleteLabel(ctx, userID, mutation.ID)
+	case models.SyncEntityTask:
+		err = s.tasks.DeleteTask(ctx, userID, mutation.ID)
+	default:
+		entities, loadErr := s.sync.GetSyncEntities(ctx, userID, map[string][]uuid.UUID{models.SyncEntityReminder: {mutation.ID}})
+		if loadErr != nil {
+			return fmt.Errorf("查询提醒失败: %w", loadErr)
+		}
+		if len(entities.Reminders) == 0 {
+			return nil
+		}
+		err = s.reminders.DeleteReminder(ctx, userID, entities.Reminders[0].TaskID, mutation.ID)
+	}
+	if errors.Is(err, ErrProjectNotFound) || errors.Is(err, ErrLabelNotFound) ||
+		errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrReminderNotFound) {
+		return nil
+	}
+	return err
+}
+
+// currentFields 获取记录当前可同步字段的值，字段名与请求中的一致
+func (s *SyncService) currentFields(ctx context.Context, userID uuid.UUID, entityType string, id uuid.UUID) (map[string]json.RawMessage, error) {
+	entities, err := s.sync.GetSyncEntities(ctx, userID, map[string][]uuid.UUID{entityType: {id}})
+	if err != nil {
+		return nil, fmt.Errorf("查询记录失败: %w", err)
+	}
+
+	var current any
+	var labelIDs []uuid.UUID
+	switch {
+	case len(entities.Projects) > 0:
+		current = toProjectResponse(entities.Projects[0])
+	case len(entities.Labels) > 0:
+		current = toLabelResponse(entities.Labels[0])
+	case len(entities.Tasks) > 0:
+		current = toTaskResponse(entities.Tasks[0])
+		labelIDs = make([]uuid.UUID, 0, len(entities.Tasks[0].Labels))
+		for _, label := range entities.Tasks[0].Labels {
+			labelIDs = append(labelIDs, label.ID)
+		}
+	case len(entities.Reminders) > 0:
+		current = toReminderResponse(entities.Reminders[0])
+	default:
+		// 所属任务已删除的提醒
+		return nil, errSyncEntityDeleted
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if labelIDs != nil {
+		if fields["labelIds"], err = json.Marshal(labelIDs); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// checkSyncFields 检查修改中的字段是否都可以同步
+func checkSyncFields(mutation *SyncMutation) error {
+	for name := range mutation.Fields {
+		if !slices.Contains(syncFields[mutation.Type], name) {
+			return fmt.Errorf("%w: 不支持的字段%s", ErrInvalidSyncMutation, name)
+		}
+	}
+	return nil
+}
+
+// splitTaskStatus 取出任务的status字段，完成状态通过完成和重新打开接口修改
+func splitTaskStatus(fields map[string]json.RawMessage) (map[string]json.RawMessage, models.TaskStatus, error) {
+	raw, ok := fields["status"]
+	if !ok {
+		return fields, "", nil
+	}
+
+	var status models.TaskStatus
+	if err := json.Unmarshal(raw, &status); err != nil || (status != models.TaskStatusCompleted && status != models.TaskStatusIncomplete) {
+		return nil, "", fmt.Errorf("%w: status必须是incomplete或completed", ErrInvalidSyncMutation)
+	}
+	rest := make(map[string]json.RawMessage, len(fields)-1)
+	for name, value := range fields {
+		if name != "status" {
+			rest[name] = value
+		}
+	}
+	return rest, status, nil
+}
+
+// decodeSyncFields 把字段转换为请求结构，并使用与普通接口相同的校验规则
+func decodeSyncFields(fields map[string]json.RawMessage, req any) error {
+	data, err := json.Marshal(fields)
+	if err == nil {
+		err = json.Unmarshal(data, req)
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(req)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSyncMutation, err)
+	}
+	return nil
+}
+
+// isSyncClientError 判断是否为客户端修改导致的错误
+func isSyncClientError(err error) bool {
+	for _, target := range []error{
+		ErrInvalidSyncMutation, errSyncEntityDeleted, errSyncIDConflict, errSyncReminderImmutable,
+		ErrProjectNotFound, ErrTaskNotFound, ErrLabelNotFound, ErrReminderNotFound,
+		ErrSectionNotFound, ErrProjectGroupNotFound, ErrProjectNameExists, ErrLabelNameExists,
+		ErrInvalidParentTask, ErrInvalidSection, ErrInvalidProjectGroup,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncValueEqual 比较两个字段值，缺失的值视为null
+func syncValueEqual(a, b json.RawMessage) bool {
+	return reflect.DeepEqual(normalizeSyncValue(a), normalizeSyncValue(b))
+}
+
+// normalizeSyncValue 解析字段值用于比较：时间统一为UTC，数组按元素排序，同一个值的不同写法视为相等
+func normalizeSyncValue(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC()
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		sort.Strings(items)
+		return items
+	}
+	return value
+}
+
+// parseSyncToken 解析同步令牌，令牌是变更序号，为空表示从头同步
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidSyncToken
+	}
+	return seq, nil
+}
+
+// formatSyncToken 生成同步令牌
+func formatSyncToken(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+// toRecurrenceExceptionResponse 转换为循环例外响应结构
+func toRecurrenceExceptionResponse(exception *models.TaskRecurrenceException) *RecurrenceExceptionResponse {
+	return &RecurrenceExceptionResponse{
+		ID:              exception.ID,
+		RecurringTaskID: exception.RecurringTaskID,
+		OriginalTime:    exception.OriginalTime,
+		NewTaskID:       exception.NewTaskID,
+	}
+}
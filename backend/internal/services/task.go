@@ -0,0 +1,683 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTaskNotFound      = errors.New("任务不存在")
+	ErrInvalidParentTask = errors.New("父任务无效")
+	ErrInvalidTaskQuery  = errors.New("任务查询参数无效")
+)
+
+// DefaultTaskPriority 默认任务优先级（最低）
+const DefaultTaskPriority = 4
+
+// TaskService 任务服务
+type TaskService struct {
+	tx       repository.Transactor
+	tasks    repository.TaskRepository
+	projects repository.ProjectRepository
+	labels   repository.LabelRepository
+	sections repository.SectionRepository
+	events   *EventBus
+}
+
+// NewTaskService 创建任务服务实例，events为nil时不发布事件
+func NewTaskService(tx repository.Transactor, tasks repository.TaskRepository, projects repository.ProjectRepository, labels repository.LabelRepository, sections repository.SectionRepository, events *EventBus) *TaskService {
+	return &TaskService{
+		tx:       tx,
+		tasks:    tasks,
+		projects: projects,
+		labels:   labels,
+		sections: sections,
+		events:   events,
+	}
+}
+
+// CreateTaskRequest 创建任务请求结构
+type CreateTaskRequest struct {
+	ID          uuid.UUID   `json:"-"` // 增量同步时使用客户端生成的ID，为空时自动生成
+	ProjectID   uuid.UUID   `json:"projectId" binding:"required"`
+	ParentID    *uuid.UUID  `json:"parentId"`
+	SectionID   *uuid.UUID  `json:"sectionId"` // 只有顶层任务可以指定分栏
+	Title       string      `json:"title" binding:"required,max=255"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority" binding:"omitempty,min=1,max=4"`
+	StartTime   *time.Time  `json:"startTime"`
+	DueTime     *time.Time  `json:"dueTime"`
+	RRuleString string      `json:"rruleString"`
+	LabelIDs    []uuid.UUID `json:"labelIds"`
+}
+
+// UpdateTaskRequest 更新任务请求结构，未提供的字段保持不变，可选字段传null表示清空
+type UpdateTaskRequest struct {
+	ProjectID   *uuid.UUID            `json:"projectId"`
+	ParentID    Optional[uuid.UUID]   `json:"parentId"`
+	SectionID   Optional[uuid.UUID]   `json:"sectionId"`
+	Title       *string               `json:"title" binding:"omitempty,min=1,max=255"`
+	Description *string               `json:"description"`
+	Priority    *int                  `json:"priority" binding:"omitempty,min=1,max=4"`
+	StartTime   Optional[time.Time]   `json:"startTime"`
+	DueTime     Optional[time.Time]   `json:"dueTime"`
+	RRuleString Optional[string]      `json:"rruleString"`
+	LabelIDs    Optional[[]uuid.UUID] `json:"labelIds"`
+}
+
+// MoveTaskRequest 拖动排序任务请求，sectionId用于移动到分栏，传null表示默认分栏
+// 提供参照任务时分栏与参照任务相同，不提供时移到该分栏末尾
+type MoveTaskRequest struct {
+	MoveRequest
+	SectionID Optional[uuid.UUID] `json:"sectionId"`
+}
+
+// TaskListQuery 任务列表查询参数
+type TaskListQuery struct {
+	ProjectID string `form:"projectId"`
+	ParentID  string `form:"parentId"`
+	Status    string `form:"status" binding:"omitempty,oneof=incomplete completed"`
+	Priority  int    `form:"priority" binding:"omitempty,min=1,max=4"`
+	DueFrom   string `form:"dueFrom"`
+	DueTo     string `form:"dueTo"`
+	Filter    string `form:"filter" binding:"omitempty,max=500"` // 筛选表达式，如 due:<=7d AND label:work
+}
+
+// ReminderResponse 提醒响应结构
+type ReminderResponse struct {
+	ID        uuid.UUID `json:"id"`
+	TaskID    uuid.UUID `json:"taskId"`
+	RemindAt  time.Time `json:"remindAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TaskResponse 任务响应结构
+type TaskResponse struct {
+	ID          uuid.UUID           `json:"id"`
+	ProjectID   uuid.UUID           `json:"projectId"`
+	ParentID    *uuid.UUID          `json:"parentId,omitempty"`
+	SectionID   *uuid.UUID          `json:"sectionId,omitempty"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Status      models.TaskStatus   `json:"status"`
+	Priority    int                 `json:"priority"`
+	StartTime   *time.Time          `json:"startTime,omitempty"`
+	DueTime     *time.Time          `json:"dueTime,omitempty"`
+	CompletedAt *time.Time          `json:"completedAt,omitempty"`
+	RRuleString string              `json:"rruleString,omitempty"`
+	SortOrder   string              `json:"sortOrder"`
+	ICalUID     string              `json:"icalUid,omitempty"`
+	Labels      []*LabelResponse    `json:"labels"`
+	Reminders   []*ReminderResponse `json:"reminders,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+}
+
+// CreateTask 创建任务
+func (s *TaskService) CreateTask(ctx context.Context, userID uuid.UUID, req *CreateTaskRequest) (*TaskResponse, error) {
+	if err := s.checkProject(ctx, userID, req.ProjectID); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, userID, uuid.Nil, req.ProjectID, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if req.SectionID != nil {
+		if err := s.checkSection(ctx, userID, req.ProjectID, req.ParentID, *req.SectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	labelIDs, err := s.resolveLabels(ctx, userID, req.LabelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := req.Priority
+	if priority == 0 {
+		priority = DefaultTaskPriority
+	}
+
+	task := &models.Task{
+		ID:          req.ID,
+		UserID:      userID,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+		SectionID:   req.SectionID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Status:      models.TaskStatusIncomplete,
+		Priority:    priority,
+		StartTime:   req.StartTime,
+		DueTime:     req.DueTime,
+		RRuleString: strings.TrimSpace(req.RRuleString),
+	}
+	// 新任务排在同级任务末尾，与标签关联在同一事务中写入
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		key, err := appendTaskSortOrder(ctx, s.tasks, scopeOf(userID, task.ProjectID, task.ParentID), uuid.Nil)
+		if err != nil {
+			return err
+		}
+		task.SortOrder = key
+		if err := s.tasks.CreateTask(ctx, task); err != nil {
+			return fmt.Errorf("创建任务失败: %w", err)
+		}
+		if len(labelIDs) > 0 {
+			if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+				return fmt.Errorf("设置任务标签失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publishTask(ctx, userID, task.ID, EventTaskCreated)
+}
+
+// GetTask 获取任务详情
+func (s *TaskService) GetTask(ctx context.Context, userID, id uuid.UUID) (*TaskResponse, error) {
+	task, err := s.getTask(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toTaskResponse(task), nil
+}
+
+// ListTasks 分页获取任务列表，默认按截止时间排序
+func (s *TaskService) ListTasks(ctx context.Context, userID uuid.UUID, query *TaskListQuery, pageQuery *pagination.Query) (*pagination.Page[*TaskResponse], error) {
+	filter, err := query.toFilter()
+	if err != nil {
+		return nil, err
+	}
+	return listTaskPage(ctx, s.tasks, userID, filter, pageQuery)
+}
+
+// listTaskPage 按过滤条件分页查询任务并转换为响应结构
+func listTaskPage(ctx context.Context, tasks repository.TaskRepository, userID uuid.UUID, filter repository.TaskFilter, query *pagination.Query) (*pagination.Page[*TaskResponse], error) {
+	req, err := query.Request(repository.TaskSortFields, "due")
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := tasks.ListTasks(ctx, userID, filter, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	found, next := pagination.Trim(found, req, func(task *models.Task) (interface{}, uuid.UUID) {
+		return repository.TaskSortValue(task, req.Sort.Field.Name), task.ID
+	})
+
+	page := &pagination.Page[*TaskResponse]{Items: make([]*TaskResponse, 0, len(found)), NextCursor: next}
+	for _, task := range found {
+		page.Items = append(page.Items, toTaskResponse(task))
+	}
+	return page, nil
+}
+
+// UpdateTask 更新任务
+func (s *TaskService) UpdateTask(ctx context.Context, userID, id uuid.UUID, req *UpdateTaskRequest) (*TaskResponse, error) {
+	task, err := s.getTask(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	oldScope := scopeOf(userID, task.ProjectID, task.ParentID)
+
+	if req.ProjectID != nil && *req.ProjectID != task.ProjectID {
+		if err := s.checkProject(ctx, userID, *req.ProjectID); err != nil {
+			return nil, err
+		}
+		task.ProjectID = *req.ProjectID
+		// 移动到其他项目时脱离原父任务和分栏
+		if !req.ParentID.Set {
+			task.ParentID = nil
+		}
+		if !req.SectionID.Set {
+			task.SectionID = nil
+		}
+	}
+	if req.ParentID.Set {
+		if req.ParentID.Value != nil {
+			if err := s.checkParent(ctx, userID, task.ID, task.ProjectID, *req.ParentID.Value); err != nil {
+				return nil, err
+			}
+		}
+		task.ParentID = req.ParentID.Value
+		// 子任务跟随父任务展示，不属于任何分栏
+		if task.ParentID != nil && !req.SectionID.Set {
+			task.SectionID = nil
+		}
+	}
+	if req.SectionID.Set {
+		if req.SectionID.Value != nil {
+			if err := s.checkSection(ctx, userID, task.ProjectID, task.ParentID, *req.SectionID.Value); err != nil {
+				return nil, err
+			}
+		}
+		task.SectionID = req.SectionID.Value
+	}
+	if req.Title != nil {
+		task.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.Priority != nil {
+		task.Priority = *req.Priority
+	}
+	if req.StartTime.Set {
+		task.StartTime = req.StartTime.Value
+	}
+	if req.DueTime.Set {
+		task.DueTime = req.DueTime.Value
+	}
+	if req.RRuleString.Set {
+		task.RRuleString = ""
+		if req.RRuleString.Value != nil {
+			task.RRuleString = strings.TrimSpace(*req.RRuleString.Value)
+		}
+	}
+
+	// 标签先校验，再与任务更新在同一事务中写入
+	var labelIDs []uuid.UUID
+	if req.LabelIDs.Set {
+		var ids []uuid.UUID
+		if req.LabelIDs.Value != nil {
+			ids = *req.LabelIDs.Value
+		}
+		if labelIDs, err = s.resolveLabels(ctx, userID, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		// 移动到其他项目或父任务下时排在新位置的末尾
+		if scope := scopeOf(userID, task.ProjectID, task.ParentID); !sameScope(scope, oldScope) {
+			key, err := appendTaskSortOrder(ctx, s.tasks, scope, task.ID)
+			if err != nil {
+				return err
+			}
+			task.SortOrder = key
+		}
+		if err := s.tasks.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("更新任务失败: %w", err)
+		}
+		if req.LabelIDs.Set {
+			if err := s.tasks.SetTaskLabels(ctx, task.ID, labelIDs); err != nil {
+				return fmt.Errorf("设置任务标签失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publishTask(ctx, userID, task.ID, EventTaskUpdated)
+}
+
+// MoveTask 拖动排序任务，只修改被移动任务的排序键
+// 参照任务所在的项目、父任务和分栏即为新的位置；没有参照任务时可以通过sectionId移到某个分栏的末尾
+func (s *TaskService) MoveTask(ctx context.Context, userID, id uuid.UUID, req *MoveTaskRequest) (*TaskResponse, error) {
+	task, err := s.getTask(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.moveAnchor(ctx, userID, task.ID, req.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.moveAnchor(ctx, userID, task.ID, req.BeforeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确定新的排序范围和分栏
+	scope := scopeOf(userID, task.ProjectID, task.ParentID)
+	sectionID := task.SectionID
+	anchor := after
+	if anchor == nil {
+		anchor = before
+	}
+	switch {
+	case after != nil && before != nil && !sameTaskPosition(after, before):
+		return nil, fmt.Errorf("%w: afterId和beforeId不是同一位置的任务", ErrInvalidMove)
+	case anchor != nil:
+		if req.SectionID.Set && !sameID(req.SectionID.Value, anchor.SectionID) {
+			return nil, fmt.Errorf("%w: sectionId与参照任务所在分栏不一致", ErrInvalidMove)
+		}
+		scope = scopeOf(userID, anchor.ProjectID, anchor.ParentID)
+		sectionID = anchor.SectionID
+	case req.SectionID.Set:
+		scope = scopeOf(userID, task.ProjectID, nil)
+		if req.SectionID.Value != nil {
+			section, err := s.sections.GetSectionByID(ctx, userID, *req.SectionID.Value)
+			if err != nil {
+				return nil, fmt.Errorf("查询分栏失败: %w", err)
+			}
+			if section == nil {
+				return nil, ErrInvalidSection
+			}
+			if err := s.checkProject(ctx, userID, section.ProjectID); err != nil {
+				return nil, err
+			}
+			scope.ProjectID = section.ProjectID
+		}
+		sectionID = req.SectionID.Value
+	}
+	if scope.ParentID != nil && !sameScope(scope, scopeOf(userID, task.ProjectID, task.ParentID)) {
+		if err := s.checkParent(ctx, userID, task.ID, scope.ProjectID, *scope.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	var afterKey, beforeKey *sortAnchor
+	if after != nil {
+		afterKey = &sortAnchor{ID: after.ID, Key: after.SortOrder}
+	}
+	if before != nil {
+		beforeKey = &sortAnchor{ID: before.ID, Key: before.SortOrder}
+	}
+
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		rebalance := func(ctx context.Context) (map[uuid.UUID]string, error) {
+			return rebalanceTaskScope(ctx, s.tasks, scope)
+		}
+		key, err := sortOrderBetween(ctx, afterKey, beforeKey, taskNeighbor(s.tasks, scope, task.ID), rebalance)
+		if err != nil {
+			return err
+		}
+
+		task.ProjectID = scope.ProjectID
+		task.ParentID = scope.ParentID
+		task.SectionID = sectionID
+		task.SortOrder = key
+		if err := s.tasks.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("移动任务失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publishTask(ctx, userID, task.ID, EventTaskUpdated)
+}
+
+// moveAnchor 获取移动时参照的任务，参照任务不能是被移动的任务本身
+func (s *TaskService) moveAnchor(ctx context.Context, userID, taskID uuid.UUID, anchorID *uuid.UUID) (*models.Task, error) {
+	if anchorID == nil {
+		return nil, nil
+	}
+	if *anchorID == taskID {
+		return nil, fmt.Errorf("%w: 不能以任务自身为参照", ErrInvalidMove)
+	}
+
+	anchor, err := s.tasks.GetTaskByID(ctx, userID, *anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	if anchor == nil {
+		return nil, fmt.Errorf("%w: 参照任务不存在", ErrInvalidMove)
+	}
+	return anchor, nil
+}
+
+// sameTaskPosition 判断两个任务是否位于同一排序范围和分栏
+func sameTaskPosition(a, b *models.Task) bool {
+	return sameScope(scopeOf(a.UserID, a.ProjectID, a.ParentID), scopeOf(b.UserID, b.ProjectID, b.ParentID)) &&
+		sameID(a.SectionID, b.SectionID)
+}
+
+// CompleteTask 标记任务为已完成
+func (s *TaskService) CompleteTask(ctx context.Context, userID, id uuid.UUID) (*TaskResponse, error) {
+	return s.setCompleted(ctx, userID, id, true)
+}
+
+// ReopenTask 将任务重新标记为未完成
+func (s *TaskService) ReopenTask(ctx context.Context, userID, id uuid.UUID) (*TaskResponse, error) {
+	return s.setCompleted(ctx, userID, id, false)
+}
+
+// DeleteTask 删除任务及其子任务
+func (s *TaskService) DeleteTask(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getTask(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if err := s.tasks.DeleteTask(ctx, userID, id); err != nil {
+		return fmt.Errorf("删除任务失败: %w", err)
+	}
+	s.events.Publish(ctx, userID, EventTaskDeleted, &DeletedEntity{ID: id})
+	return nil
+}
+
+// setCompleted 修改任务完成状态
+func (s *TaskService) setCompleted(ctx context.Context, userID, id uuid.UUID, completed bool) (*TaskResponse, error) {
+	task, err := s.getTask(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if completed == task.IsCompleted() {
+		return toTaskResponse(task), nil
+	}
+	if completed {
+		task.MarkCompleted()
+	} else {
+		task.MarkIncomplete()
+	}
+
+	if err := s.tasks.UpdateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("更新任务状态失败: %w", err)
+	}
+
+	eventType := EventTaskUpdated
+	if completed {
+		eventType = EventTaskCompleted
+	}
+	resp := toTaskResponse(task)
+	s.events.Publish(ctx, userID, eventType, resp)
+	return resp, nil
+}
+
+// publishTask 获取变更后的任务并发布事件
+func (s *TaskService) publishTask(ctx context.Context, userID, id uuid.UUID, eventType string) (*TaskResponse, error) {
+	resp, err := s.GetTask(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(ctx, userID, eventType, resp)
+	return resp, nil
+}
+
+// getTask 获取用户的任务，不存在时返回ErrTaskNotFound
+func (s *TaskService) getTask(ctx context.Context, userID, id uuid.UUID) (*models.Task, error) {
+	task, err := s.tasks.GetTaskByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// checkProject 检查项目是否属于用户
+func (s *TaskService) checkProject(ctx context.Context, userID, projectID uuid.UUID) error {
+	project, err := s.projects.GetProjectByID(ctx, userID, projectID)
+	if err != nil {
+		return fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+// checkParent 检查父任务有效：属于同一项目，且不能是任务自身
+func (s *TaskService) checkParent(ctx context.Context, userID, taskID, projectID, parentID uuid.UUID) error {
+	if parentID == taskID {
+		return ErrInvalidParentTask
+	}
+
+	parent, err := s.tasks.GetTaskByID(ctx, userID, parentID)
+	if err != nil {
+		return fmt.Errorf("查询父任务失败: %w", err)
+	}
+	if parent == nil || parent.ProjectID != projectID {
+		return ErrInvalidParentTask
+	}
+	// 只支持一层子任务，避免出现循环引用
+	if parent.ParentID != nil {
+		return ErrInvalidParentTask
+	}
+	return nil
+}
+
+// checkSection 检查分栏属于任务所在的项目，子任务跟随父任务展示，不能指定分栏
+func (s *TaskService) checkSection(ctx context.Context, userID, projectID uuid.UUID, parentID *uuid.UUID, sectionID uuid.UUID) error {
+	if parentID != nil {
+		return fmt.Errorf("%w: 子任务不能指定分栏", ErrInvalidSection)
+	}
+
+	section, err := s.sections.GetSectionByID(ctx, userID, sectionID)
+	if err != nil {
+		return fmt.Errorf("查询分栏失败: %w", err)
+	}
+	if section == nil || section.ProjectID != projectID {
+		return ErrInvalidSection
+	}
+	return nil
+}
+
+// resolveLabels 校验标签都属于用户并去重
+func (s *TaskService) resolveLabels(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	labels, err := s.labels.GetLabelsByIDs(ctx, userID, unique)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	if len(labels) != len(unique) {
+		return nil, ErrLabelNotFound
+	}
+	return unique, nil
+}
+
+// toFilter 将查询参数转换为仓储过滤条件
+func (q *TaskListQuery) toFilter() (repository.TaskFilter, error) {
+	var filter repository.TaskFilter
+
+	if q.ProjectID != "" {
+		id, err := uuid.Parse(q.ProjectID)
+		if err != nil {
+			return filter, fmt.Errorf("%w: projectId", ErrInvalidTaskQuery)
+		}
+		filter.ProjectID = &id
+	}
+	if q.ParentID != "" {
+		id, err := uuid.Parse(q.ParentID)
+		if err != nil {
+			return filter, fmt.Errorf("%w: parentId", ErrInvalidTaskQuery)
+		}
+		filter.ParentID = &id
+	}
+	if q.Status != "" {
+		status := models.TaskStatus(q.Status)
+		filter.Status = &status
+	}
+	if q.Priority != 0 {
+		priority := q.Priority
+		filter.Priority = &priority
+	}
+	if q.DueFrom != "" {
+		t, err := time.Parse(time.RFC3339, q.DueFrom)
+		if err != nil {
+			return filter, fmt.Errorf("%w: dueFrom", ErrInvalidTaskQuery)
+		}
+		filter.DueFrom = &t
+	}
+	if q.DueTo != "" {
+		t, err := time.Parse(time.RFC3339, q.DueTo)
+		if err != nil {
+			return filter, fmt.Errorf("%w: dueTo", ErrInvalidTaskQuery)
+		}
+		filter.DueTo = &t
+	}
+	if q.Filter != "" {
+		expr, err := parseFilter(q.Filter)
+		if err != nil {
+			return filter, err
+		}
+		filter.Expr = expr
+		filter.Now = time.Now()
+	}
+	return filter, nil
+}
+
+// toTaskResponse 转换为任务响应结构
+func toTaskResponse(task *models.Task) *TaskResponse {
+	labels := make([]*LabelResponse, 0, len(task.Labels))
+	for i := range task.Labels {
+		labels = append(labels, toLabelResponse(&task.Labels[i]))
+	}
+
+	var reminders []*ReminderResponse
+	for i := range task.Reminders {
+		reminders = append(reminders, toReminderResponse(&task.Reminders[i]))
+	}
+
+	return &TaskResponse{
+		ID:          task.ID,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		SectionID:   task.SectionID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		StartTime:   task.StartTime,
+		DueTime:     task.DueTime,
+		CompletedAt: task.CompletedAt,
+		RRuleString: task.RRuleString,
+		SortOrder:   task.SortOrder,
+		ICalUID:     task.ICalUID,
+		Labels:      labels,
+		Reminders:   reminders,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+	}
+}
+
+// toReminderResponse 转换为提醒响应结构
+func toReminderResponse(reminder *models.Reminder) *ReminderResponse {
+	return &ReminderResponse{
+		ID:        reminder.ID,
+		TaskID:    reminder.TaskID,
+		RemindAt:  reminder.RemindAt,
+		CreatedAt: reminder.CreatedAt,
+	}
+}
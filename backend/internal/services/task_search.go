@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSearchLimit 搜索结果默认条数
+	DefaultSearchLimit = 20
+	// maxSearchTerms 参与匹配的查询词上限
+	maxSearchTerms = 8
+	// searchSnippetRunes 服务端生成描述片段时截取的字符数
+	searchSnippetRunes = 60
+
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// TaskSearchQuery 任务搜索参数，过滤条件与任务列表一致
+type TaskSearchQuery struct {
+	TaskListQuery
+	Q     string `form:"q" binding:"required,max=200"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TaskHighlight 搜索命中的高亮片段，文本已做HTML转义，命中部分用<mark>包裹
+type TaskHighlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// TaskSearchResult 任务搜索结果
+type TaskSearchResult struct {
+	*TaskResponse
+	Rank      float64       `json:"rank"`
+	Highlight TaskHighlight `json:"highlight"`
+}
+
+// SearchTasks 按标题、描述和标签名称搜索任务，每个词都按前缀匹配以支持输入时联想
+func (s *TaskService) SearchTasks(ctx context.Context, userID uuid.UUID, query *TaskSearchQuery) ([]*TaskSearchResult, error) {
+	filter, err := query.toFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(strings.TrimSpace(query.Q))
+	if text == "" {
+		return nil, fmt.Errorf("%w: q", ErrInvalidTaskQuery)
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+
+	search := repository.TaskSearch{
+		TaskFilter: filter,
+		Terms:      searchTerms(text),
+		Text:       text,
+		Limit:      limit,
+	}
+	hits, err := s.tasks.SearchTasks(ctx, userID, search)
+	if err != nil {
+		return nil, fmt.Errorf("搜索任务失败: %w", err)
+	}
+
+	// 数据库只能高亮分词命中的部分，中文等子串命中由服务端补充高亮
+	needles := append([]string{text}, search.Terms...)
+	results := make([]*TaskSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		highlight := TaskHighlight{Title: renderSnippet(hit.TitleSnippet), Description: renderSnippet(hit.DescriptionSnippet)}
+		if !strings.Contains(highlight.Title, highlightStart) {
+			highlight.Title = highlightText(hit.Task.Title, needles, 0)
+		}
+		if !strings.Contains(highlight.Description, highlightStart) {
+			highlight.Description = highlightText(hit.Task.Description, needles, searchSnippetRunes)
+			if !strings.Contains(highlight.Description, highlightStart) {
+				highlight.Description = ""
+			}
+		}
+
+		results = append(results, &TaskSearchResult{
+			TaskResponse: toTaskResponse(hit.Task),
+			Rank:         hit.Rank,
+			Highlight:    highlight,
+		})
+	}
+	return results, nil
+}
+
+// searchTerms 按非字母数字字符切分查询文本，去重后返回
+func searchTerms(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		terms = append(terms, field)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// renderSnippet 将数据库返回的原文片段转义为HTML，并把命中标记替换为<mark>
+// 不成对的标记直接丢弃，保证输出中只有成对的<mark>标签
+func renderSnippet(snippet string) string {
+	var b strings.Builder
+	open := false
+	for snippet != "" {
+		i := strings.IndexAny(snippet, repository.HighlightStart+repository.HighlightStop)
+		if i < 0 {
+			b.WriteString(html.EscapeString(snippet))
+			break
+		}
+		b.WriteString(html.EscapeString(snippet[:i]))
+		switch {
+		case snippet[i:i+1] == repository.HighlightStart && !open:
+			b.WriteString(highlightStart)
+			open = true
+		case snippet[i:i+1] == repository.HighlightStop && open:
+			b.WriteString(highlightStop)
+			open = false
+		}
+		snippet = snippet[i+1:]
+	}
+	if open {
+		b.WriteString(highlightStop)
+	}
+	return b.String()
+}
+
+// highlightText 用<mark>标记text中出现的needles（不区分大小写），其余文本做HTML转义
+// maxRunes大于0时只返回第一个命中位置附近的片段
+func highlightText(text string, needles []string, maxRunes int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 优先匹配较长的词，避免整句命中被拆成多段
+	patterns := make([][]rune, 0, len(needles))
+	for _, needle := range needles {
+		if needle != "" {
+			patterns = append(patterns, []rune(needle))
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool { return len(patterns[i]) > len(patterns[j]) })
+
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, pattern := range patterns {
+			if hasRunePrefix(lower[i:], pattern) {
+				matched = len(pattern)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		spans = append(spans, span{i, i + matched})
+		i += matched
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		if len(spans) > 0 {
+			start = spans[0].start - maxRunes/3
+		}
+		start = max(start, 0)
+		end = min(start+maxRunes, len(runes))
+		// 片段末尾不截断命中词
+		for _, sp := range spans {
+			if sp.start < end && sp.end > end {
+				end = sp.end
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, sp := range spans {
+		if sp.start < start || sp.start >= end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString(highlightStart)
+		b.WriteString(html.EscapeString(string(runes[sp.start:sp.end])))
+		b.WriteString(highlightStop)
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// hasRunePrefix 判断s是否以prefix开头
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"ticktick-backend/internal/repository"
+)
+
+func TestRenderSnippet(t *testing.T) {
+	start, stop := repository.HighlightStart, repository.HighlightStop
+	tests := []struct {
+		snippet string
+		want    string
+	}{
+		{"", ""},
+		{"Buy " + start + "milk" + stop, "Buy <mark>milk</mark>"},
+		{"<b>" + start + "milk" + stop + "</b> & co", "&lt;b&gt;<mark>milk</mark>&lt;/b&gt; &amp; co"},
+		{start + "<script>" + stop, "<mark>&lt;script&gt;</mark>"},
+		// 不成对的标记不会产生未闭合的标签
+		{stop + "a" + start + start + "b", "a<mark>b</mark>"},
+	}
+	for _, tt := range tests {
+		if got := renderSnippet(tt.snippet); got != tt.want {
+			t.Errorf("renderSnippet(%q) = %q, 期望 %q", tt.snippet, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTextEscapes(t *testing.T) {
+	tests := []struct {
+		text    string
+		needles []string
+		want    string
+	}{
+		{"Buy milk", []string{"mil"}, "Buy <mark>mil</mark>k"},
+		{`<img src=x onerror="alert(1)">milk`, []string{"milk"}, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt;<mark>milk</mark>"},
+		{"a<b", []string{"<"}, "a<mark>&lt;</mark>b"},
+		{"Tom & Jerry", []string{"zzz"}, "Tom &amp; Jerry"},
+	}
+	for _, tt := range tests {
+		if got := highlightText(tt.text, tt.needles, 0); got != tt.want {
+			t.Errorf("highlightText(%q) = %q, 期望 %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"log"
 	"time"
+
+	"ticktick-backend/internal/metrics"
 )
 
 // TokenMonitor Token监控服务
@@ -38,7 +40,8 @@ func (tm *TokenMonitor) Start() {
 	// 启动健康检查任务
 	go tm.startHealthCheckTask()
 
-	// 启动统计任务
+	// 启动统计任务，启动时先采集一次以便指标立即可用
+	go tm.collectStats()
 	go tm.startStatsTask()
 }
 
@@ -224,24 +227,12 @@ func (tm *TokenMonitor) collectStats() {
 	// 记录统计信息到日志
 	log.Printf("Token统计信息: %+v", stats)
 
-	// 这里可以将统计信息发送到监控系统
-	// 例如: Prometheus, InfluxDB, CloudWatch等
 	tm.sendStatsToMonitoring(stats)
 }
 
-// sendStatsToMonitoring 发送统计信息到监控系统
+// sendStatsToMonitoring 发送统计信息到监控系统（Prometheus）
 func (tm *TokenMonitor) sendStatsToMonitoring(stats map[string]interface{}) {
-	// 实际项目中可以集成具体的监控系统
-	// 这里只是示例
-
-	// 示例: 发送到Prometheus
-	// prometheus.GaugeVec.WithLabelValues("blacklist").Set(float64(stats["blacklist_count"]))
-
-	// 示例: 发送到InfluxDB
-	// influxClient.WritePoint(context.Background(), "token_stats", stats)
-
-	// 目前只记录到日志
-	log.Printf("统计信息已记录: %+v", stats)
+	metrics.SetTokenStats(stats)
 }
 
 // GetMonitoringData 获取监控数据
@@ -276,6 +267,11 @@ func (tm *TokenMonitor) ForceCleanup() error {
 	return nil
 }
 
+// IsRunning 监控服务是否正在运行
+func (tm *TokenMonitor) IsRunning() bool {
+	return tm.isRunning
+}
+
 // GetHealthStatus 获取健康状态
 func (tm *TokenMonitor) GetHealthStatus() map[string]interface{} {
 	status := make(map[string]interface{})
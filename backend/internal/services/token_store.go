@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,15 +18,24 @@ const (
 	RefreshTokenType TokenType = "refresh"
 )
 
+// 撤销原因
+const (
+	RevokeReasonSessionLimit = "session_limit" // 超出会话数量上限被淘汰
+)
+
+// sessionTouchInterval 会话最后使用时间的更新间隔，避免每个请求都重写会话信息
+const sessionTouchInterval = time.Minute
+
 // RefreshTokenInfo RefreshToken信息
 type RefreshTokenInfo struct {
-	UserID     uuid.UUID `json:"userId"`
-	Email      string    `json:"email"`
-	TokenID    string    `json:"tokenId"`
-	DeviceInfo string    `json:"deviceInfo"`
-	CreatedAt  time.Time `json:"createdAt"`
-	LastUsedAt time.Time `json:"lastUsedAt"`
-	ExpiresAt  time.Time `json:"expiresAt"`
+	UserID     uuid.UUID  `json:"userId"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role,omitempty"`
+	TokenID    string     `json:"tokenId"`
+	DeviceInfo DeviceInfo `json:"deviceInfo"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `json:"lastUsedAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
 }
 
 // BlacklistInfo 黑名单信息
@@ -39,12 +49,13 @@ type BlacklistInfo struct {
 
 // SessionInfo 会话信息
 type SessionInfo struct {
-	TokenID               string    `json:"tokenId"`
-	DeviceInfo            string    `json:"deviceInfo"`
-	CreatedAt             time.Time `json:"createdAt"`
-	LastUsedAt            time.Time `json:"lastUsedAt"`
-	AccessTokenExpiresAt  time.Time `json:"accessTokenExpiresAt"`
-	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+	TokenID               string     `json:"tokenId"`
+	DeviceInfo            DeviceInfo `json:"deviceInfo"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	LastUsedAt            time.Time  `json:"lastUsedAt"`
+	AccessTokenExpiresAt  time.Time  `json:"accessTokenExpiresAt"`
+	RefreshTokenExpiresAt time.Time  `json:"refreshTokenExpiresAt"`
+	IsCurrent             bool       `json:"isCurrent"`
 }
 
 // UserOnlineInfo 用户在线信息
@@ -84,6 +95,10 @@ func (ts *TokenStore) userOnlineKey(userID uuid.UUID) string {
 	return fmt.Sprintf("user_online:%s", userID.String())
 }
 
+func (ts *TokenStore) sessionTouchKey(tokenID string) string {
+	return fmt.Sprintf("session_touch:%s", tokenID)
+}
+
 // StoreRefreshToken 存储RefreshToken
 func (ts *TokenStore) StoreRefreshToken(info *RefreshTokenInfo) error {
 	key := ts.refreshTokenKey(info.UserID, info.TokenID)
@@ -109,7 +124,7 @@ func (ts *TokenStore) StoreRefreshToken(info *RefreshTokenInfo) error {
 	}
 
 	// 更新用户在线状态
-	if err := ts.updateUserOnlineStatus(info.UserID, info.DeviceInfo); err != nil {
+	if err := ts.updateUserOnlineStatus(info.UserID, info.DeviceInfo.String()); err != nil {
 		return fmt.Errorf("更新用户在线状态失败: %w", err)
 	}
 
@@ -333,7 +348,7 @@ func (ts *TokenStore) RevokeAllUserTokens(userID uuid.UUID, reason string) error
 			TokenType:  RefreshTokenType,
 			RevokedAt:  time.Now(),
 			Reason:     reason,
-			DeviceInfo: refreshInfo.DeviceInfo,
+			DeviceInfo: refreshInfo.DeviceInfo.String(),
 		}
 
 		blacklistKey := ts.blacklistKey(tokenID)
@@ -377,7 +392,7 @@ func (ts *TokenStore) RevokeUserSession(userID uuid.UUID, tokenID string, reason
 		TokenType:  RefreshTokenType,
 		RevokedAt:  time.Now(),
 		Reason:     reason,
-		DeviceInfo: refreshInfo.DeviceInfo,
+		DeviceInfo: refreshInfo.DeviceInfo.String(),
 	}
 
 	if err := ts.AddToBlacklist(tokenID, blacklistInfo, refreshInfo.ExpiresAt); err != nil {
@@ -392,6 +407,60 @@ func (ts *TokenStore) RevokeUserSession(userID uuid.UUID, tokenID string, reason
 	return nil
 }
 
+// EnforceSessionLimit 执行会话数量限制
+// 超出角色允许的会话数时，按LastUsedAt淘汰最久未使用的会话（keepTokenID除外），返回被淘汰的会话
+func (ts *TokenStore) EnforceSessionLimit(userID uuid.UUID, role string, keepTokenID string) ([]*SessionInfo, error) {
+	limit := ts.redis.GetConfig().MaxSessionsForRole(role)
+	if limit <= 0 {
+		return nil, nil // 不限制
+	}
+
+	sessions, err := ts.GetUserSessionsInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	excess := len(sessions) - limit
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	// 按最后使用时间升序排列，最久未使用的排在前面
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.Before(sessions[j].LastUsedAt)
+	})
+
+	evicted := make([]*SessionInfo, 0, excess)
+	for _, session := range sessions {
+		if len(evicted) >= excess {
+			break
+		}
+		if session.TokenID == keepTokenID {
+			continue
+		}
+
+		if err := ts.RevokeUserSession(userID, session.TokenID, RevokeReasonSessionLimit); err != nil {
+			return evicted, fmt.Errorf("淘汰会话失败: %w", err)
+		}
+		evicted = append(evicted, session)
+	}
+
+	return evicted, nil
+}
+
+// TouchSession 记录会话被使用，同一会话在sessionTouchInterval内最多更新一次最后使用时间
+// 会话上限按最后使用时间淘汰，因此认证中间件在每个请求上调用
+func (ts *TokenStore) TouchSession(userID uuid.UUID, tokenID string) error {
+	first, err := ts.redis.SetNX(ts.sessionTouchKey(tokenID), 1, sessionTouchInterval)
+	if err != nil {
+		return fmt.Errorf("记录会话使用失败: %w", err)
+	}
+	if !first {
+		return nil
+	}
+	return ts.UpdateRefreshTokenLastUsed(userID, tokenID)
+}
+
 // UpdateRefreshTokenLastUsed 更新RefreshToken最后使用时间
 func (ts *TokenStore) UpdateRefreshTokenLastUsed(userID uuid.UUID, tokenID string) error {
 	// 获取当前信息
@@ -406,8 +475,40 @@ func (ts *TokenStore) UpdateRefreshTokenLastUsed(userID uuid.UUID, tokenID strin
 	// 更新最后使用时间
 	info.LastUsedAt = time.Now()
 
-	// 重新存储
-	key := ts.refreshTokenKey(userID, tokenID)
+	if err := ts.updateRefreshToken(info); err != nil {
+		return err
+	}
+
+	// 更新用户在线状态
+	if err := ts.updateUserOnlineStatus(userID, info.DeviceInfo.String()); err != nil {
+		return fmt.Errorf("更新用户在线状态失败: %w", err)
+	}
+
+	return nil
+}
+
+// RenameSession 重命名会话的设备名称
+func (ts *TokenStore) RenameSession(userID uuid.UUID, tokenID string, name string) (*RefreshTokenInfo, error) {
+	info, err := ts.GetRefreshToken(userID, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("获取RefreshToken信息失败: %w", err)
+	}
+	if info == nil {
+		return nil, fmt.Errorf("会话不存在")
+	}
+
+	info.DeviceInfo.Name = NormalizeDeviceName(name)
+
+	if err := ts.updateRefreshToken(info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// updateRefreshToken 保持原有TTL重新存储RefreshToken信息
+func (ts *TokenStore) updateRefreshToken(info *RefreshTokenInfo) error {
+	key := ts.refreshTokenKey(info.UserID, info.TokenID)
 	data, err := json.Marshal(info)
 	if err != nil {
 		return fmt.Errorf("序列化RefreshToken信息失败: %w", err)
@@ -418,16 +519,14 @@ func (ts *TokenStore) UpdateRefreshTokenLastUsed(userID uuid.UUID, tokenID strin
 	if err != nil {
 		return fmt.Errorf("获取TTL失败: %w", err)
 	}
+	if ttl <= 0 {
+		return fmt.Errorf("RefreshToken已过期")
+	}
 
 	if err := ts.redis.Set(key, data, ttl); err != nil {
 		return fmt.Errorf("更新RefreshToken失败: %w", err)
 	}
 
-	// 更新用户在线状态
-	if err := ts.updateUserOnlineStatus(userID, info.DeviceInfo); err != nil {
-		return fmt.Errorf("更新用户在线状态失败: %w", err)
-	}
-
 	return nil
 }
 
@@ -458,6 +557,11 @@ func (ts *TokenStore) GetUserSessionsInfo(userID uuid.UUID) ([]*SessionInfo, err
 		sessionsInfo = append(sessionsInfo, sessionInfo)
 	}
 
+	// 最近使用的会话排在前面
+	sort.Slice(sessionsInfo, func(i, j int) bool {
+		return sessionsInfo[i].LastUsedAt.After(sessionsInfo[j].LastUsedAt)
+	})
+
 	return sessionsInfo, nil
 }
 
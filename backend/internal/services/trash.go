@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTrashItemNotFound   = errors.New("回收站中不存在该项目或任务")
+	ErrTrashProjectDeleted = errors.New("任务所属项目已删除，请先恢复项目")
+)
+
+// TrashService 回收站服务
+type TrashService struct {
+	trash    repository.TrashRepository
+	projects repository.ProjectRepository
+	tasks    repository.TaskRepository
+}
+
+// NewTrashService 创建回收站服务实例
+func NewTrashService(trash repository.TrashRepository, projects repository.ProjectRepository, tasks repository.TaskRepository) *TrashService {
+	return &TrashService{
+		trash:    trash,
+		projects: projects,
+		tasks:    tasks,
+	}
+}
+
+// TrashedProjectResponse 回收站中的项目
+type TrashedProjectResponse struct {
+	*ProjectResponse
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// TrashedTaskResponse 回收站中的任务
+type TrashedTaskResponse struct {
+	*TaskResponse
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// TrashListQuery 回收站列表参数，项目和任务分别分页
+type TrashListQuery struct {
+	Type string `form:"type" binding:"omitempty,oneof=projects tasks"` // 默认为tasks
+}
+
+// ListDeletedProjects 分页获取回收站中的项目，默认按删除时间倒序
+func (s *TrashService) ListDeletedProjects(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*TrashedProjectResponse], error) {
+	req, err := query.Request(repository.TrashedProjectSortFields, "-deleted")
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.trash.ListDeletedProjects(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询已删除项目失败: %w", err)
+	}
+	projects, next := pagination.Trim(projects, req, func(project *models.Project) (interface{}, uuid.UUID) {
+		return repository.TrashedProjectSortValue(project, req.Sort.Field.Name), project.ID
+	})
+
+	page := &pagination.Page[*TrashedProjectResponse]{Items: make([]*TrashedProjectResponse, 0, len(projects)), NextCursor: next}
+	for _, project := range projects {
+		page.Items = append(page.Items, &TrashedProjectResponse{
+			ProjectResponse: toProjectResponse(project),
+			DeletedAt:       project.DeletedAt.Time,
+		})
+	}
+	return page, nil
+}
+
+// ListDeletedTasks 分页获取回收站中单独删除的任务，默认按删除时间倒序
+// 随项目或父任务一起删除的任务不单独列出
+func (s *TrashService) ListDeletedTasks(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*TrashedTaskResponse], error) {
+	req, err := query.Request(repository.TrashedTaskSortFields, "-deleted")
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.trash.ListDeletedTasks(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询已删除任务失败: %w", err)
+	}
+	tasks, next := pagination.Trim(tasks, req, func(task *models.Task) (interface{}, uuid.UUID) {
+		return repository.TrashedTaskSortValue(task, req.Sort.Field.Name), task.ID
+	})
+
+	page := &pagination.Page[*TrashedTaskResponse]{Items: make([]*TrashedTaskResponse, 0, len(tasks)), NextCursor: next}
+	for _, task := range tasks {
+		page.Items = append(page.Items, &TrashedTaskResponse{
+			TaskResponse: toTaskResponse(task),
+			DeletedAt:    task.DeletedAt.Time,
+		})
+	}
+	return page, nil
+}
+
+// RestoreProject 从回收站恢复项目及其任务
+func (s *TrashService) RestoreProject(ctx context.Context, userID, id uuid.UUID) (*ProjectResponse, error) {
+	project, err := s.trash.GetDeletedProject(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询已删除项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrTrashItemNotFound
+	}
+
+	// 删除后可能已经创建了同名项目
+	exists, err := s.projects.ProjectNameExists(ctx, userID, project.Name, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("检查项目名称失败: %w", err)
+	}
+	if exists {
+		return nil, ErrProjectNameExists
+	}
+
+	if err := s.trash.RestoreProject(ctx, userID, id); err != nil {
+		return nil, fmt.Errorf("恢复项目失败: %w", err)
+	}
+
+	restored, err := s.projects.GetProjectByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	return toProjectResponse(restored), nil
+}
+
+// RestoreTask 从回收站恢复任务及其子任务、标签和提醒
+func (s *TrashService) RestoreTask(ctx context.Context, userID, id uuid.UUID) (*TaskResponse, error) {
+	task, err := s.trash.GetDeletedTask(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询已删除任务失败: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTrashItemNotFound
+	}
+
+	project, err := s.projects.GetProjectByID(ctx, userID, task.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败: %w", err)
+	}
+	if project == nil {
+		return nil, ErrTrashProjectDeleted
+	}
+
+	// 父任务已不存在时恢复为顶层任务
+	detachParent := false
+	if task.ParentID != nil {
+		parent, err := s.tasks.GetTaskByID(ctx, userID, *task.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("查询父任务失败: %w", err)
+		}
+		detachParent = parent == nil
+	}
+
+	if err := s.trash.RestoreTask(ctx, userID, id, detachParent); err != nil {
+		return nil, fmt.Errorf("恢复任务失败: %w", err)
+	}
+
+	restored, err := s.tasks.GetTaskByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	return toTaskResponse(restored), nil
+}
+
+// DeleteProject 彻底删除回收站中的项目
+func (s *TrashService) DeleteProject(ctx context.Context, userID, id uuid.UUID) error {
+	project, err := s.trash.GetDeletedProject(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("查询已删除项目失败: %w", err)
+	}
+	if project == nil {
+		return ErrTrashItemNotFound
+	}
+
+	if err := s.trash.PurgeProject(ctx, userID, id); err != nil {
+		return fmt.Errorf("彻底删除项目失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteTask 彻底删除回收站中的任务
+func (s *TrashService) DeleteTask(ctx context.Context, userID, id uuid.UUID) error {
+	task, err := s.trash.GetDeletedTask(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("查询已删除任务失败: %w", err)
+	}
+	if task == nil {
+		return ErrTrashItemNotFound
+	}
+
+	if err := s.trash.PurgeTask(ctx, userID, id); err != nil {
+		return fmt.Errorf("彻底删除任务失败: %w", err)
+	}
+	return nil
+}
+
+// EmptyTrash 清空回收站
+func (s *TrashService) EmptyTrash(ctx context.Context, userID uuid.UUID) error {
+	if err := s.trash.EmptyTrash(ctx, userID); err != nil {
+		return fmt.Errorf("清空回收站失败: %w", err)
+	}
+	return nil
+}
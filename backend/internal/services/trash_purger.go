@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/repository"
+)
+
+// TrashPurger 回收站清理任务，定期彻底删除超过保留期的软删除数据
+type TrashPurger struct {
+	trash     repository.TrashRepository
+	retention time.Duration
+	interval  time.Duration
+	stopChan  chan struct{}
+	mu        sync.Mutex
+	isRunning bool
+}
+
+// NewTrashPurger 创建回收站清理任务
+func NewTrashPurger(trash repository.TrashRepository, cfg *config.TrashConfig) *TrashPurger {
+	return &TrashPurger{
+		trash:     trash,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		interval:  cfg.PurgeInterval,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start 启动清理任务，未配置保留天数时不启动
+func (p *TrashPurger) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isRunning || p.retention <= 0 || p.interval <= 0 {
+		return
+	}
+
+	p.isRunning = true
+	log.Printf("回收站清理任务启动，保留 %s", p.retention)
+	go p.run()
+}
+
+// Stop 停止清理任务
+func (p *TrashPurger) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.isRunning {
+		return
+	}
+
+	p.isRunning = false
+	close(p.stopChan)
+	log.Println("回收站清理任务停止")
+}
+
+// run 启动时先执行一次，之后按间隔执行
+func (p *TrashPurger) run() {
+	p.Purge(context.Background())
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.Purge(context.Background())
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// Purge 彻底删除超过保留期的软删除数据
+func (p *TrashPurger) Purge(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+	purged, err := p.trash.PurgeDeletedBefore(ctx, cutoff)
+	if err != nil {
+		log.Printf("清理回收站失败: %v", err)
+		return
+	}
+
+	var total int64
+	for _, count := range purged {
+		total += count
+	}
+	if total > 0 {
+		log.Printf("回收站清理完成，彻底删除 %v", purged)
+	}
+}
@@ -1,24 +1,28 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"ticktick-backend/internal/dal"
 	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/repository"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrPasswordResetRequired 账号需要重置密码后才能登录
+var ErrPasswordResetRequired = errors.New("需要重置密码")
+
 // UserService 用户服务
 type UserService struct {
-	userDAL *dal.UserDAL
+	userDAL repository.UserRepository
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(db *dal.Database) *UserService {
+func NewUserService(users repository.UserRepository) *UserService {
 	return &UserService{
-		userDAL: dal.NewUserDAL(db),
+		userDAL: users,
 	}
 }
 
@@ -36,20 +40,27 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ResetPasswordRequest 重置密码请求结构
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
 // UserResponse 用户响应结构（不包含敏感信息）
 type UserResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
 	FirstName string    `json:"firstName"`
 	LastName  string    `json:"lastName"`
+	Role      string    `json:"role"`
 	CreatedAt string    `json:"createdAt"`
 	UpdatedAt string    `json:"updatedAt"`
 }
 
 // Register 用户注册
-func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
+func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*UserResponse, error) {
 	// 检查邮箱是否已存在
-	exists, err := s.userDAL.EmailExists(req.Email)
+	exists, err := s.userDAL.EmailExists(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("检查邮箱失败: %w", err)
 	}
@@ -71,7 +82,7 @@ func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
 		LastName:     req.LastName,
 	}
 
-	if err := s.userDAL.CreateUser(user); err != nil {
+	if err := s.userDAL.CreateUser(ctx, user); err != nil {
 		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 
@@ -79,9 +90,9 @@ func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
 }
 
 // Login 用户登录
-func (s *UserService) Login(req *LoginRequest) (*UserResponse, error) {
+func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*UserResponse, error) {
 	// 根据邮箱查找用户
-	user, err := s.userDAL.GetUserByEmail(req.Email)
+	user, err := s.userDAL.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("查找用户失败: %w", err)
 	}
@@ -94,12 +105,17 @@ func (s *UserService) Login(req *LoginRequest) (*UserResponse, error) {
 		return nil, errors.New("邮箱或密码错误")
 	}
 
+	// 被标记为需要重置密码的账号不允许直接登录
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
 	return s.toUserResponse(user), nil
 }
 
 // GetUserByID 根据ID获取用户
-func (s *UserService) GetUserByID(id uuid.UUID) (*UserResponse, error) {
-	user, err := s.userDAL.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*UserResponse, error) {
+	user, err := s.userDAL.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("查找用户失败: %w", err)
 	}
@@ -110,6 +126,27 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*UserResponse, error) {
 	return s.toUserResponse(user), nil
 }
 
+// RequirePasswordReset 标记用户需要重置密码
+func (s *UserService) RequirePasswordReset(ctx context.Context, id uuid.UUID) error {
+	if err := s.userDAL.SetPasswordResetRequired(ctx, id, true); err != nil {
+		return fmt.Errorf("标记重置密码失败: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword 重置用户密码
+func (s *UserService) ResetPassword(ctx context.Context, id uuid.UUID, newPassword string) error {
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+
+	if err := s.userDAL.UpdatePassword(ctx, id, hashedPassword); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	return nil
+}
+
 // hashPassword 哈希密码
 func (s *UserService) hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -129,6 +166,7 @@ func (s *UserService) toUserResponse(user *models.User) *UserResponse {
 		Email:     user.Email,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -0,0 +1,642 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ticktick-backend/config"
+	"ticktick-backend/internal/models"
+	"ticktick-backend/internal/pagination"
+	"ticktick-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("Webhook不存在")
+	ErrWebhookDeliveryNotFound = errors.New("投递记录不存在")
+	ErrInvalidWebhook          = errors.New("Webhook参数无效")
+	ErrWebhookDisabled         = errors.New("Webhook已停用")
+
+	errWebhookAddressBlocked = errors.New("不允许投递到内网地址")
+)
+
+// 请求头，签名为HMAC-SHA256(secret, timestamp + "." + body)的十六进制，接收方应校验签名并拒绝过旧的时间戳
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// webhookQueueKey 待投递队列，成员是投递记录ID，分数是下次投递的时间（毫秒）
+	webhookQueueKey = "webhook:queue"
+	// webhookClaimBatch 每次从队列取出的最大投递数
+	webhookClaimBatch = 20
+	// webhookResponseLimit 投递记录中保存的响应体长度上限
+	webhookResponseLimit = 2048
+)
+
+// webhookClaimScript 取出到期的投递并把分数推迟到租约结束
+// 投递进程在租约内崩溃时，租约过期后投递会被重新取出，保证至少投递一次
+const webhookClaimScript = `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return ids
+`
+
+// webhookBlockedPrefixes 内网、回环和链路本地地址之外，同样不允许投递的保留地址段
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级NAT
+	netip.MustParsePrefix("198.18.0.0/15"), // 网络基准测试
+}
+
+// CreateWebhookRequest 创建Webhook请求结构
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=task.created task.updated task.completed project.deleted reminder.fired"`
+}
+
+// UpdateWebhookRequest 更新Webhook请求结构，未提供的字段保持不变
+// 重新启用被自动停用的端点时清零连续失败次数
+type UpdateWebhookRequest struct {
+	URL     *string   `json:"url" binding:"omitempty,url,max=2048"`
+	Events  *[]string `json:"events" binding:"omitempty,min=1,dive,oneof=task.created task.updated task.completed project.deleted reminder.fired"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// WebhookResponse Webhook响应结构，Secret只在创建时返回
+type WebhookResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	URL          string     `json:"url"`
+	Events       []string   `json:"events"`
+	Secret       string     `json:"secret,omitempty"`
+	Enabled      bool       `json:"enabled"`
+	FailureCount int        `json:"failureCount"`
+	DisabledAt   *time.Time `json:"disabledAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// WebhookDeliveryResponse 投递记录响应结构
+type WebhookDeliveryResponse struct {
+	ID            uuid.UUID       `json:"id"`
+	EventID       uuid.UUID       `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  *int            `json:"responseCode"`
+	ResponseBody  string          `json:"responseBody"`
+	Error         string          `json:"error"`
+	DurationMs    int64           `json:"durationMs"`
+	NextAttemptAt *time.Time      `json:"nextAttemptAt"`
+	RedeliveryOf  *uuid.UUID      `json:"redeliveryOf"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	DeliveredAt   *time.Time      `json:"deliveredAt"`
+}
+
+// WebhookService Webhook服务，把事件总线上的事件写入投递记录并通过Redis队列异步投递
+// 投递失败按指数退避重试，端点连续失败达到上限后自动停用
+type WebhookService struct {
+	webhooks repository.WebhookRepository
+	redis    *RedisService
+	client   *http.Client
+	config   config.WebhookConfig
+}
+
+// NewWebhookService 创建Webhook服务实例
+func NewWebhookService(webhooks repository.WebhookRepository, redis *RedisService, cfg *config.Config) *WebhookService {
+	return &WebhookService{
+		webhooks: webhooks,
+		redis:    redis,
+		client:   newWebhookClient(cfg.Webhook),
+		config:   cfg.Webhook,
+	}
+}
+
+// ListWebhooks 分页获取用户的Webhook，默认按创建时间排序
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID uuid.UUID, query *pagination.Query) (*pagination.Page[*WebhookResponse], error) {
+	req, err := query.Request(repository.WebhookSortFields, "created")
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhooks.ListWebhooks(ctx, userID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询Webhook失败: %w", err)
+	}
+	webhooks, next := pagination.Trim(webhooks, req, func(webhook *models.Webhook) (interface{}, uuid.UUID) {
+		return repository.WebhookSortValue(webhook, req.Sort.Field.Name), webhook.ID
+	})
+
+	page := &pagination.Page[*WebhookResponse]{Items: make([]*WebhookResponse, 0, len(webhooks)), NextCursor: next}
+	for _, webhook := range webhooks {
+		page.Items = append(page.Items, toWebhookResponse(webhook))
+	}
+	return page, nil
+}
+
+// GetWebhook 获取Webhook详情
+func (s *WebhookService) GetWebhook(ctx context.Context, userID, id uuid.UUID) (*WebhookResponse, error) {
+	webhook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookResponse(webhook), nil
+}
+
+// CreateWebhook 注册Webhook，签名密钥只在本次响应中返回
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID uuid.UUID, req *CreateWebhookRequest) (*WebhookResponse, error) {
+	target, err := s.normalizeURL(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newRandomToken()
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+
+	webhook := &models.Webhook{
+		UserID:  userID,
+		URL:     target,
+		Secret:  secret,
+		Events:  normalizeWebhookEvents(req.Events),
+		Enabled: true,
+	}
+	if err := s.webhooks.CreateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("创建Webhook失败: %w", err)
+	}
+
+	resp := toWebhookResponse(webhook)
+	resp.Secret = secret
+	return resp, nil
+}
+
+// UpdateWebhook 更新Webhook
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID, id uuid.UUID, req *UpdateWebhookRequest) (*WebhookResponse, error) {
+	webhook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if webhook.URL, err = s.normalizeURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Events != nil {
+		webhook.Events = normalizeWebhookEvents(*req.Events)
+	}
+	if req.Enabled != nil && *req.Enabled != webhook.Enabled {
+		webhook.Enabled = *req.Enabled
+		if webhook.Enabled {
+			webhook.FailureCount = 0
+			webhook.DisabledAt = nil
+		}
+	}
+
+	if err := s.webhooks.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("更新Webhook失败: %w", err)
+	}
+	return toWebhookResponse(webhook), nil
+}
+
+// DeleteWebhook 删除Webhook及其投递记录，队列中剩余的投递在取出时丢弃
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.webhooks.DeleteWebhook(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("删除Webhook失败: %w", err)
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries 分页获取Webhook的投递记录，默认最新的在前
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID, query *pagination.Query) (*pagination.Page[*WebhookDeliveryResponse], error) {
+	req, err := query.Request(repository.WebhookDeliverySortFields, "-created")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhooks.ListWebhookDeliveries(ctx, webhookID, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	deliveries, next := pagination.Trim(deliveries, req, func(delivery *models.WebhookDelivery) (interface{}, uuid.UUID) {
+		return repository.WebhookDeliverySortValue(delivery, req.Sort.Field.Name), delivery.ID
+	})
+
+	page := &pagination.Page[*WebhookDeliveryResponse]{Items: make([]*WebhookDeliveryResponse, 0, len(deliveries)), NextCursor: next}
+	for _, delivery := range deliveries {
+		page.Items = append(page.Items, toWebhookDeliveryResponse(delivery))
+	}
+	return page, nil
+}
+
+// Redeliver 按原请求体重新投递一次，生成新的投递记录
+func (s *WebhookService) Redeliver(ctx context.Context, userID, webhookID, deliveryID uuid.UUID) (*WebhookDeliveryResponse, error) {
+	webhook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+
+	original, err := s.webhooks.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	if original == nil || original.WebhookID != webhook.ID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID:    webhook.ID,
+		EventID:      original.EventID,
+		EventType:    original.EventType,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+	}
+	if err := s.enqueue(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return toWebhookDeliveryResponse(delivery), nil
+}
+
+// HandleEvent 为订阅了该事件的已启用端点创建投递并放入队列，注册到事件总线上使用
+func (s *WebhookService) HandleEvent(ctx context.Context, event *Event) {
+	req, err := (&pagination.Query{}).Request(repository.WebhookSortFields, "created")
+	if err != nil {
+		log.Printf("查询Webhook失败: %v", err)
+		return
+	}
+	req.Limit = 0
+	webhooks, err := s.webhooks.ListWebhooks(ctx, event.UserID, req)
+	if err != nil {
+		log.Printf("查询Webhook失败: %v", err)
+		return
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				log.Printf("序列化事件失败: %v", err)
+				return
+			}
+		}
+
+		delivery := &models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   string(payload),
+		}
+		if err := s.enqueue(ctx, delivery); err != nil {
+			log.Printf("Webhook %s 投递入队失败: %v", webhook.ID, err)
+		}
+	}
+}
+
+// enqueue 创建待投递记录并放入队列，立即可以投递
+func (s *WebhookService) enqueue(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = &now
+	if err := s.webhooks.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("创建投递记录失败: %w", err)
+	}
+	if err := s.redis.ZAdd(webhookQueueKey, float64(now.UnixMilli()), delivery.ID.String()); err != nil {
+		return fmt.Errorf("投递入队失败: %w", err)
+	}
+	return nil
+}
+
+// QueueDepth 返回队列中等待投递（包括等待重试）的投递数，用于队列长度指标
+func (s *WebhookService) QueueDepth() (int64, error) {
+	return s.redis.ZCard(webhookQueueKey)
+}
+
+// ProcessQueue 取出队列中到期的投递并发送，返回处理的投递数
+func (s *WebhookService) ProcessQueue(ctx context.Context) (int, error) {
+	now := time.Now()
+	lease := now.Add(s.config.Timeout + time.Minute)
+	result, err := s.redis.Eval(webhookClaimScript, []string{webhookQueueKey},
+		now.UnixMilli(), lease.UnixMilli(), webhookClaimBatch)
+	if err != nil {
+		return 0, fmt.Errorf("读取投递队列失败: %w", err)
+	}
+	members, _ := result.([]interface{})
+
+	var wg sync.WaitGroup
+	for _, member := range members {
+		id, err := uuid.Parse(fmt.Sprint(member))
+		if err != nil {
+			s.dequeue(fmt.Sprint(member))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.deliver(ctx, id); err != nil {
+				// 保留在队列中，租约过期后重新取出
+				log.Printf("Webhook投递 %s 失败: %v", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return len(members), nil
+}
+
+// deliver 发送一次投递并记录结果，失败时按退避时间重新入队
+func (s *WebhookService) deliver(ctx context.Context, id uuid.UUID) error {
+	delivery, err := s.webhooks.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	if delivery == nil || delivery.Status != models.WebhookDeliveryPending {
+		s.dequeue(id.String())
+		return nil
+	}
+	webhook, err := s.webhooks.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	if webhook == nil {
+		s.dequeue(id.String())
+		return nil
+	}
+	if !webhook.Enabled {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = ErrWebhookDisabled.Error()
+		delivery.NextAttemptAt = nil
+		if err := s.webhooks.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		s.dequeue(id.String())
+		return nil
+	}
+
+	s.send(ctx, webhook, delivery)
+
+	if delivery.Error == "" {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		if err := s.webhooks.RecordWebhookSuccess(ctx, webhook.ID); err != nil {
+			log.Printf("更新Webhook %s 失败次数失败: %v", webhook.ID, err)
+		}
+	} else {
+		disabled, err := s.webhooks.RecordWebhookFailure(ctx, webhook.ID, s.config.DisableAfter, time.Now())
+		if err != nil {
+			log.Printf("更新Webhook %s 失败次数失败: %v", webhook.ID, err)
+		}
+		if disabled {
+			log.Printf("Webhook %s 连续投递失败%d次，已自动停用", webhook.ID, s.config.DisableAfter)
+		}
+		if disabled || delivery.Attempts >= s.config.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := time.Now().Add(s.retryDelay(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.webhooks.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return err
+	}
+	if delivery.NextAttemptAt != nil {
+		return s.redis.ZAdd(webhookQueueKey, float64(delivery.NextAttemptAt.UnixMilli()), id.String())
+	}
+	s.dequeue(id.String())
+	return nil
+}
+
+// send 发送带签名的请求，把响应码、响应体和错误写入投递记录，非2xx响应视为失败
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	start := time.Now()
+	delivery.Attempts++
+	delivery.DeliveredAt = &start
+	delivery.ResponseCode = nil
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+	defer func() { delivery.DurationMs = time.Since(start).Milliseconds() }()
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ticktick-backend-webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	code := resp.StatusCode
+	delivery.ResponseCode = &code
+	delivery.ResponseBody = strings.ToValidUTF8(string(bytes.TrimSpace(body)), "")
+	if code < 200 || code > 299 {
+		delivery.Error = fmt.Sprintf("端点返回状态码 %d", code)
+	}
+}
+
+// retryDelay 第attempt次失败后的等待时间，从RetryBaseDelay开始每次翻倍，不超过RetryMaxDelay
+func (s *WebhookService) retryDelay(attempt int) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := 1; i < attempt && delay < s.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if s.config.RetryMaxDelay > 0 && delay > s.config.RetryMaxDelay {
+		delay = s.config.RetryMaxDelay
+	}
+	return delay
+}
+
+// dequeue 从队列移除投递
+func (s *WebhookService) dequeue(member string) {
+	if err := s.redis.ZRem(webhookQueueKey, member); err != nil {
+		log.Printf("移除Webhook投递 %s 失败: %v", member, err)
+	}
+}
+
+// getWebhook 获取用户的Webhook，不存在时返回ErrWebhookNotFound
+func (s *WebhookService) getWebhook(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	webhook, err := s.webhooks.GetWebhookByID(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询Webhook失败: %w", err)
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// SignWebhookPayload 计算请求体的签名，返回十六进制
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeURL 校验端点地址，只允许http和https，主机不能解析到内网、回环或链路本地地址
+// 注册后域名仍可能被重新解析到内网地址，投递时由newWebhookClient在建立连接时再次校验
+func (s *WebhookService) normalizeURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: url必须是http或https地址", ErrInvalidWebhook)
+	}
+	if s.config.AllowPrivateNetworks {
+		return raw, nil
+	}
+
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname()); err != nil {
+		return "", fmt.Errorf("%w: 无法解析url的主机名", ErrInvalidWebhook)
+	}
+	for _, addr := range addrs {
+		if isWebhookAddressBlocked(addr) {
+			return "", fmt.Errorf("%w: url不能指向内网、回环或链路本地地址", ErrInvalidWebhook)
+		}
+	}
+	return raw, nil
+}
+
+// newWebhookClient 创建投递使用的HTTP客户端
+// 不允许内网地址时在建立连接时校验实际连接的IP，防止域名在注册后被解析到内网地址（DNS rebinding）；
+// 不使用环境变量中的代理，也不跟随重定向，重定向响应按非2xx的失败处理
+func newWebhookClient(cfg config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if isWebhookAddressBlocked(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errWebhookAddressBlocked, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isWebhookAddressBlocked 判断是否为不允许投递的内网、回环、链路本地、组播或未指定地址
+func isWebhookAddressBlocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range webhookBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWebhookEvents 去重并按固定顺序排列订阅的事件类型
+func normalizeWebhookEvents(events []string) []string {
+	normalized := make([]string, 0, len(events))
+	for _, eventType := range WebhookEventTypes {
+		if slices.Contains(events, eventType) {
+			normalized = append(normalized, eventType)
+		}
+	}
+	return normalized
+}
+
+func toWebhookResponse(webhook *models.Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:           webhook.ID,
+		URL:          webhook.URL,
+		Events:       webhook.Events,
+		Enabled:      webhook.Enabled,
+		FailureCount: webhook.FailureCount,
+		DisabledAt:   webhook.DisabledAt,
+		CreatedAt:    webhook.CreatedAt,
+		UpdatedAt:    webhook.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *models.WebhookDelivery) *WebhookDeliveryResponse {
+	return &WebhookDeliveryResponse{
+		ID:            delivery.ID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		ResponseCode:  delivery.ResponseCode,
+		ResponseBody:  delivery.ResponseBody,
+		Error:         delivery.Error,
+		DurationMs:    delivery.DurationMs,
+		NextAttemptAt: delivery.NextAttemptAt,
+		RedeliveryOf:  delivery.RedeliveryOf,
+		Payload:       json.RawMessage(delivery.Payload),
+		CreatedAt:     delivery.CreatedAt,
+		DeliveredAt:   delivery.DeliveredAt,
+	}
+}
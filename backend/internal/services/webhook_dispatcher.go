@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// WebhookDispatcher Webhook投递任务，定期从Redis队列取出到期的投递并发送
+// 多个实例可以同时运行，队列通过租约保证同一投递不会被并发发送
+type WebhookDispatcher struct {
+	webhooks  *WebhookService
+	interval  time.Duration
+	stopChan  chan struct{}
+	mu        sync.Mutex
+	isRunning bool
+	heartbeat workerHeartbeat
+}
+
+// NewWebhookDispatcher 创建Webhook投递任务
+func NewWebhookDispatcher(webhooks *WebhookService) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhooks: webhooks,
+		interval: webhooks.config.PollInterval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动投递任务，未配置轮询间隔时不启动
+func (d *WebhookDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isRunning || d.interval <= 0 {
+		return
+	}
+
+	d.isRunning = true
+	d.heartbeat.beat()
+	log.Printf("Webhook投递任务启动，轮询间隔 %s", d.interval)
+	go d.run()
+}
+
+// Stop 停止投递任务
+func (d *WebhookDispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.isRunning {
+		return
+	}
+
+	d.isRunning = false
+	close(d.stopChan)
+	log.Println("Webhook投递任务停止")
+}
+
+// run 按间隔处理队列，一次取满时不等待，继续处理积压的投递
+func (d *WebhookDispatcher) run() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for {
+				d.heartbeat.beat()
+				processed, err := d.webhooks.ProcessQueue(context.Background())
+				if err != nil {
+					log.Printf("处理Webhook队列失败: %v", err)
+				}
+				if err != nil || processed < webhookClaimBatch {
+					break
+				}
+				select {
+				case <-d.stopChan:
+					return
+				default:
+				}
+			}
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+// CheckAlive 就绪检查：投递任务停止或长时间没有完成一轮处理时返回错误
+// 一轮最多并发等待一次请求超时，未配置轮询间隔（不启用）时不检查
+func (d *WebhookDispatcher) CheckAlive(ctx context.Context) error {
+	if d.interval <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	running := d.isRunning
+	d.mu.Unlock()
+	return d.heartbeat.check("Webhook投递任务", running, 3*d.interval+d.webhooks.config.Timeout+time.Minute)
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// workerHeartbeat 后台任务的心跳，每轮循环更新一次，供就绪检查判断任务是否卡住
+type workerHeartbeat struct {
+	last atomic.Int64
+}
+
+// beat 记录一次心跳
+func (h *workerHeartbeat) beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// check 任务未运行或超过maxAge没有心跳时返回错误
+func (h *workerHeartbeat) check(name string, running bool, maxAge time.Duration) error {
+	if !running {
+		return fmt.Errorf("%s未运行", name)
+	}
+	if age := time.Since(time.Unix(0, h.last.Load())); age > maxAge {
+		return fmt.Errorf("%s已 %s 没有心跳", name, age.Truncate(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWorkerHeartbeat(t *testing.T) {
+	var h workerHeartbeat
+	if err := h.check("测试任务", false, time.Minute); err == nil {
+		t.Fatal("任务未运行时应返回错误")
+	}
+
+	h.beat()
+	if err := h.check("测试任务", true, time.Minute); err != nil {
+		t.Fatalf("刚有心跳时返回错误: %v", err)
+	}
+
+	h.last.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if err := h.check("测试任务", true, time.Minute); err == nil {
+		t.Fatal("心跳超时时应返回错误")
+	}
+}
+
+func TestDispatcherCheckAliveDisabled(t *testing.T) {
+	// 未配置间隔时任务不启动，也不影响就绪状态
+	d := NewReminderDispatcher(nil, nil, nil, 0)
+	d.Start()
+	if err := d.CheckAlive(context.Background()); err != nil {
+		t.Fatalf("未启用的提醒触发任务CheckAlive = %v", err)
+	}
+}
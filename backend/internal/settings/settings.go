@@ -0,0 +1,175 @@
+// Package settings 定义用户设置文档的结构、默认值、版本升级和校验
+//
+// 设置以JSON文档整体保存，并记录写入时的结构版本。读取时先填入当前版本的默认值，
+// 再覆盖已保存的内容，因此新增的字段自动取默认值；字段含义发生变化时通过 upgrades 迁移旧文档
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// CurrentVersion 当前的设置文档结构版本
+const CurrentVersion = 1
+
+const (
+	// MaxReminderOffsets 默认提醒的最大数量
+	MaxReminderOffsets = 5
+	// MaxReminderOffset 默认提醒最多提前的分钟数（28天）
+	MaxReminderOffset = 28 * 24 * 60
+)
+
+// ErrInvalidSettings 设置内容不符合结构或取值范围
+var ErrInvalidSettings = errors.New("设置无效")
+
+// DateFormats 支持的日期格式
+var DateFormats = []string{"YYYY-MM-DD", "YYYY/MM/DD", "MM/DD/YYYY", "DD/MM/YYYY", "DD.MM.YYYY"}
+
+// Locales 支持的界面语言
+var Locales = []string{"zh-CN", "en-US"}
+
+// clockPattern 24小时制的HH:MM
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// Document 用户设置文档
+type Document struct {
+	TimeZone               string               `json:"timeZone"`               // IANA时区，如 Asia/Shanghai
+	WeekStart              int                  `json:"weekStart"`              // 每周的第一天，0为周日，与time.Weekday一致
+	DateFormat             string               `json:"dateFormat"`             // 日期显示格式，取值见DateFormats
+	Locale                 string               `json:"locale"`                 // 界面语言，取值见Locales
+	DefaultReminderOffsets []int                `json:"defaultReminderOffsets"` // 新任务默认提醒，截止时间前的分钟数
+	NotificationChannels   NotificationChannels `json:"notificationChannels"`
+	QuietHours             QuietHours           `json:"quietHours"`
+}
+
+// NotificationChannels 各通知渠道的开关
+type NotificationChannels struct {
+	Email bool `json:"email"`
+	Push  bool `json:"push"`  // 浏览器或桌面推送
+	InApp bool `json:"inApp"` // 应用内通知
+}
+
+// QuietHours 免打扰时段，结束时间早于开始时间表示跨越午夜
+type QuietHours struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"` // HH:MM
+	End     string `json:"end"`   // HH:MM
+}
+
+// Defaults 返回当前版本的默认设置
+func Defaults() *Document {
+	return &Document{
+		TimeZone:               "Asia/Shanghai",
+		WeekStart:              int(time.Monday),
+		DateFormat:             DateFormats[0],
+		Locale:                 Locales[0],
+		DefaultReminderOffsets: []int{0},
+		NotificationChannels:   NotificationChannels{Email: true, Push: true, InApp: true},
+		QuietHours:             QuietHours{Enabled: false, Start: "22:00", End: "08:00"},
+	}
+}
+
+// upgrades 按顺序将旧版本文档迁移到下一个版本，upgrades[i] 把版本 i+1 升级为 i+2
+// 只新增字段时不需要迁移，默认值会自动补上
+var upgrades []func(doc map[string]json.RawMessage) error
+
+// Decode 读取以version版本保存的设置文档，raw为空表示用户尚未保存过设置
+// 旧版本的文档先迁移到当前版本，缺少的字段取默认值
+func Decode(version int, raw []byte) (*Document, error) {
+	doc := Defaults()
+	if len(raw) == 0 {
+		return doc, nil
+	}
+	if version < 1 || version > CurrentVersion {
+		return nil, fmt.Errorf("不支持的设置版本 %d", version)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("解析设置失败: %w", err)
+	}
+	for v := version; v < CurrentVersion; v++ {
+		if err := upgrades[v-1](fields); err != nil {
+			return nil, fmt.Errorf("升级设置版本 %d 失败: %w", v, err)
+		}
+	}
+
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(upgraded, doc); err != nil {
+		return nil, fmt.Errorf("解析设置失败: %w", err)
+	}
+	return doc, nil
+}
+
+// Patch 将部分设置合并到doc中并校验结果，嵌套对象按字段合并，数组整体替换
+// 出现未知字段或类型不符时返回ErrInvalidSettings，doc保持不变
+func Patch(doc *Document, patch []byte) (*Document, error) {
+	patched := *doc
+	patched.DefaultReminderOffsets = slices.Clone(doc.DefaultReminderOffsets)
+
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: 请求体只能包含一个JSON对象", ErrInvalidSettings)
+	}
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
+
+// Validate 校验各字段的取值范围，并将默认提醒按提前时间排序
+func (d *Document) Validate() error {
+	if d.TimeZone == "" || d.TimeZone == "Local" {
+		return fmt.Errorf("%w: 时区不能为空", ErrInvalidSettings)
+	}
+	if _, err := time.LoadLocation(d.TimeZone); err != nil {
+		return fmt.Errorf("%w: 未知的时区 %q", ErrInvalidSettings, d.TimeZone)
+	}
+	if d.WeekStart < int(time.Sunday) || d.WeekStart > int(time.Saturday) {
+		return fmt.Errorf("%w: weekStart 必须在0到6之间", ErrInvalidSettings)
+	}
+	if !slices.Contains(DateFormats, d.DateFormat) {
+		return fmt.Errorf("%w: 不支持的日期格式 %q", ErrInvalidSettings, d.DateFormat)
+	}
+	if !slices.Contains(Locales, d.Locale) {
+		return fmt.Errorf("%w: 不支持的语言 %q", ErrInvalidSettings, d.Locale)
+	}
+
+	if d.DefaultReminderOffsets == nil {
+		d.DefaultReminderOffsets = []int{}
+	}
+	if len(d.DefaultReminderOffsets) > MaxReminderOffsets {
+		return fmt.Errorf("%w: 默认提醒最多 %d 个", ErrInvalidSettings, MaxReminderOffsets)
+	}
+	seen := make(map[int]bool, len(d.DefaultReminderOffsets))
+	for _, offset := range d.DefaultReminderOffsets {
+		if offset < 0 || offset > MaxReminderOffset {
+			return fmt.Errorf("%w: 默认提醒必须在截止时间前0到%d分钟之间", ErrInvalidSettings, MaxReminderOffset)
+		}
+		if seen[offset] {
+			return fmt.Errorf("%w: 默认提醒重复", ErrInvalidSettings)
+		}
+		seen[offset] = true
+	}
+	slices.Sort(d.DefaultReminderOffsets)
+
+	if !clockPattern.MatchString(d.QuietHours.Start) || !clockPattern.MatchString(d.QuietHours.End) {
+		return fmt.Errorf("%w: 免打扰时间必须是HH:MM格式", ErrInvalidSettings)
+	}
+	if d.QuietHours.Enabled && d.QuietHours.Start == d.QuietHours.End {
+		return fmt.Errorf("%w: 免打扰的开始和结束时间不能相同", ErrInvalidSettings)
+	}
+	return nil
+}